@@ -0,0 +1,383 @@
+// access control lists: users, their passwords and permissions to commands, keys and channels
+package acl
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+	"github.com/codecrafters-io/redis-starter-go/app/config"
+	"github.com/codecrafters-io/redis-starter-go/app/logger"
+)
+
+const DefaultUsername = "default"
+
+// parameters which change requires update of default user
+var RequirePassParams = []string{"requirepass"}
+
+type Acl struct {
+	config *config.Config
+	// guards users, their fields and log
+	mu    sync.RWMutex
+	users map[string]*User
+	log   aclLog
+}
+
+// describes why command was rejected
+type Denial struct {
+	Reason LogReasonEnum
+	// command, key or channel name
+	Object string
+}
+
+// user as shown by ACL GETUSER
+type UserInfo struct {
+	Flags     []string
+	Passwords []string
+	Commands  string
+	Keys      string
+	Channels  string
+}
+
+// creates acl with default user, loads users from aclfile if it is configured
+func New(config *config.Config) (*Acl, error) {
+	acl := &Acl{
+		config: config,
+		users:  map[string]*User{DefaultUsername: newDefaultUser()},
+	}
+	if config.GetAclFile() != "" {
+		err := acl.Load()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if config.GetRequirePass() != "" {
+		err := acl.ApplyRequirePass()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return acl, nil
+}
+
+// default user can do everything without password, like in redis
+func newDefaultUser() *User {
+	user := newUser(DefaultUsername)
+	for _, rule := range []string{"on", "nopass", "~*", "&*", "+@all"} {
+		user.applyRule(rule)
+	}
+	return user
+}
+
+// requirepass is a shortcut for password of default user
+func (this *Acl) ApplyRequirePass() error {
+	rules := []string{"nopass"}
+	if pass := this.config.GetRequirePass(); pass != "" {
+		rules = []string{"resetpass", ">" + pass}
+	}
+	return this.SetUser(DefaultUsername, rules)
+}
+
+// returns user new connections start with, client is authenticated only if default user needs no password
+func (this *Acl) GetDefaultUser() (*User, bool) {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	user := this.users[DefaultUsername]
+	return user, user.enabled && user.nopass
+}
+
+func (this *Acl) Authenticate(username string, password string) (*User, error) {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	user, ok := this.users[username]
+	if !ok || !user.enabled || !user.checkPassword(password) {
+		return nil, WrongPassError
+	}
+	return user, nil
+}
+
+// reports is user removed, clients authenticated as removed user lose authentication
+func (this *Acl) IsDeleted(user *User) bool {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return user.deleted
+}
+
+// checks that user can run cmd and access its keys and channels, returns nil if command is allowed
+func (this *Acl) CheckCommand(user *User, cmd *command.Command) *Denial {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return checkCommand(user, cmd)
+}
+
+func checkCommand(user *User, cmd *command.Command) *Denial {
+	name, spec := cmd.GetSpec()
+	if !user.canRun(name, spec) {
+		return &Denial{Reason: CommandLogReason, Object: name}
+	}
+	if spec == nil {
+		return nil
+	}
+	for i, key := range cmd.GetKeys() {
+		if !user.canAccessKey(key, spec.GetKeyAccess(i)) {
+			return &Denial{Reason: KeyLogReason, Object: key}
+		}
+	}
+	for _, channel := range cmd.GetChannels() {
+		if !user.canAccessChannel(channel, spec.ChannelPatterns) {
+			return &Denial{Reason: ChannelLogReason, Object: channel}
+		}
+	}
+	return nil
+}
+
+// checks command by acl name without arguments, e.g. psync that is read by replication outside of executor
+func (this *Acl) CanRun(user *User, aclName string) bool {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	spec, _ := command.GetSpecByAclName(aclName)
+	return user.canRun(aclName, spec)
+}
+
+func (this *Acl) CanAccessChannel(user *User, channel string, isPattern bool) bool {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return user.canAccessChannel(channel, isPattern)
+}
+
+// message returned to client that was denied
+func (this *Denial) Message(username string) string {
+	switch this.Reason {
+	case KeyLogReason:
+		return fmt.Sprintf("User %v has no permissions to access the '%v' key", username, this.Object)
+	case ChannelLogReason:
+		return fmt.Sprintf("User %v has no permissions to access the '%v' channel", username, this.Object)
+	}
+	return fmt.Sprintf("User %v has no permissions to run the '%v' command", username, this.Object)
+}
+
+// checks command as if it was sent by username, used by ACL DRYRUN
+func (this *Acl) Dryrun(username string, cmd *command.Command) (string, error) {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	user, ok := this.users[username]
+	if !ok {
+		return "", fmt.Errorf("ERR User '%v' not found", username)
+	}
+	denial := checkCommand(user, cmd)
+	if denial == nil {
+		return "", nil
+	}
+	return denial.Message(username), nil
+}
+
+// applies rules to user creating it if needed, either all rules are applied or none of them
+func (this *Acl) SetUser(name string, rules []string) error {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.setUser(name, rules)
+}
+
+func (this *Acl) setUser(name string, rules []string) error {
+	if strings.ContainsAny(name, " \t\r\n") {
+		return fmt.Errorf("ERR Usernames can't contain spaces or null characters")
+	}
+	existing, ok := this.users[name]
+	var user *User
+	if ok {
+		user = existing.clone()
+	} else {
+		user = newUser(name)
+	}
+	for _, rule := range rules {
+		err := user.applyRule(rule)
+		if err != nil {
+			return err
+		}
+	}
+	if ok {
+		// clients keep pointer to user, so changes are applied in place
+		*existing = *user
+		return nil
+	}
+	this.users[name] = user
+	return nil
+}
+
+func (this *Acl) GetUser(name string) *UserInfo {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	user, ok := this.users[name]
+	if !ok {
+		return nil
+	}
+	return &UserInfo{
+		Flags:     user.describeFlags(),
+		Passwords: append([]string{}, user.passwords...),
+		Commands:  user.describeCommands(),
+		Keys:      user.describeKeys(),
+		Channels:  user.describeChannels(),
+	}
+}
+
+// removes users and returns amount of removed ones
+func (this *Acl) DelUsers(names []string) (int, error) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	for _, name := range names {
+		if name == DefaultUsername {
+			return 0, DefaultUserRemoveError
+		}
+	}
+	deleted := 0
+	for _, name := range names {
+		user, ok := this.users[name]
+		if !ok {
+			continue
+		}
+		user.deleted = true
+		delete(this.users, name)
+		deleted++
+	}
+	return deleted, nil
+}
+
+func (this *Acl) sortedNames() []string {
+	names := make([]string, 0, len(this.users))
+	for name := range this.users {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (this *Acl) Users() []string {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return this.sortedNames()
+}
+
+// describes every user in format of acl file
+func (this *Acl) List() []string {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	out := []string{}
+	for _, name := range this.sortedNames() {
+		out = append(out, this.users[name].describe())
+	}
+	return out
+}
+
+func (this *Acl) AddLogEntry(entry LogEntry) {
+	maxLen := this.config.GetAclLogMaxLen()
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.log.add(entry, maxLen)
+}
+
+// returns count newest log entries, negative count returns every entry
+func (this *Acl) GetLog(count int) []LogEntry {
+	maxLen := this.config.GetAclLogMaxLen()
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	// acllog-max-len could be decreased by CONFIG SET
+	this.log.trim(maxLen)
+	return this.log.get(count)
+}
+
+func (this *Acl) ResetLog() {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.log.reset()
+}
+
+// replaces users with users from aclfile, if file has any error users stay unchanged
+func (this *Acl) Load() error {
+	path := this.config.GetAclFile()
+	if path == "" {
+		return NoAclFileError
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("ERR Error loading ACLs, opening file '%v': %v", path, err)
+	}
+
+	loaded := &Acl{users: map[string]*User{}}
+	errs := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] != "user" || len(fields) < 2 {
+			errs = append(errs, fmt.Sprintf("%v:%v: should start with user keyword followed by the username", path, lineNum))
+			continue
+		}
+		if _, ok := loaded.users[fields[1]]; ok {
+			errs = append(errs, fmt.Sprintf("%v:%v: duplicate user '%v' found", path, lineNum, fields[1]))
+			continue
+		}
+		err := loaded.setUser(fields[1], fields[2:])
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%v:%v: %v", path, lineNum, strings.TrimPrefix(err.Error(), "ERR ")))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("ERR %v", strings.Join(errs, ". "))
+	}
+	if _, ok := loaded.users[DefaultUsername]; !ok {
+		loaded.users[DefaultUsername] = newDefaultUser()
+	}
+
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	for name, user := range this.users {
+		fresh, ok := loaded.users[name]
+		if !ok {
+			user.deleted = true
+			continue
+		}
+		*user = *fresh
+		loaded.users[name] = user
+	}
+	this.users = loaded.users
+	logger.Logger.Info("acl users loaded", logger.String("file", path), logger.Int("users", len(this.users)))
+	return nil
+}
+
+// writes users to aclfile, file is replaced atomically
+func (this *Acl) Save() error {
+	path := this.config.GetAclFile()
+	if path == "" {
+		return NoAclFileError
+	}
+	content := strings.Join(this.List(), "\n") + "\n"
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("ERR There was an error trying to save the ACLs. Please check the server logs for more information")
+	}
+	_, err = tmp.WriteString(content)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	closeErr := tmp.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		logger.Logger.Error("Error saving acl file", logger.String("file", path), logger.String("error", err.Error()))
+		return fmt.Errorf("ERR There was an error trying to save the ACLs. Please check the server logs for more information")
+	}
+	return nil
+}
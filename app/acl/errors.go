@@ -0,0 +1,13 @@
+package acl
+
+import "errors"
+
+var WrongPassError = errors.New("WRONGPASS invalid username-password pair or user is disabled.")
+
+var NoPasswordConfiguredError = errors.New("ERR AUTH <password> called without any password configured for the default user. Are you sure your configuration is correct?")
+
+var NoAuthError = errors.New("NOAUTH Authentication required.")
+
+var DefaultUserRemoveError = errors.New("ERR The 'default' user cannot be removed")
+
+var NoAclFileError = errors.New("ERR This Redis instance is not configured to use an ACL file. You may want to specify users via the ACL SETUSER command and then issue a CONFIG REWRITE (assuming you have a Redis configuration file set) in order to store users in the Redis configuration.")
@@ -0,0 +1,84 @@
+package acl
+
+import (
+	"time"
+)
+
+type LogReasonEnum string
+
+const (
+	AuthLogReason    LogReasonEnum = "auth"
+	CommandLogReason LogReasonEnum = "command"
+	KeyLogReason     LogReasonEnum = "key"
+	ChannelLogReason LogReasonEnum = "channel"
+)
+
+type LogContextEnum string
+
+const (
+	TopLevelLogContext LogContextEnum = "toplevel"
+	MultiLogContext    LogContextEnum = "multi"
+)
+
+// similar denials that happen within this interval are grouped into single entry
+const logGroupInterval = 60 * time.Second
+
+// security event shown by ACL LOG
+type LogEntry struct {
+	Count      int
+	Reason     LogReasonEnum
+	Context    LogContextEnum
+	Object     string
+	Username   string
+	ClientInfo string
+	EntryId    int
+	Created    time.Time
+	Updated    time.Time
+}
+
+// entries are kept newest first, guarded by acl mutex
+type aclLog struct {
+	entries []*LogEntry
+	nextId  int
+}
+
+func (this *aclLog) add(entry LogEntry, maxLen int) {
+	now := time.Now()
+	for _, e := range this.entries {
+		if e.Reason == entry.Reason && e.Context == entry.Context && e.Object == entry.Object &&
+			e.Username == entry.Username && now.Sub(e.Updated) < logGroupInterval {
+			e.Count++
+			e.Updated = now
+			e.ClientInfo = entry.ClientInfo
+			return
+		}
+	}
+	entry.Count = 1
+	entry.EntryId = this.nextId
+	entry.Created = now
+	entry.Updated = now
+	this.nextId++
+	this.entries = append([]*LogEntry{&entry}, this.entries...)
+	this.trim(maxLen)
+}
+
+func (this *aclLog) trim(maxLen int) {
+	if len(this.entries) > maxLen {
+		this.entries = this.entries[:maxLen]
+	}
+}
+
+func (this *aclLog) get(count int) []LogEntry {
+	if count < 0 || count > len(this.entries) {
+		count = len(this.entries)
+	}
+	out := make([]LogEntry, count)
+	for i := 0; i < count; i++ {
+		out[i] = *this.entries[i]
+	}
+	return out
+}
+
+func (this *aclLog) reset() {
+	this.entries = nil
+}
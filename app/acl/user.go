@@ -0,0 +1,395 @@
+package acl
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+	"github.com/codecrafters-io/redis-starter-go/app/glob"
+)
+
+// user fields are guarded by mutex of acl that owns user
+type User struct {
+	name    string
+	enabled bool
+	nopass  bool
+	// sha256 hashes of passwords in hex
+	passwords []string
+	// user can run every command including commands added in future
+	allCommands bool
+	// acl names of allowed commands and subcommands
+	allowed map[string]bool
+	// +/- command rules in order they were applied, used to describe user
+	commandRules []string
+	allKeys      bool
+	keyPatterns  []keyPattern
+	allChannels  bool
+	channels     []string
+	// user was removed by ACL DELUSER or ACL LOAD, clients authenticated as it have to authenticate again
+	deleted bool
+}
+
+type keyPattern struct {
+	pattern string
+	access  command.KeyAccessEnum
+}
+
+// new user has no permissions and is disabled, like in redis
+func newUser(name string) *User {
+	return &User{
+		name:    name,
+		allowed: map[string]bool{},
+	}
+}
+
+func (this *User) GetName() string {
+	return this.name
+}
+
+func (this *User) clone() *User {
+	out := *this
+	out.passwords = append([]string(nil), this.passwords...)
+	out.allowed = make(map[string]bool, len(this.allowed))
+	for name := range this.allowed {
+		out.allowed[name] = true
+	}
+	out.commandRules = append([]string(nil), this.commandRules...)
+	out.keyPatterns = append([]keyPattern(nil), this.keyPatterns...)
+	out.channels = append([]string(nil), this.channels...)
+	return &out
+}
+
+func HashPassword(password string) string {
+	sum := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
+
+func ruleError(rule string, msg string) error {
+	return fmt.Errorf("ERR Error in ACL SETUSER modifier '%v': %v", rule, msg)
+}
+
+// applies single ACL SETUSER rule
+func (this *User) applyRule(rule string) error {
+	lower := strings.ToLower(rule)
+	switch lower {
+	case "on":
+		this.enabled = true
+		return nil
+	case "off":
+		this.enabled = false
+		return nil
+	case "nopass":
+		this.nopass = true
+		this.passwords = nil
+		return nil
+	case "resetpass":
+		this.nopass = false
+		this.passwords = nil
+		return nil
+	case "allkeys":
+		return this.applyRule("~*")
+	case "resetkeys":
+		this.allKeys = false
+		this.keyPatterns = nil
+		return nil
+	case "allchannels":
+		return this.applyRule("&*")
+	case "resetchannels":
+		this.allChannels = false
+		this.channels = nil
+		return nil
+	case "allcommands":
+		return this.applyRule("+@all")
+	case "nocommands":
+		return this.applyRule("-@all")
+	case "reset":
+		for _, r := range []string{"resetpass", "resetkeys", "resetchannels", "off", "-@all"} {
+			this.applyRule(r)
+		}
+		return nil
+	}
+	if rule == "" {
+		return ruleError(rule, "Syntax error")
+	}
+
+	switch rule[0] {
+	case '>':
+		this.addPassword(HashPassword(rule[1:]))
+		return nil
+	case '<':
+		if !this.removePassword(HashPassword(rule[1:])) {
+			return ruleError(rule, "The password you are trying to remove from the user does not exist")
+		}
+		return nil
+	case '#':
+		hash := strings.ToLower(rule[1:])
+		if !isValidHash(hash) {
+			return ruleError(rule, "The password hash must be exactly 64 characters and contain only lowercase hexadecimal characters")
+		}
+		this.addPassword(hash)
+		return nil
+	case '!':
+		hash := strings.ToLower(rule[1:])
+		if !isValidHash(hash) {
+			return ruleError(rule, "The password hash must be exactly 64 characters and contain only lowercase hexadecimal characters")
+		}
+		if !this.removePassword(hash) {
+			return ruleError(rule, "The password you are trying to remove from the user does not exist")
+		}
+		return nil
+	case '~':
+		return this.addKeyPattern(rule, rule[1:], command.ReadKeyAccess|command.WriteKeyAccess)
+	case '%':
+		flags, pattern, ok := strings.Cut(rule[1:], "~")
+		if !ok || flags == "" {
+			return ruleError(rule, "Syntax error")
+		}
+		var access command.KeyAccessEnum
+		for _, f := range strings.ToUpper(flags) {
+			switch f {
+			case 'R':
+				access |= command.ReadKeyAccess
+			case 'W':
+				access |= command.WriteKeyAccess
+			default:
+				return ruleError(rule, "Syntax error")
+			}
+		}
+		return this.addKeyPattern(rule, pattern, access)
+	case '&':
+		pattern := rule[1:]
+		if pattern == "*" {
+			this.allChannels = true
+			this.channels = nil
+			return nil
+		}
+		if this.allChannels {
+			return ruleError(rule, "Adding a pattern after the * pattern (or the 'allchannels' flag) is not valid and does not have any effect. Try 'resetchannels' to start with an empty list of channels")
+		}
+		this.channels = append(this.channels, pattern)
+		return nil
+	case '+', '-':
+		return this.applyCommandRule(rule, rule[0] == '+', strings.ToLower(rule[1:]))
+	}
+	return ruleError(rule, "Syntax error")
+}
+
+func isValidHash(hash string) bool {
+	if len(hash) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
+
+func (this *User) addPassword(hash string) {
+	this.nopass = false
+	for _, p := range this.passwords {
+		if p == hash {
+			return
+		}
+	}
+	this.passwords = append(this.passwords, hash)
+}
+
+func (this *User) removePassword(hash string) bool {
+	for i, p := range this.passwords {
+		if p == hash {
+			this.passwords = append(this.passwords[:i], this.passwords[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (this *User) addKeyPattern(rule string, pattern string, access command.KeyAccessEnum) error {
+	full := access == command.ReadKeyAccess|command.WriteKeyAccess
+	if pattern == "*" && full {
+		this.allKeys = true
+		this.keyPatterns = nil
+		return nil
+	}
+	if this.allKeys {
+		return ruleError(rule, "Adding a pattern after the * pattern (or the 'allkeys' flag) is not valid and does not have any effect. Try 'resetkeys' to start with an empty list of patterns")
+	}
+	this.keyPatterns = append(this.keyPatterns, keyPattern{pattern: pattern, access: access})
+	return nil
+}
+
+// applies +command, -command, +command|subcommand and +@category rules
+func (this *User) applyCommandRule(rule string, allow bool, name string) error {
+	var names []string
+	if strings.HasPrefix(name, "@") {
+		category := name[1:]
+		if category == "all" {
+			this.allCommands = allow
+			this.allowed = map[string]bool{}
+			this.commandRules = nil
+			names = command.GetAclNames()
+		} else {
+			if !command.IsCategory(category) {
+				return ruleError(rule, "Unknown command or category name in ACL")
+			}
+			for _, n := range command.GetAclNames() {
+				spec, _ := command.GetSpecByAclName(n)
+				if spec.HasCategory(command.CategoryEnum(category)) {
+					names = append(names, n)
+				}
+			}
+		}
+	} else {
+		spec, ok := command.GetSpecByAclName(name)
+		if !ok {
+			return ruleError(rule, "Unknown command or category name in ACL")
+		}
+		if spec.Subcommands == nil {
+			names = []string{name}
+		} else {
+			for sub := range spec.Subcommands {
+				names = append(names, name+"|"+sub)
+			}
+		}
+	}
+
+	if !allow {
+		this.allCommands = false
+	}
+	for _, n := range names {
+		if allow {
+			this.allowed[n] = true
+		} else {
+			delete(this.allowed, n)
+		}
+	}
+	this.commandRules = append(this.commandRules, rule[:1]+name)
+	return nil
+}
+
+func (this *User) canRun(aclName string, spec *command.CommandSpec) bool {
+	if this.allCommands {
+		return true
+	}
+	if spec != nil {
+		return this.allowed[aclName]
+	}
+	// unknown subcommand of container command is allowed only if every subcommand is allowed
+	containerSpec, ok := command.GetSpecByAclName(aclName)
+	if !ok || containerSpec.Subcommands == nil {
+		return false
+	}
+	for sub := range containerSpec.Subcommands {
+		if !this.allowed[aclName+"|"+sub] {
+			return false
+		}
+	}
+	return true
+}
+
+func (this *User) canAccessKey(key string, access command.KeyAccessEnum) bool {
+	if this.allKeys {
+		return true
+	}
+	for _, p := range this.keyPatterns {
+		if p.access&access == access && glob.Match(p.pattern, key, false) {
+			return true
+		}
+	}
+	return false
+}
+
+// pattern subscriptions are checked literally, like in redis
+func (this *User) canAccessChannel(channel string, isPattern bool) bool {
+	if this.allChannels {
+		return true
+	}
+	for _, p := range this.channels {
+		if isPattern && p == channel {
+			return true
+		}
+		if !isPattern && glob.Match(p, channel, false) {
+			return true
+		}
+	}
+	return false
+}
+
+func (this *User) checkPassword(password string) bool {
+	if this.nopass {
+		return true
+	}
+	// hashes are compared in constant time, like by time_independent_strcmp of redis, so timing does not leak them
+	hash := []byte(HashPassword(password))
+	matched := 0
+	for _, p := range this.passwords {
+		matched |= subtle.ConstantTimeCompare([]byte(p), hash)
+	}
+	return matched == 1
+}
+
+func (this *User) describeFlags() []string {
+	flags := []string{"off"}
+	if this.enabled {
+		flags[0] = "on"
+	}
+	if this.nopass {
+		flags = append(flags, "nopass")
+	}
+	return flags
+}
+
+func (this *User) describeKeys() string {
+	if this.allKeys {
+		return "~*"
+	}
+	parts := make([]string, len(this.keyPatterns))
+	for i, p := range this.keyPatterns {
+		switch p.access {
+		case command.ReadKeyAccess:
+			parts[i] = "%R~" + p.pattern
+		case command.WriteKeyAccess:
+			parts[i] = "%W~" + p.pattern
+		default:
+			parts[i] = "~" + p.pattern
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func (this *User) describeChannels() string {
+	if this.allChannels {
+		return "&*"
+	}
+	if len(this.channels) == 0 {
+		return "resetchannels"
+	}
+	parts := make([]string, len(this.channels))
+	for i, p := range this.channels {
+		parts[i] = "&" + p
+	}
+	return strings.Join(parts, " ")
+}
+
+func (this *User) describeCommands() string {
+	rules := this.commandRules
+	if len(rules) == 0 || (rules[0] != "+@all" && rules[0] != "-@all") {
+		rules = append([]string{"-@all"}, rules...)
+	}
+	return strings.Join(rules, " ")
+}
+
+// describes user as rules accepted by ACL SETUSER, used by ACL LIST and acl file
+func (this *User) describe() string {
+	parts := []string{"user", this.name}
+	parts = append(parts, this.describeFlags()...)
+	for _, p := range this.passwords {
+		parts = append(parts, "#"+p)
+	}
+	if keys := this.describeKeys(); keys != "" {
+		parts = append(parts, keys)
+	}
+	parts = append(parts, this.describeChannels(), this.describeCommands())
+	return strings.Join(parts, " ")
+}
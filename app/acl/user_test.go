@@ -0,0 +1,130 @@
+package acl
+
+import (
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+)
+
+func newTestCommand(t *testing.T, args ...string) *command.Command {
+	values := make([]*datatypes.Data, len(args))
+	for i, a := range args {
+		values[i] = datatypes.ConstructBulkString(a)
+	}
+	cmd, err := command.DataTypeToCommand(datatypes.ConstructArrayFromData(values))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return cmd
+}
+
+func newTestUser(t *testing.T, rules ...string) *User {
+	user := newUser("test")
+	for _, rule := range rules {
+		err := user.applyRule(rule)
+		if err != nil {
+			t.Fatalf("expected no error applying %v, got %v", rule, err)
+		}
+	}
+	return user
+}
+
+func TestUser_CommandPermissions(t *testing.T) {
+	user := newTestUser(t, "~*", "+@read", "-type", "+config|get")
+
+	cases := []struct {
+		args    []string
+		allowed bool
+	}{
+		{[]string{"GET", "k"}, true},
+		{[]string{"TYPE", "k"}, false},
+		{[]string{"SET", "k", "v"}, false},
+		{[]string{"CONFIG", "GET", "port"}, true},
+		{[]string{"CONFIG", "SET", "port", "1"}, false},
+	}
+	for _, c := range cases {
+		denial := checkCommand(user, newTestCommand(t, c.args...))
+		if (denial == nil) != c.allowed {
+			t.Fatalf("expected allowed %v for %v, got denial %v", c.allowed, c.args, denial)
+		}
+	}
+}
+
+func TestUser_KeyPermissions(t *testing.T) {
+	user := newTestUser(t, "+@all", "~app:*", "%R~ro:*", "%W~wo:*")
+
+	cases := []struct {
+		args    []string
+		allowed bool
+	}{
+		{[]string{"SET", "app:1", "v"}, true},
+		{[]string{"GET", "ro:1"}, true},
+		{[]string{"SET", "ro:1", "v"}, false},
+		{[]string{"GET", "wo:1"}, false},
+		{[]string{"SET", "wo:1", "v"}, true},
+		{[]string{"GET", "other"}, false},
+		{[]string{"XREAD", "STREAMS", "app:s", "ro:s", "0", "0"}, true},
+		{[]string{"XREAD", "STREAMS", "app:s", "other", "0", "0"}, false},
+	}
+	for _, c := range cases {
+		denial := checkCommand(user, newTestCommand(t, c.args...))
+		if (denial == nil) != c.allowed {
+			t.Fatalf("expected allowed %v for %v, got denial %v", c.allowed, c.args, denial)
+		}
+	}
+}
+
+// destination of store commands is written, while their sources are only read
+func TestUser_StoreCommandKeyPermissions(t *testing.T) {
+	user := newTestUser(t, "+@all", "%W~dst*", "%R~src*")
+
+	cases := []struct {
+		args    []string
+		allowed bool
+	}{
+		{[]string{"BITOP", "AND", "dst", "src1", "src2"}, true},
+		{[]string{"BITOP", "NOT", "dst", "src"}, true},
+		{[]string{"BITOP", "AND", "src", "dst"}, false},
+		{[]string{"BITOP", "AND", "dst", "src", "dst2"}, false},
+		{[]string{"GEOSEARCHSTORE", "dst", "src", "FROMLONLAT", "0", "0", "BYRADIUS", "1", "km"}, true},
+		{[]string{"GEOSEARCHSTORE", "src", "dst", "FROMLONLAT", "0", "0", "BYRADIUS", "1", "km"}, false},
+		{[]string{"GEORADIUS", "src", "0", "0", "1", "km", "STORE", "dst"}, true},
+		{[]string{"GEORADIUS", "src", "0", "0", "1", "km", "STOREDIST", "src2"}, false},
+		// destination of PFMERGE is merged too, so it is read as well
+		{[]string{"PFMERGE", "dst", "src"}, false},
+	}
+	for _, c := range cases {
+		denial := checkCommand(user, newTestCommand(t, c.args...))
+		if (denial == nil) != c.allowed {
+			t.Fatalf("expected allowed %v for %v, got denial %v", c.allowed, c.args, denial)
+		}
+	}
+	user = newTestUser(t, "+@all", "~dst*", "%R~src*")
+	if denial := checkCommand(user, newTestCommand(t, "PFMERGE", "dst", "src1", "src2")); denial != nil {
+		t.Fatalf("expected PFMERGE to be allowed with read and write access to destination, got denial %v", denial)
+	}
+}
+
+func TestUser_Passwords(t *testing.T) {
+	user := newTestUser(t, ">first", "#"+HashPassword("second"))
+	if !user.checkPassword("first") || !user.checkPassword("second") || user.checkPassword("third") {
+		t.Fatalf("unexpected password check result")
+	}
+	err := user.applyRule("<third")
+	if err == nil {
+		t.Fatalf("expected error removing unknown password")
+	}
+	user.applyRule("nopass")
+	if !user.checkPassword("anything") {
+		t.Fatalf("expected nopass user to accept any password")
+	}
+}
+
+func TestUser_Describe(t *testing.T) {
+	user := newTestUser(t, "on", "nopass", "~a*", "%R~b*", "&ch", "+@read", "-get")
+	expected := "user test on nopass ~a* %R~b* &ch -@all +@read -get"
+	if user.describe() != expected {
+		t.Fatalf("expected %v, got %v", expected, user.describe())
+	}
+}
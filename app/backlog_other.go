@@ -0,0 +1,10 @@
+//go:build !unix
+
+package main
+
+import "net"
+
+// backlog can not be changed after listen on this platform, go default is used
+func setBacklog(l net.Listener, backlog int) error {
+	return nil
+}
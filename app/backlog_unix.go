@@ -0,0 +1,49 @@
+//go:build unix
+
+package main
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/codecrafters-io/redis-starter-go/app/logger"
+)
+
+// applies tcp-backlog to listener, go listens with backlog of somaxconn and
+// listen called again on listening socket only changes its backlog
+func setBacklog(l net.Listener, backlog int) error {
+	sc, ok := l.(syscall.Conn)
+	if !ok {
+		return nil
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var listenErr error
+	err = raw.Control(func(fd uintptr) {
+		listenErr = syscall.Listen(int(fd), backlog)
+	})
+	if err != nil {
+		return err
+	}
+	warnSomaxconn(backlog)
+	return listenErr
+}
+
+// kernel silently truncates backlog to somaxconn, redis warns about it the same way
+func warnSomaxconn(backlog int) {
+	content, err := os.ReadFile("/proc/sys/net/core/somaxconn")
+	if err != nil {
+		return
+	}
+	somaxconn, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil || somaxconn >= backlog {
+		return
+	}
+	logger.Logger.Warn("tcp-backlog cannot be enforced because /proc/sys/net/core/somaxconn is set to the lower value",
+		logger.Int("tcp-backlog", backlog), logger.Int("somaxconn", somaxconn))
+}
@@ -0,0 +1,90 @@
+package bitmap
+
+import "math"
+
+type OverflowEnum string
+
+// BITFIELD overflow modes, WRAP wraps around, SAT saturates at min or max value, FAIL skips operation
+const (
+	Wrap OverflowEnum = "WRAP"
+	Sat  OverflowEnum = "SAT"
+	Fail OverflowEnum = "FAIL"
+)
+
+// returns unsigned field of width bits stored at bit offset, bits beyond b are zeros
+func GetUnsigned(b []byte, offset uint64, width int) uint64 {
+	v := uint64(0)
+	for i := 0; i < width; i++ {
+		v = v<<1 | uint64(GetBit(b, offset+uint64(i)))
+	}
+	return v
+}
+
+// returns signed field of width bits stored at bit offset in two's complement
+func GetSigned(b []byte, offset uint64, width int) int64 {
+	v := GetUnsigned(b, offset, width)
+	if width < 64 && v&(1<<(width-1)) != 0 {
+		v |= ^uint64(0) << width
+	}
+	return int64(v)
+}
+
+// writes low width bits of v at bit offset, b should be long enough to hold field
+func SetField(b []byte, offset uint64, width int, v uint64) {
+	for i := 0; i < width; i++ {
+		SetBit(b, offset+uint64(i), int(v>>(width-1-i))&1)
+	}
+}
+
+// returns value+incr for unsigned field of width bits limited according to overflow mode,
+// ok is false if result does not fit and mode is FAIL
+func AddUnsigned(value uint64, incr int64, width int, mode OverflowEnum) (uint64, bool) {
+	maxValue := uint64(math.MaxUint64) >> (64 - width)
+	overflow := value > maxValue || incr > 0 && uint64(incr) > maxValue-value
+	// -incr of min int64 is min int64 again, but its conversion to uint64 is still correct magnitude
+	underflow := !overflow && incr < 0 && uint64(-incr) > value
+	if !overflow && !underflow {
+		return value + uint64(incr), true
+	}
+	switch mode {
+	case Sat:
+		if overflow {
+			return maxValue, true
+		}
+		return 0, true
+	case Fail:
+		return 0, false
+	}
+	return (value + uint64(incr)) & maxValue, true
+}
+
+// returns value+incr for signed field of width bits limited according to overflow mode,
+// ok is false if result does not fit and mode is FAIL
+func AddSigned(value int64, incr int64, width int, mode OverflowEnum) (int64, bool) {
+	maxValue := int64(math.MaxInt64 >> (64 - width))
+	minValue := -maxValue - 1
+	overflow := value > maxValue || incr > 0 && value > maxValue-incr
+	underflow := !overflow && (value < minValue || incr < 0 && value < minValue-incr)
+	if !overflow && !underflow {
+		return value + incr, true
+	}
+	switch mode {
+	case Sat:
+		if overflow {
+			return maxValue, true
+		}
+		return minValue, true
+	case Fail:
+		return 0, false
+	}
+	res := uint64(value) + uint64(incr)
+	if width < 64 {
+		// sign bit of field is propagated to higher bits
+		if res&(1<<(width-1)) != 0 {
+			res |= ^uint64(0) << width
+		} else {
+			res &^= ^uint64(0) << width
+		}
+	}
+	return int64(res), true
+}
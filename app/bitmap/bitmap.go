@@ -0,0 +1,83 @@
+// bit level operations on redis strings, bit 0 is the most significant bit of the first byte
+package bitmap
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"unsafe"
+)
+
+// returns bytes of string without copying, returned slice must not be modified
+func Bytes(s string) []byte {
+	return unsafe.Slice(unsafe.StringData(s), len(s))
+}
+
+// returns string holding bytes of b without copying, b must not be modified after it
+func String(b []byte) string {
+	return unsafe.String(unsafe.SliceData(b), len(b))
+}
+
+func GetBit(b []byte, offset uint64) int {
+	idx := offset >> 3
+	if idx >= uint64(len(b)) {
+		return 0
+	}
+	return int(b[idx]>>(7-offset&7)) & 1
+}
+
+// sets bit growing b with zero bytes if needed, returns updated slice and previous bit
+func SetBit(b []byte, offset uint64, bit int) ([]byte, int) {
+	idx := offset >> 3
+	if idx >= uint64(len(b)) {
+		b = append(b, make([]byte, idx+1-uint64(len(b)))...)
+	}
+	mask := byte(1) << (7 - offset&7)
+	old := 0
+	if b[idx]&mask != 0 {
+		old = 1
+	}
+	if bit == 1 {
+		b[idx] |= mask
+	} else {
+		b[idx] &^= mask
+	}
+	return b, old
+}
+
+// counts set bits, 8 bytes at a time
+func Count(b []byte) int {
+	count := 0
+	for len(b) >= 8 {
+		count += bits.OnesCount64(binary.LittleEndian.Uint64(b))
+		b = b[8:]
+	}
+	for _, c := range b {
+		count += bits.OnesCount8(c)
+	}
+	return count
+}
+
+// returns position of first bit equal to bit, -1 if there is no such bit.
+// words consisting only of other bit value are skipped 8 bytes at a time
+func Pos(b []byte, bit int) int {
+	skip := uint64(0)
+	if bit == 0 {
+		skip = ^uint64(0)
+	}
+	i := 0
+	for ; i+8 <= len(b); i += 8 {
+		if binary.BigEndian.Uint64(b[i:]) != skip {
+			break
+		}
+	}
+	for ; i < len(b); i++ {
+		c := b[i]
+		if bit == 0 {
+			c = ^c
+		}
+		if c != 0 {
+			return i*8 + bits.LeadingZeros8(c)
+		}
+	}
+	return -1
+}
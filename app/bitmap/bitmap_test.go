@@ -0,0 +1,139 @@
+package bitmap
+
+import (
+	"bytes"
+	"math"
+	"math/bits"
+	"math/rand"
+	"testing"
+)
+
+func TestCountAndPos_MatchNaive(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for n := 0; n < 40; n++ {
+		b := make([]byte, n)
+		r.Read(b)
+		// sparse and dense inputs exercise skipping of whole words
+		if n%3 == 0 {
+			b = make([]byte, n)
+		}
+		if n%3 == 1 {
+			b = bytes.Repeat([]byte{0xff}, n)
+		}
+		if n > 0 && n%3 != 2 {
+			b[n-1] ^= 0x10
+		}
+		naiveCount, naivePos := 0, [2]int{-1, -1}
+		for i, c := range b {
+			naiveCount += bits.OnesCount8(c)
+			for j := 0; j < 8; j++ {
+				bit := int(c>>(7-j)) & 1
+				if naivePos[bit] == -1 {
+					naivePos[bit] = i*8 + j
+				}
+			}
+		}
+		if got := Count(b); got != naiveCount {
+			t.Fatalf("expected count %v of %x, got %v", naiveCount, b, got)
+		}
+		for bit := 0; bit <= 1; bit++ {
+			if got := Pos(b, bit); got != naivePos[bit] {
+				t.Fatalf("expected pos of %v in %x to be %v, got %v", bit, b, naivePos[bit], got)
+			}
+		}
+	}
+}
+
+func TestSetBit_GrowsAndReturnsOldBit(t *testing.T) {
+	b, old := SetBit(nil, 10, 1)
+	if len(b) != 2 || b[1] != 0x20 || old != 0 {
+		t.Fatalf("expected [00 20] and old bit 0, got %x and %v", b, old)
+	}
+	b, old = SetBit(b, 10, 0)
+	if b[1] != 0 || old != 1 {
+		t.Fatalf("expected bit to be cleared and old bit 1, got %x and %v", b, old)
+	}
+}
+
+func TestApply(t *testing.T) {
+	a := []byte{0xf0, 0x0f, 0xff, 0x00, 0xaa, 0x55, 0x01, 0x80, 0x11}
+	b := []byte{0xff, 0x00}
+	c := []byte{0x0f}
+	cases := []struct {
+		op       OpEnum
+		srcs     [][]byte
+		expected []byte
+	}{
+		{And, [][]byte{a, b}, []byte{0xf0, 0, 0, 0, 0, 0, 0, 0, 0}},
+		{Or, [][]byte{a, b}, []byte{0xff, 0x0f, 0xff, 0x00, 0xaa, 0x55, 0x01, 0x80, 0x11}},
+		{Xor, [][]byte{a, b}, []byte{0x0f, 0x0f, 0xff, 0x00, 0xaa, 0x55, 0x01, 0x80, 0x11}},
+		{Not, [][]byte{b}, []byte{0x00, 0xff}},
+		{Diff, [][]byte{b, c}, []byte{0xf0, 0x00}},
+		{Diff1, [][]byte{c, b}, []byte{0xf0, 0x00}},
+		{AndOr, [][]byte{b, a, c}, []byte{0xff, 0, 0, 0, 0, 0, 0, 0, 0}},
+		{One, [][]byte{b, c, c}, []byte{0xf0, 0x00}},
+	}
+	for _, tc := range cases {
+		if got := Apply(tc.op, tc.srcs); !bytes.Equal(got, tc.expected) {
+			t.Fatalf("expected %v to produce %x, got %x", tc.op, tc.expected, got)
+		}
+	}
+}
+
+func TestBitfield_GetSet(t *testing.T) {
+	b := make([]byte, 3)
+	SetField(b, 5, 8, 0xab)
+	if got := GetUnsigned(b, 5, 8); got != 0xab {
+		t.Fatalf("expected 0xab, got %#x", got)
+	}
+	if got := GetSigned(b, 5, 8); got != -85 {
+		t.Fatalf("expected -85, got %v", got)
+	}
+	if got := GetSigned(b, 5, 4); got != -6 {
+		t.Fatalf("expected -6, got %v", got)
+	}
+}
+
+func TestBitfield_Overflow(t *testing.T) {
+	unsigned := []struct {
+		value, expected uint64
+		incr            int64
+		mode            OverflowEnum
+		ok              bool
+	}{
+		{250, 4, 10, Wrap, true},
+		{250, 255, 10, Sat, true},
+		{250, 0, 10, Fail, false},
+		{5, 251, -10, Wrap, true},
+		{5, 0, -10, Sat, true},
+		{5, 0, math.MinInt64, Sat, true},
+		{math.MaxUint64, 255, 0, Wrap, true},
+	}
+	for _, c := range unsigned {
+		res, ok := AddUnsigned(c.value, c.incr, 8, c.mode)
+		if res != c.expected || ok != c.ok {
+			t.Fatalf("expected u8 %v+%v in %v mode to be %v %v, got %v %v", c.value, c.incr, c.mode, c.expected, c.ok, res, ok)
+		}
+	}
+	signed := []struct {
+		value, incr, expected int64
+		width                 int
+		mode                  OverflowEnum
+		ok                    bool
+	}{
+		{120, 10, -126, 8, Wrap, true},
+		{120, 10, 127, 8, Sat, true},
+		{-120, -10, 126, 8, Wrap, true},
+		{-120, -10, -128, 8, Sat, true},
+		{-120, -10, 0, 8, Fail, false},
+		{math.MinInt64, 0, -128, 8, Sat, true},
+		{math.MaxInt64, 1, math.MinInt64, 64, Wrap, true},
+		{math.MinInt64, -1, math.MinInt64, 64, Sat, true},
+	}
+	for _, c := range signed {
+		res, ok := AddSigned(c.value, c.incr, c.width, c.mode)
+		if res != c.expected || ok != c.ok {
+			t.Fatalf("expected i%v %v+%v in %v mode to be %v %v, got %v %v", c.width, c.value, c.incr, c.mode, c.expected, c.ok, res, ok)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package bitmap
+
+import "encoding/binary"
+
+type OpEnum string
+
+// BITOP operations, DIFF, DIFF1 and ANDOR combine first source with union of the rest, ONE keeps bits set in exactly one source
+const (
+	And   OpEnum = "AND"
+	Or    OpEnum = "OR"
+	Xor   OpEnum = "XOR"
+	Not   OpEnum = "NOT"
+	Diff  OpEnum = "DIFF"
+	Diff1 OpEnum = "DIFF1"
+	AndOr OpEnum = "ANDOR"
+	One   OpEnum = "ONE"
+)
+
+// applies op to sources 8 bytes at a time, shorter sources are padded with zero bytes,
+// result is as long as the longest source
+func Apply(op OpEnum, srcs [][]byte) []byte {
+	maxLen := 0
+	for _, src := range srcs {
+		maxLen = max(maxLen, len(src))
+	}
+	res := make([]byte, maxLen)
+	words := make([]uint64, len(srcs))
+	for i := 0; i < maxLen; i += 8 {
+		for j, src := range srcs {
+			words[j] = load(src, i)
+		}
+		w := combine(op, words)
+		if i+8 <= maxLen {
+			binary.BigEndian.PutUint64(res[i:], w)
+		} else {
+			var buf [8]byte
+			binary.BigEndian.PutUint64(buf[:], w)
+			copy(res[i:], buf[:])
+		}
+	}
+	return res
+}
+
+// loads 8 bytes starting at i, bytes beyond src are zeros
+func load(src []byte, i int) uint64 {
+	if i+8 <= len(src) {
+		return binary.BigEndian.Uint64(src[i:])
+	}
+	var buf [8]byte
+	if i < len(src) {
+		copy(buf[:], src[i:])
+	}
+	return binary.BigEndian.Uint64(buf[:])
+}
+
+func combine(op OpEnum, words []uint64) uint64 {
+	switch op {
+	case Not:
+		return ^words[0]
+	case Diff:
+		return words[0] &^ union(words[1:])
+	case Diff1:
+		return ^words[0] & union(words[1:])
+	case AndOr:
+		return words[0] & union(words[1:])
+	case One:
+		// ones holds bits seen odd times, twos bits seen at least twice
+		ones, twos := uint64(0), uint64(0)
+		for _, w := range words {
+			twos |= ones & w
+			ones ^= w
+		}
+		return ones &^ twos
+	}
+	res := words[0]
+	for _, w := range words[1:] {
+		switch op {
+		case And:
+			res &= w
+		case Or:
+			res |= w
+		case Xor:
+			res ^= w
+		}
+	}
+	return res
+}
+
+func union(words []uint64) uint64 {
+	res := uint64(0)
+	for _, w := range words {
+		res |= w
+	}
+	return res
+}
@@ -0,0 +1,471 @@
+// client connected to server: connection info, authenticated user and state changed by CLIENT command
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/acl"
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+)
+
+type TypeEnum string
+
+const (
+	NormalType  TypeEnum = "normal"
+	MasterType  TypeEnum = "master"
+	ReplicaType TypeEnum = "replica"
+	PubsubType  TypeEnum = "pubsub"
+)
+
+type ReplyModeEnum string
+
+const (
+	ReplyOn   ReplyModeEnum = "ON"
+	ReplyOff  ReplyModeEnum = "OFF"
+	ReplySkip ReplyModeEnum = "SKIP"
+)
+
+type UnblockReasonEnum string
+
+const (
+	// blocked command returns as if its timeout elapsed
+	UnblockTimeout UnblockReasonEnum = "TIMEOUT"
+	// blocked command returns UnblockedError
+	UnblockError UnblockReasonEnum = "ERROR"
+)
+
+var UnblockedError = errors.New("UNBLOCKED client unblocked via CLIENT UNBLOCK")
+
+var InvalidNameError = errors.New("ERR Client names cannot contain spaces, newlines or special characters.")
+
+// connection that client replies to
+type Conn interface {
+	io.Writer
+	Close() error
+	RemoteAddr() net.Addr
+	LocalAddr() net.Addr
+}
+
+// implemented by connections that buffer replies, e.g. event loop connection
+type outputBuffered interface {
+	OutputBuffered() int
+}
+
+type Client struct {
+	id        int64
+	conn      Conn
+	addr      string
+	laddr     string
+	unix      bool
+	createdAt time.Time
+
+	// guards fields below, they are read by CLIENT LIST and changed by commands of other clients
+	mu              sync.Mutex
+	typ             TypeEnum
+	name            string
+	user            *acl.User
+	authenticated   bool
+	lastCmd         string
+	lastInteraction time.Time
+	noEvict         bool
+	noTouch         bool
+	// database selected by SELECT
+	db int
+	// protocol version selected by HELLO
+	resp int
+	// amount of channels, patterns and shard channels client is subscribed to
+	sub  int
+	psub int
+	ssub int
+	// amount of queued commands, -1 outside of transaction
+	multi int
+	// unread bytes of query buffer
+	qbuf int
+	// connection is closed after reply to current command, e.g. client killed itself
+	closeAfterReply bool
+	closed          bool
+	blocked         bool
+	// closed by CLIENT UNBLOCK, reason tells how blocked command returns
+	unblock       chan struct{}
+	unblockReason UnblockReasonEnum
+
+	// owned by connection goroutine
+	replyMode ReplyModeEnum
+	// reply of current command is dropped because of CLIENT REPLY SKIP sent before it
+	skipCurrent bool
+	skipNext    bool
+}
+
+func newClient(id int64, conn Conn, typ TypeEnum, user *acl.User, authenticated bool) *Client {
+	now := time.Now()
+	_, unix := conn.LocalAddr().(*net.UnixAddr)
+	laddr := formatAddr(conn.LocalAddr())
+	addr := laddr
+	if !unix {
+		addr = formatAddr(conn.RemoteAddr())
+	}
+	return &Client{
+		id:              id,
+		conn:            conn,
+		addr:            addr,
+		laddr:           laddr,
+		unix:            unix,
+		createdAt:       now,
+		typ:             typ,
+		user:            user,
+		authenticated:   authenticated,
+		lastInteraction: now,
+		lastCmd:         "NULL",
+		multi:           -1,
+		resp:            2,
+		replyMode:       ReplyOn,
+	}
+}
+
+// unix socket clients have no remote address, redis shows socket path with fake port for both addresses
+func formatAddr(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	if unixAddr, ok := addr.(*net.UnixAddr); ok {
+		return unixAddr.Name + ":0"
+	}
+	return addr.String()
+}
+
+// returns socket fd for CLIENT LIST, -1 if connection does not expose it
+func connFd(conn Conn) int {
+	if fdConn, ok := conn.(interface{ Fd() int }); ok {
+		return fdConn.Fd()
+	}
+	if netConn, ok := conn.(interface{ NetConn() net.Conn }); ok {
+		return connFd(netConn.NetConn())
+	}
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return -1
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return -1
+	}
+	fd := -1
+	raw.Control(func(s uintptr) {
+		fd = int(s)
+	})
+	return fd
+}
+
+func (this *Client) GetId() int64 {
+	return this.id
+}
+
+func (this *Client) GetAddr() string {
+	return this.addr
+}
+
+func (this *Client) GetLocalAddr() string {
+	return this.laddr
+}
+
+func (this *Client) GetType() TypeEnum {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.typ
+}
+
+func (this *Client) SetType(typ TypeEnum) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.typ = typ
+}
+
+func (this *Client) GetName() string {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.name
+}
+
+func (this *Client) SetName(name string) error {
+	for _, c := range name {
+		if c < '!' || c > '~' {
+			return InvalidNameError
+		}
+	}
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.name = name
+	return nil
+}
+
+// returns user commands are checked against and is client authenticated
+func (this *Client) GetUser() (*acl.User, bool) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.user, this.authenticated
+}
+
+func (this *Client) SetUser(user *acl.User, authenticated bool) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.user = user
+	this.authenticated = authenticated
+}
+
+func (this *Client) GetAge() time.Duration {
+	return time.Since(this.createdAt)
+}
+
+func (this *Client) GetIdle() time.Duration {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return time.Since(this.lastInteraction)
+}
+
+func (this *Client) SetNoEvict(noEvict bool) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.noEvict = noEvict
+}
+
+func (this *Client) GetDb() int {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.db
+}
+
+func (this *Client) SetDb(db int) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.db = db
+}
+
+func (this *Client) GetResp() int {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.resp
+}
+
+func (this *Client) SetResp(resp int) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.resp = resp
+}
+
+// sets amount of subscriptions, client with subscriptions is pubsub client and leaves this state without them
+func (this *Client) SetSubscriptions(sub int, psub int, ssub int) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.sub = sub
+	this.psub = psub
+	this.ssub = ssub
+	total := sub + psub + ssub
+	switch {
+	case this.typ == NormalType && total > 0:
+		this.typ = PubsubType
+	case this.typ == PubsubType && total == 0:
+		this.typ = NormalType
+	}
+}
+
+// reports is client in subscribed state where only pubsub commands are allowed, RESP3 clients are not limited
+func (this *Client) IsSubscribedMode() bool {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.typ == PubsubType && this.resp == 2
+}
+
+func (this *Client) SetNoTouch(noTouch bool) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.noTouch = noTouch
+}
+
+func (this *Client) IsNoTouch() bool {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.noTouch
+}
+
+func (this *Client) SetMulti(queued int) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.multi = queued
+}
+
+func (this *Client) SetQueryBuffer(qbuf int) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.qbuf = qbuf
+}
+
+// registers start of command execution, applies reply skip requested by previous command
+func (this *Client) BeginCommand(cmd *command.Command) {
+	name, _ := cmd.GetSpec()
+	this.mu.Lock()
+	this.lastCmd = name
+	this.lastInteraction = time.Now()
+	this.mu.Unlock()
+	this.skipCurrent = this.skipNext
+	this.skipNext = false
+}
+
+// replaces connection of client, e.g. replica connection detached from event loop,
+// client is not replied after this call
+func (this *Client) SetConn(conn Conn) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.conn = conn
+}
+
+// writes reply unless replies are switched off by CLIENT REPLY, nil reply is not written
+func (this *Client) Reply(data *datatypes.Data) {
+	if data == nil || this.replyMode == ReplyOff || this.skipCurrent {
+		return
+	}
+	this.conn.Write(data.Marshall())
+}
+
+// writes message which is not a reply to command, e.g. pubsub message, it is delivered regardless of reply mode
+func (this *Client) Push(data *datatypes.Data) {
+	this.mu.Lock()
+	conn := this.conn
+	this.mu.Unlock()
+	conn.Write(data.Marshall())
+}
+
+// writes raw bytes bypassing reply mode, used for protocol errors
+func (this *Client) Write(p []byte) (int, error) {
+	return this.conn.Write(p)
+}
+
+// CLIENT REPLY SKIP and OFF are not replied themselves
+func (this *Client) SetReplyMode(mode ReplyModeEnum) {
+	switch mode {
+	case ReplySkip:
+		this.skipCurrent = true
+		this.skipNext = true
+	default:
+		this.replyMode = mode
+	}
+}
+
+// closes connection, if client is the caller connection is closed after reply
+func (this *Client) Kill(caller *Client) {
+	this.mu.Lock()
+	if this == caller {
+		this.closeAfterReply = true
+		this.mu.Unlock()
+		return
+	}
+	this.closed = true
+	conn := this.conn
+	this.mu.Unlock()
+	conn.Close()
+}
+
+func (this *Client) ShouldClose() bool {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.closeAfterReply || this.closed
+}
+
+// marks client blocked by command, returned channel is closed when client is unblocked by CLIENT UNBLOCK
+func (this *Client) Block() <-chan struct{} {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.blocked = true
+	this.unblock = make(chan struct{})
+	this.unblockReason = ""
+	return this.unblock
+}
+
+// ends blocking, returns reason of CLIENT UNBLOCK or empty reason if command was not unblocked
+func (this *Client) EndBlock() UnblockReasonEnum {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.blocked = false
+	this.unblock = nil
+	return this.unblockReason
+}
+
+func (this *Client) IsBlocked() bool {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.blocked
+}
+
+// wakes blocked client up, returns false if client is not blocked
+func (this *Client) Unblock(reason UnblockReasonEnum) bool {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if !this.blocked || this.unblockReason != "" {
+		return false
+	}
+	this.unblockReason = reason
+	close(this.unblock)
+	return true
+}
+
+func (this *Client) flags() string {
+	var sb strings.Builder
+	if this.closeAfterReply || this.closed {
+		sb.WriteByte('A')
+	}
+	if this.blocked {
+		sb.WriteByte('b')
+	}
+	if this.noEvict {
+		sb.WriteByte('e')
+	}
+	switch this.typ {
+	case MasterType:
+		sb.WriteByte('M')
+	case ReplicaType:
+		sb.WriteByte('S')
+	case PubsubType:
+		sb.WriteByte('P')
+	}
+	if this.noTouch {
+		sb.WriteByte('T')
+	}
+	if this.unix {
+		sb.WriteByte('U')
+	}
+	if this.multi >= 0 {
+		sb.WriteByte('x')
+	}
+	if sb.Len() == 0 {
+		return "N"
+	}
+	return sb.String()
+}
+
+// describes client in format of CLIENT LIST
+func (this *Client) Info() string {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	now := time.Now()
+	obuf := 0
+	if buffered, ok := this.conn.(outputBuffered); ok {
+		obuf = buffered.OutputBuffered()
+	}
+	username := ""
+	if this.user != nil {
+		username = this.user.GetName()
+	}
+	return fmt.Sprintf(
+		"id=%v addr=%v laddr=%v fd=%v name=%v age=%v idle=%v flags=%v db=%v sub=%v psub=%v ssub=%v multi=%v qbuf=%v obl=0 oll=0 omem=%v cmd=%v user=%v resp=%v",
+		this.id, this.addr, this.laddr, connFd(this.conn), this.name,
+		int(now.Sub(this.createdAt).Seconds()), int(now.Sub(this.lastInteraction).Seconds()),
+		this.flags(), this.db, this.sub, this.psub, this.ssub, this.multi, this.qbuf, obuf, this.lastCmd, username, this.resp,
+	)
+}
@@ -0,0 +1,185 @@
+package client
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/acl"
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+var MaxClientsError = errors.New("ERR max number of clients reached")
+
+// server wide table of connected clients
+type Table struct {
+	mu      sync.RWMutex
+	clients map[int64]*Client
+	nextId  int64
+	pause   pauseState
+	// counts pauses, timer of pause that was extended or ended since it was armed must not end current one
+	pauseGen uint64
+	// connection counters shown by INFO stats
+	totalConnections    int64
+	rejectedConnections int64
+}
+
+// clients are paused by CLIENT PAUSE until deadline or CLIENT UNPAUSE
+type pauseState struct {
+	until time.Time
+	// every command is paused, otherwise only write commands are
+	all bool
+	// closed when pause ends
+	done  chan struct{}
+	timer *time.Timer
+}
+
+func NewTable() *Table {
+	return &Table{
+		clients: map[int64]*Client{},
+		nextId:  1,
+	}
+}
+
+// creates client for accepted connection and adds it to table, connection is rejected with MaxClientsError
+// if table already has maxClients clients, 0 maxClients means no limit
+func (this *Table) Register(conn Conn, typ TypeEnum, user *acl.User, authenticated bool, maxClients int) (*Client, error) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.totalConnections++
+	if maxClients > 0 && len(this.clients) >= maxClients {
+		this.rejectedConnections++
+		return nil, MaxClientsError
+	}
+	c := newClient(this.nextId, conn, typ, user, authenticated)
+	this.nextId++
+	this.clients[c.id] = c
+	return c, nil
+}
+
+func (this *Table) Unregister(c *Client) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	delete(this.clients, c.id)
+}
+
+func (this *Table) Get(id int64) (*Client, bool) {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	c, ok := this.clients[id]
+	return c, ok
+}
+
+func (this *Table) Len() int {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return len(this.clients)
+}
+
+// returns clients accepted by filter ordered by id, nil filter accepts every client
+func (this *Table) List(filter func(c *Client) bool) []*Client {
+	this.mu.RLock()
+	out := make([]*Client, 0, len(this.clients))
+	for _, c := range this.clients {
+		out = append(out, c)
+	}
+	this.mu.RUnlock()
+
+	filtered := out[:0]
+	for _, c := range out {
+		if filter == nil || filter(c) {
+			filtered = append(filtered, c)
+		}
+	}
+	sort.Slice(filtered, func(i, j int) bool { return filtered[i].id < filtered[j].id })
+	return filtered
+}
+
+// closes connections of clients idle longer than timeout, replicas, master, blocked and pubsub clients are kept
+// like in redis, returns amount of closed connections
+func (this *Table) CloseIdle(timeout time.Duration) int {
+	idle := this.List(func(c *Client) bool {
+		switch c.GetType() {
+		case MasterType, ReplicaType, PubsubType:
+			return false
+		}
+		return !c.IsBlocked() && c.GetIdle() > timeout
+	})
+	for _, c := range idle {
+		c.Kill(nil)
+	}
+	return len(idle)
+}
+
+func (this *Table) GetInfo() []types.Kv {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return []types.Kv{
+		{"total_connections_received", strconv.FormatInt(this.totalConnections, 10)},
+		{"rejected_connections", strconv.FormatInt(this.rejectedConnections, 10)},
+	}
+}
+
+// pauses clients for timeout, like in redis longer pause and pause of every command take precedence
+func (this *Table) Pause(timeout time.Duration, all bool) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	until := time.Now().Add(timeout)
+	if this.pause.done == nil {
+		this.pause = pauseState{until: until, all: all, done: make(chan struct{})}
+	} else {
+		if until.After(this.pause.until) {
+			this.pause.until = until
+		}
+		this.pause.all = this.pause.all || all
+		this.pause.timer.Stop()
+	}
+	// stopped timer may have already fired and wait for lock, so it ends pause only if it is still the armed one
+	this.pauseGen++
+	gen := this.pauseGen
+	this.pause.timer = time.AfterFunc(time.Until(this.pause.until), func() {
+		this.expirePause(gen)
+	})
+}
+
+func (this *Table) Unpause() {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.endPause()
+}
+
+// ends pause armed with gen, pause that was extended or replaced since then is kept
+func (this *Table) expirePause(gen uint64) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if gen != this.pauseGen {
+		return
+	}
+	this.endPause()
+}
+
+// should be called under lock
+func (this *Table) endPause() {
+	if this.pause.done == nil {
+		return
+	}
+	this.pause.timer.Stop()
+	close(this.pause.done)
+	this.pause = pauseState{}
+}
+
+// returns channel closed when pause ends, nil if command of client is not paused
+func (this *Table) PausedUntil(c *Client, isWrite bool) <-chan struct{} {
+	typ := c.GetType()
+	if typ == MasterType || typ == ReplicaType {
+		return nil
+	}
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	if this.pause.done == nil || (!this.pause.all && !isWrite) {
+		return nil
+	}
+	return this.pause.done
+}
@@ -0,0 +1,161 @@
+package client
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+type testConn struct {
+	remote net.Addr
+	local  net.Addr
+	closed bool
+}
+
+func newTestConn(port int) *testConn {
+	return &testConn{
+		remote: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port},
+		local:  &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 6379},
+	}
+}
+
+func (this *testConn) Write(p []byte) (int, error) { return len(p), nil }
+func (this *testConn) Close() error                { this.closed = true; return nil }
+func (this *testConn) RemoteAddr() net.Addr        { return this.remote }
+func (this *testConn) LocalAddr() net.Addr         { return this.local }
+
+func mustRegister(t *testing.T, table *Table, conn Conn, typ TypeEnum) *Client {
+	c, err := table.Register(conn, typ, nil, true, 0)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return c
+}
+
+func TestTable_RegisterAndList(t *testing.T) {
+	table := NewTable()
+	first := mustRegister(t, table, newTestConn(1000), NormalType)
+	second := mustRegister(t, table, newTestConn(1001), ReplicaType)
+	if first.GetId() != 1 || second.GetId() != 2 {
+		t.Fatalf("expected ids 1 and 2, got %v and %v", first.GetId(), second.GetId())
+	}
+	if second.GetAddr() != "127.0.0.1:1001" || second.GetLocalAddr() != "127.0.0.1:6379" {
+		t.Fatalf("unexpected addresses %v %v", second.GetAddr(), second.GetLocalAddr())
+	}
+
+	replicas := table.List(func(c *Client) bool { return c.GetType() == ReplicaType })
+	if len(replicas) != 1 || replicas[0] != second {
+		t.Fatalf("expected only second client, got %v", replicas)
+	}
+
+	_, err := table.Register(newTestConn(1002), NormalType, nil, true, 2)
+	if err != MaxClientsError {
+		t.Fatalf("expected %v, got %v", MaxClientsError, err)
+	}
+
+	table.Unregister(first)
+	if _, ok := table.Get(first.GetId()); ok {
+		t.Fatalf("expected unregistered client to be removed")
+	}
+	if table.Len() != 1 {
+		t.Fatalf("expected 1 client, got %v", table.Len())
+	}
+}
+
+func TestTable_Pause(t *testing.T) {
+	table := NewTable()
+	normal := mustRegister(t, table, newTestConn(1000), NormalType)
+	replica := mustRegister(t, table, newTestConn(1001), ReplicaType)
+
+	table.Pause(time.Minute, false)
+	if table.PausedUntil(normal, false) != nil {
+		t.Fatalf("expected read command not to be paused by write pause")
+	}
+	done := table.PausedUntil(normal, true)
+	if done == nil {
+		t.Fatalf("expected write command to be paused")
+	}
+	if table.PausedUntil(replica, true) != nil {
+		t.Fatalf("expected replica not to be paused")
+	}
+
+	// pause of every command takes precedence over write pause
+	table.Pause(time.Millisecond, true)
+	if table.PausedUntil(normal, false) == nil {
+		t.Fatalf("expected read command to be paused")
+	}
+
+	table.Unpause()
+	select {
+	case <-done:
+	default:
+		t.Fatalf("expected pause channel to be closed by unpause")
+	}
+	if table.PausedUntil(normal, true) != nil {
+		t.Fatalf("expected no pause after unpause")
+	}
+}
+
+// timer of earlier pause may fire after pause was extended or ended and paused again
+func TestTable_StalePauseTimerKeepsNewerPause(t *testing.T) {
+	table := NewTable()
+	normal := mustRegister(t, table, newTestConn(1000), NormalType)
+
+	table.Pause(time.Minute, false)
+	extended := table.pauseGen
+	table.Pause(time.Hour, false)
+	table.expirePause(extended)
+	if table.PausedUntil(normal, true) == nil {
+		t.Fatalf("expected extended pause to be kept by timer of previous deadline")
+	}
+
+	table.Unpause()
+	ended := table.pauseGen
+	table.Pause(time.Minute, false)
+	table.expirePause(ended)
+	if table.PausedUntil(normal, true) == nil {
+		t.Fatalf("expected new pause to be kept by timer of ended pause")
+	}
+
+	table.expirePause(table.pauseGen)
+	if table.PausedUntil(normal, true) != nil {
+		t.Fatalf("expected pause to be ended by its own timer")
+	}
+
+	table.Pause(time.Millisecond, false)
+	deadline := time.Now().Add(2 * time.Second)
+	for table.PausedUntil(normal, true) != nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected pause to expire")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestClient_KillAndUnblock(t *testing.T) {
+	table := NewTable()
+	caller := mustRegister(t, table, newTestConn(1000), NormalType)
+	conn := newTestConn(1001)
+	target := mustRegister(t, table, conn, NormalType)
+
+	if target.Unblock(UnblockError) {
+		t.Fatalf("expected not blocked client not to be unblocked")
+	}
+	unblocked := target.Block()
+	if !target.Unblock(UnblockError) {
+		t.Fatalf("expected blocked client to be unblocked")
+	}
+	<-unblocked
+	if reason := target.EndBlock(); reason != UnblockError {
+		t.Fatalf("expected reason %v, got %v", UnblockError, reason)
+	}
+
+	target.Kill(caller)
+	if !conn.closed || !target.ShouldClose() {
+		t.Fatalf("expected killed client connection to be closed")
+	}
+	caller.Kill(caller)
+	if !caller.ShouldClose() {
+		t.Fatalf("expected client killing itself to be closed after reply")
+	}
+}
@@ -6,11 +6,25 @@ import (
 	"strings"
 
 	"github.com/codecrafters-io/redis-starter-go/app/commands"
+	aclcommand "github.com/codecrafters-io/redis-starter-go/app/commands/acl_command"
+	authcommand "github.com/codecrafters-io/redis-starter-go/app/commands/auth_command"
+	bitmapcommand "github.com/codecrafters-io/redis-starter-go/app/commands/bitmap_command"
+	clientcommand "github.com/codecrafters-io/redis-starter-go/app/commands/client_command"
+	connectioncommand "github.com/codecrafters-io/redis-starter-go/app/commands/connection_command"
+	dbcommand "github.com/codecrafters-io/redis-starter-go/app/commands/db_command"
+	geocommand "github.com/codecrafters-io/redis-starter-go/app/commands/geo_command"
+	hllcommand "github.com/codecrafters-io/redis-starter-go/app/commands/hll_command"
 	incrcommand "github.com/codecrafters-io/redis-starter-go/app/commands/incr_command"
 	infocommand "github.com/codecrafters-io/redis-starter-go/app/commands/info_command"
+	pubsubcommand "github.com/codecrafters-io/redis-starter-go/app/commands/pubsub_command"
 	replconfcommand "github.com/codecrafters-io/redis-starter-go/app/commands/repl_conf_command"
+	scancommand "github.com/codecrafters-io/redis-starter-go/app/commands/scan_command"
+	shutdowncommand "github.com/codecrafters-io/redis-starter-go/app/commands/shutdown_command"
+	stringcommand "github.com/codecrafters-io/redis-starter-go/app/commands/string_command"
 	"github.com/codecrafters-io/redis-starter-go/app/commands/type_command"
 	xaddcommand "github.com/codecrafters-io/redis-starter-go/app/commands/xadd_command"
+	xgroupcommand "github.com/codecrafters-io/redis-starter-go/app/commands/xgroup_command"
+	xinfocommand "github.com/codecrafters-io/redis-starter-go/app/commands/xinfo_command"
 	xrangecommand "github.com/codecrafters-io/redis-starter-go/app/commands/xrange_command"
 	xreadcommand "github.com/codecrafters-io/redis-starter-go/app/commands/xread_command"
 	"github.com/codecrafters-io/redis-starter-go/app/data_types"
@@ -20,33 +34,107 @@ import (
 type CommandEnum string
 
 const (
-	PING       = "PING"
-	PONG       = "PONG"
-	ECHO       = "ECHO"
-	SET        = "SET"
-	GET        = "GET"
-	INFO       = "INFO"
-	CONFIG     = "CONFIG"
-	REPLCONF   = "REPLCONF"
-	OK         = "OK"
-	PSYNC      = "PSYNC"
-	FULLRESYNC = "FULLRESYNC"
-	WAIT       = "WAIT"
-	KEYS       = "KEYS"
-	TYPE       = "TYPE"
-	XADD       = "XADD"
-	XRANGE     = "XRANGE"
-	XREAD      = "XREAD"
-	INCR       = "INCR"
-	MULTI      = "MULTI"
-	EXEC       = "EXEC"
-	DISCARD    = "DISCARD"
+	PING                 = "PING"
+	PONG                 = "PONG"
+	ECHO                 = "ECHO"
+	SET                  = "SET"
+	GET                  = "GET"
+	INFO                 = "INFO"
+	CONFIG               = "CONFIG"
+	REPLCONF             = "REPLCONF"
+	OK                   = "OK"
+	PSYNC                = "PSYNC"
+	FULLRESYNC           = "FULLRESYNC"
+	WAIT                 = "WAIT"
+	KEYS                 = "KEYS"
+	TYPE                 = "TYPE"
+	XADD                 = "XADD"
+	XRANGE               = "XRANGE"
+	XREAD                = "XREAD"
+	INCR                 = "INCR"
+	MULTI                = "MULTI"
+	EXEC                 = "EXEC"
+	DISCARD              = "DISCARD"
+	AUTH                 = "AUTH"
+	ACL                  = "ACL"
+	CLIENT               = "CLIENT"
+	SHUTDOWN             = "SHUTDOWN"
+	SELECT               = "SELECT"
+	MOVE                 = "MOVE"
+	SWAPDB               = "SWAPDB"
+	DBSIZE               = "DBSIZE"
+	FLUSHDB              = "FLUSHDB"
+	FLUSHALL             = "FLUSHALL"
+	SCAN                 = "SCAN"
+	HSCAN                = "HSCAN"
+	SSCAN                = "SSCAN"
+	ZSCAN                = "ZSCAN"
+	APPEND               = "APPEND"
+	STRLEN               = "STRLEN"
+	GETRANGE             = "GETRANGE"
+	SETRANGE             = "SETRANGE"
+	MGET                 = "MGET"
+	MSET                 = "MSET"
+	MSETNX               = "MSETNX"
+	LCS                  = "LCS"
+	INCRBY               = "INCRBY"
+	DECR                 = "DECR"
+	DECRBY               = "DECRBY"
+	INCRBYFLOAT          = "INCRBYFLOAT"
+	SETBIT               = "SETBIT"
+	GETBIT               = "GETBIT"
+	BITCOUNT             = "BITCOUNT"
+	BITPOS               = "BITPOS"
+	BITOP                = "BITOP"
+	BITFIELD             = "BITFIELD"
+	BITFIELD_RO          = "BITFIELD_RO"
+	PFADD                = "PFADD"
+	PFCOUNT              = "PFCOUNT"
+	PFMERGE              = "PFMERGE"
+	GEOADD               = "GEOADD"
+	GEODIST              = "GEODIST"
+	GEOPOS               = "GEOPOS"
+	GEOHASH              = "GEOHASH"
+	GEOSEARCH            = "GEOSEARCH"
+	GEOSEARCHSTORE       = "GEOSEARCHSTORE"
+	GEORADIUS            = "GEORADIUS"
+	GEORADIUS_RO         = "GEORADIUS_RO"
+	GEORADIUSBYMEMBER    = "GEORADIUSBYMEMBER"
+	GEORADIUSBYMEMBER_RO = "GEORADIUSBYMEMBER_RO"
+	XGROUP               = "XGROUP"
+	XREADGROUP           = "XREADGROUP"
+	XACK                 = "XACK"
+	XPENDING             = "XPENDING"
+	XCLAIM               = "XCLAIM"
+	XAUTOCLAIM           = "XAUTOCLAIM"
+	XLEN                 = "XLEN"
+	XDEL                 = "XDEL"
+	XTRIM                = "XTRIM"
+	XREVRANGE            = "XREVRANGE"
+	XINFO                = "XINFO"
+	XSETID               = "XSETID"
+	SUBSCRIBE            = "SUBSCRIBE"
+	UNSUBSCRIBE          = "UNSUBSCRIBE"
+	PSUBSCRIBE           = "PSUBSCRIBE"
+	PUNSUBSCRIBE         = "PUNSUBSCRIBE"
+	PUBLISH              = "PUBLISH"
+	PUBSUB               = "PUBSUB"
+	HELLO                = "HELLO"
+	QUIT                 = "QUIT"
+	RESET                = "RESET"
+	SSUBSCRIBE           = "SSUBSCRIBE"
+	SUNSUBSCRIBE         = "SUNSUBSCRIBE"
+	SPUBLISH             = "SPUBLISH"
 )
 
 type Command struct {
 	Type CommandEnum
 	Raw  *datatypes.Data
 	Args commands.CommandArgs
+	// error of arguments parsing, reported to client instead of executing command
+	ArgsErr error
+	// commands propagated to replicas instead of command, set by executor when effects of command are not deterministic
+	Rewrites []*Command
 }
 
 func DataTypeToCommand(d *datatypes.Data) (cmd *Command, err error) {
@@ -73,6 +161,7 @@ func DataTypeToCommand(d *datatypes.Data) (cmd *Command, err error) {
 	err = out.ParseArgs()
 	if err != nil {
 		logger.Logger.Error("Error parsing args", logger.String("error", err.Error()))
+		out.ArgsErr = err
 	}
 	return out, nil
 }
@@ -93,8 +182,74 @@ func (this *Command) Marshall() []byte {
 	return this.Raw.Marshall()
 }
 
+// returns commands propagated to replicas after command is executed
+func (this *Command) Propagated() []*Command {
+	if this.Rewrites != nil {
+		return this.Rewrites
+	}
+	if this.IsWriteCommand() {
+		return []*Command{this}
+	}
+	return nil
+}
+
+// adds command propagated instead of command
+func (this *Command) Rewrite(typ CommandEnum, args ...string) {
+	this.Rewrites = append(this.Rewrites, Construct(typ, args...))
+}
+
+// reports is command propagated to replicas as is
 func (this *Command) IsWriteCommand() bool {
-	return this.Type == SET
+	switch this.Type {
+	case SET, MOVE, SWAPDB, FLUSHDB, FLUSHALL, APPEND, SETRANGE, MSET, MSETNX, INCR, INCRBY, DECR, DECRBY, INCRBYFLOAT,
+		SETBIT, BITOP, BITFIELD, PFADD, PFMERGE, GEOADD, GEOSEARCHSTORE, XDEL, XSETID, XGROUP, XACK:
+		return true
+	// XADD, XTRIM, XREADGROUP, XCLAIM and XAUTOCLAIM are propagated by executor as Rewrites
+	// publish does not change data, it is propagated so subscribers of replicas receive messages too
+	case PUBLISH, SPUBLISH:
+		return true
+	case GEORADIUS, GEORADIUSBYMEMBER:
+		if this.Args == nil {
+			return false
+		}
+		var query geocommand.SearchQuery
+		searchArg, _ := this.Args.GetArgValue(geocommand.Search)
+		searchArg.ToType(&query)
+		return query.StoreKey != ""
+	}
+	return false
+}
+
+// reports is command can block connection for unbounded amount of time
+func (this *Command) IsBlocking() bool {
+	switch this.Type {
+	case WAIT, SHUTDOWN:
+		return true
+	case XREAD:
+		if this.Args == nil {
+			return false
+		}
+		_, ok := this.Args.GetArgValue(xreadcommand.Block)
+		return ok
+	case XREADGROUP:
+		if this.Args == nil {
+			return false
+		}
+		var query xgroupcommand.ReadGroupQuery
+		queryArg, _ := this.Args.GetArgValue(xgroupcommand.Query)
+		queryArg.ToType(&query)
+		return query.Block
+	}
+	return false
+}
+
+// reports can command be executed by RESP2 client subscribed to channels or patterns
+func (this *Command) IsAllowedInSubscribedMode() bool {
+	switch this.Type {
+	case SUBSCRIBE, UNSUBSCRIBE, PSUBSCRIBE, PUNSUBSCRIBE, SSUBSCRIBE, SUNSUBSCRIBE, PING, QUIT, RESET:
+		return true
+	}
+	return false
 }
 
 func (this *Command) IsNeedAddReplica() bool {
@@ -167,6 +322,49 @@ func ConstructReplConf(args ...string) *Command {
 	}}
 }
 
+// SELECT db, sent to replicas before commands of other database
+// constructs command from name and arguments, Args are not parsed
+func Construct(typ CommandEnum, args ...string) *Command {
+	values := make([]*datatypes.Data, 0, len(args)+1)
+	values = append(values, &datatypes.Data{Type: datatypes.BULK_STRING, Value: string(typ)})
+	for _, arg := range args {
+		values = append(values, &datatypes.Data{Type: datatypes.BULK_STRING, Value: arg})
+	}
+	return &Command{Type: typ, Raw: &datatypes.Data{Type: datatypes.ARRAY, Values: values}}
+}
+
+func ConstructSelect(db int) *Command {
+	return &Command{Type: SELECT, Raw: &datatypes.Data{
+		Type: datatypes.ARRAY,
+		Values: []*datatypes.Data{
+			{Type: datatypes.BULK_STRING, Value: SELECT},
+			{Type: datatypes.BULK_STRING, Value: strconv.Itoa(db)},
+		},
+	}}
+}
+
+// AUTH [username] password, username is skipped when it is empty
+func ConstructAuth(username string, password string) *datatypes.Data {
+	values := []*datatypes.Data{{
+		Type:  datatypes.BULK_STRING,
+		Value: AUTH,
+	}}
+	if username != "" {
+		values = append(values, &datatypes.Data{
+			Type:  datatypes.BULK_STRING,
+			Value: username,
+		})
+	}
+	values = append(values, &datatypes.Data{
+		Type:  datatypes.BULK_STRING,
+		Value: password,
+	})
+	return &datatypes.Data{
+		Type:   datatypes.ARRAY,
+		Values: values,
+	}
+}
+
 func ConstructFullResync(id string, offset int) *Command {
 	return &Command{
 		Type: FULLRESYNC,
@@ -178,27 +376,97 @@ func ConstructFullResync(id string, offset int) *Command {
 }
 
 var commandMap = map[string]CommandEnum{
-	"PING":       PING,
-	"PONG":       PONG,
-	"ECHO":       ECHO,
-	"SET":        SET,
-	"GET":        GET,
-	"CONFIG":     CONFIG,
-	"INFO":       INFO,
-	"REPLCONF":   REPLCONF,
-	"OK":         OK,
-	"PSYNC":      PSYNC,
-	"FULLRESYNC": FULLRESYNC,
-	"WAIT":       WAIT,
-	"KEYS":       KEYS,
-	"TYPE":       TYPE,
-	"XADD":       XADD,
-	"XRANGE":     XRANGE,
-	"XREAD":      XREAD,
-	"INCR":       INCR,
-	"MULTI":      MULTI,
-	"EXEC":       EXEC,
-	"DISCARD":    DISCARD,
+	"PING":                 PING,
+	"PONG":                 PONG,
+	"ECHO":                 ECHO,
+	"SET":                  SET,
+	"GET":                  GET,
+	"CONFIG":               CONFIG,
+	"INFO":                 INFO,
+	"REPLCONF":             REPLCONF,
+	"OK":                   OK,
+	"PSYNC":                PSYNC,
+	"FULLRESYNC":           FULLRESYNC,
+	"WAIT":                 WAIT,
+	"KEYS":                 KEYS,
+	"TYPE":                 TYPE,
+	"XADD":                 XADD,
+	"XRANGE":               XRANGE,
+	"XREAD":                XREAD,
+	"INCR":                 INCR,
+	"MULTI":                MULTI,
+	"EXEC":                 EXEC,
+	"DISCARD":              DISCARD,
+	"AUTH":                 AUTH,
+	"ACL":                  ACL,
+	"CLIENT":               CLIENT,
+	"SHUTDOWN":             SHUTDOWN,
+	"SELECT":               SELECT,
+	"MOVE":                 MOVE,
+	"SWAPDB":               SWAPDB,
+	"DBSIZE":               DBSIZE,
+	"FLUSHDB":              FLUSHDB,
+	"FLUSHALL":             FLUSHALL,
+	"SCAN":                 SCAN,
+	"HSCAN":                HSCAN,
+	"SSCAN":                SSCAN,
+	"ZSCAN":                ZSCAN,
+	"APPEND":               APPEND,
+	"STRLEN":               STRLEN,
+	"GETRANGE":             GETRANGE,
+	"SETRANGE":             SETRANGE,
+	"MGET":                 MGET,
+	"MSET":                 MSET,
+	"MSETNX":               MSETNX,
+	"LCS":                  LCS,
+	"INCRBY":               INCRBY,
+	"DECR":                 DECR,
+	"DECRBY":               DECRBY,
+	"INCRBYFLOAT":          INCRBYFLOAT,
+	"SETBIT":               SETBIT,
+	"GETBIT":               GETBIT,
+	"BITCOUNT":             BITCOUNT,
+	"BITPOS":               BITPOS,
+	"BITOP":                BITOP,
+	"BITFIELD":             BITFIELD,
+	"BITFIELD_RO":          BITFIELD_RO,
+	"PFADD":                PFADD,
+	"PFCOUNT":              PFCOUNT,
+	"PFMERGE":              PFMERGE,
+	"GEOADD":               GEOADD,
+	"GEODIST":              GEODIST,
+	"GEOPOS":               GEOPOS,
+	"GEOHASH":              GEOHASH,
+	"GEOSEARCH":            GEOSEARCH,
+	"GEOSEARCHSTORE":       GEOSEARCHSTORE,
+	"GEORADIUS":            GEORADIUS,
+	"GEORADIUS_RO":         GEORADIUS_RO,
+	"GEORADIUSBYMEMBER":    GEORADIUSBYMEMBER,
+	"GEORADIUSBYMEMBER_RO": GEORADIUSBYMEMBER_RO,
+	"XGROUP":               XGROUP,
+	"XREADGROUP":           XREADGROUP,
+	"XACK":                 XACK,
+	"XPENDING":             XPENDING,
+	"XCLAIM":               XCLAIM,
+	"XAUTOCLAIM":           XAUTOCLAIM,
+	"XLEN":                 XLEN,
+	"XDEL":                 XDEL,
+	"XTRIM":                XTRIM,
+	"XREVRANGE":            XREVRANGE,
+	"XINFO":                XINFO,
+	"XSETID":               XSETID,
+	"SUBSCRIBE":            SUBSCRIBE,
+	"UNSUBSCRIBE":          UNSUBSCRIBE,
+	"PSUBSCRIBE":           PSUBSCRIBE,
+	"PUNSUBSCRIBE":         PUNSUBSCRIBE,
+	"PUBLISH":              PUBLISH,
+	"PUBSUB":               PUBSUB,
+	"HELLO":                HELLO,
+	"QUIT":                 QUIT,
+	"RESET":                RESET,
+	"SSUBSCRIBE":           SSUBSCRIBE,
+	"SUNSUBSCRIBE":         SUNSUBSCRIBE,
+	"SPUBLISH":             SPUBLISH,
 }
 
 func GetCommandAccordName(name string) (cmd CommandEnum, ok bool) {
@@ -380,7 +648,25 @@ func (t *Command) ParseArgs() error {
 	case XADD:
 		args, err := xaddcommand.ParseXaddArgs(t.Raw.Values)
 		if err != nil {
-			return fmt.Errorf("Error parsing xadd args: %w", err)
+			return err
+		}
+		t.Args = args
+	case XLEN:
+		args, err := xaddcommand.ParseXlenArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case XDEL:
+		args, err := xaddcommand.ParseXdelArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case XTRIM:
+		args, err := xaddcommand.ParseXtrimArgs(t.Raw.Values)
+		if err != nil {
+			return err
 		}
 		t.Args = args
 	case TYPE:
@@ -389,22 +675,322 @@ func (t *Command) ParseArgs() error {
 			return fmt.Errorf("Error parsing type args: %w", err)
 		}
 		t.Args = args
-	case XRANGE:
+	case XRANGE, XREVRANGE:
 		args, err := xrangecommand.ParseXrangeArgs(t.Raw.Values)
 		if err != nil {
-			return fmt.Errorf("Error parsing xrange args: %w", err)
+			return err
 		}
 		t.Args = args
 	case XREAD:
 		args, err := xreadcommand.ParseXreadArgs(t.Raw.Values)
 		if err != nil {
-			return fmt.Errorf("Error parsing xread args: %w", err)
+			return err
 		}
 		t.Args = args
-	case INCR:
+	case INCR, DECR:
 		args, err := incrcommand.ParseIncrArgs(t.Raw.Values)
 		if err != nil {
-			return fmt.Errorf("Error parsing incr args: %w", err)
+			return err
+		}
+		t.Args = args
+	case INCRBY, DECRBY:
+		args, err := incrcommand.ParseIncrbyArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case INCRBYFLOAT:
+		args, err := incrcommand.ParseIncrbyfloatArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case SETBIT:
+		args, err := bitmapcommand.ParseSetbitArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case GETBIT:
+		args, err := bitmapcommand.ParseGetbitArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case BITCOUNT:
+		args, err := bitmapcommand.ParseBitcountArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case BITPOS:
+		args, err := bitmapcommand.ParseBitposArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case BITOP:
+		args, err := bitmapcommand.ParseBitopArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case BITFIELD, BITFIELD_RO:
+		args, err := bitmapcommand.ParseBitfieldArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case PFADD:
+		args, err := hllcommand.ParsePfaddArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case PFCOUNT:
+		args, err := hllcommand.ParsePfcountArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case PFMERGE:
+		args, err := hllcommand.ParsePfmergeArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case GEOADD:
+		args, err := geocommand.ParseGeoaddArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case GEODIST:
+		args, err := geocommand.ParseGeodistArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case GEOPOS, GEOHASH:
+		args, err := geocommand.ParseMembersArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case GEOSEARCH:
+		args, err := geocommand.ParseGeosearchArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case GEOSEARCHSTORE:
+		args, err := geocommand.ParseGeosearchstoreArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case GEORADIUS, GEORADIUS_RO:
+		args, err := geocommand.ParseGeoradiusArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case GEORADIUSBYMEMBER, GEORADIUSBYMEMBER_RO:
+		args, err := geocommand.ParseGeoradiusbymemberArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case XINFO:
+		args, err := xinfocommand.ParseXinfoArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case XSETID:
+		args, err := xaddcommand.ParseXsetidArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case XGROUP:
+		args, err := xgroupcommand.ParseXgroupArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case XREADGROUP:
+		args, err := xgroupcommand.ParseXreadgroupArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case XACK:
+		args, err := xgroupcommand.ParseXackArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case XPENDING:
+		args, err := xgroupcommand.ParseXpendingArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case XCLAIM:
+		args, err := xgroupcommand.ParseXclaimArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case XAUTOCLAIM:
+		args, err := xgroupcommand.ParseXautoclaimArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case AUTH:
+		args, err := authcommand.ParseAuthArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case ACL:
+		args, err := aclcommand.ParseAclArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case SUBSCRIBE, PSUBSCRIBE, SSUBSCRIBE:
+		args, err := pubsubcommand.ParseSubscribeArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case UNSUBSCRIBE, PUNSUBSCRIBE, SUNSUBSCRIBE:
+		args, err := pubsubcommand.ParseUnsubscribeArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case PUBLISH, SPUBLISH:
+		args, err := pubsubcommand.ParsePublishArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case PUBSUB:
+		args, err := pubsubcommand.ParsePubsubArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case HELLO:
+		args, err := connectioncommand.ParseHelloArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case RESET:
+		args, err := connectioncommand.ParseResetArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case CLIENT:
+		args, err := clientcommand.ParseClientArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case SHUTDOWN:
+		args, err := shutdowncommand.ParseShutdownArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case SELECT:
+		args, err := dbcommand.ParseSelectArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case MOVE:
+		args, err := dbcommand.ParseMoveArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case SWAPDB:
+		args, err := dbcommand.ParseSwapdbArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case DBSIZE:
+		args, err := dbcommand.ParseDbsizeArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case FLUSHDB, FLUSHALL:
+		args, err := dbcommand.ParseFlushArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case SCAN:
+		args, err := scancommand.ParseScanArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case HSCAN, SSCAN, ZSCAN:
+		args, err := scancommand.ParseKeyScanArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case APPEND:
+		args, err := stringcommand.ParseAppendArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case STRLEN:
+		args, err := stringcommand.ParseStrlenArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case GETRANGE:
+		args, err := stringcommand.ParseGetrangeArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case SETRANGE:
+		args, err := stringcommand.ParseSetrangeArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case MGET:
+		args, err := stringcommand.ParseMgetArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case MSET, MSETNX:
+		args, err := stringcommand.ParseMsetArgs(t.Raw.Values)
+		if err != nil {
+			return err
+		}
+		t.Args = args
+	case LCS:
+		args, err := stringcommand.ParseLcsArgs(t.Raw.Values)
+		if err != nil {
+			return err
 		}
 		t.Args = args
 	}
@@ -419,8 +1005,16 @@ const (
 	Dbfilename = "dbfilename"
 )
 
+type ConfigSubcommandEnum string
+
+const (
+	ConfigGet = "GET"
+	ConfigSet = "SET"
+)
+
 type ConfigArgs struct {
-	Args []ConfigArgEnum
+	Subcommand ConfigSubcommandEnum
+	Args       []ConfigArgEnum
 }
 
 func (t Command) GetConfigArgs() (args *ConfigArgs, err error) {
@@ -432,7 +1026,10 @@ func (t Command) GetConfigArgs() (args *ConfigArgs, err error) {
 		return nil, fmt.Errorf("GetReplConfPortArgs Error: not enough values to construct repl conf port args")
 	}
 	argsSlice := make([]ConfigArgEnum, len(values)-2)
-	out := &ConfigArgs{Args: argsSlice}
+	out := &ConfigArgs{
+		Subcommand: ConfigSubcommandEnum(strings.ToUpper(values[1].Value)),
+		Args:       argsSlice,
+	}
 	for i := 2; i < len(values); i++ {
 		name := values[i].Value
 		argsSlice[i-2] = ConfigArgEnum(name)
@@ -0,0 +1,425 @@
+package command
+
+import (
+	"sort"
+	"strings"
+
+	geocommand "github.com/codecrafters-io/redis-starter-go/app/commands/geo_command"
+	xgroupcommand "github.com/codecrafters-io/redis-starter-go/app/commands/xgroup_command"
+	xreadcommand "github.com/codecrafters-io/redis-starter-go/app/commands/xread_command"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+)
+
+// acl command categories, names are the same as in redis
+type CategoryEnum string
+
+const (
+	CategoryKeyspace    CategoryEnum = "keyspace"
+	CategoryRead        CategoryEnum = "read"
+	CategoryWrite       CategoryEnum = "write"
+	CategorySet         CategoryEnum = "set"
+	CategorySortedSet   CategoryEnum = "sortedset"
+	CategoryList        CategoryEnum = "list"
+	CategoryHash        CategoryEnum = "hash"
+	CategoryString      CategoryEnum = "string"
+	CategoryBitmap      CategoryEnum = "bitmap"
+	CategoryHyperLogLog CategoryEnum = "hyperloglog"
+	CategoryGeo         CategoryEnum = "geo"
+	CategoryStream      CategoryEnum = "stream"
+	CategoryPubsub      CategoryEnum = "pubsub"
+	CategoryAdmin       CategoryEnum = "admin"
+	CategoryFast        CategoryEnum = "fast"
+	CategorySlow        CategoryEnum = "slow"
+	CategoryBlocking    CategoryEnum = "blocking"
+	CategoryDangerous   CategoryEnum = "dangerous"
+	CategoryConnection  CategoryEnum = "connection"
+	CategoryTransaction CategoryEnum = "transaction"
+	CategoryScripting   CategoryEnum = "scripting"
+)
+
+var categories = []CategoryEnum{
+	CategoryKeyspace, CategoryRead, CategoryWrite, CategorySet, CategorySortedSet, CategoryList, CategoryHash,
+	CategoryString, CategoryBitmap, CategoryHyperLogLog, CategoryGeo, CategoryStream, CategoryPubsub, CategoryAdmin,
+	CategoryFast, CategorySlow, CategoryBlocking, CategoryDangerous, CategoryConnection, CategoryTransaction, CategoryScripting,
+}
+
+// how command accesses its keys, checked against acl key patterns
+type KeyAccessEnum int
+
+const (
+	ReadKeyAccess  KeyAccessEnum = 1
+	WriteKeyAccess KeyAccessEnum = 2
+)
+
+// static information about command used by acl
+type CommandSpec struct {
+	Categories []CategoryEnum
+	// command can be executed by not authenticated client
+	NoAuth bool
+	// keys are placed from FirstKey to LastKey argument with Step, FirstKey 0 means command has no keys,
+	// negative LastKey counts from the end of arguments
+	FirstKey  int
+	LastKey   int
+	Step      int
+	KeyAccess KeyAccessEnum
+	// access of keys in order of GetKeys for commands that access keys differently, e.g. destination of BITOP
+	// is written while its sources are only read, the last access applies to the rest of keys
+	KeyAccesses []KeyAccessEnum
+	// extracts keys of commands which key positions depend on arguments
+	getKeys func(values []*datatypes.Data) []string
+	// channels are placed from FirstChannel to LastChannel argument, with ChannelPatterns they are patterns
+	// which are allowed only if acl has the same pattern
+	FirstChannel    int
+	LastChannel     int
+	ChannelPatterns bool
+	// container commands like CONFIG or ACL are checked by their subcommands
+	Subcommands map[string]*CommandSpec
+}
+
+func keysAt(first int, last int, step int, access KeyAccessEnum, categories ...CategoryEnum) *CommandSpec {
+	return &CommandSpec{
+		Categories: categories,
+		FirstKey:   first,
+		LastKey:    last,
+		Step:       step,
+		KeyAccess:  access,
+	}
+}
+
+// sets access of every key by its order
+func (this *CommandSpec) withKeyAccesses(accesses ...KeyAccessEnum) *CommandSpec {
+	this.KeyAccesses = accesses
+	return this
+}
+
+// returns access of key with index i of GetKeys
+func (this *CommandSpec) GetKeyAccess(i int) KeyAccessEnum {
+	if len(this.KeyAccesses) == 0 {
+		return this.KeyAccess
+	}
+	return this.KeyAccesses[min(i, len(this.KeyAccesses)-1)]
+}
+
+func noKeys(categories ...CategoryEnum) *CommandSpec {
+	return &CommandSpec{Categories: categories}
+}
+
+func channelsAt(first int, last int, patterns bool, categories ...CategoryEnum) *CommandSpec {
+	return &CommandSpec{
+		Categories:      categories,
+		FirstChannel:    first,
+		LastChannel:     last,
+		ChannelPatterns: patterns,
+	}
+}
+
+var commandSpecs = map[CommandEnum]*CommandSpec{
+	PING:      noKeys(CategoryFast, CategoryConnection),
+	ECHO:      noKeys(CategoryFast, CategoryConnection),
+	SET:       keysAt(1, 1, 1, WriteKeyAccess, CategoryWrite, CategoryString, CategorySlow),
+	GET:       keysAt(1, 1, 1, ReadKeyAccess, CategoryRead, CategoryString, CategoryFast),
+	INFO:      noKeys(CategorySlow, CategoryDangerous),
+	REPLCONF:  noKeys(CategoryAdmin, CategorySlow, CategoryDangerous),
+	PSYNC:     noKeys(CategoryAdmin, CategorySlow, CategoryDangerous),
+	WAIT:      noKeys(CategorySlow, CategoryConnection),
+	KEYS:      noKeys(CategoryKeyspace, CategoryRead, CategorySlow, CategoryDangerous),
+	TYPE:      keysAt(1, 1, 1, ReadKeyAccess, CategoryKeyspace, CategoryRead, CategoryFast),
+	XADD:      keysAt(1, 1, 1, WriteKeyAccess, CategoryWrite, CategoryStream, CategoryFast),
+	XRANGE:    keysAt(1, 1, 1, ReadKeyAccess, CategoryRead, CategoryStream, CategorySlow),
+	XREVRANGE: keysAt(1, 1, 1, ReadKeyAccess, CategoryRead, CategoryStream, CategorySlow),
+	XLEN:      keysAt(1, 1, 1, ReadKeyAccess, CategoryRead, CategoryStream, CategoryFast),
+	XDEL:      keysAt(1, 1, 1, WriteKeyAccess, CategoryWrite, CategoryStream, CategoryFast),
+	XTRIM:     keysAt(1, 1, 1, WriteKeyAccess, CategoryWrite, CategoryStream, CategorySlow),
+	XREAD: {
+		Categories: []CategoryEnum{CategoryRead, CategoryStream, CategorySlow, CategoryBlocking},
+		KeyAccess:  ReadKeyAccess,
+		getKeys:    xreadcommand.GetKeys,
+	},
+	XGROUP: {
+		Subcommands: map[string]*CommandSpec{
+			"create":         keysAt(2, 2, 1, WriteKeyAccess, CategoryWrite, CategoryStream, CategorySlow),
+			"setid":          keysAt(2, 2, 1, WriteKeyAccess, CategoryWrite, CategoryStream, CategorySlow),
+			"destroy":        keysAt(2, 2, 1, WriteKeyAccess, CategoryWrite, CategoryStream, CategorySlow),
+			"createconsumer": keysAt(2, 2, 1, WriteKeyAccess, CategoryWrite, CategoryStream, CategorySlow),
+			"delconsumer":    keysAt(2, 2, 1, WriteKeyAccess, CategoryWrite, CategoryStream, CategorySlow),
+		},
+	},
+	XREADGROUP: {
+		Categories: []CategoryEnum{CategoryWrite, CategoryStream, CategorySlow, CategoryBlocking},
+		KeyAccess:  WriteKeyAccess,
+		getKeys:    xgroupcommand.GetKeys,
+	},
+	XINFO: {
+		Subcommands: map[string]*CommandSpec{
+			"stream":    keysAt(2, 2, 1, ReadKeyAccess, CategoryRead, CategoryStream, CategorySlow),
+			"groups":    keysAt(2, 2, 1, ReadKeyAccess, CategoryRead, CategoryStream, CategorySlow),
+			"consumers": keysAt(2, 2, 1, ReadKeyAccess, CategoryRead, CategoryStream, CategorySlow),
+		},
+	},
+	XSETID:         keysAt(1, 1, 1, WriteKeyAccess, CategoryWrite, CategoryStream, CategoryFast),
+	XACK:           keysAt(1, 1, 1, WriteKeyAccess, CategoryWrite, CategoryStream, CategoryFast),
+	XPENDING:       keysAt(1, 1, 1, ReadKeyAccess, CategoryRead, CategoryStream, CategorySlow),
+	XCLAIM:         keysAt(1, 1, 1, WriteKeyAccess, CategoryWrite, CategoryStream, CategoryFast),
+	XAUTOCLAIM:     keysAt(1, 1, 1, WriteKeyAccess, CategoryWrite, CategoryStream, CategoryFast),
+	INCR:           keysAt(1, 1, 1, WriteKeyAccess, CategoryWrite, CategoryString, CategoryFast),
+	INCRBY:         keysAt(1, 1, 1, WriteKeyAccess, CategoryWrite, CategoryString, CategoryFast),
+	DECR:           keysAt(1, 1, 1, WriteKeyAccess, CategoryWrite, CategoryString, CategoryFast),
+	DECRBY:         keysAt(1, 1, 1, WriteKeyAccess, CategoryWrite, CategoryString, CategoryFast),
+	INCRBYFLOAT:    keysAt(1, 1, 1, WriteKeyAccess, CategoryWrite, CategoryString, CategoryFast),
+	SETBIT:         keysAt(1, 1, 1, WriteKeyAccess, CategoryWrite, CategoryBitmap, CategorySlow),
+	GETBIT:         keysAt(1, 1, 1, ReadKeyAccess, CategoryRead, CategoryBitmap, CategoryFast),
+	BITCOUNT:       keysAt(1, 1, 1, ReadKeyAccess, CategoryRead, CategoryBitmap, CategorySlow),
+	BITPOS:         keysAt(1, 1, 1, ReadKeyAccess, CategoryRead, CategoryBitmap, CategorySlow),
+	BITOP:          keysAt(2, -1, 1, WriteKeyAccess, CategoryWrite, CategoryBitmap, CategorySlow).withKeyAccesses(WriteKeyAccess, ReadKeyAccess),
+	BITFIELD:       keysAt(1, 1, 1, WriteKeyAccess, CategoryWrite, CategoryBitmap, CategorySlow),
+	BITFIELD_RO:    keysAt(1, 1, 1, ReadKeyAccess, CategoryRead, CategoryBitmap, CategoryFast),
+	PFADD:          keysAt(1, 1, 1, WriteKeyAccess, CategoryWrite, CategoryHyperLogLog, CategoryFast),
+	PFCOUNT:        keysAt(1, -1, 1, ReadKeyAccess, CategoryRead, CategoryHyperLogLog, CategorySlow),
+	PFMERGE:        keysAt(1, -1, 1, WriteKeyAccess, CategoryWrite, CategoryHyperLogLog, CategorySlow).withKeyAccesses(ReadKeyAccess|WriteKeyAccess, ReadKeyAccess),
+	GEOADD:         keysAt(1, 1, 1, WriteKeyAccess, CategoryWrite, CategoryGeo, CategorySlow),
+	GEODIST:        keysAt(1, 1, 1, ReadKeyAccess, CategoryRead, CategoryGeo, CategorySlow),
+	GEOPOS:         keysAt(1, 1, 1, ReadKeyAccess, CategoryRead, CategoryGeo, CategorySlow),
+	GEOHASH:        keysAt(1, 1, 1, ReadKeyAccess, CategoryRead, CategoryGeo, CategorySlow),
+	GEOSEARCH:      keysAt(1, 1, 1, ReadKeyAccess, CategoryRead, CategoryGeo, CategorySlow),
+	GEOSEARCHSTORE: keysAt(1, 2, 1, WriteKeyAccess, CategoryWrite, CategoryGeo, CategorySlow).withKeyAccesses(WriteKeyAccess, ReadKeyAccess),
+	GEORADIUS: {
+		Categories: []CategoryEnum{CategoryWrite, CategoryGeo, CategorySlow},
+		KeyAccess:  WriteKeyAccess,
+		// source is read, key of STORE or STOREDIST is written
+		KeyAccesses: []KeyAccessEnum{ReadKeyAccess, WriteKeyAccess},
+		getKeys:     geocommand.GetKeys,
+	},
+	GEORADIUS_RO: keysAt(1, 1, 1, ReadKeyAccess, CategoryRead, CategoryGeo, CategorySlow),
+	GEORADIUSBYMEMBER: {
+		Categories: []CategoryEnum{CategoryWrite, CategoryGeo, CategorySlow},
+		KeyAccess:  WriteKeyAccess,
+		// source is read, key of STORE or STOREDIST is written
+		KeyAccesses: []KeyAccessEnum{ReadKeyAccess, WriteKeyAccess},
+		getKeys:     geocommand.GetKeys,
+	},
+	GEORADIUSBYMEMBER_RO: keysAt(1, 1, 1, ReadKeyAccess, CategoryRead, CategoryGeo, CategorySlow),
+	MULTI:                noKeys(CategoryFast, CategoryTransaction),
+	EXEC:                 noKeys(CategorySlow, CategoryTransaction),
+	DISCARD:              noKeys(CategoryFast, CategoryTransaction),
+	AUTH: {
+		Categories: []CategoryEnum{CategoryFast, CategoryConnection},
+		NoAuth:     true,
+	},
+	SHUTDOWN: noKeys(CategoryAdmin, CategorySlow, CategoryDangerous),
+	SELECT:   noKeys(CategoryFast, CategoryConnection),
+	MOVE:     keysAt(1, 1, 1, WriteKeyAccess, CategoryKeyspace, CategoryWrite, CategoryFast),
+	SWAPDB:   noKeys(CategoryKeyspace, CategoryWrite, CategoryFast, CategoryDangerous),
+	DBSIZE:   noKeys(CategoryKeyspace, CategoryRead, CategoryFast),
+	FLUSHDB:  noKeys(CategoryKeyspace, CategoryWrite, CategorySlow, CategoryDangerous),
+	FLUSHALL: noKeys(CategoryKeyspace, CategoryWrite, CategorySlow, CategoryDangerous),
+	SCAN:     noKeys(CategoryKeyspace, CategoryRead, CategorySlow),
+	HSCAN:    keysAt(1, 1, 1, ReadKeyAccess, CategoryRead, CategoryHash, CategorySlow),
+	SSCAN:    keysAt(1, 1, 1, ReadKeyAccess, CategoryRead, CategorySet, CategorySlow),
+	ZSCAN:    keysAt(1, 1, 1, ReadKeyAccess, CategoryRead, CategorySortedSet, CategorySlow),
+	APPEND:   keysAt(1, 1, 1, WriteKeyAccess, CategoryWrite, CategoryString, CategoryFast),
+	STRLEN:   keysAt(1, 1, 1, ReadKeyAccess, CategoryRead, CategoryString, CategoryFast),
+	GETRANGE: keysAt(1, 1, 1, ReadKeyAccess, CategoryRead, CategoryString, CategorySlow),
+	SETRANGE: keysAt(1, 1, 1, WriteKeyAccess, CategoryWrite, CategoryString, CategorySlow),
+	MGET:     keysAt(1, -1, 1, ReadKeyAccess, CategoryRead, CategoryString, CategoryFast),
+	MSET:     keysAt(1, -1, 2, WriteKeyAccess, CategoryWrite, CategoryString, CategorySlow),
+	MSETNX:   keysAt(1, -1, 2, WriteKeyAccess, CategoryWrite, CategoryString, CategorySlow),
+	LCS:      keysAt(1, 2, 1, ReadKeyAccess, CategoryRead, CategoryString, CategorySlow),
+	CONFIG: {
+		Subcommands: map[string]*CommandSpec{
+			"get": noKeys(CategoryAdmin, CategorySlow, CategoryDangerous),
+			"set": noKeys(CategoryAdmin, CategorySlow, CategoryDangerous),
+		},
+	},
+	CLIENT: {
+		Subcommands: map[string]*CommandSpec{
+			"getname":  noKeys(CategorySlow, CategoryConnection),
+			"id":       noKeys(CategorySlow, CategoryConnection),
+			"info":     noKeys(CategorySlow, CategoryConnection),
+			"kill":     noKeys(CategoryAdmin, CategorySlow, CategoryDangerous, CategoryConnection),
+			"list":     noKeys(CategoryAdmin, CategorySlow, CategoryDangerous, CategoryConnection),
+			"no-evict": noKeys(CategoryAdmin, CategorySlow, CategoryDangerous, CategoryConnection),
+			"no-touch": noKeys(CategoryFast, CategoryConnection),
+			"pause":    noKeys(CategoryAdmin, CategorySlow, CategoryDangerous, CategoryConnection),
+			"reply":    noKeys(CategorySlow, CategoryConnection),
+			"setname":  noKeys(CategorySlow, CategoryConnection),
+			"unblock":  noKeys(CategoryAdmin, CategorySlow, CategoryDangerous, CategoryConnection),
+			"unpause":  noKeys(CategoryAdmin, CategorySlow, CategoryDangerous, CategoryConnection),
+		},
+	},
+	SUBSCRIBE:    channelsAt(1, -1, false, CategoryPubsub, CategorySlow),
+	PSUBSCRIBE:   channelsAt(1, -1, true, CategoryPubsub, CategorySlow),
+	UNSUBSCRIBE:  noKeys(CategoryPubsub, CategorySlow),
+	PUNSUBSCRIBE: noKeys(CategoryPubsub, CategorySlow),
+	PUBLISH:      channelsAt(1, 1, false, CategoryPubsub, CategoryFast),
+	SSUBSCRIBE:   channelsAt(1, -1, false, CategoryPubsub, CategorySlow),
+	SUNSUBSCRIBE: noKeys(CategoryPubsub, CategorySlow),
+	SPUBLISH:     channelsAt(1, 1, false, CategoryPubsub, CategoryFast),
+	PUBSUB: {
+		Subcommands: map[string]*CommandSpec{
+			"channels":      noKeys(CategoryPubsub, CategorySlow),
+			"numpat":        noKeys(CategoryPubsub, CategorySlow),
+			"numsub":        noKeys(CategoryPubsub, CategorySlow),
+			"shardchannels": noKeys(CategoryPubsub, CategorySlow),
+			"shardnumsub":   noKeys(CategoryPubsub, CategorySlow),
+		},
+	},
+	HELLO: {
+		Categories: []CategoryEnum{CategoryFast, CategoryConnection},
+		NoAuth:     true,
+	},
+	QUIT: {
+		Categories: []CategoryEnum{CategoryFast, CategoryConnection},
+		NoAuth:     true,
+	},
+	RESET: {
+		Categories: []CategoryEnum{CategoryFast, CategoryConnection},
+		NoAuth:     true,
+	},
+	ACL: {
+		Subcommands: map[string]*CommandSpec{
+			"cat":     noKeys(CategorySlow),
+			"deluser": noKeys(CategoryAdmin, CategorySlow, CategoryDangerous),
+			"dryrun":  noKeys(CategoryAdmin, CategorySlow, CategoryDangerous),
+			"genpass": noKeys(CategorySlow),
+			"getuser": noKeys(CategoryAdmin, CategorySlow, CategoryDangerous),
+			"list":    noKeys(CategoryAdmin, CategorySlow, CategoryDangerous),
+			"load":    noKeys(CategoryAdmin, CategorySlow, CategoryDangerous),
+			"log":     noKeys(CategoryAdmin, CategorySlow, CategoryDangerous),
+			"save":    noKeys(CategoryAdmin, CategorySlow, CategoryDangerous),
+			"setuser": noKeys(CategoryAdmin, CategorySlow, CategoryDangerous),
+			"users":   noKeys(CategoryAdmin, CategorySlow, CategoryDangerous),
+			"whoami":  noKeys(CategorySlow),
+		},
+	},
+}
+
+func GetCategories() []CategoryEnum {
+	return categories
+}
+
+func IsCategory(name string) bool {
+	for _, c := range categories {
+		if string(c) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// returns names of every command and subcommand known to acl, subcommands are named as command|subcommand
+func GetAclNames() []string {
+	names := []string{}
+	for cmd, spec := range commandSpecs {
+		name := strings.ToLower(string(cmd))
+		if spec.Subcommands == nil {
+			names = append(names, name)
+			continue
+		}
+		for sub := range spec.Subcommands {
+			names = append(names, name+"|"+sub)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// returns spec by acl name, e.g. get or config|get
+func GetSpecByAclName(name string) (*CommandSpec, bool) {
+	cmdName, subName, isSub := strings.Cut(strings.ToLower(name), "|")
+	cmdType, ok := GetCommandAccordName(strings.ToUpper(cmdName))
+	if !ok {
+		return nil, false
+	}
+	spec, ok := commandSpecs[cmdType]
+	if !ok {
+		return nil, false
+	}
+	if !isSub {
+		return spec, true
+	}
+	sub, ok := spec.Subcommands[subName]
+	return sub, ok
+}
+
+func (this *CommandSpec) HasCategory(category CategoryEnum) bool {
+	for _, c := range this.Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// returns name used by acl and spec of command, for container commands spec of subcommand is returned,
+// spec is nil for commands which are not known to acl
+func (this *Command) GetSpec() (string, *CommandSpec) {
+	name := strings.ToLower(string(this.Type))
+	spec, ok := commandSpecs[this.Type]
+	if !ok {
+		return name, nil
+	}
+	if spec.Subcommands == nil {
+		return name, spec
+	}
+	if this.Raw == nil || len(this.Raw.Values) < 2 {
+		return name, nil
+	}
+	subName := strings.ToLower(this.Raw.Values[1].Value)
+	sub, ok := spec.Subcommands[subName]
+	if !ok {
+		return name, nil
+	}
+	return name + "|" + subName, sub
+}
+
+// reports does command belong to acl category, e.g. write commands are held by CLIENT PAUSE WRITE
+func (this *Command) HasCategory(category CategoryEnum) bool {
+	_, spec := this.GetSpec()
+	return spec != nil && spec.HasCategory(category)
+}
+
+// returns keys accessed by command
+func (this *Command) GetKeys() []string {
+	_, spec := this.GetSpec()
+	if spec == nil || this.Raw == nil {
+		return nil
+	}
+	values := this.Raw.Values
+	if spec.getKeys != nil {
+		return spec.getKeys(values)
+	}
+	if spec.FirstKey == 0 || spec.FirstKey >= len(values) {
+		return nil
+	}
+	last := spec.LastKey
+	if last < 0 {
+		last = len(values) + last
+	}
+	if last >= len(values) {
+		last = len(values) - 1
+	}
+	keys := []string{}
+	for i := spec.FirstKey; i <= last; i += spec.Step {
+		keys = append(keys, values[i].Value)
+	}
+	return keys
+}
+
+// returns channels accessed by command
+func (this *Command) GetChannels() []string {
+	_, spec := this.GetSpec()
+	if spec == nil || this.Raw == nil || spec.FirstChannel == 0 {
+		return nil
+	}
+	values := this.Raw.Values
+	last := spec.LastChannel
+	if last < 0 {
+		last = len(values) + last
+	}
+	last = min(last, len(values)-1)
+	channels := []string{}
+	for i := spec.FirstChannel; i <= last; i++ {
+		channels = append(channels, values[i].Value)
+	}
+	return channels
+}
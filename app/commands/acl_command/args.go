@@ -0,0 +1,72 @@
+package aclcommand
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/app/commands"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+)
+
+type AclArgsEnum string
+
+const (
+	Subcommand = "subcommand"
+	Args       = "args"
+)
+
+type AclSubcommandEnum string
+
+const (
+	Cat     = "CAT"
+	Deluser = "DELUSER"
+	Dryrun  = "DRYRUN"
+	Genpass = "GENPASS"
+	Getuser = "GETUSER"
+	List    = "LIST"
+	Load    = "LOAD"
+	Log     = "LOG"
+	Save    = "SAVE"
+	Setuser = "SETUSER"
+	Users   = "USERS"
+	Whoami  = "WHOAMI"
+)
+
+// allowed amount of subcommand arguments, max -1 means unlimited
+var subcommandArity = map[string][2]int{
+	Cat:     {0, 1},
+	Deluser: {1, -1},
+	Dryrun:  {2, -1},
+	Genpass: {0, 1},
+	Getuser: {1, 1},
+	List:    {0, 0},
+	Load:    {0, 0},
+	Log:     {0, 1},
+	Save:    {0, 0},
+	Setuser: {1, -1},
+	Users:   {0, 0},
+	Whoami:  {0, 0},
+}
+
+// ACL subcommand [arg ...]
+func ParseAclArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 2 {
+		return nil, fmt.Errorf("ERR wrong number of arguments for 'acl' command")
+	}
+	subcommand := strings.ToUpper(values[1].Value)
+	arity, ok := subcommandArity[subcommand]
+	if !ok {
+		return nil, fmt.Errorf("ERR unknown subcommand '%v'. Try ACL HELP.", values[1].Value)
+	}
+	rest := make([]string, len(values)-2)
+	for i, v := range values[2:] {
+		rest[i] = v.Value
+	}
+	if len(rest) < arity[0] || (arity[1] >= 0 && len(rest) > arity[1]) {
+		return nil, fmt.Errorf("ERR wrong number of arguments for 'acl|%v' command", strings.ToLower(subcommand))
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(Subcommand, commands.NewStringArgValue(subcommand))
+	args.SetArgValue(Args, commands.NewStringsArgValue(rest))
+	return args, nil
+}
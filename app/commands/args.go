@@ -2,6 +2,7 @@ package commands
 
 import (
 	"fmt"
+	"reflect"
 
 	"github.com/codecrafters-io/redis-starter-go/app/types"
 )
@@ -36,6 +37,8 @@ const (
 	String   = "string"
 	Int      = "int"
 	KeyValue = "kv"
+	Strings  = "strings"
+	Custom   = "custom"
 )
 
 type CommandArgValue struct {
@@ -43,6 +46,8 @@ type CommandArgValue struct {
 	string   string
 	num      int
 	values   []types.Kv
+	strings  []string
+	custom   any
 }
 
 func NewIntArgValue(num int) *CommandArgValue {
@@ -66,6 +71,22 @@ func NewKvArgValue(kv []types.Kv) *CommandArgValue {
 	}
 }
 
+func NewStringsArgValue(strs []string) *CommandArgValue {
+	return &CommandArgValue{
+		dataType: Strings,
+		strings:  strs,
+	}
+}
+
+// holds value of command specific type, e.g. list of BITFIELD operations,
+// it is cast by ToType to pointer of the same type
+func NewCustomArgValue(v any) *CommandArgValue {
+	return &CommandArgValue{
+		dataType: Custom,
+		custom:   v,
+	}
+}
+
 func (a CommandArgValue) ToType(typeValue any) error {
 	switch val := (typeValue).(type) {
 	case *[]types.Kv:
@@ -74,6 +95,12 @@ func (a CommandArgValue) ToType(typeValue any) error {
 		}
 		*val = a.values
 		return nil
+	case *[]string:
+		if a.dataType != Strings {
+			return WrongArgTypeCastError
+		}
+		*val = a.strings
+		return nil
 	case *int:
 		if a.dataType != Int {
 			return WrongArgTypeCastError
@@ -87,6 +114,14 @@ func (a CommandArgValue) ToType(typeValue any) error {
 		*val = a.string
 		return nil
 	default:
+		if a.dataType == Custom {
+			target := reflect.ValueOf(typeValue)
+			if target.Kind() != reflect.Pointer || !reflect.TypeOf(a.custom).AssignableTo(target.Elem().Type()) {
+				return WrongArgTypeCastError
+			}
+			target.Elem().Set(reflect.ValueOf(a.custom))
+			return nil
+		}
 		return fmt.Errorf("Error casting arg type, this type is unsoprted: %T", typeValue)
 	}
 }
@@ -0,0 +1,32 @@
+package authcommand
+
+import (
+	"github.com/codecrafters-io/redis-starter-go/app/commands"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+)
+
+type AuthArgsEnum string
+
+const (
+	Username = "username"
+	Password = "password"
+)
+
+// user authenticated by AUTH with single argument
+const DefaultUsername = "default"
+
+// AUTH [username] password
+func ParseAuthArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 2 || len(values) > 3 {
+		return nil, WrongNumberOfArgsError
+	}
+	args := commands.NewArgs()
+	if len(values) == 2 {
+		args.SetArgValue(Username, commands.NewStringArgValue(DefaultUsername))
+		args.SetArgValue(Password, commands.NewStringArgValue(values[1].Value))
+		return args, nil
+	}
+	args.SetArgValue(Username, commands.NewStringArgValue(values[1].Value))
+	args.SetArgValue(Password, commands.NewStringArgValue(values[2].Value))
+	return args, nil
+}
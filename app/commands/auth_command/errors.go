@@ -0,0 +1,5 @@
+package authcommand
+
+import "errors"
+
+var WrongNumberOfArgsError = errors.New("ERR wrong number of arguments for 'auth' command")
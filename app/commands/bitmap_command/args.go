@@ -0,0 +1,272 @@
+package bitmapcommand
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/app/bitmap"
+	"github.com/codecrafters-io/redis-starter-go/app/commands"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+)
+
+var NotIntegerError = errors.New("ERR value is not an integer or out of range")
+var SyntaxError = errors.New("ERR syntax error")
+var BitOffsetError = errors.New("ERR bit offset is not an integer or out of range")
+var BitValueError = errors.New("ERR bit is not an integer or out of range")
+var BitfieldTypeError = errors.New("ERR Invalid bitfield type. Use something like i16 u8. Note that u64 is not supported but i64 is.")
+
+type BitmapArgsEnum string
+
+const (
+	Key     = "key"
+	Keys    = "keys"
+	DestKey = "destkey"
+	Offset  = "offset"
+	Bit     = "bit"
+	Start   = "start"
+	End     = "end"
+	IsBit   = "isbit"
+	Op      = "op"
+	Ops     = "ops"
+)
+
+// BITFIELD subcommands
+const (
+	BitfieldGet    = "GET"
+	BitfieldSet    = "SET"
+	BitfieldIncrby = "INCRBY"
+)
+
+type BitfieldOp struct {
+	Op     string
+	Signed bool
+	Bits   int
+	Offset uint64
+	// value of SET or increment of INCRBY
+	Value    int64
+	Overflow bitmap.OverflowEnum
+}
+
+func wrongArity(values []*datatypes.Data) error {
+	return fmt.Errorf("ERR wrong number of arguments for '%v' command", strings.ToLower(values[0].Value))
+}
+
+// parses bit offset, offset prefixed with # is multiplied by bits of field
+func parseOffset(str string, bits int) (uint64, error) {
+	multiply := bits > 0 && strings.HasPrefix(str, "#")
+	if multiply {
+		str = str[1:]
+	}
+	offset, err := strconv.ParseInt(str, 10, 64)
+	if err != nil || offset < 0 {
+		return 0, BitOffsetError
+	}
+	if multiply {
+		if offset > math.MaxInt64/int64(bits) {
+			return 0, BitOffsetError
+		}
+		offset *= int64(bits)
+	}
+	return uint64(offset), nil
+}
+
+// SETBIT key offset value
+func ParseSetbitArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) != 4 {
+		return nil, wrongArity(values)
+	}
+	offset, err := parseOffset(values[2].Value, 0)
+	if err != nil {
+		return nil, err
+	}
+	if values[3].Value != "0" && values[3].Value != "1" {
+		return nil, BitValueError
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(Key, commands.NewStringArgValue(values[1].Value))
+	args.SetArgValue(Offset, commands.NewIntArgValue(int(offset)))
+	args.SetArgValue(Bit, commands.NewIntArgValue(int(values[3].Value[0]-'0')))
+	return args, nil
+}
+
+// GETBIT key offset
+func ParseGetbitArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) != 3 {
+		return nil, wrongArity(values)
+	}
+	offset, err := parseOffset(values[2].Value, 0)
+	if err != nil {
+		return nil, err
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(Key, commands.NewStringArgValue(values[1].Value))
+	args.SetArgValue(Offset, commands.NewIntArgValue(int(offset)))
+	return args, nil
+}
+
+// parses start, end and BYTE|BIT unit of range, missing bounds are not set
+func parseRange(args commands.CommandArgs, values []*datatypes.Data) error {
+	bounds := []string{Start, End}
+	for i, v := range values {
+		if i == 2 {
+			switch strings.ToUpper(v.Value) {
+			case "BIT":
+				args.SetArgValue(IsBit, commands.NewIntArgValue(1))
+			case "BYTE":
+			default:
+				return SyntaxError
+			}
+			continue
+		}
+		n, err := strconv.Atoi(v.Value)
+		if err != nil {
+			return NotIntegerError
+		}
+		args.SetArgValue(bounds[i], commands.NewIntArgValue(n))
+	}
+	return nil
+}
+
+// BITCOUNT key [start end [BYTE|BIT]]
+func ParseBitcountArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 2 {
+		return nil, wrongArity(values)
+	}
+	if len(values) != 2 && len(values) != 4 && len(values) != 5 {
+		return nil, SyntaxError
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(Key, commands.NewStringArgValue(values[1].Value))
+	err := parseRange(args, values[2:])
+	if err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+// BITPOS key bit [start [end [BYTE|BIT]]]
+func ParseBitposArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 3 {
+		return nil, wrongArity(values)
+	}
+	if len(values) > 6 {
+		return nil, SyntaxError
+	}
+	if values[2].Value != "0" && values[2].Value != "1" {
+		return nil, errors.New("ERR The bit argument must be 1 or 0.")
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(Key, commands.NewStringArgValue(values[1].Value))
+	args.SetArgValue(Bit, commands.NewIntArgValue(int(values[2].Value[0]-'0')))
+	err := parseRange(args, values[3:])
+	if err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+// BITOP <AND | OR | XOR | NOT | DIFF | DIFF1 | ANDOR | ONE> destkey key [key ...]
+func ParseBitopArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 4 {
+		return nil, wrongArity(values)
+	}
+	op := bitmap.OpEnum(strings.ToUpper(values[1].Value))
+	keys := make([]string, 0, len(values)-3)
+	for _, v := range values[3:] {
+		keys = append(keys, v.Value)
+	}
+	switch op {
+	case bitmap.And, bitmap.Or, bitmap.Xor, bitmap.One:
+	case bitmap.Not:
+		if len(keys) != 1 {
+			return nil, errors.New("ERR BITOP NOT must be called with a single source key.")
+		}
+	case bitmap.Diff, bitmap.Diff1, bitmap.AndOr:
+		if len(keys) < 2 {
+			return nil, fmt.Errorf("ERR BITOP %v must be called with at least two source keys.", op)
+		}
+	default:
+		return nil, SyntaxError
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(Op, commands.NewStringArgValue(string(op)))
+	args.SetArgValue(DestKey, commands.NewStringArgValue(values[2].Value))
+	args.SetArgValue(Keys, commands.NewStringsArgValue(keys))
+	return args, nil
+}
+
+// BITFIELD key [GET encoding offset | [OVERFLOW <WRAP | SAT | FAIL>] <SET encoding offset value | INCRBY encoding offset increment> ...],
+// BITFIELD_RO key [GET encoding offset ...]
+func ParseBitfieldArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 2 {
+		return nil, wrongArity(values)
+	}
+	readOnly := strings.ToUpper(values[0].Value) == "BITFIELD_RO"
+	ops := []BitfieldOp{}
+	overflow := bitmap.Wrap
+	for i := 2; i < len(values); i++ {
+		remaining := len(values) - i - 1
+		sub := strings.ToUpper(values[i].Value)
+		switch {
+		case sub == BitfieldGet && remaining >= 2:
+		case (sub == BitfieldSet || sub == BitfieldIncrby) && remaining >= 3:
+		case sub == "OVERFLOW" && remaining >= 1:
+			i++
+			overflow = bitmap.OverflowEnum(strings.ToUpper(values[i].Value))
+			if overflow != bitmap.Wrap && overflow != bitmap.Sat && overflow != bitmap.Fail {
+				return nil, errors.New("ERR Invalid OVERFLOW type specified")
+			}
+			continue
+		default:
+			return nil, SyntaxError
+		}
+		if readOnly && sub != BitfieldGet {
+			return nil, errors.New("ERR BITFIELD_RO only supports the GET subcommand")
+		}
+		op, err := parseBitfieldField(sub, values[i+1].Value, values[i+2].Value)
+		if err != nil {
+			return nil, err
+		}
+		op.Overflow = overflow
+		i += 2
+		if sub != BitfieldGet {
+			i++
+			op.Value, err = strconv.ParseInt(values[i].Value, 10, 64)
+			if err != nil {
+				return nil, NotIntegerError
+			}
+		}
+		ops = append(ops, op)
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(Key, commands.NewStringArgValue(values[1].Value))
+	args.SetArgValue(Ops, commands.NewCustomArgValue(ops))
+	return args, nil
+}
+
+// parses encoding like i16 or u8 and offset of field
+func parseBitfieldField(sub string, encoding string, offset string) (BitfieldOp, error) {
+	op := BitfieldOp{Op: sub}
+	encoding = strings.ToLower(encoding)
+	if len(encoding) < 2 || (encoding[0] != 'i' && encoding[0] != 'u') {
+		return op, BitfieldTypeError
+	}
+	op.Signed = encoding[0] == 'i'
+	bits, err := strconv.Atoi(encoding[1:])
+	maxBits := 63
+	if op.Signed {
+		maxBits = 64
+	}
+	if err != nil || bits < 1 || bits > maxBits {
+		return op, BitfieldTypeError
+	}
+	op.Bits = bits
+	op.Offset, err = parseOffset(offset, bits)
+	if err != nil {
+		return op, err
+	}
+	return op, nil
+}
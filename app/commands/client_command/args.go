@@ -0,0 +1,72 @@
+package clientcommand
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/app/commands"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+)
+
+type ClientArgsEnum string
+
+const (
+	Subcommand = "subcommand"
+	Args       = "args"
+)
+
+type ClientSubcommandEnum string
+
+const (
+	Id      = "ID"
+	Info    = "INFO"
+	List    = "LIST"
+	Setname = "SETNAME"
+	Getname = "GETNAME"
+	Kill    = "KILL"
+	Pause   = "PAUSE"
+	Unpause = "UNPAUSE"
+	NoEvict = "NO-EVICT"
+	NoTouch = "NO-TOUCH"
+	Reply   = "REPLY"
+	Unblock = "UNBLOCK"
+)
+
+// allowed amount of subcommand arguments, max -1 means unlimited
+var subcommandArity = map[string][2]int{
+	Id:      {0, 0},
+	Info:    {0, 0},
+	List:    {0, -1},
+	Setname: {1, 1},
+	Getname: {0, 0},
+	Kill:    {1, -1},
+	Pause:   {1, 2},
+	Unpause: {0, 0},
+	NoEvict: {1, 1},
+	NoTouch: {1, 1},
+	Reply:   {1, 1},
+	Unblock: {1, 2},
+}
+
+// CLIENT subcommand [arg ...]
+func ParseClientArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 2 {
+		return nil, fmt.Errorf("ERR wrong number of arguments for 'client' command")
+	}
+	subcommand := strings.ToUpper(values[1].Value)
+	arity, ok := subcommandArity[subcommand]
+	if !ok {
+		return nil, fmt.Errorf("ERR unknown subcommand '%v'. Try CLIENT HELP.", values[1].Value)
+	}
+	rest := make([]string, len(values)-2)
+	for i, v := range values[2:] {
+		rest[i] = v.Value
+	}
+	if len(rest) < arity[0] || (arity[1] >= 0 && len(rest) > arity[1]) {
+		return nil, fmt.Errorf("ERR wrong number of arguments for 'client|%v' command", strings.ToLower(subcommand))
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(Subcommand, commands.NewStringArgValue(subcommand))
+	args.SetArgValue(Args, commands.NewStringsArgValue(rest))
+	return args, nil
+}
@@ -0,0 +1,78 @@
+package connectioncommand
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/app/commands"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+)
+
+var NotIntegerError = errors.New("ERR Protocol version is not an integer or out of range")
+var NoProtoError = errors.New("NOPROTO unsupported protocol version")
+
+type HelloArgsEnum string
+
+const (
+	Query = "query"
+)
+
+type HelloQuery struct {
+	// 0 if protocol is not changed
+	Protover int
+	Auth     bool
+	Username string
+	Password string
+	SetName  bool
+	Name     string
+}
+
+// HELLO [protover [AUTH username password] [SETNAME clientname]]
+func ParseHelloArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	query := HelloQuery{}
+	if len(values) > 1 {
+		protover, err := strconv.Atoi(values[1].Value)
+		if err != nil {
+			return nil, NotIntegerError
+		}
+		query.Protover = protover
+	}
+	for i := 2; i < len(values); i++ {
+		left := len(values) - i - 1
+		switch strings.ToUpper(values[i].Value) {
+		case "AUTH":
+			if left < 2 {
+				return nil, fmt.Errorf("ERR Syntax error in HELLO option '%v'", values[i].Value)
+			}
+			query.Auth = true
+			query.Username = values[i+1].Value
+			query.Password = values[i+2].Value
+			i += 2
+		case "SETNAME":
+			if left < 1 {
+				return nil, fmt.Errorf("ERR Syntax error in HELLO option '%v'", values[i].Value)
+			}
+			query.SetName = true
+			query.Name = values[i+1].Value
+			i++
+		default:
+			return nil, fmt.Errorf("ERR Syntax error in HELLO option '%v'", values[i].Value)
+		}
+	}
+	if len(values) > 1 && (query.Protover < 2 || query.Protover > 3) {
+		return nil, NoProtoError
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(Query, commands.NewCustomArgValue(query))
+	return args, nil
+}
+
+// RESET
+func ParseResetArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) != 1 {
+		return nil, errors.New("ERR wrong number of arguments for 'reset' command")
+	}
+	return commands.NewArgs(), nil
+}
@@ -0,0 +1,107 @@
+package dbcommand
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/app/commands"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+)
+
+var NotIntegerError = errors.New("ERR value is not an integer or out of range")
+var SyntaxError = errors.New("ERR syntax error")
+
+type DbArgsEnum string
+
+const (
+	Index  = "index"
+	Key    = "key"
+	First  = "first"
+	Second = "second"
+	Mode   = "mode"
+)
+
+// FLUSHDB and FLUSHALL modes
+const (
+	Async = "ASYNC"
+	Sync  = "SYNC"
+)
+
+func wrongArity(values []*datatypes.Data) error {
+	return fmt.Errorf("ERR wrong number of arguments for '%v' command", strings.ToLower(values[0].Value))
+}
+
+// SELECT index
+func ParseSelectArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) != 2 {
+		return nil, wrongArity(values)
+	}
+	idx, err := strconv.Atoi(values[1].Value)
+	if err != nil {
+		return nil, NotIntegerError
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(Index, commands.NewIntArgValue(idx))
+	return args, nil
+}
+
+// MOVE key db
+func ParseMoveArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) != 3 {
+		return nil, wrongArity(values)
+	}
+	idx, err := strconv.Atoi(values[2].Value)
+	if err != nil {
+		return nil, NotIntegerError
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(Key, commands.NewStringArgValue(values[1].Value))
+	args.SetArgValue(Index, commands.NewIntArgValue(idx))
+	return args, nil
+}
+
+// SWAPDB index1 index2
+func ParseSwapdbArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) != 3 {
+		return nil, wrongArity(values)
+	}
+	first, err := strconv.Atoi(values[1].Value)
+	if err != nil {
+		return nil, errors.New("ERR invalid first DB index")
+	}
+	second, err := strconv.Atoi(values[2].Value)
+	if err != nil {
+		return nil, errors.New("ERR invalid second DB index")
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(First, commands.NewIntArgValue(first))
+	args.SetArgValue(Second, commands.NewIntArgValue(second))
+	return args, nil
+}
+
+// DBSIZE
+func ParseDbsizeArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) != 1 {
+		return nil, wrongArity(values)
+	}
+	return commands.NewArgs(), nil
+}
+
+// FLUSHDB [ASYNC|SYNC] and FLUSHALL [ASYNC|SYNC]
+func ParseFlushArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) > 2 {
+		return nil, SyntaxError
+	}
+	mode := Sync
+	if len(values) == 2 {
+		mode = strings.ToUpper(values[1].Value)
+		if mode != Async && mode != Sync {
+			return nil, SyntaxError
+		}
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(Mode, commands.NewStringArgValue(mode))
+	return args, nil
+}
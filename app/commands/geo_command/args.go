@@ -0,0 +1,399 @@
+package geocommand
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/app/commands"
+	incrcommand "github.com/codecrafters-io/redis-starter-go/app/commands/incr_command"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+	"github.com/codecrafters-io/redis-starter-go/app/geo"
+)
+
+var SyntaxError = errors.New("ERR syntax error")
+var NotIntegerError = errors.New("ERR value is not an integer or out of range")
+var UnitError = errors.New("ERR unsupported unit provided. please use M, KM, FT, MI")
+var CountError = errors.New("ERR COUNT must be > 0")
+var AnyWithoutCountError = errors.New("ERR the ANY argument requires COUNT argument")
+
+type GeoArgsEnum string
+
+const (
+	Key     = "key"
+	Members = "members"
+	Points  = "points"
+	Nx      = "nx"
+	Xx      = "xx"
+	Ch      = "ch"
+	Member1 = "member1"
+	Member2 = "member2"
+	Unit    = "unit"
+	Search  = "search"
+)
+
+type SortEnum int
+
+const (
+	SortNone SortEnum = iota
+	SortAsc
+	SortDesc
+)
+
+// member of GEOADD with its coordinates encoded to score
+type Point struct {
+	Member string
+	Score  float64
+}
+
+// query of GEOSEARCH, GEOSEARCHSTORE and GEORADIUS family
+type SearchQuery struct {
+	Key string
+	// center is position of member when FromMember is set, otherwise it is given in Shape
+	FromMember bool
+	Member     string
+	Shape      geo.Shape
+	WithDist   bool
+	WithHash   bool
+	WithCoord  bool
+	Sort       SortEnum
+	// 0 means unlimited
+	Count int
+	// stop as soon as Count matching members are found
+	Any bool
+	// results are stored instead of being replied when StoreKey is set
+	StoreKey  string
+	StoreDist bool
+}
+
+func wrongArity(values []*datatypes.Data) error {
+	return fmt.Errorf("ERR wrong number of arguments for '%v' command", strings.ToLower(values[0].Value))
+}
+
+func rawValues(values []*datatypes.Data) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		out = append(out, v.Value)
+	}
+	return out
+}
+
+// returns multiplier converting unit to meters
+func ParseUnit(str string) (float64, error) {
+	switch strings.ToLower(str) {
+	case "m":
+		return 1, nil
+	case "km":
+		return 1000, nil
+	case "ft":
+		return 0.3048, nil
+	case "mi":
+		return 1609.34, nil
+	}
+	return 0, UnitError
+}
+
+func parseLonLat(lonStr string, latStr string) (float64, float64, error) {
+	lon, err := incrcommand.ParseFloat(lonStr)
+	if err != nil {
+		return 0, 0, err
+	}
+	lat, err := incrcommand.ParseFloat(latStr)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !geo.ValidLonLat(lon, lat) {
+		return 0, 0, fmt.Errorf("ERR invalid longitude,latitude pair %f,%f", lon, lat)
+	}
+	return lon, lat, nil
+}
+
+// parses size, name is used in error of not numeric value
+func parseSize(str string, name string) (float64, error) {
+	size, err := incrcommand.ParseFloat(str)
+	if err != nil {
+		return 0, fmt.Errorf("ERR need numeric %v", name)
+	}
+	return size, nil
+}
+
+// parses radius followed by unit
+func parseRadius(shape *geo.Shape, values []*datatypes.Data) error {
+	radius, err := parseSize(values[0].Value, "radius")
+	if err != nil {
+		return err
+	}
+	if radius < 0 {
+		return errors.New("ERR radius cannot be negative")
+	}
+	conversion, err := ParseUnit(values[1].Value)
+	if err != nil {
+		return err
+	}
+	shape.ByBox = false
+	shape.Radius = radius
+	shape.Conversion = conversion
+	return nil
+}
+
+// parses width and height followed by unit
+func parseBox(shape *geo.Shape, values []*datatypes.Data) error {
+	width, err := parseSize(values[0].Value, "width")
+	if err != nil {
+		return err
+	}
+	height, err := parseSize(values[1].Value, "height")
+	if err != nil {
+		return err
+	}
+	if width < 0 || height < 0 {
+		return errors.New("ERR height or width cannot be negative")
+	}
+	conversion, err := ParseUnit(values[2].Value)
+	if err != nil {
+		return err
+	}
+	shape.ByBox = true
+	shape.Width = width
+	shape.Height = height
+	shape.Conversion = conversion
+	return nil
+}
+
+// GEOADD key [NX | XX] [CH] longitude latitude member [longitude latitude member ...]
+func ParseGeoaddArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 5 {
+		return nil, wrongArity(values)
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(Key, commands.NewStringArgValue(values[1].Value))
+	i := 2
+	for ; i < len(values); i++ {
+		switch strings.ToUpper(values[i].Value) {
+		case "NX":
+			args.SetArgValue(Nx, commands.NewIntArgValue(1))
+			continue
+		case "XX":
+			args.SetArgValue(Xx, commands.NewIntArgValue(1))
+			continue
+		case "CH":
+			args.SetArgValue(Ch, commands.NewIntArgValue(1))
+			continue
+		}
+		break
+	}
+	_, nx := args.GetArgValue(Nx)
+	_, xx := args.GetArgValue(Xx)
+	rest := values[i:]
+	if len(rest) == 0 || len(rest)%3 != 0 || nx && xx {
+		return nil, SyntaxError
+	}
+	points := make([]Point, 0, len(rest)/3)
+	for j := 0; j < len(rest); j += 3 {
+		lon, lat, err := parseLonLat(rest[j].Value, rest[j+1].Value)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, Point{Member: rest[j+2].Value, Score: float64(geo.Encode(lon, lat))})
+	}
+	args.SetArgValue(Points, commands.NewCustomArgValue(points))
+	return args, nil
+}
+
+// GEODIST key member1 member2 [M | KM | FT | MI]
+func ParseGeodistArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 4 {
+		return nil, wrongArity(values)
+	}
+	if len(values) > 5 {
+		return nil, SyntaxError
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(Key, commands.NewStringArgValue(values[1].Value))
+	args.SetArgValue(Member1, commands.NewStringArgValue(values[2].Value))
+	args.SetArgValue(Member2, commands.NewStringArgValue(values[3].Value))
+	unit := "m"
+	if len(values) == 5 {
+		unit = values[4].Value
+	}
+	_, err := ParseUnit(unit)
+	if err != nil {
+		return nil, err
+	}
+	args.SetArgValue(Unit, commands.NewStringArgValue(unit))
+	return args, nil
+}
+
+// GEOPOS key [member [member ...]] and GEOHASH key [member [member ...]]
+func ParseMembersArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 2 {
+		return nil, wrongArity(values)
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(Key, commands.NewStringArgValue(values[1].Value))
+	args.SetArgValue(Members, commands.NewStringsArgValue(rawValues(values[2:])))
+	return args, nil
+}
+
+// GEORADIUS key longitude latitude radius <M | KM | FT | MI> [options] [STORE key | STOREDIST key],
+// GEORADIUS_RO takes no STORE options
+func ParseGeoradiusArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 6 {
+		return nil, wrongArity(values)
+	}
+	query := SearchQuery{Key: values[1].Value}
+	lon, lat, err := parseLonLat(values[2].Value, values[3].Value)
+	if err != nil {
+		return nil, err
+	}
+	query.Shape.Lon, query.Shape.Lat = lon, lat
+	err = parseRadius(&query.Shape, values[4:6])
+	if err != nil {
+		return nil, err
+	}
+	return parseSearchOptions(&query, values, 6, false)
+}
+
+// GEORADIUSBYMEMBER key member radius <M | KM | FT | MI> [options] [STORE key | STOREDIST key],
+// GEORADIUSBYMEMBER_RO takes no STORE options
+func ParseGeoradiusbymemberArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 5 {
+		return nil, wrongArity(values)
+	}
+	query := SearchQuery{Key: values[1].Value, FromMember: true, Member: values[2].Value}
+	err := parseRadius(&query.Shape, values[3:5])
+	if err != nil {
+		return nil, err
+	}
+	return parseSearchOptions(&query, values, 5, false)
+}
+
+// GEOSEARCH key <FROMMEMBER member | FROMLONLAT longitude latitude> <BYRADIUS radius unit | BYBOX width height unit> [options]
+func ParseGeosearchArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 7 {
+		return nil, wrongArity(values)
+	}
+	query := SearchQuery{Key: values[1].Value}
+	return parseSearchOptions(&query, values, 2, true)
+}
+
+// GEOSEARCHSTORE destination source <FROMMEMBER member | FROMLONLAT longitude latitude> <BYRADIUS radius unit | BYBOX width height unit> [options] [STOREDIST]
+func ParseGeosearchstoreArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 8 {
+		return nil, wrongArity(values)
+	}
+	query := SearchQuery{Key: values[2].Value, StoreKey: values[1].Value}
+	return parseSearchOptions(&query, values, 3, true)
+}
+
+// parses options following base arguments, search enables FROMMEMBER, FROMLONLAT, BYRADIUS and BYBOX of GEOSEARCH
+func parseSearchOptions(query *SearchQuery, values []*datatypes.Data, base int, search bool) (commands.CommandArgs, error) {
+	name := strings.ToUpper(values[0].Value)
+	store := !search && !strings.HasSuffix(name, "_RO")
+	searchStore := name == "GEOSEARCHSTORE"
+	fromMember, fromLonLat, byRadius, byBox := false, false, false, false
+	for i := base; i < len(values); i++ {
+		remaining := len(values) - i - 1
+		switch opt := strings.ToUpper(values[i].Value); {
+		case opt == "WITHDIST":
+			query.WithDist = true
+		case opt == "WITHHASH":
+			query.WithHash = true
+		case opt == "WITHCOORD":
+			query.WithCoord = true
+		case opt == "ANY":
+			query.Any = true
+		case opt == "ASC":
+			query.Sort = SortAsc
+		case opt == "DESC":
+			query.Sort = SortDesc
+		case opt == "COUNT" && remaining >= 1:
+			count, err := strconv.ParseInt(values[i+1].Value, 10, 64)
+			if err != nil {
+				return nil, NotIntegerError
+			}
+			if count <= 0 {
+				return nil, CountError
+			}
+			query.Count = int(count)
+			i++
+		case (opt == "STORE" || opt == "STOREDIST") && remaining >= 1 && store:
+			query.StoreKey = values[i+1].Value
+			query.StoreDist = opt == "STOREDIST"
+			i++
+		case opt == "STOREDIST" && searchStore:
+			query.StoreDist = true
+		case opt == "FROMMEMBER" && remaining >= 1 && search && !fromLonLat:
+			query.FromMember = true
+			query.Member = values[i+1].Value
+			fromMember = true
+			i++
+		case opt == "FROMLONLAT" && remaining >= 2 && search && !fromMember:
+			lon, lat, err := parseLonLat(values[i+1].Value, values[i+2].Value)
+			if err != nil {
+				return nil, err
+			}
+			query.Shape.Lon, query.Shape.Lat = lon, lat
+			fromLonLat = true
+			i += 2
+		case opt == "BYRADIUS" && remaining >= 2 && search && !byBox:
+			err := parseRadius(&query.Shape, values[i+1:i+3])
+			if err != nil {
+				return nil, err
+			}
+			byRadius = true
+			i += 2
+		case opt == "BYBOX" && remaining >= 3 && search && !byRadius:
+			err := parseBox(&query.Shape, values[i+1:i+4])
+			if err != nil {
+				return nil, err
+			}
+			byBox = true
+			i += 3
+		default:
+			return nil, SyntaxError
+		}
+	}
+
+	if query.StoreKey != "" && (query.WithDist || query.WithHash || query.WithCoord) {
+		option := "STORE option in GEORADIUS"
+		if searchStore {
+			option = "GEOSEARCHSTORE"
+		}
+		return nil, fmt.Errorf("ERR %v is not compatible with WITHDIST, WITHHASH and WITHCOORD options", option)
+	}
+	if search && !fromMember && !fromLonLat {
+		return nil, fmt.Errorf("ERR exactly one of FROMMEMBER or FROMLONLAT can be specified for %v", values[0].Value)
+	}
+	if search && !byRadius && !byBox {
+		return nil, fmt.Errorf("ERR exactly one of BYRADIUS and BYBOX can be specified for %v", values[0].Value)
+	}
+	if query.Any && query.Count == 0 {
+		return nil, AnyWithoutCountError
+	}
+
+	args := commands.NewArgs()
+	args.SetArgValue(Search, commands.NewCustomArgValue(*query))
+	return args, nil
+}
+
+// returns source key followed by key of STORE or STOREDIST option of GEORADIUS and GEORADIUSBYMEMBER
+func GetKeys(values []*datatypes.Data) []string {
+	if len(values) < 2 {
+		return nil
+	}
+	keys := []string{values[1].Value}
+	base := 6
+	if strings.HasPrefix(strings.ToUpper(values[0].Value), "GEORADIUSBYMEMBER") {
+		base = 5
+	}
+	for i := base; i < len(values)-1; i++ {
+		opt := strings.ToUpper(values[i].Value)
+		if opt == "STORE" || opt == "STOREDIST" {
+			keys = append(keys, values[i+1].Value)
+			i++
+		}
+	}
+	return keys
+}
@@ -0,0 +1,62 @@
+package hllcommand
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/app/commands"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+)
+
+type HllArgsEnum string
+
+const (
+	Key      = "key"
+	Keys     = "keys"
+	DestKey  = "destkey"
+	Elements = "elements"
+)
+
+func wrongArity(values []*datatypes.Data) error {
+	return fmt.Errorf("ERR wrong number of arguments for '%v' command", strings.ToLower(values[0].Value))
+}
+
+func rawValues(values []*datatypes.Data) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		out = append(out, v.Value)
+	}
+	return out
+}
+
+// PFADD key [element [element ...]]
+func ParsePfaddArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 2 {
+		return nil, wrongArity(values)
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(Key, commands.NewStringArgValue(values[1].Value))
+	args.SetArgValue(Elements, commands.NewStringsArgValue(rawValues(values[2:])))
+	return args, nil
+}
+
+// PFCOUNT key [key ...]
+func ParsePfcountArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 2 {
+		return nil, wrongArity(values)
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(Keys, commands.NewStringsArgValue(rawValues(values[1:])))
+	return args, nil
+}
+
+// PFMERGE destkey [sourcekey [sourcekey ...]]
+func ParsePfmergeArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 2 {
+		return nil, wrongArity(values)
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(DestKey, commands.NewStringArgValue(values[1].Value))
+	args.SetArgValue(Keys, commands.NewStringsArgValue(rawValues(values[2:])))
+	return args, nil
+}
@@ -1,6 +1,11 @@
 package incrcommand
 
 import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
 	"github.com/codecrafters-io/redis-starter-go/app/commands"
 	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
 )
@@ -8,15 +13,63 @@ import (
 type IncrArgsEnum string
 
 const (
-	IcrKey = "icrKey"
+	IcrKey    = "icrKey"
+	Increment = "increment"
 )
 
+func wrongArity(values []*datatypes.Data) error {
+	return fmt.Errorf("ERR wrong number of arguments for '%v' command", strings.ToLower(values[0].Value))
+}
+
+// INCR key and DECR key
 func ParseIncrArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
-	if len(values) < 2 {
-		return nil, commands.NotEnoughValuesToConstructArgs
+	if len(values) != 2 {
+		return nil, wrongArity(values)
 	}
 	keyToIncr := values[1].Value
 	args := commands.NewArgs()
 	args.SetArgValue(IcrKey, commands.NewStringArgValue(keyToIncr))
 	return args, nil
 }
+
+// INCRBY key increment and DECRBY key decrement
+func ParseIncrbyArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) != 3 {
+		return nil, wrongArity(values)
+	}
+	increment, err := strconv.ParseInt(values[2].Value, 10, 64)
+	if err != nil {
+		return nil, IncrNotIntegerTypeError
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(IcrKey, commands.NewStringArgValue(values[1].Value))
+	args.SetArgValue(Increment, commands.NewIntArgValue(int(increment)))
+	return args, nil
+}
+
+// INCRBYFLOAT key increment, increment is validated and kept as string
+func ParseIncrbyfloatArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) != 3 {
+		return nil, wrongArity(values)
+	}
+	_, err := ParseLongDouble(values[2].Value)
+	if err != nil {
+		return nil, err
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(IcrKey, commands.NewStringArgValue(values[1].Value))
+	args.SetArgValue(Increment, commands.NewStringArgValue(values[2].Value))
+	return args, nil
+}
+
+// parses float argument or value, NaN and values with surrounding spaces are not valid floats
+func ParseFloat(str string) (float64, error) {
+	if strings.TrimSpace(str) != str {
+		return 0, NotFloatError
+	}
+	f, err := strconv.ParseFloat(str, 64)
+	if err != nil || math.IsNaN(f) {
+		return 0, NotFloatError
+	}
+	return f, nil
+}
@@ -3,3 +3,7 @@ package incrcommand
 import "errors"
 
 var IncrNotIntegerTypeError = errors.New("ERR value is not an integer or out of range")
+var NotFloatError = errors.New("ERR value is not a valid float")
+var OverflowError = errors.New("ERR increment or decrement would overflow")
+var DecrementOverflowError = errors.New("ERR decrement would overflow")
+var NanOrInfinityError = errors.New("ERR increment would produce NaN or Infinity")
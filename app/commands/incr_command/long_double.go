@@ -0,0 +1,48 @@
+package incrcommand
+
+import (
+	"math/big"
+	"strings"
+)
+
+// redis computes INCRBYFLOAT in x87 long double, it is emulated by floats with the same 64 bit mantissa,
+// so results are rounded the same way, e.g. 0.1 + 0.2 is 0.3 and not 0.30000000000000004
+const longDoublePrec = 64
+
+// long double holds numbers below 2^16384
+const longDoubleMaxExp = 16384
+
+// parses INCRBYFLOAT increment or value, spaces, NaN and numbers out of long double range are rejected
+func ParseLongDouble(str string) (*big.Float, error) {
+	if strings.TrimSpace(str) != str {
+		return nil, NotFloatError
+	}
+	f, _, err := new(big.Float).SetPrec(longDoublePrec).Parse(str, 10)
+	if err != nil || !f.IsInf() && f.MantExp(nil) > longDoubleMaxExp {
+		return nil, NotFloatError
+	}
+	return f, nil
+}
+
+// adds long doubles, error is returned if sum is infinite
+func AddLongDouble(x *big.Float, y *big.Float) (*big.Float, error) {
+	if x.IsInf() || y.IsInf() {
+		return nil, NanOrInfinityError
+	}
+	sum := new(big.Float).SetPrec(longDoublePrec).Add(x, y)
+	if sum.MantExp(nil) > longDoubleMaxExp {
+		return nil, NanOrInfinityError
+	}
+	return sum, nil
+}
+
+// formats long double like redis does for humans, with 17 decimals without trailing zeros
+func FormatLongDouble(f *big.Float) string {
+	str := f.Text('f', 17)
+	str = strings.TrimRight(str, "0")
+	str = strings.TrimSuffix(str, ".")
+	if str == "-0" {
+		return "0"
+	}
+	return str
+}
@@ -9,6 +9,8 @@ type InfoEnum string
 const (
 	ALL         = "all"
 	REPLICATION = "replication"
+	STATS       = "stats"
+	KEYSPACE    = "keyspace"
 )
 
 type InfoArgsEnum string
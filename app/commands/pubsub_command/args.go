@@ -0,0 +1,108 @@
+package pubsubcommand
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/app/commands"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+)
+
+type PubsubArgsEnum string
+
+const (
+	// channels or patterns of (P|S)SUBSCRIBE and (P|S)UNSUBSCRIBE
+	Names      = "names"
+	Channel    = "channel"
+	Message    = "message"
+	Subcommand = "subcommand"
+	Args       = "args"
+)
+
+type PubsubSubcommandEnum string
+
+const (
+	Channels      = "CHANNELS"
+	Numsub        = "NUMSUB"
+	Numpat        = "NUMPAT"
+	Shardchannels = "SHARDCHANNELS"
+	Shardnumsub   = "SHARDNUMSUB"
+)
+
+// allowed amount of subcommand arguments, max -1 means unlimited
+var subcommandArity = map[string][2]int{
+	Channels:      {0, 1},
+	Numsub:        {0, -1},
+	Numpat:        {0, 0},
+	Shardchannels: {0, 1},
+	Shardnumsub:   {0, -1},
+}
+
+func wrongArity(values []*datatypes.Data) error {
+	return fmt.Errorf("ERR wrong number of arguments for '%v' command", strings.ToLower(values[0].Value))
+}
+
+func names(values []*datatypes.Data) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = v.Value
+	}
+	return out
+}
+
+// SUBSCRIBE channel [channel ...]
+// PSUBSCRIBE pattern [pattern ...]
+// SSUBSCRIBE shardchannel [shardchannel ...]
+func ParseSubscribeArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 2 {
+		return nil, wrongArity(values)
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(Names, commands.NewStringsArgValue(names(values[1:])))
+	return args, nil
+}
+
+// UNSUBSCRIBE [channel [channel ...]]
+// PUNSUBSCRIBE [pattern [pattern ...]]
+// SUNSUBSCRIBE [shardchannel [shardchannel ...]]
+func ParseUnsubscribeArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	args := commands.NewArgs()
+	args.SetArgValue(Names, commands.NewStringsArgValue(names(values[1:])))
+	return args, nil
+}
+
+// PUBLISH channel message
+// SPUBLISH shardchannel message
+func ParsePublishArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) != 3 {
+		return nil, wrongArity(values)
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(Channel, commands.NewStringArgValue(values[1].Value))
+	args.SetArgValue(Message, commands.NewStringArgValue(values[2].Value))
+	return args, nil
+}
+
+// PUBSUB CHANNELS [pattern]
+// PUBSUB NUMSUB [channel [channel ...]]
+// PUBSUB NUMPAT
+// PUBSUB SHARDCHANNELS [pattern]
+// PUBSUB SHARDNUMSUB [shardchannel [shardchannel ...]]
+func ParsePubsubArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 2 {
+		return nil, wrongArity(values)
+	}
+	subcommand := strings.ToUpper(values[1].Value)
+	arity, ok := subcommandArity[subcommand]
+	if !ok {
+		return nil, fmt.Errorf("ERR unknown subcommand '%v'. Try PUBSUB HELP.", values[1].Value)
+	}
+	rest := names(values[2:])
+	if len(rest) < arity[0] || (arity[1] >= 0 && len(rest) > arity[1]) {
+		return nil, fmt.Errorf("ERR wrong number of arguments for 'pubsub|%v' command", strings.ToLower(subcommand))
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(Subcommand, commands.NewStringArgValue(subcommand))
+	args.SetArgValue(Args, commands.NewStringsArgValue(rest))
+	return args, nil
+}
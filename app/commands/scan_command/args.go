@@ -0,0 +1,102 @@
+package scancommand
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/app/commands"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+)
+
+var InvalidCursorError = errors.New("ERR invalid cursor")
+var NotIntegerError = errors.New("ERR value is not an integer or out of range")
+var SyntaxError = errors.New("ERR syntax error")
+
+type ScanArgsEnum string
+
+const (
+	Key      = "key"
+	Cursor   = "cursor"
+	Match    = "match"
+	Count    = "count"
+	Type     = "type"
+	NoValues = "novalues"
+)
+
+// amount of elements scanned by single call when COUNT is not given
+const DefaultCount = 10
+
+func wrongArity(values []*datatypes.Data) error {
+	return fmt.Errorf("ERR wrong number of arguments for '%v' command", strings.ToLower(values[0].Value))
+}
+
+// SCAN cursor [MATCH pattern] [COUNT count] [TYPE type]
+func ParseScanArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 2 {
+		return nil, wrongArity(values)
+	}
+	args := commands.NewArgs()
+	err := parseCursorAndOptions(args, values[1:], true, false)
+	if err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+// HSCAN key cursor [MATCH pattern] [COUNT count] [NOVALUES], SSCAN and ZSCAN key cursor [MATCH pattern] [COUNT count]
+func ParseKeyScanArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 3 {
+		return nil, wrongArity(values)
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(Key, commands.NewStringArgValue(values[1].Value))
+	err := parseCursorAndOptions(args, values[2:], false, strings.ToUpper(values[0].Value) == "HSCAN")
+	if err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+// parses cursor followed by options, TYPE is accepted only by SCAN, NOVALUES only by HSCAN
+func parseCursorAndOptions(args commands.CommandArgs, values []*datatypes.Data, allowType bool, allowNoValues bool) error {
+	cursor, err := strconv.ParseUint(values[0].Value, 10, 64)
+	if err != nil {
+		return InvalidCursorError
+	}
+	// cursor is kept as int with same bits, so cursors above max int survive round trip
+	args.SetArgValue(Cursor, commands.NewIntArgValue(int(cursor)))
+	args.SetArgValue(Count, commands.NewIntArgValue(DefaultCount))
+	for i := 1; i < len(values); i++ {
+		option := strings.ToUpper(values[i].Value)
+		switch {
+		case option == "NOVALUES" && allowNoValues:
+			args.SetArgValue(NoValues, commands.NewIntArgValue(1))
+			continue
+		case option == "MATCH" || option == "COUNT" || option == "TYPE" && allowType:
+		default:
+			return SyntaxError
+		}
+		if i+1 >= len(values) {
+			return SyntaxError
+		}
+		i++
+		switch option {
+		case "MATCH":
+			args.SetArgValue(Match, commands.NewStringArgValue(values[i].Value))
+		case "COUNT":
+			count, err := strconv.Atoi(values[i].Value)
+			if err != nil {
+				return NotIntegerError
+			}
+			if count < 1 {
+				return SyntaxError
+			}
+			args.SetArgValue(Count, commands.NewIntArgValue(count))
+		case "TYPE":
+			args.SetArgValue(Type, commands.NewStringArgValue(values[i].Value))
+		}
+	}
+	return nil
+}
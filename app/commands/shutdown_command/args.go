@@ -0,0 +1,58 @@
+package shutdowncommand
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/app/commands"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+)
+
+var SyntaxError = errors.New("ERR syntax error")
+
+type ShutdownArgsEnum string
+
+// Now, Force and Abort are set only if flag is given
+const (
+	SaveMode = "savemode"
+	Now      = "now"
+	Force    = "force"
+	Abort    = "abort"
+)
+
+const (
+	Save   = "SAVE"
+	NoSave = "NOSAVE"
+)
+
+// SHUTDOWN [NOSAVE|SAVE] [NOW] [FORCE] [ABORT]
+func ParseShutdownArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	args := commands.NewArgs()
+	saveMode := ""
+	abort := false
+	for _, v := range values[1:] {
+		flag := strings.ToUpper(v.Value)
+		switch flag {
+		case Save, NoSave:
+			if saveMode != "" {
+				return nil, SyntaxError
+			}
+			saveMode = flag
+		case "NOW", "FORCE", "ABORT":
+			key := strings.ToLower(flag)
+			if _, ok := args.GetArgValue(key); ok {
+				return nil, SyntaxError
+			}
+			args.SetArgValue(key, commands.NewIntArgValue(1))
+			abort = abort || flag == "ABORT"
+		default:
+			return nil, SyntaxError
+		}
+	}
+	// ABORT can not be combined with other flags
+	if abort && len(values) != 2 {
+		return nil, SyntaxError
+	}
+	args.SetArgValue(SaveMode, commands.NewStringArgValue(saveMode))
+	return args, nil
+}
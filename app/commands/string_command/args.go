@@ -0,0 +1,164 @@
+package stringcommand
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/app/commands"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+var NotIntegerError = errors.New("ERR value is not an integer or out of range")
+var SyntaxError = errors.New("ERR syntax error")
+var OffsetOutOfRangeError = errors.New("ERR offset is out of range")
+
+type StringArgsEnum string
+
+const (
+	Key          = "key"
+	Keys         = "keys"
+	Value        = "value"
+	Start        = "start"
+	End          = "end"
+	Offset       = "offset"
+	Kvs          = "kvs"
+	Len          = "len"
+	Idx          = "idx"
+	MinMatchLen  = "minmatchlen"
+	WithMatchLen = "withmatchlen"
+)
+
+func wrongArity(values []*datatypes.Data) error {
+	return fmt.Errorf("ERR wrong number of arguments for '%v' command", strings.ToLower(values[0].Value))
+}
+
+// APPEND key value
+func ParseAppendArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) != 3 {
+		return nil, wrongArity(values)
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(Key, commands.NewStringArgValue(values[1].Value))
+	args.SetArgValue(Value, commands.NewStringArgValue(values[2].Value))
+	return args, nil
+}
+
+// STRLEN key
+func ParseStrlenArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) != 2 {
+		return nil, wrongArity(values)
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(Key, commands.NewStringArgValue(values[1].Value))
+	return args, nil
+}
+
+// GETRANGE key start end
+func ParseGetrangeArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) != 4 {
+		return nil, wrongArity(values)
+	}
+	start, err := strconv.Atoi(values[2].Value)
+	if err != nil {
+		return nil, NotIntegerError
+	}
+	end, err := strconv.Atoi(values[3].Value)
+	if err != nil {
+		return nil, NotIntegerError
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(Key, commands.NewStringArgValue(values[1].Value))
+	args.SetArgValue(Start, commands.NewIntArgValue(start))
+	args.SetArgValue(End, commands.NewIntArgValue(end))
+	return args, nil
+}
+
+// SETRANGE key offset value
+func ParseSetrangeArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) != 4 {
+		return nil, wrongArity(values)
+	}
+	offset, err := strconv.Atoi(values[2].Value)
+	if err != nil {
+		return nil, NotIntegerError
+	}
+	if offset < 0 {
+		return nil, OffsetOutOfRangeError
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(Key, commands.NewStringArgValue(values[1].Value))
+	args.SetArgValue(Offset, commands.NewIntArgValue(offset))
+	args.SetArgValue(Value, commands.NewStringArgValue(values[3].Value))
+	return args, nil
+}
+
+// MGET key [key ...]
+func ParseMgetArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 2 {
+		return nil, wrongArity(values)
+	}
+	keys := make([]string, 0, len(values)-1)
+	for _, v := range values[1:] {
+		keys = append(keys, v.Value)
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(Keys, commands.NewStringsArgValue(keys))
+	return args, nil
+}
+
+// MSET key value [key value ...] and MSETNX key value [key value ...]
+func ParseMsetArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 3 || len(values)%2 != 1 {
+		return nil, wrongArity(values)
+	}
+	kvs := make([]types.Kv, 0, len(values)/2)
+	for i := 1; i < len(values); i += 2 {
+		kvs = append(kvs, types.Kv{values[i].Value, values[i+1].Value})
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(Kvs, commands.NewKvArgValue(kvs))
+	return args, nil
+}
+
+// LCS key1 key2 [LEN] [IDX] [MINMATCHLEN min-match-len] [WITHMATCHLEN]
+func ParseLcsArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 3 {
+		return nil, wrongArity(values)
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(Keys, commands.NewStringsArgValue([]string{values[1].Value, values[2].Value}))
+	for i := 3; i < len(values); i++ {
+		switch strings.ToUpper(values[i].Value) {
+		case "LEN":
+			args.SetArgValue(Len, commands.NewIntArgValue(1))
+		case "IDX":
+			args.SetArgValue(Idx, commands.NewIntArgValue(1))
+		case "WITHMATCHLEN":
+			args.SetArgValue(WithMatchLen, commands.NewIntArgValue(1))
+		case "MINMATCHLEN":
+			if i+1 >= len(values) {
+				return nil, SyntaxError
+			}
+			i++
+			minMatchLen, err := strconv.Atoi(values[i].Value)
+			if err != nil {
+				return nil, NotIntegerError
+			}
+			if minMatchLen < 0 {
+				minMatchLen = 0
+			}
+			args.SetArgValue(MinMatchLen, commands.NewIntArgValue(minMatchLen))
+		default:
+			return nil, SyntaxError
+		}
+	}
+	_, isLen := args.GetArgValue(Len)
+	_, isIdx := args.GetArgValue(Idx)
+	if isLen && isIdx {
+		return nil, errors.New("ERR If you want both the length and indexes, please just use IDX.")
+	}
+	return args, nil
+}
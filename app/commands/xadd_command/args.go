@@ -1,23 +1,53 @@
 package xaddcommand
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/codecrafters-io/redis-starter-go/app/commands"
 	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+	"github.com/codecrafters-io/redis-starter-go/app/stream"
 	"github.com/codecrafters-io/redis-starter-go/app/types"
 )
 
+var SyntaxError = errors.New("ERR syntax error")
+var NotIntegerError = errors.New("ERR value is not an integer or out of range")
+var MaxLenError = errors.New("ERR The MAXLEN argument must be >= 0.")
+var LimitError = errors.New("ERR The LIMIT argument must be >= 0.")
+var MaxLenAndMinIdError = errors.New("ERR syntax error, MAXLEN and MINID options at the same time are not compatible")
+var LimitWithoutApproxError = errors.New("ERR syntax error, LIMIT cannot be used without the special ~ option")
+var EntriesAddedError = errors.New("ERR entries_added must be positive")
+
+// default LIMIT of approximate trimming
+const defaultTrimLimit = 10000
+
 type XaddArgsEnum string
 
 const (
-	Key = "key"
-	Id  = "id"
-	Kv  = "kv"
+	Key        = "key"
+	Id         = "id"
+	Kv         = "kv"
+	NoMkStream = "nomkstream"
+	Trim       = "trim"
+	Ids        = "ids"
+	SetId      = "setid"
 )
 
 type XaddOpts struct {
-	key string
-	id  string
-	kv  []types.Kv
+	key        string
+	id         string
+	kv         []types.Kv
+	noMkStream bool
+	trim       stream.TrimOptions
+}
+
+// query of XSETID, EntriesAdded -1 and nil MaxDeletedId mean options are not given
+type SetIdQuery struct {
+	Id           stream.StreamEntrieId
+	EntriesAdded int64
+	MaxDeletedId *stream.StreamEntrieId
 }
 
 func NewXaddArgs(opts XaddOpts) commands.CommandArgs {
@@ -25,26 +55,224 @@ func NewXaddArgs(opts XaddOpts) commands.CommandArgs {
 	args.SetArgValue(Key, commands.NewStringArgValue(opts.key))
 	args.SetArgValue(Id, commands.NewStringArgValue(opts.id))
 	args.SetArgValue(Kv, commands.NewKvArgValue(opts.kv))
+	args.SetArgValue(Trim, commands.NewCustomArgValue(opts.trim))
+	if opts.noMkStream {
+		args.SetArgValue(NoMkStream, commands.NewIntArgValue(1))
+	}
 	return args
 }
 
+func wrongArity(values []*datatypes.Data) error {
+	return fmt.Errorf("ERR wrong number of arguments for '%v' command", strings.ToLower(values[0].Value))
+}
+
+// parses trimming option starting at values[i], returns amount of parsed values, 0 if values[i] is not trimming option
+func parseTrimOption(values []*datatypes.Data, i int, opts *stream.TrimOptions) (int, error) {
+	strategy := stream.TrimNone
+	switch strings.ToUpper(values[i].Value) {
+	case "MAXLEN":
+		strategy = stream.TrimMaxLen
+	case "MINID":
+		strategy = stream.TrimMinId
+	case "LIMIT":
+		if i+1 >= len(values) {
+			return 0, SyntaxError
+		}
+		limit, err := strconv.ParseInt(values[i+1].Value, 10, 64)
+		if err != nil {
+			return 0, NotIntegerError
+		}
+		if limit < 0 {
+			return 0, LimitError
+		}
+		opts.Limit = limit
+		return 2, nil
+	default:
+		return 0, nil
+	}
+	if i+1 >= len(values) {
+		return 0, SyntaxError
+	}
+	if opts.Strategy != stream.TrimNone && opts.Strategy != strategy {
+		return 0, MaxLenAndMinIdError
+	}
+	opts.Strategy = strategy
+	parsed := 1
+	switch values[i+1].Value {
+	case "~":
+		opts.Approx = true
+		parsed++
+	case "=":
+		parsed++
+	}
+	if i+parsed >= len(values) {
+		return 0, SyntaxError
+	}
+	threshold := values[i+parsed].Value
+	if strategy == stream.TrimMaxLen {
+		maxLen, err := strconv.ParseInt(threshold, 10, 64)
+		if err != nil {
+			return 0, NotIntegerError
+		}
+		if maxLen < 0 {
+			return 0, MaxLenError
+		}
+		opts.MaxLen = maxLen
+	} else {
+		minId, err := stream.ParseStrictId(threshold)
+		if err != nil {
+			return 0, err
+		}
+		opts.MinId = minId
+	}
+	return parsed + 1, nil
+}
+
+// checks LIMIT usage and sets default limit of approximate trimming
+func validateTrim(opts *stream.TrimOptions, hasLimit bool) error {
+	if hasLimit && !opts.Approx {
+		return LimitWithoutApproxError
+	}
+	if opts.Approx && !hasLimit {
+		opts.Limit = defaultTrimLimit
+	}
+	return nil
+}
+
+// XADD key [NOMKSTREAM] [MAXLEN|MINID [=|~] threshold [LIMIT count]] *|id field value [field value ...]
 func ParseXaddArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 5 {
+		return nil, wrongArity(values)
+	}
+	opts := XaddOpts{key: values[1].Value}
+	hasLimit := false
+	i := 2
+	for ; i < len(values); i++ {
+		if strings.ToUpper(values[i].Value) == "NOMKSTREAM" {
+			opts.noMkStream = true
+			continue
+		}
+		parsed, err := parseTrimOption(values, i, &opts.trim)
+		if err != nil {
+			return nil, err
+		}
+		if parsed == 0 {
+			break
+		}
+		hasLimit = hasLimit || strings.ToUpper(values[i].Value) == "LIMIT"
+		i += parsed - 1
+	}
+	if err := validateTrim(&opts.trim, hasLimit); err != nil {
+		return nil, err
+	}
+	fields := len(values) - i - 1
+	if i >= len(values) || fields < 2 || fields%2 != 0 {
+		return nil, wrongArity(values)
+	}
+	opts.id = values[i].Value
+	opts.kv = make([]types.Kv, 0, fields/2)
+	for j := i + 1; j < len(values)-1; j += 2 {
+		opts.kv = append(opts.kv, types.Kv{values[j].Value, values[j+1].Value})
+	}
+	return NewXaddArgs(opts), nil
+}
+
+// XTRIM key MAXLEN|MINID [=|~] threshold [LIMIT count]
+func ParseXtrimArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 4 {
+		return nil, wrongArity(values)
+	}
+	trim := stream.TrimOptions{}
+	hasLimit := false
+	for i := 2; i < len(values); i++ {
+		parsed, err := parseTrimOption(values, i, &trim)
+		if err != nil {
+			return nil, err
+		}
+		if parsed == 0 {
+			return nil, SyntaxError
+		}
+		hasLimit = hasLimit || strings.ToUpper(values[i].Value) == "LIMIT"
+		i += parsed - 1
+	}
+	if trim.Strategy == stream.TrimNone {
+		return nil, SyntaxError
+	}
+	if err := validateTrim(&trim, hasLimit); err != nil {
+		return nil, err
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(Key, commands.NewStringArgValue(values[1].Value))
+	args.SetArgValue(Trim, commands.NewCustomArgValue(trim))
+	return args, nil
+}
+
+// XDEL key id [id ...]
+func ParseXdelArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
 	if len(values) < 3 {
-		return nil, commands.NotEnoughValuesToConstructArgs
+		return nil, wrongArity(values)
+	}
+	ids := make([]stream.StreamEntrieId, 0, len(values)-2)
+	for _, v := range values[2:] {
+		id, err := stream.ParseStrictId(v.Value)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
 	}
-	key := values[1]
-	streamId := values[2]
-	kv := make([]types.Kv, 0, len(values)-3/2)
+	args := commands.NewArgs()
+	args.SetArgValue(Key, commands.NewStringArgValue(values[1].Value))
+	args.SetArgValue(Ids, commands.NewCustomArgValue(ids))
+	return args, nil
+}
 
-	for i := 3; i < len(values)-1; i += 2 {
-		key := values[i]
-		value := values[i+1]
-		kv = append(kv, types.Kv{key.Value, value.Value})
+// XLEN key
+func ParseXlenArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) != 2 {
+		return nil, wrongArity(values)
 	}
+	args := commands.NewArgs()
+	args.SetArgValue(Key, commands.NewStringArgValue(values[1].Value))
+	return args, nil
+}
 
-	return NewXaddArgs(XaddOpts{
-		key: key.Value,
-		id:  streamId.Value,
-		kv:  kv,
-	}), nil
+// XSETID key last-id [ENTRIESADDED entries-added] [MAXDELETEDID max-deleted-id]
+func ParseXsetidArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 3 {
+		return nil, wrongArity(values)
+	}
+	id, err := stream.ParseStrictId(values[2].Value)
+	if err != nil {
+		return nil, err
+	}
+	query := SetIdQuery{Id: id, EntriesAdded: -1}
+	for i := 3; i < len(values); i++ {
+		if i+1 >= len(values) {
+			return nil, SyntaxError
+		}
+		switch strings.ToUpper(values[i].Value) {
+		case "ENTRIESADDED":
+			entriesAdded, err := strconv.ParseInt(values[i+1].Value, 10, 64)
+			if err != nil {
+				return nil, NotIntegerError
+			}
+			if entriesAdded < 0 {
+				return nil, EntriesAddedError
+			}
+			query.EntriesAdded = entriesAdded
+		case "MAXDELETEDID":
+			maxDeletedId, err := stream.ParseStrictId(values[i+1].Value)
+			if err != nil {
+				return nil, err
+			}
+			query.MaxDeletedId = &maxDeletedId
+		default:
+			return nil, SyntaxError
+		}
+		i++
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(Key, commands.NewStringArgValue(values[1].Value))
+	args.SetArgValue(SetId, commands.NewCustomArgValue(query))
+	return args, nil
 }
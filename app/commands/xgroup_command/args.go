@@ -0,0 +1,509 @@
+package xgroupcommand
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/app/commands"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+	"github.com/codecrafters-io/redis-starter-go/app/stream"
+)
+
+var SyntaxError = errors.New("ERR syntax error")
+var NotIntegerError = errors.New("ERR value is not an integer or out of range")
+var EntriesReadError = errors.New("ERR value for ENTRIESREAD must be positive or -1")
+var TimeoutNotIntegerError = errors.New("ERR timeout is not an integer or out of range")
+var NegativeTimeoutError = errors.New("ERR timeout is negative")
+var MissingGroupError = errors.New("ERR Missing GROUP option for XREADGROUP")
+var UnbalancedStreamsError = errors.New("ERR Unbalanced 'xreadgroup' list of streams: for each stream key an ID or '>' must be specified.")
+var LastIdInGroupError = errors.New("ERR The $ ID is meaningless in the context of XREADGROUP: you want to read the history of this consumer by specifying a proper ID, or use the > ID to get new messages. The $ ID would just return an empty result set.")
+var InvalidStartError = errors.New("ERR invalid start ID for the interval")
+var InvalidEndError = errors.New("ERR invalid end ID for the interval")
+var CountError = errors.New("ERR COUNT must be > 0")
+
+type XgroupArgsEnum string
+
+const (
+	Query = "query"
+)
+
+type XgroupSubcommandEnum string
+
+const (
+	Create         = "CREATE"
+	SetId          = "SETID"
+	Destroy        = "DESTROY"
+	CreateConsumer = "CREATECONSUMER"
+	DelConsumer    = "DELCONSUMER"
+)
+
+// allowed amount of subcommand arguments after key and group, max -1 means unlimited
+var subcommandArity = map[string][2]int{
+	Create:         {1, 4},
+	SetId:          {1, 3},
+	Destroy:        {0, 0},
+	CreateConsumer: {1, 1},
+	DelConsumer:    {1, 1},
+}
+
+// query of XGROUP subcommand
+type GroupQuery struct {
+	Subcommand string
+	Key        string
+	Group      string
+	// nil means last id of stream ($)
+	Id          *stream.StreamEntrieId
+	EntriesRead int64
+	MkStream    bool
+	Consumer    string
+}
+
+// query of XREADGROUP, Ids are nil for > which reads entries never delivered to group
+type ReadGroupQuery struct {
+	Group    string
+	Consumer string
+	// 0 means unlimited
+	Count   int
+	Block   bool
+	Timeout int
+	NoAck   bool
+	Keys    []string
+	Ids     []*stream.StreamEntrieId
+}
+
+type AckQuery struct {
+	Key   string
+	Group string
+	Ids   []stream.StreamEntrieId
+}
+
+// query of XPENDING, summary is requested when Extended is not set
+type PendingQuery struct {
+	Key      string
+	Group    string
+	Extended bool
+	MinIdle  int64
+	Start    stream.StreamEntrieId
+	End      stream.StreamEntrieId
+	Count    int
+	// nil means every consumer
+	Consumer *string
+}
+
+type ClaimQuery struct {
+	Key      string
+	Group    string
+	Consumer string
+	MinIdle  int64
+	Ids      []stream.StreamEntrieId
+	// -1 means option is not given
+	Idle       int64
+	Time       int64
+	RetryCount int64
+	Force      bool
+	JustId     bool
+	LastId     stream.StreamEntrieId
+}
+
+type AutoClaimQuery struct {
+	Key      string
+	Group    string
+	Consumer string
+	MinIdle  int64
+	Start    stream.StreamEntrieId
+	Count    int
+	JustId   bool
+}
+
+func wrongArity(values []*datatypes.Data) error {
+	return fmt.Errorf("ERR wrong number of arguments for '%v' command", strings.ToLower(values[0].Value))
+}
+
+func parseInt(str string) (int64, error) {
+	n, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return 0, NotIntegerError
+	}
+	return n, nil
+}
+
+// parses id or $ which is returned as nil
+func parseGroupId(str string) (*stream.StreamEntrieId, error) {
+	if str == "$" {
+		return nil, nil
+	}
+	id, err := stream.ParseStrictId(str)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func parseEntriesRead(str string) (int64, error) {
+	n, err := parseInt(str)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 && n != stream.InvalidEntriesRead {
+		return 0, EntriesReadError
+	}
+	return n, nil
+}
+
+// parses idle time in milliseconds, negative time is treated as 0
+func parseMinIdle(str string, cmd string) (int64, error) {
+	n, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("ERR Invalid min-idle-time argument for %v", cmd)
+	}
+	return max(n, 0), nil
+}
+
+func queryArgs(query any) commands.CommandArgs {
+	args := commands.NewArgs()
+	args.SetArgValue(Query, commands.NewCustomArgValue(query))
+	return args
+}
+
+// XGROUP CREATE key group id|$ [MKSTREAM] [ENTRIESREAD n]
+// XGROUP SETID key group id|$ [ENTRIESREAD n]
+// XGROUP DESTROY key group
+// XGROUP CREATECONSUMER|DELCONSUMER key group consumer
+func ParseXgroupArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 2 {
+		return nil, wrongArity(values)
+	}
+	subcommand := strings.ToUpper(values[1].Value)
+	arity, ok := subcommandArity[subcommand]
+	if !ok {
+		return nil, fmt.Errorf("ERR unknown subcommand '%v'. Try XGROUP HELP.", values[1].Value)
+	}
+	rest := len(values) - 4
+	if rest < arity[0] || (arity[1] >= 0 && rest > arity[1]) {
+		return nil, fmt.Errorf("ERR wrong number of arguments for 'xgroup|%v' command", strings.ToLower(subcommand))
+	}
+	query := GroupQuery{
+		Subcommand:  subcommand,
+		Key:         values[2].Value,
+		Group:       values[3].Value,
+		EntriesRead: stream.InvalidEntriesRead,
+	}
+	switch subcommand {
+	case CreateConsumer, DelConsumer:
+		query.Consumer = values[4].Value
+	case Create, SetId:
+		id, err := parseGroupId(values[4].Value)
+		if err != nil {
+			return nil, err
+		}
+		query.Id = id
+		for i := 5; i < len(values); i++ {
+			switch strings.ToUpper(values[i].Value) {
+			case "MKSTREAM":
+				if subcommand != Create {
+					return nil, SyntaxError
+				}
+				query.MkStream = true
+			case "ENTRIESREAD":
+				if i+1 >= len(values) {
+					return nil, SyntaxError
+				}
+				entriesRead, err := parseEntriesRead(values[i+1].Value)
+				if err != nil {
+					return nil, err
+				}
+				query.EntriesRead = entriesRead
+				i++
+			default:
+				return nil, SyntaxError
+			}
+		}
+	}
+	return queryArgs(query), nil
+}
+
+// XREADGROUP GROUP group consumer [COUNT count] [BLOCK ms] [NOACK] STREAMS key [key ...] id [id ...]
+func ParseXreadgroupArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 7 {
+		return nil, wrongArity(values)
+	}
+	query := ReadGroupQuery{}
+	hasGroup := false
+	streamsAt := 0
+	for i := 1; i < len(values) && streamsAt == 0; i++ {
+		more := len(values) - i - 1
+		switch opt := strings.ToUpper(values[i].Value); {
+		case opt == "BLOCK" && more > 0:
+			timeout, err := strconv.ParseInt(values[i+1].Value, 10, 64)
+			if err != nil || timeout > math.MaxInt32 {
+				return nil, TimeoutNotIntegerError
+			}
+			if timeout < 0 {
+				return nil, NegativeTimeoutError
+			}
+			query.Block = true
+			query.Timeout = int(timeout)
+			i++
+		case opt == "COUNT" && more > 0:
+			count, err := parseInt(values[i+1].Value)
+			if err != nil {
+				return nil, err
+			}
+			query.Count = int(max(count, 0))
+			i++
+		case opt == "GROUP" && more > 1:
+			query.Group = values[i+1].Value
+			query.Consumer = values[i+2].Value
+			hasGroup = true
+			i += 2
+		case opt == "NOACK":
+			query.NoAck = true
+		case opt == "STREAMS" && more > 0:
+			streamsAt = i + 1
+		default:
+			return nil, SyntaxError
+		}
+	}
+	if streamsAt == 0 {
+		return nil, SyntaxError
+	}
+	streams := values[streamsAt:]
+	if len(streams)%2 != 0 {
+		return nil, UnbalancedStreamsError
+	}
+	if !hasGroup {
+		return nil, MissingGroupError
+	}
+	n := len(streams) / 2
+	for i := 0; i < n; i++ {
+		query.Keys = append(query.Keys, streams[i].Value)
+		idStr := streams[n+i].Value
+		switch idStr {
+		case ">":
+			query.Ids = append(query.Ids, nil)
+			continue
+		case "$":
+			return nil, LastIdInGroupError
+		}
+		id, err := stream.ParseStrictId(idStr)
+		if err != nil {
+			return nil, err
+		}
+		query.Ids = append(query.Ids, &id)
+	}
+	return queryArgs(query), nil
+}
+
+// XACK key group id [id ...]
+func ParseXackArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 4 {
+		return nil, wrongArity(values)
+	}
+	query := AckQuery{Key: values[1].Value, Group: values[2].Value}
+	for _, v := range values[3:] {
+		id, err := stream.ParseStrictId(v.Value)
+		if err != nil {
+			return nil, err
+		}
+		query.Ids = append(query.Ids, id)
+	}
+	return queryArgs(query), nil
+}
+
+// XPENDING key group [[IDLE min-idle-time] start end count [consumer]]
+func ParseXpendingArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 3 {
+		return nil, wrongArity(values)
+	}
+	if len(values) != 3 && (len(values) < 6 || len(values) > 9) {
+		return nil, SyntaxError
+	}
+	query := PendingQuery{Key: values[1].Value, Group: values[2].Value}
+	if len(values) == 3 {
+		return queryArgs(query), nil
+	}
+	query.Extended = true
+	startAt := 3
+	if strings.ToUpper(values[3].Value) == "IDLE" {
+		minIdle, err := parseInt(values[4].Value)
+		if err != nil {
+			return nil, err
+		}
+		if len(values) < 8 {
+			return nil, SyntaxError
+		}
+		query.MinIdle = minIdle
+		startAt += 2
+	}
+	count, err := parseInt(values[startAt+2].Value)
+	if err != nil {
+		return nil, err
+	}
+	query.Count = int(max(count, 0))
+
+	start, exclusive, err := stream.ParseIntervalId(values[startAt].Value, 0)
+	if err != nil {
+		return nil, err
+	}
+	var ok bool
+	if exclusive {
+		if start, ok = start.Incr(); !ok {
+			return nil, InvalidStartError
+		}
+	}
+	end, exclusive, err := stream.ParseIntervalId(values[startAt+1].Value, math.MaxInt)
+	if err != nil {
+		return nil, err
+	}
+	if exclusive {
+		if end, ok = end.Decr(); !ok {
+			return nil, InvalidEndError
+		}
+	}
+	query.Start = start
+	query.End = end
+	if startAt+3 < len(values) {
+		consumer := values[startAt+3].Value
+		query.Consumer = &consumer
+	}
+	return queryArgs(query), nil
+}
+
+// XCLAIM key group consumer min-idle-time id [id ...] [IDLE ms] [TIME unix-time-ms] [RETRYCOUNT count] [FORCE] [JUSTID] [LASTID id]
+func ParseXclaimArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 6 {
+		return nil, wrongArity(values)
+	}
+	minIdle, err := parseMinIdle(values[4].Value, "XCLAIM")
+	if err != nil {
+		return nil, err
+	}
+	query := ClaimQuery{
+		Key:        values[1].Value,
+		Group:      values[2].Value,
+		Consumer:   values[3].Value,
+		MinIdle:    minIdle,
+		Idle:       -1,
+		Time:       -1,
+		RetryCount: -1,
+	}
+	// ids are followed by options
+	i := 5
+	for ; i < len(values); i++ {
+		id, err := stream.ParseStrictId(values[i].Value)
+		if err != nil {
+			break
+		}
+		query.Ids = append(query.Ids, id)
+	}
+	for ; i < len(values); i++ {
+		more := len(values) - i - 1
+		opt := strings.ToUpper(values[i].Value)
+		switch {
+		case opt == "FORCE":
+			query.Force = true
+		case opt == "JUSTID":
+			query.JustId = true
+		case (opt == "IDLE" || opt == "TIME" || opt == "RETRYCOUNT") && more > 0:
+			n, err := strconv.ParseInt(values[i+1].Value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("ERR Invalid %v option argument for XCLAIM", opt)
+			}
+			switch opt {
+			case "IDLE":
+				query.Idle = max(n, 0)
+				query.Time = -1
+			case "TIME":
+				query.Time = max(n, 0)
+				query.Idle = -1
+			case "RETRYCOUNT":
+				query.RetryCount = max(n, 0)
+			}
+			i++
+		case opt == "LASTID" && more > 0:
+			id, err := stream.ParseStrictId(values[i+1].Value)
+			if err != nil {
+				return nil, err
+			}
+			query.LastId = id
+			i++
+		default:
+			return nil, fmt.Errorf("ERR Unrecognized XCLAIM option '%v'", values[i].Value)
+		}
+	}
+	return queryArgs(query), nil
+}
+
+// XAUTOCLAIM key group consumer min-idle-time start [COUNT count] [JUSTID]
+func ParseXautoclaimArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 6 {
+		return nil, wrongArity(values)
+	}
+	minIdle, err := parseMinIdle(values[4].Value, "XAUTOCLAIM")
+	if err != nil {
+		return nil, err
+	}
+	query := AutoClaimQuery{
+		Key:      values[1].Value,
+		Group:    values[2].Value,
+		Consumer: values[3].Value,
+		MinIdle:  minIdle,
+		Count:    100,
+	}
+	start, exclusive, err := stream.ParseIntervalId(values[5].Value, 0)
+	if err != nil {
+		return nil, err
+	}
+	if exclusive {
+		var ok bool
+		if start, ok = start.Incr(); !ok {
+			return nil, InvalidStartError
+		}
+	}
+	query.Start = start
+	for i := 6; i < len(values); i++ {
+		more := len(values) - i - 1
+		switch opt := strings.ToUpper(values[i].Value); {
+		case opt == "COUNT" && more > 0:
+			count, err := strconv.ParseInt(values[i+1].Value, 10, 64)
+			// count is multiplied by 10 to limit scanned entries
+			if err != nil || count < 1 || count > math.MaxInt64/10 {
+				return nil, CountError
+			}
+			query.Count = int(count)
+			i++
+		case opt == "JUSTID":
+			query.JustId = true
+		default:
+			return nil, SyntaxError
+		}
+	}
+	return queryArgs(query), nil
+}
+
+// returns stream keys of XREADGROUP placed between STREAMS keyword and ids
+func GetKeys(values []*datatypes.Data) []string {
+	for i := 1; i < len(values); i++ {
+		switch strings.ToLower(values[i].Value) {
+		case "group":
+			i += 2
+			continue
+		case "count", "block":
+			i++
+			continue
+		case "streams":
+		default:
+			continue
+		}
+		streams := values[i+1:]
+		keys := make([]string, 0, len(streams)/2)
+		for _, v := range streams[:len(streams)/2] {
+			keys = append(keys, v.Value)
+		}
+		return keys
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package xinfocommand
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/app/commands"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+)
+
+var SyntaxError = errors.New("ERR syntax error")
+var NotIntegerError = errors.New("ERR value is not an integer or out of range")
+
+type XinfoArgsEnum string
+
+const (
+	Query = "query"
+)
+
+type XinfoSubcommandEnum string
+
+const (
+	Stream    = "STREAM"
+	Groups    = "GROUPS"
+	Consumers = "CONSUMERS"
+)
+
+// default amount of entries reported by XINFO STREAM FULL
+const defaultFullCount = 10
+
+type InfoQuery struct {
+	Subcommand string
+	Key        string
+	Group      string
+	Full       bool
+	// 0 means unlimited
+	Count int
+}
+
+// XINFO STREAM key [FULL [COUNT count]]
+// XINFO GROUPS key
+// XINFO CONSUMERS key group
+func ParseXinfoArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 2 {
+		return nil, fmt.Errorf("ERR wrong number of arguments for 'xinfo' command")
+	}
+	subcommand := strings.ToUpper(values[1].Value)
+	wrongArity := fmt.Errorf("ERR wrong number of arguments for 'xinfo|%v' command", strings.ToLower(subcommand))
+	query := InfoQuery{Subcommand: subcommand}
+	switch subcommand {
+	case Stream:
+		if len(values) < 3 {
+			return nil, wrongArity
+		}
+		query.Key = values[2].Value
+		if len(values) == 3 {
+			break
+		}
+		if strings.ToUpper(values[3].Value) != "FULL" {
+			return nil, SyntaxError
+		}
+		query.Full = true
+		query.Count = defaultFullCount
+		if len(values) == 4 {
+			break
+		}
+		if len(values) != 6 || strings.ToUpper(values[4].Value) != "COUNT" {
+			return nil, SyntaxError
+		}
+		count, err := strconv.ParseInt(values[5].Value, 10, 64)
+		if err != nil {
+			return nil, NotIntegerError
+		}
+		query.Count = int(max(count, 0))
+	case Groups:
+		if len(values) != 3 {
+			return nil, wrongArity
+		}
+		query.Key = values[2].Value
+	case Consumers:
+		if len(values) != 4 {
+			return nil, wrongArity
+		}
+		query.Key = values[2].Value
+		query.Group = values[3].Value
+	default:
+		return nil, fmt.Errorf("ERR unknown subcommand '%v'. Try XINFO HELP.", values[1].Value)
+	}
+	args := commands.NewArgs()
+	args.SetArgValue(Query, commands.NewCustomArgValue(query))
+	return args, nil
+}
@@ -12,141 +12,82 @@ import (
 	"github.com/codecrafters-io/redis-starter-go/app/stream"
 )
 
-type XrangeArgsEnum string
-
-const (
-	StreamKey = "streamKey"
-	Start     = "start"
-	End       = "end"
-)
-
-func ParseXrangeArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
-	if len(values) < 4 {
-		return nil, commands.NotEnoughValuesToConstructArgs
-	}
-	key := values[1].Value
-	start := values[2].Value
-	end := values[3].Value
-
-	args := commands.NewArgs()
-
-	args.SetArgValue(StreamKey, commands.NewStringArgValue(key))
-	args.SetArgValue(Start, commands.NewStringArgValue(start))
-	args.SetArgValue(End, commands.NewStringArgValue(end))
+var SyntaxError = errors.New("ERR syntax error")
+var NotIntegerError = errors.New("ERR value is not an integer or out of range")
+var InvalidStartError = errors.New("ERR invalid start ID for the interval")
+var InvalidEndError = errors.New("ERR invalid end ID for the interval")
 
-	return args, nil
-}
-
-type ReadType string
+type XrangeArgsEnum string
 
 const (
-	InRange   = "inRange"
-	FromStart = "fromStart"
-	ToEnd     = "ToEnd"
+	Query = "query"
 )
 
 type ConstructedQuery struct {
-	Start    stream.StreamEntrieId
-	End      stream.StreamEntrieId
-	Key      string
-	ReadType ReadType
+	Key   string
+	Start stream.StreamEntrieId
+	End   stream.StreamEntrieId
+	// -1 means unlimited
+	Count int
+	// entries are returned from End to Start
+	Rev bool
 }
 
-func ParseEntrieIdFromString(id string, isStart bool) (stream.StreamEntrieId, error) {
-	splited := strings.Split(id, "-")
-	if len(splited) == 1 {
-		streamId, err := strconv.ParseInt(splited[0], 10, 64)
-		if err != nil {
-			return stream.StreamEntrieId{}, fmt.Errorf("Error parsing xrange interval id: %w", err)
-		}
-		if isStart {
-			return stream.StreamEntrieId{
-				Id:             streamId,
-				SequenceNumber: 0,
-			}, nil
-		}
-		return stream.StreamEntrieId{
-			Id:             streamId,
-			SequenceNumber: math.MaxInt,
-		}, nil
-	}
-	if len(splited) < 2 {
-		return stream.StreamEntrieId{}, errors.New("Error cannot split xrange id into two parts")
-	}
-	streamId, err := strconv.ParseInt(splited[0], 10, 64)
-	if err != nil {
-		return stream.StreamEntrieId{}, fmt.Errorf("Error parsing xrange interval id: %w", err)
-	}
-	sequenceNumber, err := strconv.Atoi(splited[1])
-	if err != nil {
-		return stream.StreamEntrieId{}, fmt.Errorf("Error parsing xrange interval seqNumber: %w", err)
+// XRANGE key start end [COUNT count]
+// XREVRANGE key end start [COUNT count]
+func ParseXrangeArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 4 {
+		return nil, fmt.Errorf("ERR wrong number of arguments for '%v' command", strings.ToLower(values[0].Value))
 	}
-
-	return stream.StreamEntrieId{
-		Id:             streamId,
-		SequenceNumber: sequenceNumber,
-	}, nil
-}
-
-func ConstructQueryFromArgs(arg commands.CommandArgs) (ConstructedQuery, error) {
-	streamKey, ok := arg.GetArgValue(StreamKey)
-	if !ok {
-		return ConstructedQuery{}, errors.New("StreamKey is not specified")
+	query := ConstructedQuery{Key: values[1].Value, Count: -1}
+	startStr, endStr := values[2].Value, values[3].Value
+	if strings.ToUpper(values[0].Value) == "XREVRANGE" {
+		query.Rev = true
+		startStr, endStr = endStr, startStr
 	}
-	var streamKeyStr string
-	err := streamKey.ToType(&streamKeyStr)
+	start, exclusive, err := stream.ParseIntervalId(startStr, 0)
 	if err != nil {
-		return ConstructedQuery{}, fmt.Errorf("Error castring stream key to str: %w", err)
-	}
-	start, ok := arg.GetArgValue(Start)
-	if !ok {
-		return ConstructedQuery{}, errors.New("Start of the interval is not specified")
+		return nil, err
 	}
-	end, ok := arg.GetArgValue(End)
-	if !ok {
-		return ConstructedQuery{}, errors.New("End of the interval is not specified")
+	var ok bool
+	if exclusive {
+		if start, ok = start.Incr(); !ok {
+			return nil, InvalidStartError
+		}
 	}
-	var startStr string
-	var endStr string
-	err = start.ToType(&startStr)
-	err = end.ToType(&endStr)
+	end, exclusive, err := stream.ParseIntervalId(endStr, math.MaxInt)
 	if err != nil {
-		return ConstructedQuery{}, fmt.Errorf("Error castring stream interval to str: %w", err)
+		return nil, err
 	}
-	if startStr == "-" {
-		endInteval, err := ParseEntrieIdFromString(endStr, false)
-		if err != nil {
-			return ConstructedQuery{}, fmt.Errorf("Error parsing end id of xrange: %w", err)
+	if exclusive {
+		if end, ok = end.Decr(); !ok {
+			return nil, InvalidEndError
 		}
-		return ConstructedQuery{
-			ReadType: FromStart,
-			End:      endInteval,
-			Key:      streamKeyStr,
-		}, nil
 	}
-	if endStr == "+" {
-		startInteval, err := ParseEntrieIdFromString(startStr, true)
+	query.Start = start
+	query.End = end
+	for i := 4; i < len(values); i++ {
+		if strings.ToUpper(values[i].Value) != "COUNT" || i+1 >= len(values) {
+			return nil, SyntaxError
+		}
+		count, err := strconv.ParseInt(values[i+1].Value, 10, 64)
 		if err != nil {
-			return ConstructedQuery{}, fmt.Errorf("Error parsing start id of xrange: %w", err)
+			return nil, NotIntegerError
 		}
-		return ConstructedQuery{
-			Start:    startInteval,
-			ReadType: ToEnd,
-			Key:      streamKeyStr,
-		}, nil
-	}
-	endInteval, err := ParseEntrieIdFromString(endStr, false)
-	if err != nil {
-		return ConstructedQuery{}, fmt.Errorf("Error parsing end id of xrange: %w", err)
+		query.Count = int(max(count, 0))
+		i++
 	}
-	startInteval, err := ParseEntrieIdFromString(startStr, true)
-	if err != nil {
-		return ConstructedQuery{}, fmt.Errorf("Error parsing start id of xrange: %w", err)
+	args := commands.NewArgs()
+	args.SetArgValue(Query, commands.NewCustomArgValue(query))
+	return args, nil
+}
+
+func ConstructQueryFromArgs(arg commands.CommandArgs) (ConstructedQuery, error) {
+	queryArg, ok := arg.GetArgValue(Query)
+	if !ok {
+		return ConstructedQuery{}, errors.New("Query of xrange is not specified")
 	}
-	return ConstructedQuery{
-		Start:    startInteval,
-		End:      endInteval,
-		Key:      streamKeyStr,
-		ReadType: InRange,
-	}, nil
+	var query ConstructedQuery
+	err := queryArg.ToType(&query)
+	return query, err
 }
@@ -3,76 +3,39 @@ package xreadcommand
 import (
 	"errors"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
 	"github.com/codecrafters-io/redis-starter-go/app/commands"
-	xrangecommand "github.com/codecrafters-io/redis-starter-go/app/commands/xrange_command"
 	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
 	"github.com/codecrafters-io/redis-starter-go/app/stream"
-	"github.com/codecrafters-io/redis-starter-go/app/types"
-	"strconv"
 )
 
+var SyntaxError = errors.New("ERR syntax error")
+var NotIntegerError = errors.New("ERR value is not an integer or out of range")
+var TimeoutNotIntegerError = errors.New("ERR timeout is not an integer or out of range")
+var NegativeTimeoutError = errors.New("ERR timeout is negative")
+var UnbalancedStreamsError = errors.New("ERR Unbalanced 'xread' list of streams: for each stream key an ID or '$' must be specified.")
+
 type XrangeArgsEnum string
 
 const (
-	Block   = "block"
-	Streams = "start"
+	Block = "block"
+	Query = "query"
 )
 
-func ParseXreadArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
-	if len(values) < 4 {
-		return nil, commands.NotEnoughValuesToConstructArgs
-	}
-	args := commands.NewArgs()
-	block := values[1].Value
-	if block == "block" {
-		blockTimeout := values[2].Value
-		blockT, err := strconv.Atoi(blockTimeout)
-		if err != nil {
-			return nil, fmt.Errorf("Error parsing block timeout: %w", err)
-		}
-		args.SetArgValue(Block, commands.NewIntArgValue(blockT))
-		if values[3].Value != "streams" {
-			return nil, errors.New("Keywoard streams does not specified")
-		}
-		selectedStreams := make([]types.Kv, 0, (len(values)-3)/2)
-		left := 4
-		right := ((len(values) - 4) / 2) + 4
-		for right < len(values) {
-			streamName := values[left]
-			streamQuery := values[right]
-			selectedStreams = append(selectedStreams, types.Kv{streamName.Value, streamQuery.Value})
-			left++
-			right++
-		}
-		args.SetArgValue(Streams, commands.NewKvArgValue(selectedStreams))
-		return args, nil
-	}
-	if block != "streams" {
-		return nil, errors.New("Keywoard streams does not specified")
-
-	}
-	selectedStreams := make([]types.Kv, 0, (len(values)-2)/2)
-	left := 2
-	right := ((len(values) - 2) / 2) + 2
-	for right < len(values) {
-		streamName := values[left]
-		streamQuery := values[right]
-		selectedStreams = append(selectedStreams, types.Kv{streamName.Value, streamQuery.Value})
-		left++
-		right++
-	}
-	args.SetArgValue(Streams, commands.NewKvArgValue(selectedStreams))
-
-	return args, nil
-}
-
 type ReadType string
 
 const (
 	InRange = "inRange"
+	// entries added after the call, $ id
 	OnlyNew = "onlyNew"
+	// the last entrie of stream, + id
+	LastEntry = "lastEntry"
 )
 
+// entries with ids greater than Start are read
 type StreamQuery struct {
 	Start    stream.StreamEntrieId
 	Key      string
@@ -80,51 +43,100 @@ type StreamQuery struct {
 }
 
 type ConstrctedStreamsQueries struct {
-	IsBlocked      bool
+	IsBlocked bool
+	// 0 means forever
 	BlockedTimeout int
-	Queries        []StreamQuery
+	// 0 means unlimited
+	Count   int
+	Queries []StreamQuery
 }
 
-func ConstructQueryFromArgs(arg commands.CommandArgs) (ConstrctedStreamsQueries, error) {
-	out := ConstrctedStreamsQueries{}
-	blockedTimeout, isBlocked := arg.GetArgValue(Block)
-	out.IsBlocked = isBlocked
-	if isBlocked {
-		var blockTieo int
-		blockedTimeout.ToType(&blockTieo)
-		out.BlockedTimeout = blockTieo
+// XREAD [COUNT count] [BLOCK milliseconds] STREAMS key [key ...] id [id ...]
+func ParseXreadArgs(values []*datatypes.Data) (commands.CommandArgs, error) {
+	if len(values) < 4 {
+		return nil, fmt.Errorf("ERR wrong number of arguments for 'xread' command")
 	}
-	selectedStreams, ok := arg.GetArgValue(Streams)
-	if !ok {
-		return ConstrctedStreamsQueries{}, errors.New("Error cannot construct xread query, selected streams is empty")
+	args := commands.NewArgs()
+	query := ConstrctedStreamsQueries{}
+	streamsAt := 0
+	for i := 1; i < len(values) && streamsAt == 0; i++ {
+		more := len(values) - i - 1
+		switch opt := strings.ToUpper(values[i].Value); {
+		case opt == "BLOCK" && more > 0:
+			timeout, err := strconv.ParseInt(values[i+1].Value, 10, 64)
+			if err != nil || timeout > math.MaxInt32 {
+				return nil, TimeoutNotIntegerError
+			}
+			if timeout < 0 {
+				return nil, NegativeTimeoutError
+			}
+			query.IsBlocked = true
+			query.BlockedTimeout = int(timeout)
+			args.SetArgValue(Block, commands.NewIntArgValue(int(timeout)))
+			i++
+		case opt == "COUNT" && more > 0:
+			count, err := strconv.ParseInt(values[i+1].Value, 10, 64)
+			if err != nil {
+				return nil, NotIntegerError
+			}
+			query.Count = int(max(count, 0))
+			i++
+		case opt == "STREAMS" && more > 0:
+			streamsAt = i + 1
+		default:
+			return nil, SyntaxError
+		}
+	}
+	if streamsAt == 0 {
+		return nil, SyntaxError
 	}
-	var streams []types.Kv
-	err := selectedStreams.ToType(&streams)
-	if err != nil || streams == nil {
-		return ConstrctedStreamsQueries{}, fmt.Errorf("Error casting xread streams to kv: %w", err)
+	streams := values[streamsAt:]
+	if len(streams)%2 != 0 {
+		return nil, UnbalancedStreamsError
 	}
-	queries := make([]StreamQuery, len(streams))
-	for i, str := range streams {
-		streamKey := str[0]
-		streamId := str[1]
-		if streamId == "$" {
-			queries[i] = StreamQuery{
-				Key:      streamKey,
-				ReadType: OnlyNew,
+	n := len(streams) / 2
+	for i := 0; i < n; i++ {
+		q := StreamQuery{Key: streams[i].Value, ReadType: InRange}
+		switch idStr := streams[n+i].Value; idStr {
+		case "$":
+			q.ReadType = OnlyNew
+		case "+":
+			q.ReadType = LastEntry
+		default:
+			id, err := stream.ParseStrictId(idStr)
+			if err != nil {
+				return nil, err
 			}
-			continue
+			q.Start = id
 		}
-		parsedEntrieId, err := xrangecommand.ParseEntrieIdFromString(streamId, true)
-		if err != nil {
-			return ConstrctedStreamsQueries{}, fmt.Errorf("Error parsing %v'th stream id: %w", i, err)
+		query.Queries = append(query.Queries, q)
+	}
+	args.SetArgValue(Query, commands.NewCustomArgValue(query))
+	return args, nil
+}
+
+func ConstructQueryFromArgs(arg commands.CommandArgs) (ConstrctedStreamsQueries, error) {
+	queryArg, ok := arg.GetArgValue(Query)
+	if !ok {
+		return ConstrctedStreamsQueries{}, errors.New("Error cannot construct xread query, selected streams is empty")
+	}
+	var query ConstrctedStreamsQueries
+	err := queryArg.ToType(&query)
+	return query, err
+}
+
+// returns stream keys placed between STREAMS keyword and ids
+func GetKeys(values []*datatypes.Data) []string {
+	for i := 1; i < len(values); i++ {
+		if strings.ToLower(values[i].Value) != "streams" {
+			continue
 		}
-		queries[i] = StreamQuery{
-			Key:      streamKey,
-			ReadType: InRange,
-			Start:    parsedEntrieId,
+		streams := values[i+1:]
+		keys := make([]string, 0, len(streams)/2)
+		for _, v := range streams[:len(streams)/2] {
+			keys = append(keys, v.Value)
 		}
-
+		return keys
 	}
-	out.Queries = queries
-	return out, nil
+	return nil
 }
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+)
+
+// limits of client connections
+type clientsConfig struct {
+	maxClients int
+	// seconds client can stay idle before connection is closed, 0 disables timeout
+	timeout int
+	// period of tcp keepalive probes in seconds, 0 disables keepalive
+	tcpKeepAlive int
+	// length of queue of not accepted connections
+	tcpBacklog int
+}
+
+type clientsFlags struct {
+	maxClients   *int
+	timeout      *int
+	tcpKeepAlive *int
+	tcpBacklog   *int
+}
+
+func newClientsFlags() clientsFlags {
+	return clientsFlags{
+		maxClients:   flag.Int("maxclients", 10000, "defines max amount of connected clients"),
+		timeout:      flag.Int("timeout", 0, "defines seconds after which idle client is disconnected, 0 disables timeout"),
+		tcpKeepAlive: flag.Int("tcp-keepalive", 300, "defines period of tcp keepalive probes in seconds, 0 disables keepalive"),
+		tcpBacklog:   flag.Int("tcp-backlog", 511, "defines length of queue of not accepted connections"),
+	}
+}
+
+func parseClientsConfig(flags ConfigFlags) (clientsConfig, error) {
+	if *flags.clients.maxClients < 1 {
+		return clientsConfig{}, fmt.Errorf("Error parsing maxclients: value should be positive")
+	}
+	if *flags.clients.timeout < 0 {
+		return clientsConfig{}, fmt.Errorf("Error parsing timeout: value should not be negative")
+	}
+	if *flags.clients.tcpKeepAlive < 0 {
+		return clientsConfig{}, fmt.Errorf("Error parsing tcp-keepalive: value should not be negative")
+	}
+	if *flags.clients.tcpBacklog < 1 {
+		return clientsConfig{}, fmt.Errorf("Error parsing tcp-backlog: value should be positive")
+	}
+	return clientsConfig{
+		maxClients:   *flags.clients.maxClients,
+		timeout:      *flags.clients.timeout,
+		tcpKeepAlive: *flags.clients.tcpKeepAlive,
+		tcpBacklog:   *flags.clients.tcpBacklog,
+	}, nil
+}
+
+func parseNonNegative(value string) (int, error) {
+	num, err := strconv.Atoi(value)
+	if err != nil || num < 0 {
+		return 0, errors.New("argument must be a non negative integer")
+	}
+	return num, nil
+}
+
+func (this *Config) GetMaxClients() int {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return this.clients.maxClients
+}
+
+func (this *Config) GetTimeout() int {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return this.clients.timeout
+}
+
+func (this *Config) GetTcpKeepAlive() int {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return this.clients.tcpKeepAlive
+}
+
+func (this *Config) GetTcpBacklog() int {
+	return this.clients.tcpBacklog
+}
@@ -3,8 +3,11 @@ package config
 import (
 	"flag"
 	"fmt"
+	"math"
+	"os"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/codecrafters-io/redis-starter-go/app/types"
 )
@@ -33,13 +36,46 @@ type ReplicationConfig interface {
 type Config struct {
 	server      serverConfig
 	replication ReplicationConfig
+	tls         tlsConfig
+	security    securityConfig
+	clients     clientsConfig
+	shutdown    shutdownConfig
+	encoding    encodingConfig
+	notify      notifyConfig
+	// guards parts of config that can be changed by CONFIG SET
+	mu         sync.RWMutex
+	applyHooks map[string]*applyHook
 }
 
+type IoModelEnum string
+
+const (
+	// goroutine per accepted connection
+	GoroutineIoModel IoModelEnum = "goroutine"
+	// epoll event loop, connections are multiplexed on io threads, commands are executed by single goroutine
+	EpollIoModel IoModelEnum = "epoll"
+)
+
 // server part of config
 type serverConfig struct {
 	port       uint16
 	dir        string
 	dbFileName string
+	limits     protoLimitsConfig
+	ioModel    IoModelEnum
+	ioThreads  int
+	unixSocket string
+	// permissions applied to unix socket file, 0 keeps umask defaults
+	unixSocketPerm os.FileMode
+	// amount of databases selected by SELECT
+	databases int
+}
+
+// protocol safety limits applied to client connections
+type protoLimitsConfig struct {
+	protoMaxBulkLen        int64
+	maxMultibulkLen        int64
+	clientQueryBufferLimit int64
 }
 
 func (this *serverConfig) GetServerPort() uint16 {
@@ -132,13 +168,72 @@ func New() (*Config, error) {
 	}
 	replicationConifg.applyReplId()
 
+	limits, err := parseProtoLimits(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	ioModel, err := parseIoModel(*flags.ioModel)
+	if err != nil {
+		return nil, err
+	}
+	if *flags.ioThreads <= 0 {
+		return nil, fmt.Errorf("Error parsing io-threads: value should be positive")
+	}
+	unixSocketPerm, err := strconv.ParseUint(*flags.unixSocketPerm, 8, 32)
+	if err != nil || unixSocketPerm > 0777 {
+		return nil, fmt.Errorf("Error parsing unixsocketperm: invalid octal permissions %v", *flags.unixSocketPerm)
+	}
+
+	if *flags.databases < 1 {
+		return nil, fmt.Errorf("Error parsing databases: value should be positive")
+	}
+
+	tls, err := parseTlsConfig(flags)
+	if err != nil {
+		return nil, err
+	}
+	security, err := parseSecurityConfig(flags)
+	if err != nil {
+		return nil, err
+	}
+	clients, err := parseClientsConfig(flags)
+	if err != nil {
+		return nil, err
+	}
+	shutdown, err := parseShutdownConfig(flags)
+	if err != nil {
+		return nil, err
+	}
+	encoding, err := parseEncodingConfig(flags)
+	if err != nil {
+		return nil, err
+	}
+	notify, err := parseNotifyConfig(flags)
+	if err != nil {
+		return nil, err
+	}
+
 	config := &Config{
 		server: serverConfig{
-			port:       uint16(*flags.port),
-			dbFileName: *flags.dbFileName,
-			dir:        *flags.dir,
+			port:           uint16(*flags.port),
+			dbFileName:     *flags.dbFileName,
+			dir:            *flags.dir,
+			limits:         limits,
+			ioModel:        ioModel,
+			ioThreads:      *flags.ioThreads,
+			unixSocket:     *flags.unixSocket,
+			unixSocketPerm: os.FileMode(unixSocketPerm),
+			databases:      *flags.databases,
 		},
 		replication: &replicationConifg,
+		tls:         tls,
+		security:    security,
+		clients:     clients,
+		shutdown:    shutdown,
+		encoding:    encoding,
+		notify:      notify,
+		applyHooks:  map[string]*applyHook{},
 	}
 
 	return config, nil
@@ -156,6 +251,38 @@ func (this *Config) GetServerDbFileName() string {
 	return this.server.dbFileName
 }
 
+func (this *Config) GetProtoMaxBulkLen() int64 {
+	return this.server.limits.protoMaxBulkLen
+}
+
+func (this *Config) GetMaxMultibulkLen() int64 {
+	return this.server.limits.maxMultibulkLen
+}
+
+func (this *Config) GetClientQueryBufferLimit() int64 {
+	return this.server.limits.clientQueryBufferLimit
+}
+
+func (this *Config) GetIoModel() IoModelEnum {
+	return this.server.ioModel
+}
+
+func (this *Config) GetIoThreads() int {
+	return this.server.ioThreads
+}
+
+func (this *Config) GetUnixSocket() string {
+	return this.server.unixSocket
+}
+
+func (this *Config) GetUnixSocketPerm() os.FileMode {
+	return this.server.unixSocketPerm
+}
+
+func (this *Config) GetDatabases() int {
+	return this.server.databases
+}
+
 func (this *Config) GetAllInfo() []types.Kv {
 	allInfo := []types.Kv{}
 	allInfo = append(allInfo, this.replication.GetInfo()...)
@@ -183,19 +310,116 @@ func (this *Config) ShouldRespondOnCommand() bool {
 }
 
 type ConfigFlags struct {
-	port       *int
-	role       *string
-	dir        *string
-	dbFileName *string
+	port                   *int
+	role                   *string
+	dir                    *string
+	dbFileName             *string
+	protoMaxBulkLen        *string
+	maxMultibulkLen        *int64
+	clientQueryBufferLimit *string
+	ioModel                *string
+	ioThreads              *int
+	unixSocket             *string
+	unixSocketPerm         *string
+	databases              *int
+	tls                    tlsFlags
+	security               securityFlags
+	clients                clientsFlags
+	shutdown               shutdownFlags
+	encoding               encodingFlags
+	notify                 notifyFlags
 }
 
 func NewConfigFlags() ConfigFlags {
 	return ConfigFlags{
-		port:       flag.Int("port", 6379, "defines port"),
-		role:       flag.String("replicaof", "", "defines is server are replica or master"),
-		dir:        flag.String("dir", "", "defines rdb file path"),
-		dbFileName: flag.String("dbfilename", "", "defines rdb file name"),
+		port:                   flag.Int("port", 6379, "defines port, 0 disables tcp listener"),
+		role:                   flag.String("replicaof", "", "defines is server are replica or master"),
+		dir:                    flag.String("dir", "", "defines rdb file path"),
+		dbFileName:             flag.String("dbfilename", "", "defines rdb file name"),
+		protoMaxBulkLen:        flag.String("proto-max-bulk-len", "512mb", "defines max length of single bulk string sent by client"),
+		maxMultibulkLen:        flag.Int64("max-multibulk-len", math.MaxInt32, "defines max amount of elements in single command sent by client"),
+		clientQueryBufferLimit: flag.String("client-query-buffer-limit", "1gb", "defines max amount of bytes single command sent by client can occupy"),
+		ioModel:                flag.String("io-model", string(GoroutineIoModel), "defines networking model: goroutine or epoll"),
+		ioThreads:              flag.Int("io-threads", 4, "defines amount of io threads used by epoll io model"),
+		unixSocket:             flag.String("unixsocket", "", "defines path of unix socket to listen on"),
+		unixSocketPerm:         flag.String("unixsocketperm", "0", "defines octal permissions of unix socket file"),
+		databases:              flag.Int("databases", 16, "defines amount of databases"),
+		tls:                    newTlsFlags(),
+		security:               newSecurityFlags(),
+		clients:                newClientsFlags(),
+		shutdown:               newShutdownFlags(),
+		encoding:               newEncodingFlags(),
+		notify:                 newNotifyFlags(),
+	}
+}
+
+func parseProtoLimits(flags ConfigFlags) (protoLimitsConfig, error) {
+	protoMaxBulkLen, err := ParseMemory(*flags.protoMaxBulkLen)
+	if err != nil {
+		return protoLimitsConfig{}, fmt.Errorf("Error parsing proto-max-bulk-len: %w", err)
+	}
+	if protoMaxBulkLen < 1024*1024 {
+		return protoLimitsConfig{}, fmt.Errorf("Error parsing proto-max-bulk-len: value should be at least 1mb")
+	}
+	clientQueryBufferLimit, err := ParseMemory(*flags.clientQueryBufferLimit)
+	if err != nil {
+		return protoLimitsConfig{}, fmt.Errorf("Error parsing client-query-buffer-limit: %w", err)
+	}
+	if clientQueryBufferLimit < 1024*1024 {
+		return protoLimitsConfig{}, fmt.Errorf("Error parsing client-query-buffer-limit: value should be at least 1mb")
+	}
+	if *flags.maxMultibulkLen <= 0 {
+		return protoLimitsConfig{}, fmt.Errorf("Error parsing max-multibulk-len: value should be positive")
+	}
+	return protoLimitsConfig{
+		protoMaxBulkLen:        protoMaxBulkLen,
+		maxMultibulkLen:        *flags.maxMultibulkLen,
+		clientQueryBufferLimit: clientQueryBufferLimit,
+	}, nil
+}
+
+func parseIoModel(value string) (IoModelEnum, error) {
+	switch IoModelEnum(strings.ToLower(value)) {
+	case GoroutineIoModel:
+		return GoroutineIoModel, nil
+	case EpollIoModel:
+		return EpollIoModel, nil
+	}
+	return "", fmt.Errorf("Error parsing io-model: unknown model %v", value)
+}
+
+var memoryUnits = []struct {
+	suffix string
+	mul    int64
+}{
+	{"kb", 1024},
+	{"mb", 1024 * 1024},
+	{"gb", 1024 * 1024 * 1024},
+	{"k", 1000},
+	{"m", 1000 * 1000},
+	{"g", 1000 * 1000 * 1000},
+	{"b", 1},
+}
+
+// parses memory amount in redis format: 1gb, 512mb, 100k, 42
+func ParseMemory(value string) (int64, error) {
+	lower := strings.ToLower(value)
+	mul := int64(1)
+	for _, unit := range memoryUnits {
+		if strings.HasSuffix(lower, unit.suffix) {
+			lower = strings.TrimSuffix(lower, unit.suffix)
+			mul = unit.mul
+			break
+		}
+	}
+	num, err := strconv.ParseInt(lower, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid memory value: %v", value)
+	}
+	if num < 0 || num > math.MaxInt64/mul {
+		return 0, fmt.Errorf("invalid memory value: %v", value)
 	}
+	return num * mul, nil
 }
 
 func parseMasterHostAndPort(replicaOfFlag string) (host string, port uint16, err error) {
@@ -0,0 +1,30 @@
+package config
+
+import "testing"
+
+func TestParseMemory(t *testing.T) {
+	cases := []struct {
+		value    string
+		expected int64
+	}{
+		{"42", 42},
+		{"1k", 1000},
+		{"1KB", 1024},
+		{"512mb", 512 * 1024 * 1024},
+		{"8589934591gb", 8589934591 * 1024 * 1024 * 1024},
+	}
+	for _, c := range cases {
+		n, err := ParseMemory(c.value)
+		if err != nil {
+			t.Fatalf("expected no error for %v, got %v", c.value, err)
+		}
+		if n != c.expected {
+			t.Fatalf("expected %v to be %v, got %v", c.value, c.expected, n)
+		}
+	}
+	for _, value := range []string{"", "-1", "1tb", "99999999999gb", "8589934592gb", "9223372036854775808"} {
+		if _, err := ParseMemory(value); err == nil {
+			t.Fatalf("expected error for %v", value)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+)
+
+// thresholds of compact encodings of data types
+type encodingConfig struct {
+	// max bytes of sparse HyperLogLog, bigger ones are converted to dense encoding
+	hllSparseMaxBytes int
+	// max bytes and entries of stream listpack node, 0 means unlimited
+	streamNodeMaxBytes   int
+	streamNodeMaxEntries int
+	// max entries and member bytes of sorted set that redis keeps in listpack, such sets are scanned in single call
+	zsetMaxListpackEntries int
+	zsetMaxListpackValue   int
+}
+
+type encodingFlags struct {
+	hllSparseMaxBytes      *int
+	streamNodeMaxBytes     *int
+	streamNodeMaxEntries   *int
+	zsetMaxListpackEntries *int
+	zsetMaxListpackValue   *int
+}
+
+func newEncodingFlags() encodingFlags {
+	return encodingFlags{
+		hllSparseMaxBytes:      flag.Int("hll-sparse-max-bytes", 3000, "defines max bytes of sparse HyperLogLog representation"),
+		streamNodeMaxBytes:     flag.Int("stream-node-max-bytes", 4096, "defines max bytes of stream node, 0 means unlimited"),
+		streamNodeMaxEntries:   flag.Int("stream-node-max-entries", 100, "defines max entries of stream node, 0 means unlimited"),
+		zsetMaxListpackEntries: flag.Int("zset-max-listpack-entries", 128, "defines max entries of sorted set returned by single ZSCAN call"),
+		zsetMaxListpackValue:   flag.Int("zset-max-listpack-value", 64, "defines max member bytes of sorted set returned by single ZSCAN call"),
+	}
+}
+
+func parseEncodingConfig(flags ConfigFlags) (encodingConfig, error) {
+	if *flags.encoding.hllSparseMaxBytes < 0 {
+		return encodingConfig{}, fmt.Errorf("Error parsing hll-sparse-max-bytes: value should not be negative")
+	}
+	if *flags.encoding.streamNodeMaxBytes < 0 {
+		return encodingConfig{}, fmt.Errorf("Error parsing stream-node-max-bytes: value should not be negative")
+	}
+	if *flags.encoding.streamNodeMaxEntries < 0 {
+		return encodingConfig{}, fmt.Errorf("Error parsing stream-node-max-entries: value should not be negative")
+	}
+	if *flags.encoding.zsetMaxListpackEntries < 0 {
+		return encodingConfig{}, fmt.Errorf("Error parsing zset-max-listpack-entries: value should not be negative")
+	}
+	if *flags.encoding.zsetMaxListpackValue < 0 {
+		return encodingConfig{}, fmt.Errorf("Error parsing zset-max-listpack-value: value should not be negative")
+	}
+	return encodingConfig{
+		hllSparseMaxBytes:      *flags.encoding.hllSparseMaxBytes,
+		streamNodeMaxBytes:     *flags.encoding.streamNodeMaxBytes,
+		streamNodeMaxEntries:   *flags.encoding.streamNodeMaxEntries,
+		zsetMaxListpackEntries: *flags.encoding.zsetMaxListpackEntries,
+		zsetMaxListpackValue:   *flags.encoding.zsetMaxListpackValue,
+	}, nil
+}
+
+func (this *Config) GetHllSparseMaxBytes() int {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return this.encoding.hllSparseMaxBytes
+}
+
+func (this *Config) GetStreamNodeMaxBytes() int {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return this.encoding.streamNodeMaxBytes
+}
+
+func (this *Config) GetStreamNodeMaxEntries() int {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return this.encoding.streamNodeMaxEntries
+}
+
+func (this *Config) GetZSetMaxListpackEntries() int {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return this.encoding.zsetMaxListpackEntries
+}
+
+func (this *Config) GetZSetMaxListpackValue() int {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return this.encoding.zsetMaxListpackValue
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/codecrafters-io/redis-starter-go/app/notify"
+)
+
+// keyspace notifications part of config
+type notifyConfig struct {
+	// classes of keyspace events published to subscribers, none by default
+	keyspaceEvents notify.Class
+}
+
+type notifyFlags struct {
+	keyspaceEvents *string
+}
+
+func newNotifyFlags() notifyFlags {
+	return notifyFlags{
+		keyspaceEvents: flag.String("notify-keyspace-events", "", "defines classes of keyspace events published to subscribers, e.g. KEA"),
+	}
+}
+
+func parseNotifyConfig(flags ConfigFlags) (notifyConfig, error) {
+	keyspaceEvents, err := notify.ParseClasses(*flags.notify.keyspaceEvents)
+	if err != nil {
+		return notifyConfig{}, fmt.Errorf("Error parsing notify-keyspace-events: %w", err)
+	}
+	return notifyConfig{keyspaceEvents: keyspaceEvents}, nil
+}
+
+func (this *Config) GetNotifyKeyspaceEvents() notify.Class {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return this.notify.keyspaceEvents
+}
@@ -0,0 +1,343 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/app/glob"
+	"github.com/codecrafters-io/redis-starter-go/app/notify"
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+var UnknownParamError = errors.New("ERR Unknown option or number of arguments for CONFIG SET")
+
+// describes parameter accessible by CONFIG GET/SET, set is nil for parameters that can not be changed at runtime,
+// set is called with config write lock held
+type configParam struct {
+	get func(this *Config) string
+	set func(this *Config, value string) error
+}
+
+// hook that applies changed parameters to running server, e.g. reloads tls certificates
+type applyHook struct {
+	apply func() error
+}
+
+var configParams = map[string]configParam{
+	"port": {
+		get: func(this *Config) string { return strconv.Itoa(int(this.GetServerPort())) },
+	},
+	"dir": {
+		get: func(this *Config) string { return this.GetServerDbDir() },
+	},
+	"dbfilename": {
+		get: func(this *Config) string { return this.GetServerDbFileName() },
+	},
+	"proto-max-bulk-len": {
+		get: func(this *Config) string { return strconv.FormatInt(this.GetProtoMaxBulkLen(), 10) },
+	},
+	"max-multibulk-len": {
+		get: func(this *Config) string { return strconv.FormatInt(this.GetMaxMultibulkLen(), 10) },
+	},
+	"client-query-buffer-limit": {
+		get: func(this *Config) string { return strconv.FormatInt(this.GetClientQueryBufferLimit(), 10) },
+	},
+	"io-model": {
+		get: func(this *Config) string { return string(this.GetIoModel()) },
+	},
+	"io-threads": {
+		get: func(this *Config) string { return strconv.Itoa(this.GetIoThreads()) },
+	},
+	"unixsocket": {
+		get: func(this *Config) string { return this.GetUnixSocket() },
+	},
+	"unixsocketperm": {
+		get: func(this *Config) string { return strconv.FormatUint(uint64(this.GetUnixSocketPerm()), 8) },
+	},
+	"databases": {
+		get: func(this *Config) string { return strconv.Itoa(this.GetDatabases()) },
+	},
+	"tls-port": {
+		get: func(this *Config) string { return strconv.Itoa(int(this.GetTlsPort())) },
+	},
+	"tls-cert-file": {
+		get: func(this *Config) string { return this.GetTlsCertFile() },
+		set: func(this *Config, value string) error {
+			this.tls.certFile = value
+			return nil
+		},
+	},
+	"tls-key-file": {
+		get: func(this *Config) string { return this.GetTlsKeyFile() },
+		set: func(this *Config, value string) error {
+			this.tls.keyFile = value
+			return nil
+		},
+	},
+	"tls-ca-cert-file": {
+		get: func(this *Config) string { return this.GetTlsCaCertFile() },
+		set: func(this *Config, value string) error {
+			this.tls.caCertFile = value
+			return nil
+		},
+	},
+	"tls-auth-clients": {
+		get: func(this *Config) string { return string(this.GetTlsAuthClients()) },
+		set: func(this *Config, value string) error {
+			authClients, err := parseTlsAuthClients(value)
+			if err != nil {
+				return err
+			}
+			this.tls.authClients = authClients
+			return nil
+		},
+	},
+	"tls-replication": {
+		get: func(this *Config) string { return FormatYesNo(this.GetTlsReplication()) },
+		set: func(this *Config, value string) error {
+			replication, err := ParseYesNo(value)
+			if err != nil {
+				return err
+			}
+			this.tls.replication = replication
+			return nil
+		},
+	},
+	"requirepass": {
+		get: func(this *Config) string { return this.GetRequirePass() },
+		set: func(this *Config, value string) error {
+			this.security.requirePass = value
+			return nil
+		},
+	},
+	"aclfile": {
+		get: func(this *Config) string { return this.GetAclFile() },
+	},
+	"acllog-max-len": {
+		get: func(this *Config) string { return strconv.Itoa(this.GetAclLogMaxLen()) },
+		set: func(this *Config, value string) error {
+			maxLen, err := strconv.Atoi(value)
+			if err != nil || maxLen < 0 {
+				return errors.New("argument must be a non negative integer")
+			}
+			this.security.aclLogMaxLen = maxLen
+			return nil
+		},
+	},
+	"masteruser": {
+		get: func(this *Config) string { return this.GetMasterUser() },
+		set: func(this *Config, value string) error {
+			this.security.masterUser = value
+			return nil
+		},
+	},
+	"maxclients": {
+		get: func(this *Config) string { return strconv.Itoa(this.GetMaxClients()) },
+		set: func(this *Config, value string) error {
+			maxClients, err := strconv.Atoi(value)
+			if err != nil || maxClients < 1 {
+				return errors.New("argument must be a positive integer")
+			}
+			this.clients.maxClients = maxClients
+			return nil
+		},
+	},
+	"timeout": {
+		get: func(this *Config) string { return strconv.Itoa(this.GetTimeout()) },
+		set: func(this *Config, value string) error {
+			timeout, err := parseNonNegative(value)
+			if err != nil {
+				return err
+			}
+			this.clients.timeout = timeout
+			return nil
+		},
+	},
+	"tcp-keepalive": {
+		get: func(this *Config) string { return strconv.Itoa(this.GetTcpKeepAlive()) },
+		set: func(this *Config, value string) error {
+			keepAlive, err := parseNonNegative(value)
+			if err != nil {
+				return err
+			}
+			this.clients.tcpKeepAlive = keepAlive
+			return nil
+		},
+	},
+	"tcp-backlog": {
+		get: func(this *Config) string { return strconv.Itoa(this.GetTcpBacklog()) },
+	},
+	"shutdown-timeout": {
+		get: func(this *Config) string { return strconv.Itoa(this.GetShutdownTimeout()) },
+		set: func(this *Config, value string) error {
+			timeout, err := parseNonNegative(value)
+			if err != nil {
+				return err
+			}
+			this.shutdown.timeout = timeout
+			return nil
+		},
+	},
+	"pidfile": {
+		get: func(this *Config) string { return this.GetPidFile() },
+	},
+	"masterauth": {
+		get: func(this *Config) string { return this.GetMasterAuth() },
+		set: func(this *Config, value string) error {
+			this.security.masterAuth = value
+			return nil
+		},
+	},
+	"hll-sparse-max-bytes": {
+		get: func(this *Config) string { return strconv.Itoa(this.GetHllSparseMaxBytes()) },
+		set: func(this *Config, value string) error {
+			maxBytes, err := parseNonNegative(value)
+			if err != nil {
+				return err
+			}
+			this.encoding.hllSparseMaxBytes = maxBytes
+			return nil
+		},
+	},
+	"stream-node-max-bytes": {
+		get: func(this *Config) string { return strconv.Itoa(this.GetStreamNodeMaxBytes()) },
+		set: func(this *Config, value string) error {
+			maxBytes, err := parseNonNegative(value)
+			if err != nil {
+				return err
+			}
+			this.encoding.streamNodeMaxBytes = maxBytes
+			return nil
+		},
+	},
+	"stream-node-max-entries": {
+		get: func(this *Config) string { return strconv.Itoa(this.GetStreamNodeMaxEntries()) },
+		set: func(this *Config, value string) error {
+			maxEntries, err := parseNonNegative(value)
+			if err != nil {
+				return err
+			}
+			this.encoding.streamNodeMaxEntries = maxEntries
+			return nil
+		},
+	},
+	"zset-max-listpack-entries": {
+		get: func(this *Config) string { return strconv.Itoa(this.GetZSetMaxListpackEntries()) },
+		set: func(this *Config, value string) error {
+			maxEntries, err := parseNonNegative(value)
+			if err != nil {
+				return err
+			}
+			this.encoding.zsetMaxListpackEntries = maxEntries
+			return nil
+		},
+	},
+	"zset-max-listpack-value": {
+		get: func(this *Config) string { return strconv.Itoa(this.GetZSetMaxListpackValue()) },
+		set: func(this *Config, value string) error {
+			maxValue, err := parseNonNegative(value)
+			if err != nil {
+				return err
+			}
+			this.encoding.zsetMaxListpackValue = maxValue
+			return nil
+		},
+	},
+	"notify-keyspace-events": {
+		get: func(this *Config) string { return this.GetNotifyKeyspaceEvents().String() },
+		set: func(this *Config, value string) error {
+			keyspaceEvents, err := notify.ParseClasses(value)
+			if err != nil {
+				return err
+			}
+			this.notify.keyspaceEvents = keyspaceEvents
+			return nil
+		},
+	},
+}
+
+// returns parameters with names matching glob pattern for CONFIG GET, sorted by name
+func (this *Config) MatchParams(pattern string) []types.Kv {
+	names := make([]string, 0, len(configParams))
+	for name := range configParams {
+		if glob.Match(pattern, name, true) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	out := make([]types.Kv, 0, len(names))
+	for _, name := range names {
+		out = append(out, types.Kv{name, configParams[name].get(this)})
+	}
+	return out
+}
+
+// registers hook fired after any of params is changed by CONFIG SET,
+// if hook fails, previous values are restored
+func (this *Config) OnApply(params []string, apply func() error) {
+	hook := &applyHook{apply: apply}
+	for _, name := range params {
+		this.applyHooks[name] = hook
+	}
+}
+
+// sets parameters as single operation, either all parameters are applied or none of them
+func (this *Config) SetParams(kvs []types.Kv) error {
+	olds := make([]types.Kv, 0, len(kvs))
+	for _, kv := range kvs {
+		name := strings.ToLower(kv[0])
+		param, ok := configParams[name]
+		if !ok {
+			return fmt.Errorf("%w - '%v'", UnknownParamError, kv[0])
+		}
+		if param.set == nil {
+			return fmt.Errorf("ERR CONFIG SET failed (possibly related to argument '%v') - can't set immutable config", kv[0])
+		}
+		olds = append(olds, types.Kv{name, param.get(this)})
+	}
+
+	failed, err := this.setParams(kvs)
+	if err != nil {
+		this.setParams(olds)
+		return fmt.Errorf("ERR CONFIG SET failed (possibly related to argument '%v') - %v", failed, err)
+	}
+
+	failed, err = this.runApplyHooks(kvs)
+	if err != nil {
+		this.setParams(olds)
+		this.runApplyHooks(olds)
+		return fmt.Errorf("ERR CONFIG SET failed (possibly related to argument '%v') - %v", failed, err)
+	}
+	return nil
+}
+
+func (this *Config) setParams(kvs []types.Kv) (string, error) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	for _, kv := range kvs {
+		err := configParams[strings.ToLower(kv[0])].set(this, kv[1])
+		if err != nil {
+			return kv[0], err
+		}
+	}
+	return "", nil
+}
+
+func (this *Config) runApplyHooks(kvs []types.Kv) (string, error) {
+	fired := map[*applyHook]bool{}
+	for _, kv := range kvs {
+		hook, ok := this.applyHooks[strings.ToLower(kv[0])]
+		if !ok || fired[hook] {
+			continue
+		}
+		fired[hook] = true
+		err := hook.apply()
+		if err != nil {
+			return kv[0], err
+		}
+	}
+	return "", nil
+}
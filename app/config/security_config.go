@@ -0,0 +1,80 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+)
+
+// authentication part of config
+type securityConfig struct {
+	// password of default user, empty means default user does not need password
+	requirePass string
+	aclFile     string
+	// max amount of entries kept by ACL LOG
+	aclLogMaxLen int
+	// credentials used by replica to authenticate to master
+	masterUser string
+	masterAuth string
+}
+
+type securityFlags struct {
+	requirePass  *string
+	aclFile      *string
+	aclLogMaxLen *int
+	masterUser   *string
+	masterAuth   *string
+}
+
+func newSecurityFlags() securityFlags {
+	return securityFlags{
+		requirePass:  flag.String("requirepass", "", "defines password of default user, empty disables authentication"),
+		aclFile:      flag.String("aclfile", "", "defines path of file users are loaded from and saved to by ACL LOAD and ACL SAVE"),
+		aclLogMaxLen: flag.Int("acllog-max-len", 128, "defines max amount of entries kept by ACL LOG"),
+		masterUser:   flag.String("masteruser", "", "defines user replica authenticates as to master"),
+		masterAuth:   flag.String("masterauth", "", "defines password replica authenticates with to master"),
+	}
+}
+
+func parseSecurityConfig(flags ConfigFlags) (securityConfig, error) {
+	if *flags.security.aclLogMaxLen < 0 {
+		return securityConfig{}, fmt.Errorf("Error parsing acllog-max-len: value should not be negative")
+	}
+	if *flags.security.masterUser != "" && *flags.security.masterAuth == "" {
+		return securityConfig{}, fmt.Errorf("Error parsing masteruser: masterauth has to be specified")
+	}
+	return securityConfig{
+		requirePass:  *flags.security.requirePass,
+		aclFile:      *flags.security.aclFile,
+		aclLogMaxLen: *flags.security.aclLogMaxLen,
+		masterUser:   *flags.security.masterUser,
+		masterAuth:   *flags.security.masterAuth,
+	}, nil
+}
+
+func (this *Config) GetRequirePass() string {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return this.security.requirePass
+}
+
+func (this *Config) GetAclFile() string {
+	return this.security.aclFile
+}
+
+func (this *Config) GetAclLogMaxLen() int {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return this.security.aclLogMaxLen
+}
+
+func (this *Config) GetMasterUser() string {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return this.security.masterUser
+}
+
+func (this *Config) GetMasterAuth() string {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return this.security.masterAuth
+}
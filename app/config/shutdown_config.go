@@ -0,0 +1,46 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+)
+
+// graceful shutdown part of config
+type shutdownConfig struct {
+	// seconds SHUTDOWN waits for replicas to acknowledge replication offset
+	timeout int
+	// file where pid of server is written, removed on shutdown
+	pidFile string
+}
+
+type shutdownFlags struct {
+	timeout *int
+	pidFile *string
+}
+
+func newShutdownFlags() shutdownFlags {
+	return shutdownFlags{
+		timeout: flag.Int("shutdown-timeout", 10, "defines seconds shutdown waits for replicas to catch up"),
+		pidFile: flag.String("pidfile", "", "defines file where pid of server is written"),
+	}
+}
+
+func parseShutdownConfig(flags ConfigFlags) (shutdownConfig, error) {
+	if *flags.shutdown.timeout < 0 {
+		return shutdownConfig{}, fmt.Errorf("Error parsing shutdown-timeout: value should not be negative")
+	}
+	return shutdownConfig{
+		timeout: *flags.shutdown.timeout,
+		pidFile: *flags.shutdown.pidFile,
+	}, nil
+}
+
+func (this *Config) GetShutdownTimeout() int {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return this.shutdown.timeout
+}
+
+func (this *Config) GetPidFile() string {
+	return this.shutdown.pidFile
+}
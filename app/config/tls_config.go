@@ -0,0 +1,140 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+type TlsAuthClientsEnum string
+
+const (
+	// client has to present certificate signed by configured ca
+	TlsAuthClientsYes TlsAuthClientsEnum = "yes"
+	// client certificate is not requested
+	TlsAuthClientsNo TlsAuthClientsEnum = "no"
+	// client certificate is verified only if client presents it
+	TlsAuthClientsOptional TlsAuthClientsEnum = "optional"
+)
+
+// tls part of config
+type tlsConfig struct {
+	port        uint16
+	certFile    string
+	keyFile     string
+	caCertFile  string
+	authClients TlsAuthClientsEnum
+	// replica connects to master over tls, master accepts replicas only over tls
+	replication bool
+}
+
+type tlsFlags struct {
+	port        *int
+	certFile    *string
+	keyFile     *string
+	caCertFile  *string
+	authClients *string
+	replication *string
+}
+
+func newTlsFlags() tlsFlags {
+	return tlsFlags{
+		port:        flag.Int("tls-port", 0, "defines tls port, 0 disables tls listener"),
+		certFile:    flag.String("tls-cert-file", "", "defines path of x509 certificate used by server and replication link"),
+		keyFile:     flag.String("tls-key-file", "", "defines path of private key of tls-cert-file"),
+		caCertFile:  flag.String("tls-ca-cert-file", "", "defines path of ca certificate used to verify peers"),
+		authClients: flag.String("tls-auth-clients", string(TlsAuthClientsYes), "defines is client certificate required: yes, no or optional"),
+		replication: flag.String("tls-replication", "no", "defines is replication link uses tls: yes or no"),
+	}
+}
+
+func parseTlsConfig(flags ConfigFlags) (tlsConfig, error) {
+	authClients, err := parseTlsAuthClients(*flags.tls.authClients)
+	if err != nil {
+		return tlsConfig{}, err
+	}
+	replication, err := ParseYesNo(*flags.tls.replication)
+	if err != nil {
+		return tlsConfig{}, fmt.Errorf("Error parsing tls-replication: %w", err)
+	}
+	if *flags.tls.port < 0 || *flags.tls.port > 65535 {
+		return tlsConfig{}, fmt.Errorf("Error parsing tls-port: port is out of range")
+	}
+	return tlsConfig{
+		port:        uint16(*flags.tls.port),
+		certFile:    *flags.tls.certFile,
+		keyFile:     *flags.tls.keyFile,
+		caCertFile:  *flags.tls.caCertFile,
+		authClients: authClients,
+		replication: replication,
+	}, nil
+}
+
+func parseTlsAuthClients(value string) (TlsAuthClientsEnum, error) {
+	switch TlsAuthClientsEnum(strings.ToLower(value)) {
+	case TlsAuthClientsYes:
+		return TlsAuthClientsYes, nil
+	case TlsAuthClientsNo:
+		return TlsAuthClientsNo, nil
+	case TlsAuthClientsOptional:
+		return TlsAuthClientsOptional, nil
+	}
+	return "", fmt.Errorf("Error parsing tls-auth-clients: unknown value %v", value)
+}
+
+// parses redis boolean config value
+func ParseYesNo(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "yes":
+		return true, nil
+	case "no":
+		return false, nil
+	}
+	return false, fmt.Errorf("argument must be 'yes' or 'no'")
+}
+
+func FormatYesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
+
+func (this *Config) GetTlsPort() uint16 {
+	return this.tls.port
+}
+
+// tls is needed if server listens tls port or replicates over tls
+func (this *Config) IsTlsEnabled() bool {
+	return this.GetTlsPort() != 0 || this.GetTlsReplication()
+}
+
+func (this *Config) GetTlsCertFile() string {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return this.tls.certFile
+}
+
+func (this *Config) GetTlsKeyFile() string {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return this.tls.keyFile
+}
+
+func (this *Config) GetTlsCaCertFile() string {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return this.tls.caCertFile
+}
+
+func (this *Config) GetTlsAuthClients() TlsAuthClientsEnum {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return this.tls.authClients
+}
+
+func (this *Config) GetTlsReplication() bool {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return this.tls.replication
+}
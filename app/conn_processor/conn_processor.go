@@ -2,12 +2,19 @@ package conn_processor
 
 import (
 	"bufio"
+	"fmt"
 	"io"
 	"net"
 
+	"github.com/codecrafters-io/redis-starter-go/app/acl"
+	"github.com/codecrafters-io/redis-starter-go/app/client"
 	"github.com/codecrafters-io/redis-starter-go/app/command"
+	"github.com/codecrafters-io/redis-starter-go/app/config"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+	"github.com/codecrafters-io/redis-starter-go/app/encoder"
 	"github.com/codecrafters-io/redis-starter-go/app/executor"
 	"github.com/codecrafters-io/redis-starter-go/app/logger"
+	"github.com/codecrafters-io/redis-starter-go/app/pubsub"
 	"github.com/codecrafters-io/redis-starter-go/app/reader"
 	"github.com/codecrafters-io/redis-starter-go/app/replicas_storage"
 	"github.com/codecrafters-io/redis-starter-go/app/transaction"
@@ -17,71 +24,249 @@ type MasterConnProcessor struct {
 	replicas_storage *replicas_storage.ReplStorage
 	commandExecutor  executor.CommandExecutor
 	globalTransct    *transaction.GlobalTransaction
+	limits           reader.Limits
+	acl              *acl.Acl
+	clients          *client.Table
+	config           *config.Config
+	pubsub           *pubsub.Pubsub
 }
 
 type ReplicaConnProcessor struct {
 	commandExecutor executor.CommandExecutor
 	reader          *reader.Reader
+	clients         *client.Table
 }
 
 type ConnProcessor interface {
 	Process(conn net.Conn)
 }
 
-func NewMasterProcessor(replicas_storage *replicas_storage.ReplStorage, executor executor.CommandExecutor) ConnProcessor {
+// processor of requests that are read outside of it, e.g. by event loop
+type RequestProcessor interface {
+	NewConnState(conn client.Conn) (*ConnState, error)
+	ReleaseConnState(state *ConnState)
+	GetLimits() reader.Limits
+	Authorize(state *ConnState, cmd *command.Command) bool
+	IsPaused(state *ConnState, cmd *command.Command) bool
+	ProcessCommand(state *ConnState, cmd *command.Command)
+	HandOffReplica(state *ConnState, conn net.Conn, buffered []byte, cmd *command.Command)
+}
+
+// per connection state that lives between requests
+type ConnState struct {
+	client      *client.Client
+	transaction transaction.ConnTransaction
+}
+
+func (this *ConnState) GetClient() *client.Client {
+	return this.client
+}
+
+func NewMasterProcessor(replicas_storage *replicas_storage.ReplStorage, executor executor.CommandExecutor, acl *acl.Acl, clients *client.Table, config *config.Config, pubsub *pubsub.Pubsub) *MasterConnProcessor {
 	return &MasterConnProcessor{
 		replicas_storage: replicas_storage,
 		commandExecutor:  executor,
 		globalTransct:    transaction.NewGlobalTransactionProcessor(executor),
+		acl:              acl,
+		clients:          clients,
+		config:           config,
+		pubsub:           pubsub,
+		limits: reader.Limits{
+			MaxBulkLen:       config.GetProtoMaxBulkLen(),
+			MaxMultibulkLen:  config.GetMaxMultibulkLen(),
+			QueryBufferLimit: config.GetClientQueryBufferLimit(),
+		},
 	}
 }
 
-func NewReplicaProcessor(executor executor.CommandExecutor, reader *reader.Reader) ConnProcessor {
+func NewReplicaProcessor(executor executor.CommandExecutor, reader *reader.Reader, clients *client.Table) ConnProcessor {
 	return &ReplicaConnProcessor{
 		commandExecutor: executor,
 		reader:          reader,
+		clients:         clients,
 	}
 }
 
 func (this *MasterConnProcessor) Process(conn net.Conn) {
+	state, err := this.NewConnState(conn)
+	if err != nil {
+		conn.Write(encoder.EncodeSimpleError(err.Error()))
+		conn.Close()
+		return
+	}
+	if this.serve(conn, state) {
+		return
+	}
+	this.ReleaseConnState(state)
+	conn.Close()
+}
 
-	reader := reader.New(bufio.NewReader(conn))
-	connTransact := transaction.NewConnectionTransactionProcessor()
-
+// serves requests until connection is closed, returns true if connection was handed off to replication
+func (this *MasterConnProcessor) serve(conn net.Conn, state *ConnState) bool {
+	connReader := reader.NewWithLimits(bufio.NewReader(conn), this.limits)
 	for {
-		data, err := reader.ParseDataType()
+		data, err := connReader.ParseDataType()
 		if err != nil {
 			if err == io.EOF {
-				return
+				return false
 			}
-			logger.Logger.Error("Error parsing data", logger.String("error", err.Error()))
-			break
+			if reader.IsProtocolError(err) {
+				conn.Write(encoder.EncodeSimpleError(err.Error()))
+			}
+			logger.Logger.Error("Error parsing data", logger.String("error", err.Error()), logger.String("addr", conn.RemoteAddr().String()))
+			return false
+		}
+		if IsEmptyRequest(data) {
+			continue
 		}
 		logger.Logger.Info("Readed data:", logger.String("command:", data.String()))
 		cmd, err := command.DataTypeToCommand(data)
 		if err != nil {
 			logger.Logger.Error("Error parsing command", logger.String("error", err.Error()))
-			break
+			return false
 		}
+		state.client.SetQueryBuffer(connReader.Buffered())
 
+		if !this.Authorize(state, cmd) {
+			continue
+		}
 		if cmd.IsNeedAddReplica() {
-			this.replicas_storage.ProcessReplicaSync(conn, cmd)
-			return
+			this.HandOffReplica(state, conn, connReader.BufferedBytes(), cmd)
+			return true
 		}
-		if connTransact.ShouldConsumeCommand(cmd) {
-			conn.Write(this.globalTransct.ExecuteCmd(cmd, connTransact).Marshall())
-			continue
+		this.ProcessCommand(state, cmd)
+		if state.client.ShouldClose() {
+			return false
 		}
-		output := this.commandExecutor.ExecuteCmd(cmd, true)
-		this.replicas_storage.PropagateCmd(cmd)
-		conn.Write(output.Marshall())
+	}
+}
+
+// registers client of new connection, error is returned if connection is rejected
+func (this *MasterConnProcessor) NewConnState(conn client.Conn) (*ConnState, error) {
+	user, authenticated := this.acl.GetDefaultUser()
+	c, err := this.clients.Register(conn, client.NormalType, user, authenticated, this.config.GetMaxClients())
+	if err != nil {
+		return nil, err
+	}
+	return &ConnState{
+		client:      c,
+		transaction: transaction.NewConnectionTransactionProcessor(),
+	}, nil
+}
+
+// removes client of closed connection and its subscriptions
+func (this *MasterConnProcessor) ReleaseConnState(state *ConnState) {
+	this.pubsub.UnsubscribeAll(state.client)
+	this.clients.Unregister(state.client)
+}
 
+func (this *MasterConnProcessor) GetLimits() reader.Limits {
+	return this.limits
+}
+
+// registers command in client, checks authentication and acl permissions of command,
+// replies with error and returns false if command is rejected
+func (this *MasterConnProcessor) Authorize(state *ConnState, cmd *command.Command) bool {
+	c := state.client
+	c.BeginCommand(cmd)
+	user, authenticated := c.GetUser()
+	if this.acl.IsDeleted(user) {
+		user, _ = this.acl.GetDefaultUser()
+		authenticated = false
+		c.SetUser(user, authenticated)
+	}
+	_, spec := cmd.GetSpec()
+	if spec != nil && spec.NoAuth {
+		return true
+	}
+	if !authenticated {
+		c.Reply(datatypes.ConstructSimpleError(acl.NoAuthError.Error()))
+		return false
 	}
-	defer conn.Close()
+	denial := this.acl.CheckCommand(user, cmd)
+	// replica handshake continues with psync which is read by replication, so it is checked in advance
+	if denial == nil && cmd.IsNeedAddReplica() && !this.acl.CanRun(user, "psync") {
+		denial = &acl.Denial{Reason: acl.CommandLogReason, Object: "psync"}
+	}
+	if denial == nil {
+		return true
+	}
+	context := acl.TopLevelLogContext
+	if state.transaction.IsInTransaction() {
+		context = acl.MultiLogContext
+	}
+	this.acl.AddLogEntry(acl.LogEntry{
+		Reason:     denial.Reason,
+		Context:    context,
+		Object:     denial.Object,
+		Username:   user.GetName(),
+		ClientInfo: c.Info(),
+	})
+	c.Reply(datatypes.ConstructSimpleError("NOPERM " + denial.Message(user.GetName())))
+	return false
+}
+
+// reports is command held by CLIENT PAUSE
+func (this *MasterConnProcessor) IsPaused(state *ConnState, cmd *command.Command) bool {
+	return this.pausedUntil(state, cmd) != nil
+}
+
+func (this *MasterConnProcessor) pausedUntil(state *ConnState, cmd *command.Command) <-chan struct{} {
+	// commands queued by MULTI are executed by EXEC, so only EXEC is paused and it may contain writes
+	if state.transaction.IsInTransaction() && cmd.Type != command.EXEC {
+		return nil
+	}
+	isWrite := cmd.HasCategory(command.CategoryWrite) || cmd.Type == command.EXEC
+	return this.clients.PausedUntil(state.client, isWrite)
+}
+
+// executes single client command and replies to client, waits while command is paused
+func (this *MasterConnProcessor) ProcessCommand(state *ConnState, cmd *command.Command) {
+	for paused := this.pausedUntil(state, cmd); paused != nil; paused = this.pausedUntil(state, cmd) {
+		<-paused
+	}
+	c := state.client
+	if c.IsSubscribedMode() && !cmd.IsAllowedInSubscribedMode() {
+		name, _ := cmd.GetSpec()
+		c.Reply(datatypes.ConstructSimpleError(fmt.Sprintf("ERR Can't execute '%v': only (P|S)SUBSCRIBE / (P|S)UNSUBSCRIBE / PING / QUIT / RESET are allowed in this context", name)))
+		return
+	}
+	// RESET leaves transaction, the rest of client state is reset by executor
+	if cmd.Type == command.RESET && cmd.ArgsErr == nil {
+		state.transaction = transaction.NewConnectionTransactionProcessor()
+		c.SetMulti(-1)
+	}
+	if state.transaction.ShouldConsumeCommand(cmd) {
+		c.Reply(this.globalTransct.ExecuteCmd(c, cmd, state.transaction))
+		c.SetMulti(state.transaction.GetQueuedAmount())
+		return
+	}
+	output := this.commandExecutor.ExecuteCmd(c, cmd, true)
+	this.replicas_storage.PropagateCmd(c.GetDb(), cmd)
+	c.Reply(output)
+}
+
+// passes connection to replication, after this call connection is owned by replicas storage,
+// client stays registered as replica until replication closes connection,
+// buffered are bytes pipelined after handshake command that are already readed from conn
+func (this *MasterConnProcessor) HandOffReplica(state *ConnState, conn net.Conn, buffered []byte, cmd *command.Command) {
+	state.client.SetType(client.ReplicaType)
+	state.client.SetConn(conn)
+	this.replicas_storage.ProcessReplicaSync(conn, buffered, cmd, func() {
+		this.ReleaseConnState(state)
+	})
+}
+
+// redis silently skips null and empty multibulk requests
+func IsEmptyRequest(data *datatypes.Data) bool {
+	return data.Type == datatypes.NULL || (data.Type == datatypes.ARRAY && len(data.Values) == 0)
 }
 
 func (this *ReplicaConnProcessor) Process(conn net.Conn) {
 	logger.Logger.Info("Start processing replica connection")
+	// link to master is not limited by maxclients
+	master, _ := this.clients.Register(conn, client.MasterType, nil, true, 0)
+	defer this.clients.Unregister(master)
 
 	for {
 		data, err := this.reader.ParseDataType()
@@ -98,7 +283,8 @@ func (this *ReplicaConnProcessor) Process(conn net.Conn) {
 			logger.Logger.Error("Error parsing command from master", logger.String("error", err.Error()))
 			break
 		}
-		res := this.commandExecutor.ExecuteCmd(cmd, false)
+		master.BeginCommand(cmd)
+		res := this.commandExecutor.ExecuteCmd(master, cmd, false)
 		if res != nil{
 		conn.Write(res.Marshall())
 			
@@ -15,6 +15,9 @@ const (
 	SIMPLE_ERROR  DataTypeEnum = '-'
 	INT           DataTypeEnum = ':'
 	NULL          DataTypeEnum = '!'
+	// RESP3 types, sent only to clients switched to protocol 3 by HELLO
+	MAP  DataTypeEnum = '%'
+	PUSH DataTypeEnum = '>'
 )
 
 var commandType = map[byte]DataTypeEnum{
@@ -60,6 +63,10 @@ func (d Data) CounstructMarshall() []byte {
 		return d.marshallInt()
 	case NULL:
 		return d.marshallNull()
+	case MAP:
+		return d.marshallAggregate(MAP, len(d.Values)/2)
+	case PUSH:
+		return d.marshallAggregate(PUSH, len(d.Values))
 	}
 	return nil
 }
@@ -118,6 +125,16 @@ func (d Data) marshallArray() []byte {
 	return out
 }
 
+func (d Data) marshallAggregate(typ DataTypeEnum, n int) []byte {
+	out := []byte{byte(typ)}
+	out = append(out, strconv.Itoa(n)...)
+	out = append(out, CLFR...)
+	for _, v := range d.Values {
+		out = append(out, v.Marshall()...)
+	}
+	return out
+}
+
 func (d Data) marshallSimpleError() []byte {
 	out := make([]byte, 0)
 	out = append(out, byte(SIMPLE_ERROR))
@@ -175,6 +192,22 @@ func ConstructArrayFromData(arr []*Data) *Data {
 	return &data
 }
 
+// map of RESP3, arr holds keys followed by their values
+func ConstructMap(arr []*Data) *Data {
+	return &Data{
+		Type:   MAP,
+		Values: arr,
+	}
+}
+
+// out of band message of RESP3, e.g. pubsub message
+func ConstructPush(arr []*Data) *Data {
+	return &Data{
+		Type:   PUSH,
+		Values: arr,
+	}
+}
+
 func ConstructInt(num int) *Data {
 	return &Data{
 		Type:  INT,
@@ -188,6 +221,14 @@ func ConstructNull() *Data {
 	}
 }
 
+// null array reply, e.g. position of missing GEOPOS member
+func ConstructNullArray() *Data {
+	return &Data{
+		Type: NULL,
+		Raw:  []byte("*-1\r\n"),
+	}
+}
+
 func (d Data) Len() int {
 	if d.Raw != nil {
 		return len(d.Raw)
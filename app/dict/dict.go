@@ -0,0 +1,269 @@
+// hashtable with incremental rehashing and cursor based scan, used by keyspace and sorted sets
+package dict
+
+import (
+	"hash/maphash"
+	"math/bits"
+)
+
+// smallest amount of buckets of non empty dict
+const dictMinSize = 4
+
+// buckets visited by single rehash step while looking for non empty one
+const dictRehashEmptyVisits = 10
+
+type dictEntry[V any] struct {
+	key   string
+	value V
+	next  *dictEntry[V]
+}
+
+type dictTable[V any] struct {
+	buckets []*dictEntry[V]
+	used    int
+}
+
+func (this *dictTable[V]) mask() uint64 {
+	return uint64(len(this.buckets) - 1)
+}
+
+// hashtable with chained buckets and incremental rehashing, amount of buckets is always power of two,
+// while rehashing entries are moved from first table to second one bucket by bucket on every operation,
+// it is not safe for concurrent use
+type Dict[V any] struct {
+	tables [2]dictTable[V]
+	// index of next bucket of first table to rehash, -1 if dict is not rehashing
+	rehashIdx int
+	seed      maphash.Seed
+}
+
+func New[V any]() *Dict[V] {
+	return &Dict[V]{rehashIdx: -1, seed: maphash.MakeSeed()}
+}
+
+func (this *Dict[V]) hash(key string) uint64 {
+	return maphash.String(this.seed, key)
+}
+
+func (this *Dict[V]) isRehashing() bool {
+	return this.rehashIdx != -1
+}
+
+func (this *Dict[V]) Len() int {
+	return this.tables[0].used + this.tables[1].used
+}
+
+func (this *Dict[V]) find(key string) *dictEntry[V] {
+	if this.Len() == 0 {
+		return nil
+	}
+	this.rehashStep()
+	h := this.hash(key)
+	for i := range this.tables {
+		t := &this.tables[i]
+		if len(t.buckets) == 0 {
+			continue
+		}
+		for e := t.buckets[h&t.mask()]; e != nil; e = e.next {
+			if e.key == key {
+				return e
+			}
+		}
+		if !this.isRehashing() {
+			break
+		}
+	}
+	return nil
+}
+
+func (this *Dict[V]) Get(key string) (V, bool) {
+	e := this.find(key)
+	if e == nil {
+		var zero V
+		return zero, false
+	}
+	return e.value, true
+}
+
+// adds key or replaces value of existing one
+func (this *Dict[V]) Set(key string, value V) {
+	if e := this.find(key); e != nil {
+		e.value = value
+		return
+	}
+	this.expandIfNeeded()
+	// new keys go to second table while rehashing, so first table only shrinks
+	t := &this.tables[0]
+	if this.isRehashing() {
+		t = &this.tables[1]
+	}
+	idx := this.hash(key) & t.mask()
+	t.buckets[idx] = &dictEntry[V]{key: key, value: value, next: t.buckets[idx]}
+	t.used++
+}
+
+// removes key, reports was key present
+func (this *Dict[V]) Delete(key string) bool {
+	if this.Len() == 0 {
+		return false
+	}
+	this.rehashStep()
+	h := this.hash(key)
+	for i := range this.tables {
+		t := &this.tables[i]
+		if len(t.buckets) == 0 {
+			continue
+		}
+		idx := h & t.mask()
+		var prev *dictEntry[V]
+		for e := t.buckets[idx]; e != nil; e = e.next {
+			if e.key == key {
+				if prev == nil {
+					t.buckets[idx] = e.next
+				} else {
+					prev.next = e.next
+				}
+				t.used--
+				this.shrinkIfNeeded()
+				return true
+			}
+			prev = e
+		}
+		if !this.isRehashing() {
+			break
+		}
+	}
+	return false
+}
+
+// calls fn for every entry, dict should not be modified by fn
+func (this *Dict[V]) ForEach(fn func(key string, value V)) {
+	for i := range this.tables {
+		for _, e := range this.tables[i].buckets {
+			for ; e != nil; e = e.next {
+				fn(e.key, e.value)
+			}
+		}
+	}
+}
+
+func (this *Dict[V]) expandIfNeeded() {
+	if this.isRehashing() {
+		return
+	}
+	t := &this.tables[0]
+	if len(t.buckets) == 0 {
+		this.resize(dictMinSize)
+		return
+	}
+	if t.used >= len(t.buckets) {
+		this.resize(t.used + 1)
+	}
+}
+
+func (this *Dict[V]) shrinkIfNeeded() {
+	if this.isRehashing() {
+		return
+	}
+	t := &this.tables[0]
+	if len(t.buckets) > dictMinSize && t.used*8 < len(t.buckets) {
+		this.resize(t.used)
+	}
+}
+
+// starts rehashing into table with smallest power of two buckets able to hold size entries
+func (this *Dict[V]) resize(size int) {
+	n := dictMinSize
+	for n < size {
+		n *= 2
+	}
+	if len(this.tables[0].buckets) == 0 {
+		this.tables[0] = dictTable[V]{buckets: make([]*dictEntry[V], n)}
+		return
+	}
+	if n == len(this.tables[0].buckets) {
+		return
+	}
+	this.tables[1] = dictTable[V]{buckets: make([]*dictEntry[V], n)}
+	this.rehashIdx = 0
+}
+
+// moves one bucket of first table to second table, empty buckets are skipped up to limit
+func (this *Dict[V]) rehashStep() {
+	if !this.isRehashing() {
+		return
+	}
+	from, to := &this.tables[0], &this.tables[1]
+	emptyVisits := dictRehashEmptyVisits
+	for from.used > 0 && from.buckets[this.rehashIdx] == nil {
+		this.rehashIdx++
+		emptyVisits--
+		if emptyVisits == 0 {
+			return
+		}
+	}
+	if from.used > 0 {
+		e := from.buckets[this.rehashIdx]
+		for e != nil {
+			next := e.next
+			idx := this.hash(e.key) & to.mask()
+			e.next = to.buckets[idx]
+			to.buckets[idx] = e
+			from.used--
+			to.used++
+			e = next
+		}
+		from.buckets[this.rehashIdx] = nil
+		this.rehashIdx++
+	}
+	if from.used == 0 {
+		this.tables[0] = this.tables[1]
+		this.tables[1] = dictTable[V]{}
+		this.rehashIdx = -1
+	}
+}
+
+// visits buckets addressed by cursor and returns next cursor, 0 is returned when iteration is complete.
+// cursor is incremented in reverse bit order, so buckets already visited in table of one size map to
+// already visited buckets in table of any other size, every key present during whole iteration is returned
+// at least once even if dict is resized between calls
+func (this *Dict[V]) Scan(cursor uint64, fn func(key string, value V)) uint64 {
+	if this.Len() == 0 {
+		return 0
+	}
+	visit := func(t *dictTable[V], idx uint64) {
+		for e := t.buckets[idx]; e != nil; e = e.next {
+			fn(e.key, e.value)
+		}
+	}
+	if !this.isRehashing() {
+		t := &this.tables[0]
+		m := t.mask()
+		visit(t, cursor&m)
+		return nextCursor(cursor, m)
+	}
+
+	small, large := &this.tables[0], &this.tables[1]
+	if len(small.buckets) > len(large.buckets) {
+		small, large = large, small
+	}
+	m0, m1 := small.mask(), large.mask()
+	visit(small, cursor&m0)
+	// visits every bucket of larger table that is expansion of bucket of smaller table
+	for {
+		visit(large, cursor&m1)
+		cursor = nextCursor(cursor, m1)
+		if cursor&(m0^m1) == 0 {
+			break
+		}
+	}
+	return cursor
+}
+
+// increments reversed bits of cursor covered by mask
+func nextCursor(cursor uint64, mask uint64) uint64 {
+	cursor |= ^mask
+	cursor = bits.Reverse64(cursor)
+	cursor++
+	return bits.Reverse64(cursor)
+}
@@ -0,0 +1,70 @@
+package dict
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDict_SetGetDelete(t *testing.T) {
+	d := New[int]()
+	for i := 0; i < 1000; i++ {
+		d.Set(fmt.Sprintf("key%v", i), i)
+	}
+	if d.Len() != 1000 {
+		t.Fatalf("expected 1000 keys, got %v", d.Len())
+	}
+	for i := 0; i < 1000; i += 2 {
+		if !d.Delete(fmt.Sprintf("key%v", i)) {
+			t.Fatalf("expected key%v to be deleted", i)
+		}
+	}
+	for i := 0; i < 1000; i++ {
+		v, ok := d.Get(fmt.Sprintf("key%v", i))
+		if ok != (i%2 == 1) {
+			t.Fatalf("expected key%v presence to be %v, got %v", i, i%2 == 1, ok)
+		}
+		if ok && v != i {
+			t.Fatalf("expected key%v to hold %v, got %v", i, i, v)
+		}
+	}
+	if d.Len() != 500 {
+		t.Fatalf("expected 500 keys, got %v", d.Len())
+	}
+}
+
+// keys present during whole scan should be returned even if dict grows or shrinks between scan calls
+func TestDict_ScanReturnsStableKeysAcrossRehash(t *testing.T) {
+	for _, grow := range []bool{true, false} {
+		d := New[int]()
+		for i := 0; i < 100; i++ {
+			d.Set(fmt.Sprintf("stable%v", i), i)
+		}
+		for i := 0; i < 1500; i++ {
+			d.Set(fmt.Sprintf("temp%v", i), i)
+		}
+
+		seen := map[string]bool{}
+		cursor := uint64(0)
+		step := 0
+		for {
+			cursor = d.Scan(cursor, func(key string, _ int) {
+				seen[key] = true
+			})
+			// changes dict while scanning, so scan observes tables of different sizes and rehashing in progress
+			if grow {
+				d.Set(fmt.Sprintf("new%v", step), step)
+			} else {
+				d.Delete(fmt.Sprintf("temp%v", step))
+			}
+			step++
+			if cursor == 0 {
+				break
+			}
+		}
+		for i := 0; i < 100; i++ {
+			if !seen[fmt.Sprintf("stable%v", i)] {
+				t.Fatalf("expected stable%v to be returned by scan (grow=%v)", i, grow)
+			}
+		}
+	}
+}
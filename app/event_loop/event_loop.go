@@ -0,0 +1,333 @@
+// event loop networking model: sockets are multiplexed on small amount of io threads,
+// parsed commands are executed by single executor goroutine
+package eventloop
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+	"github.com/codecrafters-io/redis-starter-go/app/conn_processor"
+	"github.com/codecrafters-io/redis-starter-go/app/encoder"
+	"github.com/codecrafters-io/redis-starter-go/app/logger"
+	"github.com/codecrafters-io/redis-starter-go/app/reader"
+)
+
+// size of chunk readed from socket at once
+const readChunkSize = 16 * 1024
+
+// amount of commands that io threads can queue for executor before blocking
+const tasksQueueSize = 4096
+
+type EventLoop struct {
+	processor conn_processor.RequestProcessor
+	pollers   []*poller
+	next      int
+	tasks     chan task
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// unit of work for executor goroutine
+type task struct {
+	conn *conn
+	cmd  *command.Command
+	// blocking command of conn finished, queued commands can be processed
+	resume bool
+}
+
+// creates event loop with threads io threads, starts io and executor goroutines
+func New(processor conn_processor.RequestProcessor, threads int) (*EventLoop, error) {
+	if threads <= 0 {
+		return nil, errors.New("Error creating event loop: amount of io threads should be positive")
+	}
+	loop := &EventLoop{
+		processor: processor,
+		pollers:   make([]*poller, 0, threads),
+		tasks:     make(chan task, tasksQueueSize),
+		done:      make(chan struct{}),
+	}
+	for i := 0; i < threads; i++ {
+		p, err := newPoller(loop)
+		if err != nil {
+			loop.Close()
+			return nil, err
+		}
+		loop.pollers = append(loop.pollers, p)
+	}
+	for _, p := range loop.pollers {
+		go p.run()
+	}
+	go loop.runExecutor()
+	return loop, nil
+}
+
+// accepts connections from listener and registers them in pollers, returns when listener is closed
+func (this *EventLoop) Serve(l net.Listener) error {
+	for {
+		netConn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return err
+			}
+			logger.Logger.Error("Failed to accept connection", logger.String("error", err.Error()))
+			continue
+		}
+		err = this.Register(netConn)
+		if err != nil {
+			logger.Logger.Error("Failed to register connection in event loop", logger.String("error", err.Error()))
+		}
+	}
+}
+
+// takes ownership of connection, after this call netConn is closed and socket is served by event loop
+func (this *EventLoop) Register(netConn net.Conn) error {
+	p := this.pollers[this.next%len(this.pollers)]
+	this.next++
+	c := &conn{
+		poller:     p,
+		remoteAddr: netConn.RemoteAddr(),
+		localAddr:  netConn.LocalAddr(),
+	}
+	state, err := this.processor.NewConnState(c)
+	if err != nil {
+		netConn.Write(encoder.EncodeSimpleError(err.Error()))
+		netConn.Close()
+		return err
+	}
+	c.state = state
+	err = p.register(c, netConn)
+	// closed conn is already released by poller
+	if err != nil && !c.isClosed() {
+		this.processor.ReleaseConnState(state)
+	}
+	return err
+}
+
+// stops io and executor goroutines, registered connections are closed
+func (this *EventLoop) Close() {
+	this.closeOnce.Do(func() {
+		close(this.done)
+		for _, p := range this.pollers {
+			p.close()
+		}
+	})
+}
+
+func (this *EventLoop) runExecutor() {
+	for {
+		select {
+		case t := <-this.tasks:
+			this.execute(t)
+		case <-this.done:
+			return
+		}
+	}
+}
+
+// queues task for executor, returns false if event loop is closed
+func (this *EventLoop) dispatch(t task) bool {
+	select {
+	case this.tasks <- t:
+		return true
+	case <-this.done:
+		return false
+	}
+}
+
+func (this *EventLoop) execute(t task) {
+	c := t.conn
+	if t.resume {
+		c.busy = false
+		pending := c.pending
+		c.pending = nil
+		for i, cmd := range pending {
+			if c.busy {
+				c.pending = append(c.pending, pending[i:]...)
+				return
+			}
+			this.executeCmd(c, cmd)
+		}
+		return
+	}
+	if c.busy {
+		c.pending = append(c.pending, t.cmd)
+		return
+	}
+	this.executeCmd(c, t.cmd)
+}
+
+func (this *EventLoop) executeCmd(c *conn, cmd *command.Command) {
+	if c.isClosed() {
+		return
+	}
+	if !this.processor.Authorize(c.state, cmd) {
+		// io thread stopped watching socket for replica handshake, rejected client keeps being served
+		// and requests pipelined after handshake command are parsed once socket is watched again
+		if cmd.IsNeedAddReplica() {
+			c.poller.rewatch(c)
+		}
+		return
+	}
+	if cmd.IsNeedAddReplica() {
+		// io thread does not touch input of unwatched conn, requests pipelined after handshake belong to replication
+		buffered := c.in
+		c.in = nil
+		netConn, err := c.poller.detach(c)
+		if err != nil {
+			logger.Logger.Error("Error detaching replica connection from event loop", logger.String("error", err.Error()))
+			return
+		}
+		go this.processor.HandOffReplica(c.state, netConn, buffered, cmd)
+		return
+	}
+	// blocking or paused command would stall every client, so it runs aside and further commands of its conn wait for it
+	if cmd.IsBlocking() || this.processor.IsPaused(c.state, cmd) {
+		c.busy = true
+		go func() {
+			this.processCommand(c, cmd)
+			this.dispatch(task{conn: c, resume: true})
+		}()
+		return
+	}
+	this.processCommand(c, cmd)
+}
+
+func (this *EventLoop) processCommand(c *conn, cmd *command.Command) {
+	this.processor.ProcessCommand(c.state, cmd)
+	if c.state.GetClient().ShouldClose() {
+		c.Close()
+	}
+}
+
+type conn struct {
+	fd         int
+	poller     *poller
+	remoteAddr net.Addr
+	localAddr  net.Addr
+	state      *conn_processor.ConnState
+	// bytes readed from socket that do not form complete request yet, owned by io thread
+	in      []byte
+	scanner requestScanner
+	// input is parsed once conn is watched again even if socket has no new bytes, guarded by poller mu
+	reparse bool
+	// guards out, closed and socket writes
+	mu     sync.Mutex
+	out    []byte
+	closed bool
+	// owned by executor goroutine
+	busy    bool
+	pending []*command.Command
+}
+
+// writes reply to socket, bytes that socket can not accept right now are flushed by io thread
+func (this *conn) Write(p []byte) (int, error) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if this.closed {
+		return 0, net.ErrClosed
+	}
+	if len(this.out) > 0 {
+		this.out = append(this.out, p...)
+		return len(p), nil
+	}
+	return len(p), this.poller.write(this, p)
+}
+
+func (this *conn) RemoteAddr() net.Addr {
+	return this.remoteAddr
+}
+
+func (this *conn) LocalAddr() net.Addr {
+	return this.localAddr
+}
+
+func (this *conn) Fd() int {
+	return this.fd
+}
+
+// amount of reply bytes waiting for socket to become writable
+func (this *conn) OutputBuffered() int {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return len(this.out)
+}
+
+// shuts socket down, io thread sees hang up and closes connection
+func (this *conn) Close() error {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if this.closed {
+		return nil
+	}
+	return this.poller.shutdownConn(this)
+}
+
+func (this *conn) isClosed() bool {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.closed
+}
+
+// reports if input buffer grew over client-query-buffer-limit, such client is closed
+func (this *conn) exceedsQueryBuffer(limits reader.Limits) bool {
+	if limits.QueryBufferLimit > 0 && int64(len(this.in)) > limits.QueryBufferLimit {
+		logger.Logger.Error("Closing client that reached max query buffer length", logger.String("addr", this.remoteAddr.String()))
+		return true
+	}
+	return false
+}
+
+// parses complete requests from input buffer and passes them to executor,
+// returns false if connection has to be closed
+func (this *conn) processInput(loop *EventLoop) bool {
+	limits := loop.processor.GetLimits()
+	if this.exceedsQueryBuffer(limits) {
+		return false
+	}
+	consumed := 0
+	for consumed < len(this.in) {
+		// request is parsed only when all its bytes arrived, otherwise every read would parse it again
+		if !this.scanner.scan(this.in[consumed:], limits) {
+			break
+		}
+		rd := reader.NewWithLimits(bufio.NewReader(bytes.NewReader(this.in[consumed:])), limits)
+		data, err := rd.ParseDataType()
+		this.scanner.reset()
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			if reader.IsProtocolError(err) {
+				this.Write(encoder.EncodeSimpleError(err.Error()))
+			}
+			logger.Logger.Error("Error parsing data", logger.String("error", err.Error()), logger.String("addr", this.remoteAddr.String()))
+			return false
+		}
+		consumed += len(data.Raw)
+		if conn_processor.IsEmptyRequest(data) {
+			continue
+		}
+		cmd, err := command.DataTypeToCommand(data)
+		if err != nil {
+			logger.Logger.Error("Error parsing command", logger.String("error", err.Error()))
+			return false
+		}
+		// replica handshake takes the socket, so io thread stops watching it before executor detaches it
+		if cmd.IsNeedAddReplica() {
+			this.poller.unwatch(this)
+			this.in = append(this.in[:0], this.in[consumed:]...)
+			return loop.dispatch(task{conn: this, cmd: cmd})
+		}
+		if !loop.dispatch(task{conn: this, cmd: cmd}) {
+			return false
+		}
+	}
+	this.in = append(this.in[:0], this.in[consumed:]...)
+	this.state.GetClient().SetQueryBuffer(len(this.in))
+	return true
+}
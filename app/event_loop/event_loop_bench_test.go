@@ -0,0 +1,127 @@
+//go:build linux
+
+package eventloop
+
+import (
+	"bufio"
+	"flag"
+	"io"
+	"net"
+	"runtime"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/app/conn_processor"
+)
+
+var benchConns = flag.Int("bench-conns", 10000, "amount of client connections opened by networking benchmarks")
+
+var (
+	benchProcessor     *conn_processor.MasterConnProcessor
+	benchProcessorOnce sync.Once
+)
+
+func getBenchProcessor(b *testing.B) *conn_processor.MasterConnProcessor {
+	benchProcessorOnce.Do(func() {
+		benchProcessor = newTestProcessor(b)
+	})
+	return benchProcessor
+}
+
+// every process side socket needs fd for client and server end, so amount of conns is capped by fd limit
+func benchConnsAmount(b *testing.B) int {
+	var limit syscall.Rlimit
+	err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit)
+	if err != nil {
+		b.Fatalf("expected no error, got %v", err)
+	}
+	if limit.Cur < limit.Max {
+		limit.Cur = limit.Max
+		syscall.Setrlimit(syscall.RLIMIT_NOFILE, &limit)
+	}
+	available := (int(limit.Cur) - 256) / 2
+	if available < *benchConns {
+		b.Logf("fd limit %v allows only %v connections", limit.Cur, available)
+		return available
+	}
+	return *benchConns
+}
+
+type benchClient struct {
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+var pingRequest = []byte("*1\r\n$4\r\nPING\r\n")
+
+func runNetworkingBenchmark(b *testing.B, serve func(l net.Listener)) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatalf("expected no error, got %v", err)
+	}
+	defer l.Close()
+	go serve(l)
+
+	n := benchConnsAmount(b)
+	pool := make(chan *benchClient, n)
+	for i := 0; i < n; i++ {
+		conn, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			b.Fatalf("expected no error on %v'th dial, got %v", i, err)
+		}
+		defer conn.Close()
+		pool <- &benchClient{conn: conn, rd: bufio.NewReader(conn)}
+	}
+
+	runtime.GC()
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	goroutines := runtime.NumGoroutine()
+
+	reply := make([]byte, len("+PONG\r\n"))
+	b.SetParallelism(16)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		reply := make([]byte, len(reply))
+		for pb.Next() {
+			c := <-pool
+			_, err := c.conn.Write(pingRequest)
+			if err == nil {
+				_, err = io.ReadFull(c.rd, reply)
+			}
+			pool <- c
+			if err != nil {
+				b.Errorf("expected no error, got %v", err)
+				return
+			}
+		}
+	})
+	b.StopTimer()
+	b.ReportMetric(float64(goroutines), "goroutines")
+	b.ReportMetric(float64(mem.HeapInuse+mem.StackInuse)/float64(n), "mem-bytes/conn")
+}
+
+func BenchmarkGoroutinePerConnection(b *testing.B) {
+	runNetworkingBenchmark(b, func(l net.Listener) {
+		processor := getBenchProcessor(b)
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go processor.Process(conn)
+		}
+	})
+}
+
+func BenchmarkEventLoop(b *testing.B) {
+	loop, err := New(getBenchProcessor(b), 4)
+	if err != nil {
+		b.Fatalf("expected no error, got %v", err)
+	}
+	defer loop.Close()
+	runNetworkingBenchmark(b, func(l net.Listener) {
+		loop.Serve(l)
+	})
+}
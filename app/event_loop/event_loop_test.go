@@ -0,0 +1,280 @@
+//go:build linux
+
+package eventloop
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/acl"
+	"github.com/codecrafters-io/redis-starter-go/app/client"
+	"github.com/codecrafters-io/redis-starter-go/app/config"
+	"github.com/codecrafters-io/redis-starter-go/app/conn_processor"
+	"github.com/codecrafters-io/redis-starter-go/app/encoder"
+	"github.com/codecrafters-io/redis-starter-go/app/executor"
+	"github.com/codecrafters-io/redis-starter-go/app/logger"
+	"github.com/codecrafters-io/redis-starter-go/app/offset_counter"
+	"github.com/codecrafters-io/redis-starter-go/app/pubsub"
+	"github.com/codecrafters-io/redis-starter-go/app/reader"
+	"github.com/codecrafters-io/redis-starter-go/app/replicas_storage"
+	"github.com/codecrafters-io/redis-starter-go/app/slots"
+	"github.com/codecrafters-io/redis-starter-go/app/storage"
+	"go.uber.org/zap"
+)
+
+var (
+	testConfig     *config.Config
+	testConfigErr  error
+	testConfigOnce sync.Once
+)
+
+// config registers command line flags, so it is created once, the rest of server state is fresh for every processor
+func newTestProcessor(tb testing.TB) *conn_processor.MasterConnProcessor {
+	testConfigOnce.Do(func() {
+		logger.Logger = zap.NewNop()
+		testConfig, testConfigErr = config.New()
+	})
+	if testConfigErr != nil {
+		tb.Fatalf("expected no error, got %v", testConfigErr)
+	}
+	cfg := testConfig
+	accessList, err := acl.New(cfg)
+	if err != nil {
+		tb.Fatalf("expected no error, got %v", err)
+	}
+	replStorage := replicas_storage.New(cfg)
+	clients := client.NewTable()
+	ps := pubsub.New()
+	exec := executor.New(offset_counter.New(), replStorage, storage.NewDatabases(16), cfg, accessList, clients, nil, ps, slots.NewTable(), nil)
+	return conn_processor.NewMasterProcessor(replStorage, exec, accessList, clients, cfg, ps)
+}
+
+type testClient struct {
+	t    *testing.T
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+// starts event loop with fresh server state, returns dial func for its clients
+func startTestLoop(t *testing.T) func() *testClient {
+	loop, err := New(newTestProcessor(t), 2)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	go loop.Serve(l)
+	t.Cleanup(func() {
+		l.Close()
+		loop.Close()
+	})
+	return func() *testClient {
+		conn, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return &testClient{t: t, conn: conn, rd: bufio.NewReader(conn)}
+	}
+}
+
+func (this *testClient) write(raw string) {
+	this.t.Helper()
+	_, err := this.conn.Write([]byte(raw))
+	if err != nil {
+		this.t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+// reads exactly len(expected) bytes of replies and compares them
+func (this *testClient) expect(expected string) {
+	this.t.Helper()
+	this.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, len(expected))
+	_, err := io.ReadFull(this.rd, buf)
+	if err != nil {
+		this.t.Fatalf("expected reply %q, got %q with error %v", expected, buf, err)
+	}
+	if string(buf) != expected {
+		this.t.Fatalf("expected reply %q, got %q", expected, buf)
+	}
+}
+
+// checks that nothing is replied during d
+func (this *testClient) expectSilence(d time.Duration) {
+	this.t.Helper()
+	this.conn.SetReadDeadline(time.Now().Add(d))
+	b, err := this.rd.ReadByte()
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		this.t.Fatalf("expected no reply, got %q with error %v", b, err)
+	}
+}
+
+func request(args ...string) string {
+	return string(encoder.EncodeArray(args))
+}
+
+func TestEventLoop_PipelinedRequests(t *testing.T) {
+	c := startTestLoop(t)()
+	c.write(request("SET", "a", "1") + request("GET", "a") + request("PING") + request("INCR", "a"))
+	c.expect("+OK\r\n$1\r\n1\r\n+PONG\r\n:2\r\n")
+}
+
+func TestEventLoop_RequestSplitAcrossReads(t *testing.T) {
+	c := startTestLoop(t)()
+	set := request("SET", "a", strings.Repeat("v", 3*readChunkSize))
+	strlen := request("STRLEN", "a")
+	// pieces cut through headers, payload and terminators
+	for _, piece := range []string{set[:1], set[1:5], set[5:17], set[17 : len(set)-3], set[len(set)-3 : len(set)-1]} {
+		c.write(piece)
+		c.expectSilence(20 * time.Millisecond)
+	}
+	c.write(set[len(set)-1:] + strlen[:7])
+	c.expect("+OK\r\n")
+	c.write(strlen[7:])
+	c.expect(":49152\r\n")
+}
+
+func TestEventLoop_BlockingCommandHoldsLaterCommandsOfConn(t *testing.T) {
+	dial := startTestLoop(t)
+	blocked, other := dial(), dial()
+	blocked.write(request("XREAD", "BLOCK", "0", "STREAMS", "s", "$") + request("PING"))
+	blocked.expectSilence(100 * time.Millisecond)
+
+	// other clients are served while command is blocked
+	other.write(request("PING"))
+	other.expect("+PONG\r\n")
+	other.write(request("XADD", "s", "1-1", "f", "v"))
+	other.expect("$3\r\n1-1\r\n")
+
+	blocked.expect("*1\r\n*2\r\n$1\r\ns\r\n*1\r\n*2\r\n$3\r\n1-1\r\n*2\r\n$1\r\nf\r\n$1\r\nv\r\n+PONG\r\n")
+}
+
+func TestEventLoop_ReplicaHandOffKeepsPipelinedRequests(t *testing.T) {
+	replica := startTestLoop(t)()
+	// handshake requests after REPLCONF listening-port are readed by replication, not by event loop
+	replica.write(request("REPLCONF", "listening-port", "6380") + request("REPLCONF", "capa", "psync2") + request("PSYNC", "?", "-1"))
+	replica.expect("+OK\r\n+OK\r\n+FULLRESYNC ")
+	line, err := replica.rd.ReadString('\n')
+	if err != nil || !strings.HasSuffix(line, " 0\r\n") {
+		t.Fatalf("expected replication id and offset, got %q with error %v", line, err)
+	}
+	rdb := reader.New(replica.rd)
+	err = rdb.ReadRdb()
+	if err != nil {
+		t.Fatalf("expected rdb transfer, got %v", err)
+	}
+}
+
+func TestEventLoop_RejectedReplicaHandshakeKeepsServingPipelinedRequests(t *testing.T) {
+	c := startTestLoop(t)()
+	c.write(request("ACL", "SETUSER", "default", "-psync"))
+	c.expect("+OK\r\n")
+	c.write(request("REPLCONF", "listening-port", "6380") + request("PING"))
+	c.expect("-NOPERM User default has no permissions to run the 'psync' command\r\n+PONG\r\n")
+}
+
+// overrides limits of processor, config does not allow query buffer limit below 1mb
+type limitedProcessor struct {
+	conn_processor.RequestProcessor
+	limits reader.Limits
+}
+
+func (this limitedProcessor) GetLimits() reader.Limits {
+	return this.limits
+}
+
+func TestPoller_ClosesConnAsSoonAsQueryBufferLimitIsExceeded(t *testing.T) {
+	loop := &EventLoop{processor: limitedProcessor{newTestProcessor(t), reader.Limits{QueryBufferLimit: readChunkSize}}}
+	p, err := newPoller(loop)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	t.Cleanup(func() {
+		syscall.Close(p.epfd)
+		syscall.Close(p.wakeR)
+		syscall.Close(p.wakeW)
+	})
+	// pipe stands for socket that client keeps full, its read end is read by poller
+	var fds [2]int
+	if err := syscall.Pipe2(fds[:], syscall.O_NONBLOCK); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer syscall.Close(fds[1])
+	pending, err := syscall.Dup(fds[0])
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer syscall.Close(pending)
+	written, _ := syscall.Write(fds[1], []byte(request("SET", "k", strings.Repeat("v", 8*readChunkSize))))
+	if written <= 2*readChunkSize {
+		t.Fatalf("expected pipe to hold more than limit, it took %v bytes", written)
+	}
+
+	c := &conn{fd: fds[0], poller: p, remoteAddr: &net.UnixAddr{}, localAddr: &net.UnixAddr{}}
+	c.state, err = loop.processor.NewConnState(c)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	p.read(c, make([]byte, readChunkSize))
+	if !c.isClosed() {
+		t.Fatalf("expected conn over query buffer limit to be closed")
+	}
+	if len(c.in) > 2*readChunkSize {
+		t.Fatalf("expected reading to stop right after limit, got %v bytes buffered", len(c.in))
+	}
+	left, _ := syscall.Read(pending, make([]byte, written))
+	if left <= 0 {
+		t.Fatalf("expected rest of input to be left unread")
+	}
+}
+
+func TestRequestScanner_WaitsForWholeRequest(t *testing.T) {
+	raw := []byte(request("SET", "key", strings.Repeat("v", 100)) + request("PING"))
+	first := len(request("SET", "key", strings.Repeat("v", 100)))
+	s := requestScanner{}
+	for i := 1; i < first; i++ {
+		if s.scan(raw[:i], reader.Limits{}) {
+			t.Fatalf("expected request to be incomplete after %v bytes", i)
+		}
+		if s.pos > i {
+			t.Fatalf("expected scanner not to go past %v arrived bytes, got %v", i, s.pos)
+		}
+	}
+	if !s.scan(raw, reader.Limits{}) || s.pos != first {
+		t.Fatalf("expected request to end at %v, got %v", first, s.pos)
+	}
+}
+
+func TestRequestScanner_LeavesMalformedRequestsToParser(t *testing.T) {
+	limits := reader.Limits{MaxBulkLen: 10, MaxMultibulkLen: 2}
+	for _, raw := range []string{
+		"PING\r\n",
+		"*3\r\n",
+		"*-2\r\n",
+		"*x\r\n",
+		"*1\r\n$11\r\n",
+		"*1\r\n$-5\r\n",
+		"*1\r\n$9223372036854775807\r\n",
+		"\r\n",
+		"*0\r\n",
+		"*-1\r\n",
+		"*2\r\n:1\r\n+a\r\n",
+		"*1\r\n*1\r\n$-1\r\n",
+	} {
+		s := requestScanner{}
+		if !s.scan([]byte(raw), limits) {
+			t.Errorf("expected %q to be handed to parser", raw)
+		}
+	}
+}
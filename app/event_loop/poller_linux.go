@@ -0,0 +1,313 @@
+//go:build linux
+
+package eventloop
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+
+	"github.com/codecrafters-io/redis-starter-go/app/logger"
+)
+
+// max amount of events handled by single epoll_wait call
+const maxEvents = 256
+
+const readEvents = syscall.EPOLLIN | syscall.EPOLLRDHUP
+
+// io thread, waits for socket events with epoll, reads requests and flushes pending replies
+type poller struct {
+	loop  *EventLoop
+	epfd  int
+	wakeR int
+	wakeW int
+	mu    sync.Mutex
+	conns map[int]*conn
+}
+
+func newPoller(loop *EventLoop) (*poller, error) {
+	epfd, err := syscall.EpollCreate1(syscall.EPOLL_CLOEXEC)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating epoll instance: %w", err)
+	}
+	var wake [2]int
+	err = syscall.Pipe2(wake[:], syscall.O_NONBLOCK|syscall.O_CLOEXEC)
+	if err != nil {
+		syscall.Close(epfd)
+		return nil, fmt.Errorf("Error creating poller wake pipe: %w", err)
+	}
+	err = syscall.EpollCtl(epfd, syscall.EPOLL_CTL_ADD, wake[0], &syscall.EpollEvent{Events: syscall.EPOLLIN, Fd: int32(wake[0])})
+	if err != nil {
+		syscall.Close(epfd)
+		syscall.Close(wake[0])
+		syscall.Close(wake[1])
+		return nil, fmt.Errorf("Error registering poller wake pipe: %w", err)
+	}
+	return &poller{
+		loop:  loop,
+		epfd:  epfd,
+		wakeR: wake[0],
+		wakeW: wake[1],
+		conns: map[int]*conn{},
+	}, nil
+}
+
+func (this *poller) register(c *conn, netConn net.Conn) error {
+	fd, err := dupConnFd(netConn)
+	netConn.Close()
+	if err != nil {
+		return err
+	}
+	c.fd = fd
+	this.mu.Lock()
+	this.conns[fd] = c
+	this.mu.Unlock()
+	err = syscall.EpollCtl(this.epfd, syscall.EPOLL_CTL_ADD, fd, &syscall.EpollEvent{Events: readEvents, Fd: int32(fd)})
+	if err != nil {
+		this.closeConn(c)
+		return fmt.Errorf("Error adding connection to epoll: %w", err)
+	}
+	return nil
+}
+
+// takes socket fd from go runtime, dup holds the same socket after netConn is closed
+func dupConnFd(netConn net.Conn) (int, error) {
+	sc, ok := netConn.(syscall.Conn)
+	if !ok {
+		return -1, fmt.Errorf("Error getting connection fd: unsupported connection type %T", netConn)
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return -1, err
+	}
+	fd := -1
+	var dupErr error
+	err = raw.Control(func(s uintptr) {
+		fd, dupErr = syscall.Dup(int(s))
+	})
+	if err != nil {
+		return -1, err
+	}
+	if dupErr != nil {
+		return -1, dupErr
+	}
+	syscall.CloseOnExec(fd)
+	err = syscall.SetNonblock(fd, true)
+	if err != nil {
+		syscall.Close(fd)
+		return -1, err
+	}
+	return fd, nil
+}
+
+func (this *poller) run() {
+	events := make([]syscall.EpollEvent, maxEvents)
+	buf := make([]byte, readChunkSize)
+	defer this.shutdown()
+	for {
+		n, err := syscall.EpollWait(this.epfd, events, -1)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			logger.Logger.Error("Error waiting for epoll events", logger.String("error", err.Error()))
+			return
+		}
+		for i := 0; i < n; i++ {
+			fd := int(events[i].Fd)
+			if fd == this.wakeR {
+				return
+			}
+			this.mu.Lock()
+			c := this.conns[fd]
+			reparse := c != nil && c.reparse
+			if reparse {
+				c.reparse = false
+			}
+			this.mu.Unlock()
+			if c == nil {
+				continue
+			}
+			ev := events[i].Events
+			if ev&syscall.EPOLLOUT != 0 {
+				this.flush(c)
+			}
+			if reparse || ev&(readEvents|syscall.EPOLLHUP|syscall.EPOLLERR) != 0 {
+				this.read(c, buf)
+			}
+		}
+	}
+}
+
+// reads everything socket has, then parses requests,
+// client that keeps socket full is closed as soon as its input exceeds query buffer limit
+func (this *poller) read(c *conn, buf []byte) {
+	limits := this.loop.processor.GetLimits()
+	for {
+		n, err := syscall.Read(c.fd, buf)
+		if n > 0 {
+			c.in = append(c.in, buf[:n]...)
+			if c.exceedsQueryBuffer(limits) {
+				this.closeConn(c)
+				return
+			}
+			continue
+		}
+		if err == syscall.EINTR {
+			continue
+		}
+		if err == syscall.EAGAIN {
+			break
+		}
+		if err != nil {
+			logger.Logger.Error("Error reading from connection", logger.String("error", err.Error()), logger.String("addr", c.remoteAddr.String()))
+		}
+		this.closeConn(c)
+		return
+	}
+	if !c.processInput(this.loop) {
+		this.closeConn(c)
+	}
+}
+
+// writes p to socket, must be called with c.mu held
+func (this *poller) write(c *conn, p []byte) error {
+	for len(p) > 0 {
+		n, err := syscall.Write(c.fd, p)
+		if n > 0 {
+			p = p[n:]
+		}
+		if err == syscall.EINTR {
+			continue
+		}
+		if err == syscall.EAGAIN {
+			c.out = append(c.out, p...)
+			return syscall.EpollCtl(this.epfd, syscall.EPOLL_CTL_MOD, c.fd, &syscall.EpollEvent{Events: readEvents | syscall.EPOLLOUT, Fd: int32(c.fd)})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writes pending replies once socket is writable again
+func (this *poller) flush(c *conn) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+	out := c.out
+	c.out = nil
+	err := this.write(c, out)
+	if err == nil && len(c.out) == 0 {
+		err = syscall.EpollCtl(this.epfd, syscall.EPOLL_CTL_MOD, c.fd, &syscall.EpollEvent{Events: readEvents, Fd: int32(c.fd)})
+	}
+	c.mu.Unlock()
+	if err != nil {
+		logger.Logger.Error("Error writing to connection", logger.String("error", err.Error()), logger.String("addr", c.remoteAddr.String()))
+		this.closeConn(c)
+	}
+}
+
+// stops watching socket events, socket stays open
+func (this *poller) unwatch(c *conn) {
+	syscall.EpollCtl(this.epfd, syscall.EPOLL_CTL_DEL, c.fd, nil)
+	this.mu.Lock()
+	if this.conns[c.fd] == c {
+		delete(this.conns, c.fd)
+	}
+	this.mu.Unlock()
+}
+
+// starts watching socket events again after unwatch, input buffered while conn was unwatched is parsed
+// on first event, writable socket reports it right away and flush switches back to read events
+func (this *poller) rewatch(c *conn) {
+	c.mu.Lock()
+	closed := c.closed
+	c.mu.Unlock()
+	if closed {
+		return
+	}
+	this.mu.Lock()
+	this.conns[c.fd] = c
+	c.reparse = true
+	this.mu.Unlock()
+	err := syscall.EpollCtl(this.epfd, syscall.EPOLL_CTL_ADD, c.fd, &syscall.EpollEvent{Events: readEvents | syscall.EPOLLOUT, Fd: int32(c.fd)})
+	if err != nil {
+		logger.Logger.Error("Error adding connection to epoll", logger.String("error", err.Error()), logger.String("addr", c.remoteAddr.String()))
+		this.closeConn(c)
+	}
+}
+
+// removes conn from event loop and wraps its socket into blocking net.Conn
+func (this *poller) detach(c *conn) (net.Conn, error) {
+	this.unwatch(c)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, net.ErrClosed
+	}
+	c.closed = true
+	file := os.NewFile(uintptr(c.fd), c.remoteAddr.String())
+	netConn, err := net.FileConn(file)
+	file.Close()
+	if err != nil {
+		return nil, err
+	}
+	if len(c.out) > 0 {
+		_, err = netConn.Write(c.out)
+		c.out = nil
+		if err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+	return netConn, nil
+}
+
+func (this *poller) closeConn(c *conn) {
+	this.unwatch(c)
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+	c.closed = true
+	c.out = nil
+	syscall.Close(c.fd)
+	c.mu.Unlock()
+	this.loop.processor.ReleaseConnState(c.state)
+}
+
+// must be called with c.mu held
+func (this *poller) shutdownConn(c *conn) error {
+	return syscall.Shutdown(c.fd, syscall.SHUT_RDWR)
+}
+
+// wakes io thread up and makes it exit
+func (this *poller) close() {
+	_, err := syscall.Write(this.wakeW, []byte{0})
+	if err != nil && !errors.Is(err, syscall.EAGAIN) {
+		logger.Logger.Error("Error waking poller up", logger.String("error", err.Error()))
+	}
+}
+
+func (this *poller) shutdown() {
+	this.mu.Lock()
+	conns := make([]*conn, 0, len(this.conns))
+	for _, c := range this.conns {
+		conns = append(conns, c)
+	}
+	this.mu.Unlock()
+	for _, c := range conns {
+		this.closeConn(c)
+	}
+	syscall.Close(this.epfd)
+	syscall.Close(this.wakeR)
+	syscall.Close(this.wakeW)
+}
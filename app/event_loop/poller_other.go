@@ -0,0 +1,40 @@
+//go:build !linux
+
+package eventloop
+
+import (
+	"errors"
+	"net"
+)
+
+var UnsupportedPlatformError = errors.New("Error creating event loop: epoll io model is supported only on linux")
+
+type poller struct{}
+
+func newPoller(loop *EventLoop) (*poller, error) {
+	return nil, UnsupportedPlatformError
+}
+
+func (this *poller) register(c *conn, netConn net.Conn) error {
+	return UnsupportedPlatformError
+}
+
+func (this *poller) run() {}
+
+func (this *poller) write(c *conn, p []byte) error {
+	return UnsupportedPlatformError
+}
+
+func (this *poller) unwatch(c *conn) {}
+
+func (this *poller) rewatch(c *conn) {}
+
+func (this *poller) shutdownConn(c *conn) error {
+	return UnsupportedPlatformError
+}
+
+func (this *poller) detach(c *conn) (net.Conn, error) {
+	return nil, UnsupportedPlatformError
+}
+
+func (this *poller) close() {}
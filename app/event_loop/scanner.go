@@ -0,0 +1,93 @@
+package eventloop
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+
+	"github.com/codecrafters-io/redis-starter-go/app/reader"
+)
+
+// finds end of pending request without parsing it, so request is parsed once all its bytes arrived,
+// progress is kept between reads, so every byte of request is scanned once
+type requestScanner struct {
+	// offset of first byte that is not scanned yet
+	pos int
+	// amount of elements left in every array that is not complete yet
+	left []int64
+	// bytes of bulk string payload with CRLF that are not scanned yet
+	bulk int64
+}
+
+// scans bytes of in after pos, reports true once request is complete or malformed,
+// malformed request is left to parser that replies with proper protocol error
+func (this *requestScanner) scan(in []byte, limits reader.Limits) bool {
+	for {
+		if this.bulk > 0 {
+			if int64(len(in)-this.pos) < this.bulk {
+				return false
+			}
+			this.pos += int(this.bulk)
+			this.bulk = 0
+			if this.complete() {
+				return true
+			}
+			continue
+		}
+		end := bytes.IndexByte(in[this.pos:], '\n')
+		if end < 0 {
+			// parser rejects too long lines, length headers are rejected by it even earlier
+			return len(in)-this.pos > reader.MaxInlineSize+2
+		}
+		line := bytes.TrimSuffix(in[this.pos:this.pos+end], []byte("\r"))
+		this.pos += end + 1
+		if len(line) == 0 {
+			return true
+		}
+		switch line[0] {
+		case '*':
+			n, err := strconv.ParseInt(string(line[1:]), 10, 64)
+			if err != nil || n < -1 || (limits.MaxMultibulkLen > 0 && n > limits.MaxMultibulkLen) {
+				return true
+			}
+			if n > 0 {
+				this.left = append(this.left, n)
+				continue
+			}
+		case '$':
+			n, err := strconv.ParseInt(string(line[1:]), 10, 64)
+			if err != nil || n < -1 || n > math.MaxInt64-2 || (limits.MaxBulkLen > 0 && n > limits.MaxBulkLen) {
+				return true
+			}
+			if n >= 0 {
+				this.bulk = n + 2
+				continue
+			}
+		case '+', '-', ':':
+		default:
+			return true
+		}
+		if this.complete() {
+			return true
+		}
+	}
+}
+
+// counts scanned element, reports if it was the last element of request
+func (this *requestScanner) complete() bool {
+	for len(this.left) > 0 {
+		this.left[len(this.left)-1]--
+		if this.left[len(this.left)-1] > 0 {
+			return false
+		}
+		this.left = this.left[:len(this.left)-1]
+	}
+	return true
+}
+
+// starts scanning of next request
+func (this *requestScanner) reset() {
+	this.pos = 0
+	this.left = this.left[:0]
+	this.bulk = 0
+}
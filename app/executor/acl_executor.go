@@ -0,0 +1,242 @@
+package executor
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/acl"
+	"github.com/codecrafters-io/redis-starter-go/app/client"
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+	aclcommand "github.com/codecrafters-io/redis-starter-go/app/commands/acl_command"
+	authcommand "github.com/codecrafters-io/redis-starter-go/app/commands/auth_command"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+)
+
+func (this *executor) ExecuteAuth(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var username, password string
+	usernameArg, _ := cmd.Args.GetArgValue(authcommand.Username)
+	usernameArg.ToType(&username)
+	passwordArg, _ := cmd.Args.GetArgValue(authcommand.Password)
+	passwordArg.ToType(&password)
+
+	if len(cmd.Raw.Values) == 2 {
+		_, noPass := this.acl.GetDefaultUser()
+		if noPass {
+			return nil, acl.NoPasswordConfiguredError
+		}
+	}
+	err := this.authenticate(caller, username, password)
+	if err != nil {
+		return nil, err
+	}
+	return datatypes.ConstructSimpleString("OK"), nil
+}
+
+// authenticates client by AUTH or HELLO, failed attempt is logged
+func (this *executor) authenticate(caller *client.Client, username string, password string) error {
+	user, err := this.acl.Authenticate(username, password)
+	if err != nil {
+		this.acl.AddLogEntry(acl.LogEntry{
+			Reason:     acl.AuthLogReason,
+			Context:    acl.TopLevelLogContext,
+			Object:     "AUTH",
+			Username:   username,
+			ClientInfo: caller.Info(),
+		})
+		return err
+	}
+	caller.SetUser(user, true)
+	return nil
+}
+
+func (this *executor) ExecuteAcl(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var subcommand string
+	var args []string
+	subcommandArg, _ := cmd.Args.GetArgValue(aclcommand.Subcommand)
+	err := subcommandArg.ToType(&subcommand)
+	if err != nil {
+		return nil, fmt.Errorf("Error casting acl subcommand: %w", err)
+	}
+	argsArg, _ := cmd.Args.GetArgValue(aclcommand.Args)
+	err = argsArg.ToType(&args)
+	if err != nil {
+		return nil, fmt.Errorf("Error casting acl args: %w", err)
+	}
+
+	switch subcommand {
+	case aclcommand.Setuser:
+		err := this.acl.SetUser(args[0], args[1:])
+		if err != nil {
+			return nil, err
+		}
+		return datatypes.ConstructSimpleString("OK"), nil
+	case aclcommand.Getuser:
+		return this.executeAclGetuser(args[0]), nil
+	case aclcommand.Deluser:
+		deleted, err := this.acl.DelUsers(args)
+		if err != nil {
+			return nil, err
+		}
+		return datatypes.ConstructInt(deleted), nil
+	case aclcommand.List:
+		return datatypes.ConstructArray(this.acl.List()), nil
+	case aclcommand.Users:
+		return datatypes.ConstructArray(this.acl.Users()), nil
+	case aclcommand.Cat:
+		return this.executeAclCat(args)
+	case aclcommand.Log:
+		return this.executeAclLog(args)
+	case aclcommand.Dryrun:
+		return this.executeAclDryrun(args)
+	case aclcommand.Genpass:
+		return this.executeAclGenpass(args)
+	case aclcommand.Load:
+		err := this.acl.Load()
+		if err != nil {
+			return nil, err
+		}
+		return datatypes.ConstructSimpleString("OK"), nil
+	case aclcommand.Whoami:
+		user, _ := caller.GetUser()
+		return datatypes.ConstructBulkString(user.GetName()), nil
+	case aclcommand.Save:
+		err := this.acl.Save()
+		if err != nil {
+			return nil, err
+		}
+		return datatypes.ConstructSimpleString("OK"), nil
+	}
+	return nil, fmt.Errorf("ERR unknown subcommand '%v'. Try ACL HELP.", subcommand)
+}
+
+func (this *executor) executeAclGetuser(name string) *datatypes.Data {
+	info := this.acl.GetUser(name)
+	if info == nil {
+		return datatypes.ConstructNull()
+	}
+	return datatypes.ConstructArrayFromData([]*datatypes.Data{
+		datatypes.ConstructBulkString("flags"),
+		datatypes.ConstructArray(info.Flags),
+		datatypes.ConstructBulkString("passwords"),
+		datatypes.ConstructArray(info.Passwords),
+		datatypes.ConstructBulkString("commands"),
+		datatypes.ConstructBulkString(info.Commands),
+		datatypes.ConstructBulkString("keys"),
+		datatypes.ConstructBulkString(info.Keys),
+		datatypes.ConstructBulkString("channels"),
+		datatypes.ConstructBulkString(info.Channels),
+		datatypes.ConstructBulkString("selectors"),
+		datatypes.ConstructArray([]string{}),
+	})
+}
+
+func (this *executor) executeAclCat(args []string) (*datatypes.Data, error) {
+	if len(args) == 0 {
+		categories := command.GetCategories()
+		names := make([]string, len(categories))
+		for i, c := range categories {
+			names[i] = string(c)
+		}
+		return datatypes.ConstructArray(names), nil
+	}
+	category := strings.ToLower(args[0])
+	if !command.IsCategory(category) {
+		return nil, fmt.Errorf("ERR Unknown category '%v'", args[0])
+	}
+	names := []string{}
+	for _, name := range command.GetAclNames() {
+		spec, _ := command.GetSpecByAclName(name)
+		if spec.HasCategory(command.CategoryEnum(category)) {
+			names = append(names, name)
+		}
+	}
+	return datatypes.ConstructArray(names), nil
+}
+
+// ACL LOG [count | RESET]
+func (this *executor) executeAclLog(args []string) (*datatypes.Data, error) {
+	count := 10
+	if len(args) == 1 {
+		if strings.ToUpper(args[0]) == "RESET" {
+			this.acl.ResetLog()
+			return datatypes.ConstructSimpleString("OK"), nil
+		}
+		parsed, err := strconv.Atoi(args[0])
+		if err != nil || parsed < 0 {
+			return nil, errors.New("ERR value is out of range, must be positive")
+		}
+		count = parsed
+	}
+	now := time.Now()
+	entries := this.acl.GetLog(count)
+	out := make([]*datatypes.Data, len(entries))
+	for i, e := range entries {
+		out[i] = datatypes.ConstructArrayFromData([]*datatypes.Data{
+			datatypes.ConstructBulkString("count"),
+			datatypes.ConstructInt(e.Count),
+			datatypes.ConstructBulkString("reason"),
+			datatypes.ConstructBulkString(string(e.Reason)),
+			datatypes.ConstructBulkString("context"),
+			datatypes.ConstructBulkString(string(e.Context)),
+			datatypes.ConstructBulkString("object"),
+			datatypes.ConstructBulkString(e.Object),
+			datatypes.ConstructBulkString("username"),
+			datatypes.ConstructBulkString(e.Username),
+			datatypes.ConstructBulkString("age-seconds"),
+			datatypes.ConstructBulkString(strconv.FormatFloat(now.Sub(e.Created).Seconds(), 'f', 3, 64)),
+			datatypes.ConstructBulkString("client-info"),
+			datatypes.ConstructBulkString(e.ClientInfo),
+			datatypes.ConstructBulkString("entry-id"),
+			datatypes.ConstructInt(e.EntryId),
+			datatypes.ConstructBulkString("timestamp-created"),
+			datatypes.ConstructInt(int(e.Created.UnixMilli())),
+			datatypes.ConstructBulkString("timestamp-last-updated"),
+			datatypes.ConstructInt(int(e.Updated.UnixMilli())),
+		})
+	}
+	return datatypes.ConstructArrayFromData(out), nil
+}
+
+// ACL DRYRUN username command [arg ...]
+func (this *executor) executeAclDryrun(args []string) (*datatypes.Data, error) {
+	values := make([]*datatypes.Data, len(args)-1)
+	for i, a := range args[1:] {
+		values[i] = datatypes.ConstructBulkString(a)
+	}
+	cmd, err := command.DataTypeToCommand(datatypes.ConstructArrayFromData(values))
+	if err != nil {
+		return nil, fmt.Errorf("ERR Command '%v' not found", args[1])
+	}
+	denial, err := this.acl.Dryrun(args[0], cmd)
+	if err != nil {
+		return nil, err
+	}
+	if denial != "" {
+		return datatypes.ConstructBulkString(denial), nil
+	}
+	return datatypes.ConstructSimpleString("OK"), nil
+}
+
+// ACL GENPASS [bits]
+func (this *executor) executeAclGenpass(args []string) (*datatypes.Data, error) {
+	bits := 256
+	if len(args) == 1 {
+		parsed, err := strconv.Atoi(args[0])
+		if err != nil || parsed <= 0 || parsed > 4096 {
+			return nil, errors.New("ERR ACL GENPASS argument must be the number of bits for the output password, a positive number up to 4096")
+		}
+		bits = parsed
+	}
+	chars := (bits + 3) / 4
+	buf := make([]byte, (chars+1)/2)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return nil, fmt.Errorf("Error generating password: %w", err)
+	}
+	return datatypes.ConstructBulkString(hex.EncodeToString(buf)[:chars]), nil
+}
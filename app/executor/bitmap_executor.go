@@ -0,0 +1,363 @@
+package executor
+
+import (
+	"github.com/codecrafters-io/redis-starter-go/app/bitmap"
+	"github.com/codecrafters-io/redis-starter-go/app/client"
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+	bitmapcommand "github.com/codecrafters-io/redis-starter-go/app/commands/bitmap_command"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+	"github.com/codecrafters-io/redis-starter-go/app/notify"
+	"github.com/codecrafters-io/redis-starter-go/app/storage"
+)
+
+// bits can be addressed only inside of string of proto-max-bulk-len bytes
+func (this *executor) checkBitOffset(offset uint64) error {
+	if offset>>3 >= uint64(this.config.GetProtoMaxBulkLen()) {
+		return bitmapcommand.BitOffsetError
+	}
+	return nil
+}
+
+// stored strings are immutable, so SETBIT that changes bit copies the whole value once,
+// setting bits of large bitmap one by one costs O(n) per call, while redis changes string in place
+func (this *executor) ExecuteSetbit(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var key string
+	var offset, bit int
+	err := requiredArg(cmd, bitmapcommand.Key, &key)
+	if err != nil {
+		return nil, err
+	}
+	err = requiredArg(cmd, bitmapcommand.Offset, &offset)
+	if err != nil {
+		return nil, err
+	}
+	err = requiredArg(cmd, bitmapcommand.Bit, &bit)
+	if err != nil {
+		return nil, err
+	}
+	err = this.checkBitOffset(uint64(offset))
+	if err != nil {
+		return nil, err
+	}
+	var old int
+	err = this.db(caller).Update(key, func(entrie *storage.StorageValue) (*storage.StorageValue, error) {
+		current := ""
+		if entrie != nil {
+			str, ok := entrie.AsString()
+			if !ok {
+				return nil, WrongTypeError
+			}
+			current = str
+		}
+		size := offset>>3 + 1
+		if entrie != nil && size <= len(current) {
+			old = bitmap.GetBit(bitmap.Bytes(current), uint64(offset))
+			if old == bit {
+				return nil, nil
+			}
+		}
+		b := make([]byte, max(len(current), size))
+		copy(b, current)
+		b, old = bitmap.SetBit(b, uint64(offset), bit)
+		updated := storage.NewStringValue(bitmap.String(b))
+		return &updated, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	this.notifyKey(caller, notify.String, "setbit", key)
+	return datatypes.ConstructInt(old), nil
+}
+
+func (this *executor) ExecuteGetbit(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var key string
+	var offset int
+	err := requiredArg(cmd, bitmapcommand.Key, &key)
+	if err != nil {
+		return nil, err
+	}
+	err = requiredArg(cmd, bitmapcommand.Offset, &offset)
+	if err != nil {
+		return nil, err
+	}
+	err = this.checkBitOffset(uint64(offset))
+	if err != nil {
+		return nil, err
+	}
+	str, ok := this.getString(caller, key)
+	if !ok {
+		return nil, WrongTypeError
+	}
+	return datatypes.ConstructInt(bitmap.GetBit(bitmap.Bytes(str), uint64(offset))), nil
+}
+
+// byte range of BITCOUNT and BITPOS, masks select bits of first and last byte outside of BIT range
+type bitRange struct {
+	start     int
+	end       int
+	firstMask byte
+	lastMask  byte
+}
+
+// converts start and end given in bytes or bits to byte range, negative indexes count from the end,
+// ok is false if range is empty
+func toBitRange(start int, end int, length int, isBit bool) (bitRange, bool) {
+	if start < 0 && end < 0 && start > end {
+		return bitRange{}, false
+	}
+	if isBit {
+		length <<= 3
+	}
+	if start < 0 {
+		start = length + start
+	}
+	if end < 0 {
+		end = length + end
+	}
+	start = max(start, 0)
+	end = min(max(end, 0), length-1)
+	if start > end {
+		return bitRange{}, false
+	}
+	r := bitRange{start: start, end: end}
+	if isBit {
+		r.firstMask = ^byte(0xff >> (start & 7))
+		r.lastMask = byte(0xff >> (end&7 + 1))
+		r.start >>= 3
+		r.end >>= 3
+	}
+	return r, true
+}
+
+func (this *executor) rangeArgs(cmd *command.Command) (start int, end int, hasStart bool, hasEnd bool, isBit bool, err error) {
+	hasStart, err = optionalArg(cmd, bitmapcommand.Start, &start)
+	if err != nil {
+		return
+	}
+	hasEnd, err = optionalArg(cmd, bitmapcommand.End, &end)
+	if err != nil {
+		return
+	}
+	_, isBit = cmd.Args.GetArgValue(bitmapcommand.IsBit)
+	return
+}
+
+func (this *executor) ExecuteBitcount(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var key string
+	err := requiredArg(cmd, bitmapcommand.Key, &key)
+	if err != nil {
+		return nil, err
+	}
+	start, end, hasStart, _, isBit, err := this.rangeArgs(cmd)
+	if err != nil {
+		return nil, err
+	}
+	str, ok := this.getString(caller, key)
+	if !ok {
+		return nil, WrongTypeError
+	}
+	if !hasStart {
+		start, end = 0, -1
+	}
+	r, ok := toBitRange(start, end, len(str), isBit)
+	if !ok {
+		return datatypes.ConstructInt(0), nil
+	}
+	b := bitmap.Bytes(str)
+	count := bitmap.Count(b[r.start : r.end+1])
+	count -= bitmap.Count([]byte{b[r.start] & r.firstMask, b[r.end] & r.lastMask})
+	return datatypes.ConstructInt(count), nil
+}
+
+// clear bits are searched past the end of string, unless end of range is given
+func (this *executor) ExecuteBitpos(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var key string
+	var bit int
+	err := requiredArg(cmd, bitmapcommand.Key, &key)
+	if err != nil {
+		return nil, err
+	}
+	err = requiredArg(cmd, bitmapcommand.Bit, &bit)
+	if err != nil {
+		return nil, err
+	}
+	start, end, _, hasEnd, isBit, err := this.rangeArgs(cmd)
+	if err != nil {
+		return nil, err
+	}
+	entrie, exists := this.db(caller).GetEntrie(key)
+	if !exists {
+		this.notifyKey(caller, notify.KeyMiss, "keymiss", key)
+		if bit == 1 {
+			return datatypes.ConstructInt(-1), nil
+		}
+		return datatypes.ConstructInt(0), nil
+	}
+	str, ok := entrie.AsString()
+	if !ok {
+		return nil, WrongTypeError
+	}
+	if !hasEnd {
+		end = -1
+	}
+	r, ok := toBitRange(start, end, len(str), isBit)
+	if !ok {
+		return datatypes.ConstructInt(-1), nil
+	}
+	b := bitmap.Bytes(str)[r.start : r.end+1]
+	if r.firstMask != 0 || r.lastMask != 0 {
+		b = append([]byte{}, b...)
+		// bits outside of range are set to value that is not searched
+		if bit == 1 {
+			b[0] &^= r.firstMask
+			b[len(b)-1] &^= r.lastMask
+		} else {
+			b[0] |= r.firstMask
+			b[len(b)-1] |= r.lastMask
+		}
+	}
+	pos := bitmap.Pos(b, bit)
+	if pos == -1 && bit == 0 && !hasEnd {
+		pos = len(b) * 8
+	}
+	if pos != -1 {
+		pos += r.start * 8
+	}
+	return datatypes.ConstructInt(pos), nil
+}
+
+// destination is deleted if result is empty
+func (this *executor) ExecuteBitop(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var op, destKey string
+	var keys []string
+	err := requiredArg(cmd, bitmapcommand.Op, &op)
+	if err != nil {
+		return nil, err
+	}
+	err = requiredArg(cmd, bitmapcommand.DestKey, &destKey)
+	if err != nil {
+		return nil, err
+	}
+	err = requiredArg(cmd, bitmapcommand.Keys, &keys)
+	if err != nil {
+		return nil, err
+	}
+	srcs := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		str, ok := this.getString(caller, key)
+		if !ok {
+			return nil, WrongTypeError
+		}
+		srcs = append(srcs, bitmap.Bytes(str))
+	}
+	res := bitmap.Apply(bitmap.OpEnum(op), srcs)
+	if len(res) == 0 {
+		if this.db(caller).Delete(destKey) {
+			this.notifyKey(caller, notify.Generic, "del", destKey)
+		}
+		return datatypes.ConstructInt(0), nil
+	}
+	this.db(caller).SetMany([]string{destKey}, []storage.StorageValue{storage.NewStringValue(bitmap.String(res))}, false)
+	this.notifyKey(caller, notify.String, "set", destKey)
+	return datatypes.ConstructInt(len(res)), nil
+}
+
+// executes BITFIELD and BITFIELD_RO, writes grow string to fit field, operations that fail with FAIL overflow reply null
+func (this *executor) ExecuteBitfield(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var key string
+	var ops []bitmapcommand.BitfieldOp
+	err := requiredArg(cmd, bitmapcommand.Key, &key)
+	if err != nil {
+		return nil, err
+	}
+	err = requiredArg(cmd, bitmapcommand.Ops, &ops)
+	if err != nil {
+		return nil, err
+	}
+	write := false
+	for _, op := range ops {
+		err = this.checkBitOffset(op.Offset + uint64(op.Bits) - 1)
+		if err != nil {
+			return nil, err
+		}
+		write = write || op.Op != bitmapcommand.BitfieldGet
+	}
+
+	res := make([]*datatypes.Data, 0, len(ops))
+	err = this.db(caller).Update(key, func(entrie *storage.StorageValue) (*storage.StorageValue, error) {
+		current := ""
+		if entrie != nil {
+			str, ok := entrie.AsString()
+			if !ok {
+				return nil, WrongTypeError
+			}
+			current = str
+		}
+		// writes work on the only copy of value, that is stored without copying it again
+		b := bitmap.Bytes(current)
+		if write {
+			b = []byte(current)
+		}
+		for _, op := range ops {
+			if op.Op != bitmapcommand.BitfieldGet {
+				if size := int((op.Offset + uint64(op.Bits) + 7) >> 3); len(b) < size {
+					b = append(b, make([]byte, size-len(b))...)
+				}
+			}
+			res = append(res, executeBitfieldOp(b, op))
+		}
+		if !write {
+			return nil, nil
+		}
+		updated := storage.NewStringValue(bitmap.String(b))
+		return &updated, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if write {
+		this.notifyKey(caller, notify.String, "setbit", key)
+	}
+	return datatypes.ConstructArrayFromData(res), nil
+}
+
+// GET replies value of field, SET replies previous value and INCRBY new value
+func executeBitfieldOp(b []byte, op bitmapcommand.BitfieldOp) *datatypes.Data {
+	if op.Signed {
+		old := bitmap.GetSigned(b, op.Offset, op.Bits)
+		if op.Op == bitmapcommand.BitfieldGet {
+			return datatypes.ConstructInt(int(old))
+		}
+		value, incr := op.Value, int64(0)
+		if op.Op == bitmapcommand.BitfieldIncrby {
+			value, incr = old, op.Value
+		}
+		res, ok := bitmap.AddSigned(value, incr, op.Bits, op.Overflow)
+		if !ok {
+			return datatypes.ConstructNull()
+		}
+		bitmap.SetField(b, op.Offset, op.Bits, uint64(res))
+		if op.Op == bitmapcommand.BitfieldSet {
+			return datatypes.ConstructInt(int(old))
+		}
+		return datatypes.ConstructInt(int(res))
+	}
+
+	old := bitmap.GetUnsigned(b, op.Offset, op.Bits)
+	if op.Op == bitmapcommand.BitfieldGet {
+		return datatypes.ConstructInt(int(old))
+	}
+	value, incr := uint64(op.Value), int64(0)
+	if op.Op == bitmapcommand.BitfieldIncrby {
+		value, incr = old, op.Value
+	}
+	res, ok := bitmap.AddUnsigned(value, incr, op.Bits, op.Overflow)
+	if !ok {
+		return datatypes.ConstructNull()
+	}
+	bitmap.SetField(b, op.Offset, op.Bits, res)
+	if op.Op == bitmapcommand.BitfieldSet {
+		return datatypes.ConstructInt(int(old))
+	}
+	return datatypes.ConstructInt(int(res))
+}
@@ -0,0 +1,63 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+	"github.com/codecrafters-io/redis-starter-go/app/commands"
+	bitmapcommand "github.com/codecrafters-io/redis-starter-go/app/commands/bitmap_command"
+)
+
+func TestRequiredArg_ReportsMissingAndMistypedArgs(t *testing.T) {
+	args := commands.NewArgs()
+	args.SetArgValue(bitmapcommand.Key, commands.NewStringArgValue("k"))
+	cmd := &command.Command{Type: command.SETBIT, Args: args}
+
+	var key string
+	if err := requiredArg(cmd, bitmapcommand.Key, &key); err != nil || key != "k" {
+		t.Errorf("expected key arg to be k, got %q with error %v", key, err)
+	}
+	var offset int
+	if err := requiredArg(cmd, bitmapcommand.Offset, &offset); err != commands.GetUnknowArgError {
+		t.Errorf("expected GetUnknowArgError for missing arg, got %v", err)
+	}
+	if err := requiredArg(cmd, bitmapcommand.Key, &offset); err != commands.WrongArgTypeCastError {
+		t.Errorf("expected WrongArgTypeCastError for string arg cast to int, got %v", err)
+	}
+	if given, err := optionalArg(cmd, bitmapcommand.Start, &offset); given || err != nil {
+		t.Errorf("expected missing optional arg not to be given, got %v with error %v", given, err)
+	}
+	if given, err := optionalArg(cmd, bitmapcommand.Key, &offset); !given || err != commands.WrongArgTypeCastError {
+		t.Errorf("expected WrongArgTypeCastError for given optional arg, got %v with error %v", given, err)
+	}
+}
+
+func TestSetbit(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.newClient()
+	cases := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"SETBIT", "k", "7", "1"}, ":0\r\n"},
+		{[]string{"GET", "k"}, "$1\r\n\x01\r\n"},
+		// unchanged bit leaves value as it is
+		{[]string{"SETBIT", "k", "7", "1"}, ":1\r\n"},
+		{[]string{"SETBIT", "k", "6", "0"}, ":0\r\n"},
+		{[]string{"GET", "k"}, "$1\r\n\x01\r\n"},
+		{[]string{"SETBIT", "k", "17", "1"}, ":0\r\n"},
+		{[]string{"GET", "k"}, "$3\r\n\x01\x00\x40\r\n"},
+		{[]string{"SETBIT", "k", "7", "0"}, ":1\r\n"},
+		{[]string{"GET", "k"}, "$3\r\n\x00\x00\x40\r\n"},
+		// clearing bit of missing key creates it
+		{[]string{"SETBIT", "z", "9", "0"}, ":0\r\n"},
+		{[]string{"GET", "z"}, "$2\r\n\x00\x00\r\n"},
+		{[]string{"XADD", "s", "1-1", "f", "v"}, "$3\r\n1-1\r\n"},
+		{[]string{"SETBIT", "s", "0", "1"}, "-" + WrongTypeError.Error() + "\r\n"},
+	}
+	for _, tc := range cases {
+		if got := string(srv.run(c, tc.args...).Marshall()); got != tc.want {
+			t.Errorf("expected %v to reply %q, got %q", tc.args, tc.want, got)
+		}
+	}
+}
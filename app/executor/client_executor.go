@@ -0,0 +1,267 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/client"
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+	clientcommand "github.com/codecrafters-io/redis-starter-go/app/commands/client_command"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+)
+
+var ClientSyntaxError = errors.New("ERR syntax error")
+
+func (this *executor) ExecuteClient(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var subcommand string
+	var args []string
+	subcommandArg, _ := cmd.Args.GetArgValue(clientcommand.Subcommand)
+	err := subcommandArg.ToType(&subcommand)
+	if err != nil {
+		return nil, fmt.Errorf("Error casting client subcommand: %w", err)
+	}
+	argsArg, _ := cmd.Args.GetArgValue(clientcommand.Args)
+	err = argsArg.ToType(&args)
+	if err != nil {
+		return nil, fmt.Errorf("Error casting client args: %w", err)
+	}
+
+	switch subcommand {
+	case clientcommand.Id:
+		return datatypes.ConstructInt(int(caller.GetId())), nil
+	case clientcommand.Info:
+		return datatypes.ConstructBulkString(caller.Info() + "\n"), nil
+	case clientcommand.List:
+		return this.executeClientList(args)
+	case clientcommand.Setname:
+		err := caller.SetName(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return datatypes.ConstructSimpleString("OK"), nil
+	case clientcommand.Getname:
+		name := caller.GetName()
+		if name == "" {
+			return datatypes.ConstructNull(), nil
+		}
+		return datatypes.ConstructBulkString(name), nil
+	case clientcommand.Kill:
+		return this.executeClientKill(caller, args)
+	case clientcommand.Pause:
+		return this.executeClientPause(args)
+	case clientcommand.Unpause:
+		this.clients.Unpause()
+		return datatypes.ConstructSimpleString("OK"), nil
+	case clientcommand.NoEvict, clientcommand.NoTouch:
+		on, err := parseOnOff(args[0])
+		if err != nil {
+			return nil, err
+		}
+		if subcommand == clientcommand.NoEvict {
+			caller.SetNoEvict(on)
+		} else {
+			caller.SetNoTouch(on)
+		}
+		return datatypes.ConstructSimpleString("OK"), nil
+	case clientcommand.Reply:
+		mode := client.ReplyModeEnum(strings.ToUpper(args[0]))
+		if mode != client.ReplyOn && mode != client.ReplyOff && mode != client.ReplySkip {
+			return nil, ClientSyntaxError
+		}
+		caller.SetReplyMode(mode)
+		return datatypes.ConstructSimpleString("OK"), nil
+	case clientcommand.Unblock:
+		return this.executeClientUnblock(args)
+	}
+	return nil, fmt.Errorf("ERR unknown subcommand '%v'. Try CLIENT HELP.", subcommand)
+}
+
+func parseOnOff(arg string) (bool, error) {
+	switch strings.ToUpper(arg) {
+	case "ON":
+		return true, nil
+	case "OFF":
+		return false, nil
+	}
+	return false, ClientSyntaxError
+}
+
+// redis accepts slave as alias of replica
+func parseClientType(arg string) (client.TypeEnum, error) {
+	typ := strings.ToLower(arg)
+	switch typ {
+	case "slave":
+		return client.ReplicaType, nil
+	case string(client.NormalType), string(client.MasterType), string(client.ReplicaType), string(client.PubsubType):
+		return client.TypeEnum(typ), nil
+	}
+	return "", fmt.Errorf("ERR Unknown client type '%v'", arg)
+}
+
+// CLIENT LIST [TYPE type] [ID id [id ...]]
+func (this *executor) executeClientList(args []string) (*datatypes.Data, error) {
+	var typ client.TypeEnum
+	var ids map[int64]bool
+	for i := 0; i < len(args); {
+		switch strings.ToUpper(args[i]) {
+		case "TYPE":
+			if i+1 >= len(args) {
+				return nil, ClientSyntaxError
+			}
+			parsed, err := parseClientType(args[i+1])
+			if err != nil {
+				return nil, err
+			}
+			typ = parsed
+			i += 2
+		case "ID":
+			if i+1 >= len(args) {
+				return nil, ClientSyntaxError
+			}
+			ids = map[int64]bool{}
+			for i++; i < len(args); i++ {
+				id, err := strconv.ParseInt(args[i], 10, 64)
+				if err != nil || id <= 0 {
+					return nil, errors.New("ERR Invalid client ID")
+				}
+				ids[id] = true
+			}
+		default:
+			return nil, ClientSyntaxError
+		}
+	}
+	clients := this.clients.List(func(c *client.Client) bool {
+		if typ != "" && c.GetType() != typ {
+			return false
+		}
+		return ids == nil || ids[c.GetId()]
+	})
+	var sb strings.Builder
+	for _, c := range clients {
+		sb.WriteString(c.Info())
+		sb.WriteByte('\n')
+	}
+	return datatypes.ConstructBulkString(sb.String()), nil
+}
+
+// CLIENT KILL ip:port or CLIENT KILL filter value [filter value ...]
+func (this *executor) executeClientKill(caller *client.Client, args []string) (*datatypes.Data, error) {
+	if len(args) == 1 {
+		clients := this.clients.List(func(c *client.Client) bool { return c.GetAddr() == args[0] })
+		if len(clients) == 0 {
+			return nil, errors.New("ERR No such client")
+		}
+		clients[0].Kill(caller)
+		return datatypes.ConstructSimpleString("OK"), nil
+	}
+	if len(args)%2 != 0 {
+		return nil, ClientSyntaxError
+	}
+	filters := []func(c *client.Client) bool{}
+	skipMe := true
+	for i := 0; i < len(args); i += 2 {
+		value := args[i+1]
+		switch strings.ToUpper(args[i]) {
+		case "ID":
+			id, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || id <= 0 {
+				return nil, errors.New("ERR client-id should be greater than 0")
+			}
+			filters = append(filters, func(c *client.Client) bool { return c.GetId() == id })
+		case "ADDR":
+			filters = append(filters, func(c *client.Client) bool { return c.GetAddr() == value })
+		case "LADDR":
+			filters = append(filters, func(c *client.Client) bool { return c.GetLocalAddr() == value })
+		case "USER":
+			if this.acl.GetUser(value) == nil {
+				return nil, fmt.Errorf("ERR No such user '%v'", value)
+			}
+			filters = append(filters, func(c *client.Client) bool {
+				user, _ := c.GetUser()
+				return user != nil && user.GetName() == value
+			})
+		case "TYPE":
+			typ, err := parseClientType(value)
+			if err != nil {
+				return nil, err
+			}
+			filters = append(filters, func(c *client.Client) bool { return c.GetType() == typ })
+		case "SKIPME":
+			switch strings.ToLower(value) {
+			case "yes":
+				skipMe = true
+			case "no":
+				skipMe = false
+			default:
+				return nil, ClientSyntaxError
+			}
+		case "MAXAGE":
+			seconds, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, ClientSyntaxError
+			}
+			maxAge := time.Duration(seconds) * time.Second
+			filters = append(filters, func(c *client.Client) bool { return c.GetAge() >= maxAge })
+		default:
+			return nil, ClientSyntaxError
+		}
+	}
+	clients := this.clients.List(func(c *client.Client) bool {
+		if skipMe && c == caller {
+			return false
+		}
+		for _, filter := range filters {
+			if !filter(c) {
+				return false
+			}
+		}
+		return true
+	})
+	for _, c := range clients {
+		c.Kill(caller)
+	}
+	return datatypes.ConstructInt(len(clients)), nil
+}
+
+// CLIENT PAUSE timeout [WRITE|ALL]
+func (this *executor) executeClientPause(args []string) (*datatypes.Data, error) {
+	timeout, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || timeout < 0 {
+		return nil, errors.New("ERR timeout is not an integer or out of range")
+	}
+	all := true
+	if len(args) == 2 {
+		switch strings.ToUpper(args[1]) {
+		case "WRITE":
+			all = false
+		case "ALL":
+		default:
+			return nil, errors.New("ERR CLIENT PAUSE mode must be WRITE or ALL")
+		}
+	}
+	this.clients.Pause(time.Duration(timeout)*time.Millisecond, all)
+	return datatypes.ConstructSimpleString("OK"), nil
+}
+
+// CLIENT UNBLOCK id [TIMEOUT|ERROR]
+func (this *executor) executeClientUnblock(args []string) (*datatypes.Data, error) {
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return nil, errors.New("ERR value is not an integer or out of range")
+	}
+	reason := client.UnblockTimeout
+	if len(args) == 2 {
+		reason = client.UnblockReasonEnum(strings.ToUpper(args[1]))
+		if reason != client.UnblockTimeout && reason != client.UnblockError {
+			return nil, errors.New("ERR CLIENT UNBLOCK reason should be TIMEOUT or ERROR")
+		}
+	}
+	target, ok := this.clients.Get(id)
+	if !ok || !target.Unblock(reason) {
+		return datatypes.ConstructInt(0), nil
+	}
+	return datatypes.ConstructInt(1), nil
+}
@@ -3,8 +3,13 @@ package executor
 import (
 	"errors"
 	"fmt"
+	"math"
+	"math/big"
 	"strconv"
+	"time"
 
+	"github.com/codecrafters-io/redis-starter-go/app/acl"
+	"github.com/codecrafters-io/redis-starter-go/app/client"
 	"github.com/codecrafters-io/redis-starter-go/app/command"
 	"github.com/codecrafters-io/redis-starter-go/app/commands"
 	incrcommand "github.com/codecrafters-io/redis-starter-go/app/commands/incr_command"
@@ -17,7 +22,12 @@ import (
 	"github.com/codecrafters-io/redis-starter-go/app/config"
 	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
 	"github.com/codecrafters-io/redis-starter-go/app/encoder"
+	"github.com/codecrafters-io/redis-starter-go/app/glob"
 	"github.com/codecrafters-io/redis-starter-go/app/logger"
+	"github.com/codecrafters-io/redis-starter-go/app/notify"
+	"github.com/codecrafters-io/redis-starter-go/app/pubsub"
+	"github.com/codecrafters-io/redis-starter-go/app/shutdown"
+	"github.com/codecrafters-io/redis-starter-go/app/slots"
 	"github.com/codecrafters-io/redis-starter-go/app/storage"
 	"github.com/codecrafters-io/redis-starter-go/app/stream"
 	"github.com/codecrafters-io/redis-starter-go/app/types"
@@ -27,48 +37,177 @@ type CommandProcessor interface {
 	ProcessCmd(cmd *command.Command) (*datatypes.Data, error)
 }
 
+// processor of command that blocks until interrupt is closed, e.g. WAIT unblocked by CLIENT UNBLOCK
+type BlockingCommandProcessor interface {
+	ProcessBlockingCmd(cmd *command.Command, interrupt <-chan struct{}) (*datatypes.Data, error)
+}
+
+// performs SHUTDOWN, on success process exits, so only errors are returned
+type Shutdowner interface {
+	Run(options shutdown.Options) error
+	Abort() error
+}
+
 type CommandExecutor interface {
-	ExecuteCmd(cmd *command.Command, shouldRespond bool) *datatypes.Data
+	ExecuteCmd(caller *client.Client, cmd *command.Command, shouldRespond bool) *datatypes.Data
 }
 
 type executor struct {
 	counter          CommandProcessor
-	replica_prosesor CommandProcessor
-	storage          storage.Storage
+	replica_prosesor BlockingCommandProcessor
+	dbs              *storage.Databases
 	config           *config.Config
+	acl              *acl.Acl
+	clients          *client.Table
+	shutdown         Shutdowner
+	pubsub           *pubsub.Pubsub
+	slots            *slots.Table
+	notifier         *notify.Notifier
+	streamWaiters    *streamKeyWaiters
 }
 
 func New(
 	counter CommandProcessor,
-	replica_prosesor CommandProcessor,
-	storage storage.Storage,
+	replica_prosesor BlockingCommandProcessor,
+	dbs *storage.Databases,
 	config *config.Config,
+	acl *acl.Acl,
+	clients *client.Table,
+	shutdown Shutdowner,
+	pubsub *pubsub.Pubsub,
+	slots *slots.Table,
+	notifier *notify.Notifier,
 ) CommandExecutor {
 	return &executor{
 		counter:          counter,
 		replica_prosesor: replica_prosesor,
-		storage:          storage,
+		dbs:              dbs,
 		config:           config,
+		acl:              acl,
+		clients:          clients,
+		shutdown:         shutdown,
+		pubsub:           pubsub,
+		slots:            slots,
+		notifier:         notifier,
+		streamWaiters:    newStreamKeyWaiters(),
+	}
+}
+
+// returns database selected by client
+func (this *executor) db(caller *client.Client) storage.Storage {
+	return this.dbs.Get(caller.GetDb())
+}
+
+// casts value of arg that parser always sets to v
+func requiredArg(cmd *command.Command, key string, v any) error {
+	arg, ok := cmd.Args.GetArgValue(key)
+	if !ok {
+		return commands.GetUnknowArgError
+	}
+	return arg.ToType(v)
+}
+
+// casts value of arg to v if it was given, reports if it was given
+func optionalArg(cmd *command.Command, key string, v any) (bool, error) {
+	arg, ok := cmd.Args.GetArgValue(key)
+	if !ok {
+		return false, nil
 	}
+	return true, arg.ToType(v)
+}
+
+// publishes keyspace event about key of database selected by client
+func (this *executor) notifyKey(caller *client.Client, class notify.Class, event string, key string) {
+	this.notifier.Notify(class, event, key, caller.GetDb())
 }
 
-type ExecuteFunc = func(*executor, *command.Command) (*datatypes.Data, error)
+type ExecuteFunc = func(*executor, *client.Client, *command.Command) (*datatypes.Data, error)
 
 var commantToExecuteMap = map[command.CommandEnum]ExecuteFunc{
-	command.SET:      (*executor).ExecuteSet,
-	command.INFO:     (*executor).ExecuteInfo,
-	command.CONFIG:   (*executor).ExecuteConfig,
-	command.ECHO:     (*executor).ExecuteEcho,
-	command.PING:     (*executor).ExecutePing,
-	command.GET:      (*executor).ExecuteGet,
-	command.WAIT:     (*executor).ExecuteWait,
-	command.KEYS:     (*executor).ExecuteKeys,
-	command.REPLCONF: (*executor).ExecuteReplConf,
-	command.TYPE:     (*executor).ExecuteType,
-	command.XADD:     (*executor).ExecuteXadd,
-	command.XRANGE:   (*executor).ExecuteXrange,
-	command.XREAD:    (*executor).ExecuteXRead,
-	command.INCR:     (*executor).ExecuteIncr,
+	command.SET:                  (*executor).ExecuteSet,
+	command.INFO:                 (*executor).ExecuteInfo,
+	command.CONFIG:               (*executor).ExecuteConfig,
+	command.ECHO:                 (*executor).ExecuteEcho,
+	command.PING:                 (*executor).ExecutePing,
+	command.GET:                  (*executor).ExecuteGet,
+	command.WAIT:                 (*executor).ExecuteWait,
+	command.KEYS:                 (*executor).ExecuteKeys,
+	command.REPLCONF:             (*executor).ExecuteReplConf,
+	command.TYPE:                 (*executor).ExecuteType,
+	command.XADD:                 (*executor).ExecuteXadd,
+	command.XRANGE:               (*executor).ExecuteXrange,
+	command.XREVRANGE:            (*executor).ExecuteXrange,
+	command.XREAD:                (*executor).ExecuteXRead,
+	command.INCR:                 (*executor).ExecuteIncr,
+	command.INCRBY:               (*executor).ExecuteIncr,
+	command.DECR:                 (*executor).ExecuteIncr,
+	command.DECRBY:               (*executor).ExecuteIncr,
+	command.INCRBYFLOAT:          (*executor).ExecuteIncrbyfloat,
+	command.AUTH:                 (*executor).ExecuteAuth,
+	command.ACL:                  (*executor).ExecuteAcl,
+	command.CLIENT:               (*executor).ExecuteClient,
+	command.SHUTDOWN:             (*executor).ExecuteShutdown,
+	command.SELECT:               (*executor).ExecuteSelect,
+	command.MOVE:                 (*executor).ExecuteMove,
+	command.SWAPDB:               (*executor).ExecuteSwapdb,
+	command.DBSIZE:               (*executor).ExecuteDbsize,
+	command.FLUSHDB:              (*executor).ExecuteFlushdb,
+	command.FLUSHALL:             (*executor).ExecuteFlushall,
+	command.SCAN:                 (*executor).ExecuteScan,
+	command.HSCAN:                (*executor).ExecuteKeyScan,
+	command.SSCAN:                (*executor).ExecuteKeyScan,
+	command.ZSCAN:                (*executor).ExecuteKeyScan,
+	command.APPEND:               (*executor).ExecuteAppend,
+	command.STRLEN:               (*executor).ExecuteStrlen,
+	command.GETRANGE:             (*executor).ExecuteGetrange,
+	command.SETRANGE:             (*executor).ExecuteSetrange,
+	command.MGET:                 (*executor).ExecuteMget,
+	command.MSET:                 (*executor).ExecuteMset,
+	command.MSETNX:               (*executor).ExecuteMsetnx,
+	command.LCS:                  (*executor).ExecuteLcs,
+	command.SETBIT:               (*executor).ExecuteSetbit,
+	command.GETBIT:               (*executor).ExecuteGetbit,
+	command.BITCOUNT:             (*executor).ExecuteBitcount,
+	command.BITPOS:               (*executor).ExecuteBitpos,
+	command.BITOP:                (*executor).ExecuteBitop,
+	command.BITFIELD:             (*executor).ExecuteBitfield,
+	command.BITFIELD_RO:          (*executor).ExecuteBitfield,
+	command.PFADD:                (*executor).ExecutePfadd,
+	command.PFCOUNT:              (*executor).ExecutePfcount,
+	command.PFMERGE:              (*executor).ExecutePfmerge,
+	command.GEOADD:               (*executor).ExecuteGeoadd,
+	command.GEODIST:              (*executor).ExecuteGeodist,
+	command.GEOPOS:               (*executor).ExecuteGeopos,
+	command.GEOHASH:              (*executor).ExecuteGeohash,
+	command.GEOSEARCH:            (*executor).ExecuteGeosearch,
+	command.GEOSEARCHSTORE:       (*executor).ExecuteGeosearch,
+	command.GEORADIUS:            (*executor).ExecuteGeosearch,
+	command.GEORADIUS_RO:         (*executor).ExecuteGeosearch,
+	command.GEORADIUSBYMEMBER:    (*executor).ExecuteGeosearch,
+	command.GEORADIUSBYMEMBER_RO: (*executor).ExecuteGeosearch,
+	command.XGROUP:               (*executor).ExecuteXgroup,
+	command.XREADGROUP:           (*executor).ExecuteXreadgroup,
+	command.XACK:                 (*executor).ExecuteXack,
+	command.XPENDING:             (*executor).ExecuteXpending,
+	command.XCLAIM:               (*executor).ExecuteXclaim,
+	command.XAUTOCLAIM:           (*executor).ExecuteXautoclaim,
+	command.XLEN:                 (*executor).ExecuteXlen,
+	command.XDEL:                 (*executor).ExecuteXdel,
+	command.XINFO:                (*executor).ExecuteXinfo,
+	command.XSETID:               (*executor).ExecuteXsetid,
+	command.SUBSCRIBE:            (*executor).ExecuteSubscribe,
+	command.PSUBSCRIBE:           (*executor).ExecuteSubscribe,
+	command.UNSUBSCRIBE:          (*executor).ExecuteUnsubscribe,
+	command.PUNSUBSCRIBE:         (*executor).ExecuteUnsubscribe,
+	command.SSUBSCRIBE:           (*executor).ExecuteSubscribe,
+	command.SUNSUBSCRIBE:         (*executor).ExecuteUnsubscribe,
+	command.SPUBLISH:             (*executor).ExecutePublish,
+	command.PUBLISH:              (*executor).ExecutePublish,
+	command.PUBSUB:               (*executor).ExecutePubsub,
+	command.HELLO:                (*executor).ExecuteHello,
+	command.QUIT:                 (*executor).ExecuteQuit,
+	command.RESET:                (*executor).ExecuteReset,
+	command.XTRIM:                (*executor).ExecuteXtrim,
 }
 
 func (this *executor) mathcCommandToExecuteFunc(cmd *command.Command) (exec ExecuteFunc, ok bool) {
@@ -76,12 +215,18 @@ func (this *executor) mathcCommandToExecuteFunc(cmd *command.Command) (exec Exec
 	return exec, ok
 }
 
-func (this *executor) ExecuteCmd(cmd *command.Command, shouldRespond bool) *datatypes.Data {
+func (this *executor) ExecuteCmd(caller *client.Client, cmd *command.Command, shouldRespond bool) *datatypes.Data {
 	logger.Logger.Info("execute command", logger.String("command", string(cmd.Type)))
 	exec, ok := this.mathcCommandToExecuteFunc(cmd)
 	if !ok {
 		return datatypes.ConstructSimpleError(fmt.Sprintf("not found command implimentation %v", cmd.Type))
 	}
+	if cmd.ArgsErr != nil {
+		if !shouldRespond {
+			return nil
+		}
+		return datatypes.ConstructSimpleError(cmd.ArgsErr.Error())
+	}
 	counterRes, err := this.counter.ProcessCmd(cmd)
 	if err != nil {
 		return datatypes.ConstructSimpleError(err.Error())
@@ -89,7 +234,7 @@ func (this *executor) ExecuteCmd(cmd *command.Command, shouldRespond bool) *data
 	if counterRes != nil {
 		return counterRes
 	}
-	res, err := exec(this, cmd)
+	res, err := exec(this, caller, cmd)
 	if !shouldRespond {
 		return nil
 	}
@@ -99,58 +244,68 @@ func (this *executor) ExecuteCmd(cmd *command.Command, shouldRespond bool) *data
 	return res
 }
 
-func (this *executor) ExecuteSet(cmd *command.Command) (*datatypes.Data, error) {
+func (this *executor) ExecuteSet(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
 	args, err := cmd.GetSetArgs()
 	if err != nil {
 		return nil, err
 	}
 
-	intVal, err := strconv.Atoi(args.Value)
-	var constructedEntrie storage.StorageValue
-	if err != nil {
-		constructedEntrie = storage.NewStringValue(args.Value)
-	} else {
-		constructedEntrie = storage.NewIntValue(intVal)
-	}
+	constructedEntrie := storage.NewValueFromString(args.Value)
 
 	if args.Px != -1 {
-		err = this.storage.SetExp(args.Key, constructedEntrie, args.Px)
+		err = this.db(caller).SetExp(args.Key, constructedEntrie, args.Px)
 	} else {
-		err = this.storage.Set(args.Key, constructedEntrie)
+		err = this.db(caller).Set(args.Key, constructedEntrie)
 		logger.Logger.Debug("set storageval", logger.String("key", args.Key), logger.String("value", args.Value))
 	}
 
 	if err != nil {
 		return nil, err
 	}
+	this.notifyKey(caller, notify.String, "set", args.Key)
+	if args.Px != -1 {
+		this.notifyKey(caller, notify.Generic, "expire", args.Key)
+	}
 
 	return datatypes.ConstructSimpleString("OK"), nil
 }
 
-func (this *executor) ExecuteGet(cmd *command.Command) (*datatypes.Data, error) {
+func (this *executor) ExecuteGet(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
 	getArgs, err := cmd.GetGetArgs()
 	if err != nil {
 		return nil, err
 	}
 
-	val, err := this.storage.Get(getArgs.Key)
+	val, err := this.db(caller).Get(getArgs.Key)
 	logger.Logger.Debug("get storageval", logger.String("key", getArgs.Key), logger.String("value", val))
 	if err != nil {
 		return nil, err
 	}
 
 	if val == "" {
+		this.notifyKey(caller, notify.KeyMiss, "keymiss", getArgs.Key)
 		return datatypes.ConstructNull(), nil
 	}
 
 	return datatypes.ConstructBulkString(val), nil
 }
 
-func (this *executor) ExecutePing(cmd *command.Command) (*datatypes.Data, error) {
+// PING [message], subscribed RESP2 client is replied with array like pubsub message
+func (this *executor) ExecutePing(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	message, hasMessage := "", false
+	if cmd.Raw != nil && len(cmd.Raw.Values) > 1 {
+		message, hasMessage = cmd.Raw.Values[1].Value, true
+	}
+	if caller.IsSubscribedMode() {
+		return datatypes.ConstructArray([]string{"pong", message}), nil
+	}
+	if hasMessage {
+		return datatypes.ConstructBulkString(message), nil
+	}
 	return datatypes.ConstructSimpleString("PONG"), nil
 }
 
-func (this *executor) ExecuteEcho(cmd *command.Command) (*datatypes.Data, error) {
+func (this *executor) ExecuteEcho(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
 	echo, err := cmd.GetEchoArgs()
 	if err != nil {
 		return nil, err
@@ -158,7 +313,7 @@ func (this *executor) ExecuteEcho(cmd *command.Command) (*datatypes.Data, error)
 	return datatypes.ConstructBulkString(echo.Echo), nil
 }
 
-func (this *executor) ExecuteInfo(cmd *command.Command) (*datatypes.Data, error) {
+func (this *executor) ExecuteInfo(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
 	args, ok := cmd.Args.GetArgValue(infocommand.Type)
 	if !ok {
 		return nil, commands.GetUnknowArgError
@@ -172,33 +327,62 @@ func (this *executor) ExecuteInfo(cmd *command.Command) (*datatypes.Data, error)
 	case infocommand.REPLICATION:
 		repInfo := this.config.GetReplicationInfo()
 		return datatypes.ConstructBulkString(encoder.EncodeKvs(repInfo)), nil
+	case infocommand.STATS:
+		stats := append(this.clients.GetInfo(), this.pubsub.GetInfo()...)
+		return datatypes.ConstructBulkString(encoder.EncodeKvs(stats)), nil
+	case infocommand.KEYSPACE:
+		return datatypes.ConstructBulkString(encoder.EncodeKvs(this.dbs.GetInfo())), nil
 	case infocommand.ALL:
-		allInfo := this.config.GetAllInfo()
+		allInfo := append(this.config.GetAllInfo(), this.clients.GetInfo()...)
+		allInfo = append(allInfo, this.pubsub.GetInfo()...)
+		allInfo = append(allInfo, this.dbs.GetInfo()...)
 		return datatypes.ConstructBulkString(encoder.EncodeKvs(allInfo)), nil
 	}
 	return nil, fmt.Errorf("executing info Error: unknown info type: %v", infoType)
 }
 
-func (this *executor) ExecuteConfig(cmd *command.Command) (*datatypes.Data, error) {
+func (this *executor) ExecuteConfig(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
 	args, err := cmd.GetConfigArgs()
 	if err != nil {
 		return nil, err
 	}
-	res := make([]string, 0, len(args.Args)*2)
-	for _, arg := range args.Args {
-		switch arg {
-		case command.Dir:
-			res = append(res, command.Dir, this.config.GetServerDbDir())
-		case command.Dbfilename:
-			res = append(res, command.Dbfilename, this.config.GetServerDbFileName())
+	switch args.Subcommand {
+	case command.ConfigGet:
+		res := make([]string, 0, len(args.Args)*2)
+		// parameter matched by several patterns is returned once
+		seen := map[string]bool{}
+		for _, arg := range args.Args {
+			for _, kv := range this.config.MatchParams(string(arg)) {
+				if !seen[kv[0]] {
+					seen[kv[0]] = true
+					res = append(res, kv[0], kv[1])
+				}
+			}
+		}
+		return datatypes.ConstructArray(res), nil
+	case command.ConfigSet:
+		if len(args.Args)%2 != 0 {
+			return nil, errors.New("ERR wrong number of arguments for 'config|set' command")
+		}
+		kvs := make([]types.Kv, 0, len(args.Args)/2)
+		for i := 0; i < len(args.Args); i += 2 {
+			kvs = append(kvs, types.Kv{string(args.Args[i]), string(args.Args[i+1])})
+		}
+		err = this.config.SetParams(kvs)
+		if err != nil {
+			return nil, err
 		}
+		return datatypes.ConstructSimpleString("OK"), nil
 	}
-
-	return datatypes.ConstructArray(res), nil
+	return nil, fmt.Errorf("ERR unknown subcommand '%v'. Try CONFIG HELP.", args.Subcommand)
 }
 
-func (this *executor) ExecuteWait(cmd *command.Command) (*datatypes.Data, error) {
-	res, err := this.replica_prosesor.ProcessCmd(cmd)
+func (this *executor) ExecuteWait(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	unblocked := caller.Block()
+	res, err := this.replica_prosesor.ProcessBlockingCmd(cmd, unblocked)
+	if caller.EndBlock() == client.UnblockError {
+		return nil, client.UnblockedError
+	}
 
 	if err != nil {
 		return nil, err
@@ -207,7 +391,7 @@ func (this *executor) ExecuteWait(cmd *command.Command) (*datatypes.Data, error)
 	return res, nil
 }
 
-func (this *executor) ExecuteReplConf(cmd *command.Command) (*datatypes.Data, error) {
+func (this *executor) ExecuteReplConf(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
 	args, ok := cmd.Args.GetArgValue(replconfcommand.GetAck)
 	if !ok {
 		return nil, nil
@@ -228,13 +412,23 @@ func (this *executor) ExecuteReplConf(cmd *command.Command) (*datatypes.Data, er
 	return res, err
 }
 
-func (this *executor) ExecuteKeys(cmd *command.Command) (*datatypes.Data, error) {
-	res := this.storage.GetKeys()
+func (this *executor) ExecuteKeys(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	if len(cmd.Raw.Values) != 2 {
+		return nil, errors.New("ERR wrong number of arguments for 'keys' command")
+	}
+	pattern := cmd.Raw.Values[1].Value
+	allKeys := pattern == "*"
+	res := []string{}
+	this.db(caller).ForEach(func(key string, _ storage.StorageValue, _ time.Time) {
+		if allKeys || glob.Match(pattern, key, false) {
+			res = append(res, key)
+		}
+	})
 
 	return datatypes.ConstructArray(res), nil
 }
 
-func (this *executor) ExecuteType(cmd *command.Command) (*datatypes.Data, error) {
+func (this *executor) ExecuteType(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
 	typeKey, ok := cmd.Args.GetArgValue(type_command.TypeKey)
 	if !ok {
 		return nil, commands.GetUnknowArgError
@@ -244,14 +438,15 @@ func (this *executor) ExecuteType(cmd *command.Command) (*datatypes.Data, error)
 	if err != nil {
 		return nil, fmt.Errorf("Error casting type arg to str: %w", err)
 	}
-	res := this.storage.GetType(strTypeKey)
+	res := this.db(caller).GetType(strTypeKey)
 	if res == "" {
+		this.notifyKey(caller, notify.KeyMiss, "keymiss", strTypeKey)
 		return datatypes.ConstructSimpleString("none"), nil
 	}
 	return datatypes.ConstructSimpleString(string(res)), nil
 }
 
-func (this *executor) ExecuteXadd(cmd *command.Command) (*datatypes.Data, error) {
+func (this *executor) ExecuteXadd(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
 	logger.Logger.Debug("start executing xadd")
 	streamKey, ok := cmd.Args.GetArgValue(xaddcommand.Key)
 	logger.Logger.Debug("get key arg", logger.String("arg", fmt.Sprintf("%v", streamKey)))
@@ -265,12 +460,17 @@ func (this *executor) ExecuteXadd(cmd *command.Command) (*datatypes.Data, error)
 		return nil, fmt.Errorf("Error casting key stream arg to string: %w", err)
 	}
 	logger.Logger.Debug("parse stream key", logger.String("key", streamKeyStr))
-	streamEntrie, ok := this.storage.GetEntrie(streamKeyStr)
+	streamEntrie, ok := this.db(caller).GetEntrie(streamKeyStr)
 	logger.Logger.Debug("get storage entries")
 	var currentStream stream.Stream
+	_, noMkStream := cmd.Args.GetArgValue(xaddcommand.NoMkStream)
+	if !ok && noMkStream {
+		return datatypes.ConstructNull(), nil
+	}
 	if !ok {
 		s := stream.NewStream()
-		this.storage.Set(streamKeyStr, storage.NewStreamValue(s))
+		this.db(caller).Set(streamKeyStr, storage.NewStreamValue(s))
+		defer this.streamWaiters.notify(caller.GetDb(), streamKeyStr)
 		currentStream = s
 	} else {
 		storageStream, err := streamEntrie.ToStream()
@@ -279,7 +479,7 @@ func (this *executor) ExecuteXadd(cmd *command.Command) (*datatypes.Data, error)
 		}
 		currentStream = storageStream
 	}
-	logger.Logger.Debug("get active stream", logger.String("stream", fmt.Sprintf("%v", currentStream)))
+	logger.Logger.Debug("get active stream", logger.String("key", streamKeyStr))
 	streamEntrieId, ok := cmd.Args.GetArgValue(xaddcommand.Id)
 	if !ok {
 		return nil, commands.GetUnknowArgError
@@ -306,33 +506,55 @@ func (this *executor) ExecuteXadd(cmd *command.Command) (*datatypes.Data, error)
 	if err != nil {
 		return nil, fmt.Errorf("Error casting stream values to kv: %w", err)
 	}
-	currentStream.Add(stream.NewStreamEntrieFromKv(*validEntrieid, streamKvValues))
+	limits := stream.NodeLimits{
+		MaxBytes:   this.config.GetStreamNodeMaxBytes(),
+		MaxEntries: this.config.GetStreamNodeMaxEntries(),
+	}
+	currentStream.Add(stream.NewStreamEntrieFromKv(*validEntrieid, streamKvValues), limits)
 	logger.Logger.Debug("add entrie to stream")
+	this.notifyKey(caller, notify.Stream, "xadd", streamKeyStr)
+	var trim stream.TrimOptions
+	trimArg, _ := cmd.Args.GetArgValue(xaddcommand.Trim)
+	trimArg.ToType(&trim)
+	trimmed := 0
+	if trim.Strategy != stream.TrimNone {
+		trimmed = currentStream.Trim(trim)
+	}
+	if trimmed > 0 {
+		this.notifyKey(caller, notify.Stream, "xtrim", streamKeyStr)
+	}
+	// generated id and approximate trimming are not deterministic, so replicas get the id and the resulting length
+	propagated := []string{streamKeyStr}
+	if noMkStream {
+		propagated = append(propagated, "NOMKSTREAM")
+	}
+	if trimmed > 0 {
+		propagated = append(propagated, "MAXLEN", "=", strconv.Itoa(currentStream.Len()))
+	}
+	propagated = append(propagated, validEntrieid.String())
+	for _, kv := range streamKvValues {
+		propagated = append(propagated, kv[0], kv[1])
+	}
+	cmd.Rewrite(command.XADD, propagated...)
 	return datatypes.ConstructBulkString(validEntrieid.String()), nil
 }
 
-func (this *executor) ExecuteXrange(cmd *command.Command) (*datatypes.Data, error) {
+func (this *executor) ExecuteXrange(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
 	query, err := xrangecommand.ConstructQueryFromArgs(cmd.Args)
 	if err != nil {
 		return nil, fmt.Errorf("Error constructing xrange query: %w", err)
 	}
-	streamEntrie, ok := this.storage.GetEntrie(query.Key)
-	if !ok {
-		return datatypes.ConstructNull(), nil
-	}
-	selectedStream, err := streamEntrie.ToStream()
+	selectedStream, err := this.readStream(caller, query.Key)
 	if err != nil {
 		return nil, err
 	}
-	var selectedEntries []*stream.StreamEntrie
-	switch query.ReadType {
-	case xrangecommand.FromStart:
-		selectedEntries = selectedStream.GetInRangeIncl(nil, &query.End)
-	case xrangecommand.ToEnd:
-		selectedEntries = selectedStream.GetInRangeIncl(&query.Start, nil)
-	case xrangecommand.InRange:
-		selectedEntries = selectedStream.GetInRangeIncl(&query.Start, &query.End)
+	if selectedStream == nil {
+		return datatypes.ConstructArray([]string{}), nil
 	}
+	if query.Count == 0 {
+		return datatypes.ConstructNullArray(), nil
+	}
+	selectedEntries := selectedStream.GetRange(query.Start, query.End, max(query.Count, 0), query.Rev)
 	encodedEntries := make([]*datatypes.Data, len(selectedEntries))
 
 	for i, ent := range selectedEntries {
@@ -342,99 +564,234 @@ func (this *executor) ExecuteXrange(cmd *command.Command) (*datatypes.Data, erro
 	return datatypes.ConstructArrayFromData(encodedEntries), nil
 }
 
-func (this *executor) ExecuteXRead(cmd *command.Command) (*datatypes.Data, error) {
+// reads entries added after given ids, with BLOCK waits until any of streams gets new entries when nothing is served
+func (this *executor) ExecuteXRead(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
 	query, err := xreadcommand.ConstructQueryFromArgs(cmd.Args)
 	if err != nil {
-		return nil, fmt.Errorf("Error constructing xread args: %w", err)
+		return nil, err
+	}
+	// $ and + are resolved once, so entries added while blocked are served
+	for i, q := range query.Queries {
+		if q.ReadType == xreadcommand.InRange {
+			continue
+		}
+		s, err := this.getStream(caller, q.Key)
+		if err != nil {
+			return nil, err
+		}
+		if s == nil {
+			continue
+		}
+		query.Queries[i].Start = s.LastId()
+		if q.ReadType != xreadcommand.LastEntry {
+			continue
+		}
+		if last := s.GetRange(stream.StreamEntrieId{}, stream.MaxId, 1, true); len(last) > 0 {
+			query.Queries[i].Start, _ = last[0].Id.Decr()
+		}
 	}
-	results := make([]*datatypes.Data, len(query.Queries))
-	if !query.IsBlocked {
-		for i, q := range query.Queries {
-			selectedStream, ok := this.storage.GetEntrie(q.Key)
+
+	blocker := newStreamBlocker(caller, query.BlockedTimeout)
+	defer blocker.close()
+	if query.IsBlocked {
+		for _, q := range query.Queries {
+			blocker.add(this.streamWaiters.watch(caller.GetDb(), q.Key, blocker.changed))
+		}
+	}
+	for {
+		results := []*datatypes.Data{}
+		for _, q := range query.Queries {
+			s, err := this.readStream(caller, q.Key)
+			if err != nil {
+				return nil, err
+			}
+			if s == nil {
+				continue
+			}
+			if query.IsBlocked {
+				blocker.watch(s)
+			}
+			start, ok := q.Start.Incr()
 			if !ok {
-				results[i] = datatypes.ConstructArrayFromData([]*datatypes.Data{
-					datatypes.ConstructBulkString(q.Key),
-					datatypes.ConstructNull(),
-				})
 				continue
 			}
-			st, err := selectedStream.ToStream()
-			if err != nil {
-				return nil, fmt.Errorf("Error converting %v'th query to stream: %w", i, err)
+			entries := s.GetRange(start, stream.MaxId, query.Count, false)
+			if len(entries) == 0 {
+				continue
 			}
-			entries := st.GetInRangeExcl(&q.Start, nil)
 			encodedEntries := make([]*datatypes.Data, len(entries))
 			for i, e := range entries {
 				encodedEntries[i] = e.ToDataType()
 			}
-			results[i] = datatypes.ConstructArrayFromData([]*datatypes.Data{
+			results = append(results, datatypes.ConstructArrayFromData([]*datatypes.Data{
 				datatypes.ConstructBulkString(q.Key),
 				datatypes.ConstructArrayFromData(encodedEntries),
-			})
+			}))
+		}
+		if len(results) > 0 {
+			return datatypes.ConstructArrayFromData(results), nil
+		}
+		if !query.IsBlocked {
+			return datatypes.ConstructNullArray(), nil
+		}
+		changed, err := blocker.wait()
+		if err != nil {
+			return nil, err
+		}
+		if !changed {
+			return datatypes.ConstructNullArray(), nil
 		}
-		return datatypes.ConstructArrayFromData(results), nil
 	}
-	blockedQuery := query.Queries[0]
-	queryEntrie, ok := this.storage.GetEntrie(blockedQuery.Key)
+}
+
+// executes INCR, DECR, INCRBY and DECRBY, value may be integer encoded or string holding canonical 64 bit integer
+func (this *executor) ExecuteIncr(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	argVal, ok := cmd.Args.GetArgValue(incrcommand.IcrKey)
 	if !ok {
-		return datatypes.ConstructArrayFromData([]*datatypes.Data{
-			datatypes.ConstructBulkString(blockedQuery.Key),
-			datatypes.ConstructNull(),
-		}), nil
+		return nil, errors.New("Error IncrKey does not specified")
 	}
-	queryStream, err := queryEntrie.ToStream()
+	var strKey string
+	err := argVal.ToType(&strKey)
 	if err != nil {
-		return nil, fmt.Errorf("Error converting xread entrie to stream: %w", err)
+		return nil, fmt.Errorf("Error casting incr key arg to string: %w", err)
 	}
-	if blockedQuery.ReadType == xreadcommand.OnlyNew {
-		newEntrie := queryStream.BlockUntilNew(query.BlockedTimeout)
-		if newEntrie == nil {
-			return datatypes.ConstructNull(), nil
+	increment := 1
+	if incrementArg, ok := cmd.Args.GetArgValue(incrcommand.Increment); ok {
+		err = incrementArg.ToType(&increment)
+		if err != nil {
+			return nil, fmt.Errorf("Error casting increment arg to int: %w", err)
 		}
-		return datatypes.ConstructArrayFromData([]*datatypes.Data{datatypes.ConstructArrayFromData([]*datatypes.Data{
-			datatypes.ConstructBulkString(blockedQuery.Key),
-			datatypes.ConstructArrayFromData([]*datatypes.Data{newEntrie.ToDataType()}),
-		})}), nil
-	}
-	existedEnties := queryStream.GetInRangeExcl(&blockedQuery.Start, nil)
-	logger.Logger.Debug("blocking stream", logger.String("entries", fmt.Sprintf("%v", existedEnties)))
-	newEntrie := queryStream.BlockUntilNew(query.BlockedTimeout)
-	logger.Logger.Debug("unblocking stream", logger.String("entries", fmt.Sprintf("%v", existedEnties)), logger.String("new entrie", fmt.Sprintf("%v", newEntrie)))
-	if newEntrie == nil {
-		logger.Logger.Debug("write nil")
-		return datatypes.ConstructNull(), nil
 	}
-	existedEnties = append(existedEnties, newEntrie)
-	encodedQueries := make([]*datatypes.Data, len(existedEnties))
-	for i, e := range existedEnties {
-		encodedQueries[i] = e.ToDataType()
+	increment, err = signedIncrement(cmd.Type, increment)
+	if err != nil {
+		return nil, err
 	}
-	logger.Logger.Debug("serialize entries")
-	streamData := datatypes.ConstructArrayFromData([]*datatypes.Data{
-		datatypes.ConstructBulkString(blockedQuery.Key),
-		datatypes.ConstructArrayFromData(encodedQueries),
+
+	var res int
+	err = this.db(caller).Update(strKey, func(entrie *storage.StorageValue) (*storage.StorageValue, error) {
+		current := 0
+		if entrie != nil {
+			n, err := storedInt(entrie)
+			if err != nil {
+				return nil, err
+			}
+			current = n
+		}
+		res, err = addInt(current, increment)
+		if err != nil {
+			return nil, err
+		}
+		updated := storage.NewIntValue(res)
+		return &updated, nil
 	})
-	return datatypes.ConstructArrayFromData([]*datatypes.Data{
-		streamData,
-	}), nil
+	if err != nil {
+		return nil, err
+	}
+	this.notifyKey(caller, notify.String, "incrby", strKey)
+	return datatypes.ConstructInt(res), nil
 }
 
-func (this *executor) ExecuteIncr(cmd *command.Command) (*datatypes.Data, error) {
+// result is computed and formatted like redis does with long double, without exponent
+func (this *executor) ExecuteIncrbyfloat(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
 	argVal, ok := cmd.Args.GetArgValue(incrcommand.IcrKey)
 	if !ok {
 		return nil, errors.New("Error IncrKey does not specified")
 	}
-	var strKey string
-	argVal.ToType(&strKey)
-	entrie, ok := this.storage.GetEntrie(strKey)
+	var strKey, strIncrement string
+	err := argVal.ToType(&strKey)
+	if err != nil {
+		return nil, fmt.Errorf("Error casting incr key arg to string: %w", err)
+	}
+	incrementArg, ok := cmd.Args.GetArgValue(incrcommand.Increment)
+	if !ok {
+		return nil, errors.New("Error increment does not specified")
+	}
+	err = incrementArg.ToType(&strIncrement)
+	if err != nil {
+		return nil, fmt.Errorf("Error casting increment arg to string: %w", err)
+	}
+	increment, err := incrcommand.ParseLongDouble(strIncrement)
+	if err != nil {
+		return nil, err
+	}
+
+	var res string
+	err = this.db(caller).Update(strKey, func(entrie *storage.StorageValue) (*storage.StorageValue, error) {
+		current := new(big.Float)
+		if entrie != nil {
+			f, err := storedFloat(entrie)
+			if err != nil {
+				return nil, err
+			}
+			current = f
+		}
+		res, err = addFloat(current, increment)
+		if err != nil {
+			return nil, err
+		}
+		updated := storage.NewValueFromString(res)
+		return &updated, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	this.notifyKey(caller, notify.String, "incrbyfloat", strKey)
+	return datatypes.ConstructBulkString(res), nil
+}
+
+// returns increment applied by INCR, DECR, INCRBY or DECRBY, decrements are negated
+func signedIncrement(typ command.CommandEnum, increment int) (int, error) {
+	switch typ {
+	case command.DECR:
+		return -1, nil
+	case command.DECRBY:
+		if increment == math.MinInt64 {
+			return 0, incrcommand.DecrementOverflowError
+		}
+		return -increment, nil
+	}
+	return increment, nil
+}
+
+// adds increment to current, error is returned if sum does not fit 64 bit integer
+func addInt(current int, increment int) (int, error) {
+	if (increment < 0 && current < 0 && increment < math.MinInt64-current) ||
+		(increment > 0 && current > 0 && increment > math.MaxInt64-current) {
+		return 0, incrcommand.OverflowError
+	}
+	return current + increment, nil
+}
+
+// adds increment to current and formats sum like redis does
+func addFloat(current *big.Float, increment *big.Float) (string, error) {
+	sum, err := incrcommand.AddLongDouble(current, increment)
+	if err != nil {
+		return "", err
+	}
+	return incrcommand.FormatLongDouble(sum), nil
+}
+
+// returns float held by value, integer encoded values are read as their decimal form
+func storedFloat(entrie *storage.StorageValue) (*big.Float, error) {
+	str, ok := entrie.AsString()
 	if !ok {
-		this.storage.Set(strKey, storage.NewIntValue(1))
-		return datatypes.ConstructInt(1), nil
+		return nil, WrongTypeError
 	}
-	if entrie.GetType() != storage.Int {
-		return nil, incrcommand.IncrNotIntegerTypeError
+	return incrcommand.ParseLongDouble(str)
+}
+
+// returns integer held by value, strings are accepted only in canonical form, e.g. "10" but not "010" or "+10"
+func storedInt(entrie *storage.StorageValue) (int, error) {
+	switch entrie.GetType() {
+	case storage.Int:
+		return entrie.ToInt()
+	case storage.String:
+		str, _ := entrie.ToString()
+		n, err := strconv.ParseInt(str, 10, 64)
+		if err != nil || strconv.FormatInt(n, 10) != str {
+			return 0, incrcommand.IncrNotIntegerTypeError
+		}
+		return int(n), nil
 	}
-	intVal, _ := entrie.ToInt()
-	this.storage.Set(strKey, storage.NewIntValue(intVal+1))
-	return datatypes.ConstructInt(intVal + 1), nil
+	return 0, WrongTypeError
 }
@@ -0,0 +1,165 @@
+package executor
+
+import (
+	"math"
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+	incrcommand "github.com/codecrafters-io/redis-starter-go/app/commands/incr_command"
+	"github.com/codecrafters-io/redis-starter-go/app/storage"
+)
+
+func TestStoredInt(t *testing.T) {
+	cases := []struct {
+		value storage.StorageValue
+		want  int
+		err   error
+	}{
+		{storage.NewIntValue(10), 10, nil},
+		{storage.NewIntValue(math.MinInt64), math.MinInt64, nil},
+		{storage.NewValueFromString("-42"), -42, nil},
+		{storage.NewStringValue("9223372036854775807"), math.MaxInt64, nil},
+		{storage.NewStringValue("-9223372036854775808"), math.MinInt64, nil},
+		{storage.NewStringValue("0"), 0, nil},
+		// strings holding integer not in canonical form are not integers for redis
+		{storage.NewStringValue("010"), 0, incrcommand.IncrNotIntegerTypeError},
+		{storage.NewStringValue("+1"), 0, incrcommand.IncrNotIntegerTypeError},
+		{storage.NewStringValue("-0"), 0, incrcommand.IncrNotIntegerTypeError},
+		{storage.NewStringValue(" 1"), 0, incrcommand.IncrNotIntegerTypeError},
+		{storage.NewStringValue("1 "), 0, incrcommand.IncrNotIntegerTypeError},
+		{storage.NewStringValue(""), 0, incrcommand.IncrNotIntegerTypeError},
+		{storage.NewStringValue("1.0"), 0, incrcommand.IncrNotIntegerTypeError},
+		{storage.NewStringValue("9223372036854775808"), 0, incrcommand.IncrNotIntegerTypeError},
+		{storage.NewStringValue("-9223372036854775809"), 0, incrcommand.IncrNotIntegerTypeError},
+		{storage.NewStreamValue(nil), 0, WrongTypeError},
+	}
+	for _, c := range cases {
+		got, err := storedInt(&c.value)
+		if err != c.err || got != c.want {
+			t.Errorf("expected %+v to hold %v with error %v, got %v with error %v", c.value, c.want, c.err, got, err)
+		}
+	}
+}
+
+func TestAddInt(t *testing.T) {
+	cases := []struct {
+		typ       command.CommandEnum
+		current   int
+		increment int
+		want      int
+		err       error
+	}{
+		{command.INCR, 1, 1, 2, nil},
+		{command.DECR, 1, 1, 0, nil},
+		{command.INCRBY, -5, 3, -2, nil},
+		{command.DECRBY, -5, 3, -8, nil},
+		{command.INCRBY, math.MaxInt64 - 1, 1, math.MaxInt64, nil},
+		{command.INCRBY, math.MaxInt64, 1, 0, incrcommand.OverflowError},
+		{command.INCR, math.MaxInt64, 1, 0, incrcommand.OverflowError},
+		{command.INCRBY, 1, math.MaxInt64, 0, incrcommand.OverflowError},
+		{command.INCRBY, -1, math.MinInt64, 0, incrcommand.OverflowError},
+		{command.INCRBY, math.MinInt64, -1, 0, incrcommand.OverflowError},
+		{command.DECR, math.MinInt64, 1, 0, incrcommand.OverflowError},
+		{command.DECR, math.MinInt64 + 1, 1, math.MinInt64, nil},
+		{command.INCRBY, math.MinInt64, math.MaxInt64, -1, nil},
+		{command.INCRBY, math.MaxInt64, math.MinInt64, -1, nil},
+		{command.DECRBY, 0, math.MaxInt64, -math.MaxInt64, nil},
+		{command.DECRBY, -2, math.MaxInt64, 0, incrcommand.OverflowError},
+		// negated MinInt64 does not fit 64 bits even if sum would
+		{command.DECRBY, -1, math.MinInt64, 0, incrcommand.DecrementOverflowError},
+		{command.DECRBY, 0, math.MinInt64, 0, incrcommand.DecrementOverflowError},
+	}
+	for _, c := range cases {
+		increment, err := signedIncrement(c.typ, c.increment)
+		got := 0
+		if err == nil {
+			got, err = addInt(c.current, increment)
+		}
+		if err != c.err || got != c.want {
+			t.Errorf("expected %v %v %v to give %v with error %v, got %v with error %v", c.typ, c.current, c.increment, c.want, c.err, got, err)
+		}
+	}
+}
+
+func TestStoredFloat(t *testing.T) {
+	valid := map[string]string{
+		"1":      "1",
+		"-1.5":   "-1.5",
+		"+2":     "2",
+		"010":    "10",
+		".5":     "0.5",
+		"5.":     "5",
+		"1e3":    "1000",
+		"1E-2":   "0.01",
+		"-0":     "0",
+		"5.0e3":  "5000",
+		"3.0000": "3",
+	}
+	for str, want := range valid {
+		value := storage.NewStringValue(str)
+		got, err := storedFloat(&value)
+		if err != nil || incrcommand.FormatLongDouble(got) != want {
+			t.Errorf("expected %q to be parsed as %v, got %v with error %v", str, want, got, err)
+		}
+	}
+	value := storage.NewIntValue(math.MaxInt64)
+	got, err := storedFloat(&value)
+	if err != nil || incrcommand.FormatLongDouble(got) != "9223372036854775807" {
+		t.Errorf("expected integer encoded value to be read exactly, got %v with error %v", got, err)
+	}
+	// fits long double, but not double
+	value = storage.NewStringValue("1e400")
+	got, err = storedFloat(&value)
+	if err != nil || len(incrcommand.FormatLongDouble(got)) != 401 {
+		t.Errorf("expected 1e400 to be valid float, got %v with error %v", got, err)
+	}
+	for _, str := range []string{"", " 1", "1 ", "\t1", "1\n", "abc", "1.5x", "nan", "NaN", "1,5", "--1", "1_000", "0x10", "1e5000", "-1e5000"} {
+		value := storage.NewStringValue(str)
+		if _, err := storedFloat(&value); err != incrcommand.NotFloatError {
+			t.Errorf("expected %q not to be valid float, got %v", str, err)
+		}
+	}
+	stream := storage.NewStreamValue(nil)
+	if _, err := storedFloat(&stream); err != WrongTypeError {
+		t.Errorf("expected WrongTypeError for stream, got %v", err)
+	}
+}
+
+// expected sums are replies of redis, which computes them in long double
+func TestAddFloat(t *testing.T) {
+	cases := []struct {
+		current   string
+		increment string
+		want      string
+		err       error
+	}{
+		{"10.50", "0.1", "10.6", nil},
+		{"5.0e3", "2.0e2", "5200", nil},
+		{"0.1", "0.2", "0.3", nil},
+		{"3", "-3", "0", nil},
+		{"-1.5", "0", "-1.5", nil},
+		{"0", "1e21", "1000000000000000000000", nil},
+		{"0", "1e-7", "0.0000001", nil},
+		// digits after 17th decimal are not formatted
+		{"0", "1e-18", "0", nil},
+		{"9223372036854775807", "1", "9223372036854775808", nil},
+		{"1.1", "2.2", "3.3", nil},
+		{"inf", "1", "", incrcommand.NanOrInfinityError},
+		{"0", "-inf", "", incrcommand.NanOrInfinityError},
+		{"1e4932", "1e4932", "", incrcommand.NanOrInfinityError},
+	}
+	for _, c := range cases {
+		current, err := incrcommand.ParseLongDouble(c.current)
+		if err != nil {
+			t.Fatalf("expected %q to be valid float, got %v", c.current, err)
+		}
+		increment, err := incrcommand.ParseLongDouble(c.increment)
+		if err != nil {
+			t.Fatalf("expected %q to be valid float, got %v", c.increment, err)
+		}
+		got, err := addFloat(current, increment)
+		if err != c.err || got != c.want {
+			t.Errorf("expected %v + %v to give %q with error %v, got %q with error %v", c.current, c.increment, c.want, c.err, got, err)
+		}
+	}
+}
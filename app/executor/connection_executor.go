@@ -0,0 +1,76 @@
+package executor
+
+import (
+	"errors"
+
+	"github.com/codecrafters-io/redis-starter-go/app/client"
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+	connectioncommand "github.com/codecrafters-io/redis-starter-go/app/commands/connection_command"
+	"github.com/codecrafters-io/redis-starter-go/app/config"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+)
+
+var HelloNoAuthError = errors.New("NOAUTH HELLO must be called with the client already authenticated, otherwise the HELLO <proto> AUTH <user> <pass> option can be used to authenticate the client and select the RESP protocol version at the same time")
+
+// version reported by HELLO, the same as in rdb files written by server
+const serverVersion = "7.2.0"
+
+// HELLO [protover [AUTH username password] [SETNAME clientname]], replies with server and connection info
+func (this *executor) ExecuteHello(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var query connectioncommand.HelloQuery
+	queryArg, _ := cmd.Args.GetArgValue(connectioncommand.Query)
+	queryArg.ToType(&query)
+	if query.Auth {
+		err := this.authenticate(caller, query.Username, query.Password)
+		if err != nil {
+			return nil, err
+		}
+	} else if _, authenticated := caller.GetUser(); !authenticated {
+		return nil, HelloNoAuthError
+	}
+	if query.SetName {
+		err := caller.SetName(query.Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if query.Protover != 0 {
+		caller.SetResp(query.Protover)
+	}
+	role := "master"
+	if this.config.GetRole() == config.SLAVE {
+		role = "replica"
+	}
+	fields := []*datatypes.Data{
+		datatypes.ConstructBulkString("server"), datatypes.ConstructBulkString("redis"),
+		datatypes.ConstructBulkString("version"), datatypes.ConstructBulkString(serverVersion),
+		datatypes.ConstructBulkString("proto"), datatypes.ConstructInt(caller.GetResp()),
+		datatypes.ConstructBulkString("id"), datatypes.ConstructInt(int(caller.GetId())),
+		datatypes.ConstructBulkString("mode"), datatypes.ConstructBulkString("standalone"),
+		datatypes.ConstructBulkString("role"), datatypes.ConstructBulkString(role),
+		datatypes.ConstructBulkString("modules"), datatypes.ConstructArray([]string{}),
+	}
+	if caller.GetResp() == 3 {
+		return datatypes.ConstructMap(fields), nil
+	}
+	return datatypes.ConstructArrayFromData(fields), nil
+}
+
+// connection is closed after reply
+func (this *executor) ExecuteQuit(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	caller.Kill(caller)
+	return datatypes.ConstructSimpleString("OK"), nil
+}
+
+// resets connection state to state of new connection, transaction is discarded by connection processor
+func (this *executor) ExecuteReset(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	this.pubsub.UnsubscribeAll(caller)
+	caller.SetReplyMode(client.ReplyOn)
+	caller.SetDb(0)
+	caller.SetResp(2)
+	caller.SetNoEvict(false)
+	caller.SetNoTouch(false)
+	user, authenticated := this.acl.GetDefaultUser()
+	caller.SetUser(user, authenticated)
+	return datatypes.ConstructSimpleString("RESET"), nil
+}
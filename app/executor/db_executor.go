@@ -0,0 +1,75 @@
+package executor
+
+import (
+	"errors"
+
+	"github.com/codecrafters-io/redis-starter-go/app/client"
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+	dbcommand "github.com/codecrafters-io/redis-starter-go/app/commands/db_command"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+	"github.com/codecrafters-io/redis-starter-go/app/notify"
+	"github.com/codecrafters-io/redis-starter-go/app/storage"
+)
+
+func (this *executor) ExecuteSelect(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var idx int
+	idxArg, _ := cmd.Args.GetArgValue(dbcommand.Index)
+	idxArg.ToType(&idx)
+	err := this.dbs.CheckIndex(idx)
+	if err != nil {
+		return nil, err
+	}
+	caller.SetDb(idx)
+	return datatypes.ConstructSimpleString("OK"), nil
+}
+
+func (this *executor) ExecuteMove(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var key string
+	var idx int
+	keyArg, _ := cmd.Args.GetArgValue(dbcommand.Key)
+	keyArg.ToType(&key)
+	idxArg, _ := cmd.Args.GetArgValue(dbcommand.Index)
+	idxArg.ToType(&idx)
+	err := this.dbs.CheckIndex(idx)
+	if err != nil {
+		return nil, err
+	}
+	from := caller.GetDb()
+	if from == idx {
+		return nil, errors.New("ERR source and destination objects are the same")
+	}
+	if this.dbs.Move(key, from, idx) {
+		this.notifier.Notify(notify.Generic, "move_from", key, from)
+		this.notifier.Notify(notify.Generic, "move_to", key, idx)
+		return datatypes.ConstructInt(1), nil
+	}
+	return datatypes.ConstructInt(0), nil
+}
+
+func (this *executor) ExecuteSwapdb(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var first, second int
+	firstArg, _ := cmd.Args.GetArgValue(dbcommand.First)
+	firstArg.ToType(&first)
+	secondArg, _ := cmd.Args.GetArgValue(dbcommand.Second)
+	secondArg.ToType(&second)
+	if this.dbs.CheckIndex(first) != nil || this.dbs.CheckIndex(second) != nil {
+		return nil, storage.DbIndexOutOfRangeError
+	}
+	this.dbs.Swap(first, second)
+	return datatypes.ConstructSimpleString("OK"), nil
+}
+
+func (this *executor) ExecuteDbsize(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	return datatypes.ConstructInt(this.db(caller).KeysLen()), nil
+}
+
+// ASYNC and SYNC flushes are the same, flushed keys are released by garbage collector in background anyway
+func (this *executor) ExecuteFlushdb(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	this.db(caller).Flush()
+	return datatypes.ConstructSimpleString("OK"), nil
+}
+
+func (this *executor) ExecuteFlushall(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	this.dbs.FlushAll()
+	return datatypes.ConstructSimpleString("OK"), nil
+}
@@ -0,0 +1,314 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/app/client"
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+	geocommand "github.com/codecrafters-io/redis-starter-go/app/commands/geo_command"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+	"github.com/codecrafters-io/redis-starter-go/app/geo"
+	"github.com/codecrafters-io/redis-starter-go/app/notify"
+	"github.com/codecrafters-io/redis-starter-go/app/sortedset"
+	"github.com/codecrafters-io/redis-starter-go/app/storage"
+)
+
+var MemberDecodeError = errors.New("ERR could not decode requested zset member")
+
+type geoPoint struct {
+	member string
+	score  float64
+	dist   float64
+	lon    float64
+	lat    float64
+}
+
+func zsetOf(entrie *storage.StorageValue) (sortedset.SortedSet, error) {
+	if entrie.GetType() != storage.ZSet {
+		return nil, WrongTypeError
+	}
+	return entrie.ToZSet()
+}
+
+// returns sorted set stored at key for read only command, nil if key does not exist
+func (this *executor) getZSet(caller *client.Client, key string) (sortedset.SortedSet, error) {
+	entrie, exists := this.db(caller).GetEntrie(key)
+	if !exists {
+		this.notifyKey(caller, notify.KeyMiss, "keymiss", key)
+		return nil, nil
+	}
+	return zsetOf(entrie)
+}
+
+// formats coordinate with 17 decimals without trailing zeros, e.g. 13.36138933897018433
+func formatCoord(v float64) string {
+	str := strconv.FormatFloat(v, 'f', 17, 64)
+	str = strings.TrimRight(str, "0")
+	return strings.TrimSuffix(str, ".")
+}
+
+func constructCoords(lon float64, lat float64) *datatypes.Data {
+	return datatypes.ConstructArray([]string{formatCoord(lon), formatCoord(lat)})
+}
+
+// replies number of added members, with CH number of added and updated members
+func (this *executor) ExecuteGeoadd(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var key string
+	var points []geocommand.Point
+	keyArg, _ := cmd.Args.GetArgValue(geocommand.Key)
+	keyArg.ToType(&key)
+	pointsArg, _ := cmd.Args.GetArgValue(geocommand.Points)
+	pointsArg.ToType(&points)
+	_, nx := cmd.Args.GetArgValue(geocommand.Nx)
+	_, xx := cmd.Args.GetArgValue(geocommand.Xx)
+	_, ch := cmd.Args.GetArgValue(geocommand.Ch)
+
+	added, changed := 0, 0
+	err := this.db(caller).Update(key, func(entrie *storage.StorageValue) (*storage.StorageValue, error) {
+		if entrie == nil && xx {
+			return nil, nil
+		}
+		zset := sortedset.NewSortedSet()
+		if entrie != nil {
+			var err error
+			zset, err = zsetOf(entrie)
+			if err != nil {
+				return nil, err
+			}
+		}
+		for _, p := range points {
+			_, exists := zset.Score(p.Member)
+			if nx && exists || xx && !exists {
+				continue
+			}
+			isAdded, isChanged := zset.Add(p.Member, p.Score)
+			if isAdded {
+				added++
+			}
+			if isChanged {
+				changed++
+			}
+		}
+		// existing set is updated in place
+		if entrie != nil {
+			return nil, nil
+		}
+		value := storage.NewZSetValue(zset)
+		return &value, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if added+changed > 0 {
+		this.notifyKey(caller, notify.ZSet, "zadd", key)
+	}
+	if ch {
+		return datatypes.ConstructInt(added + changed), nil
+	}
+	return datatypes.ConstructInt(added), nil
+}
+
+// replies distance in given unit with 4 decimals, null if key or any of members does not exist
+func (this *executor) ExecuteGeodist(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var key, member1, member2, unit string
+	keyArg, _ := cmd.Args.GetArgValue(geocommand.Key)
+	keyArg.ToType(&key)
+	member1Arg, _ := cmd.Args.GetArgValue(geocommand.Member1)
+	member1Arg.ToType(&member1)
+	member2Arg, _ := cmd.Args.GetArgValue(geocommand.Member2)
+	member2Arg.ToType(&member2)
+	unitArg, _ := cmd.Args.GetArgValue(geocommand.Unit)
+	unitArg.ToType(&unit)
+	conversion, _ := geocommand.ParseUnit(unit)
+
+	zset, err := this.getZSet(caller, key)
+	if err != nil {
+		return nil, err
+	}
+	if zset == nil {
+		return datatypes.ConstructNull(), nil
+	}
+	score1, ok1 := zset.Score(member1)
+	score2, ok2 := zset.Score(member2)
+	if !ok1 || !ok2 {
+		return datatypes.ConstructNull(), nil
+	}
+	lon1, lat1 := geo.Decode(uint64(score1))
+	lon2, lat2 := geo.Decode(uint64(score2))
+	distance := geo.Distance(lon1, lat1, lon2, lat2) / conversion
+	return datatypes.ConstructBulkString(fmt.Sprintf("%.4f", distance)), nil
+}
+
+// replies longitude and latitude of every member, null array for missing members
+func (this *executor) ExecuteGeopos(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var key string
+	var members []string
+	keyArg, _ := cmd.Args.GetArgValue(geocommand.Key)
+	keyArg.ToType(&key)
+	membersArg, _ := cmd.Args.GetArgValue(geocommand.Members)
+	membersArg.ToType(&members)
+	zset, err := this.getZSet(caller, key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*datatypes.Data, 0, len(members))
+	for _, member := range members {
+		if zset == nil {
+			out = append(out, datatypes.ConstructNullArray())
+			continue
+		}
+		score, ok := zset.Score(member)
+		if !ok {
+			out = append(out, datatypes.ConstructNullArray())
+			continue
+		}
+		out = append(out, constructCoords(geo.Decode(uint64(score))))
+	}
+	return datatypes.ConstructArrayFromData(out), nil
+}
+
+// replies standard geohash of every member, null for missing members
+func (this *executor) ExecuteGeohash(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var key string
+	var members []string
+	keyArg, _ := cmd.Args.GetArgValue(geocommand.Key)
+	keyArg.ToType(&key)
+	membersArg, _ := cmd.Args.GetArgValue(geocommand.Members)
+	membersArg.ToType(&members)
+	zset, err := this.getZSet(caller, key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*datatypes.Data, 0, len(members))
+	for _, member := range members {
+		if zset == nil {
+			out = append(out, datatypes.ConstructNull())
+			continue
+		}
+		score, ok := zset.Score(member)
+		if !ok {
+			out = append(out, datatypes.ConstructNull())
+			continue
+		}
+		out = append(out, datatypes.ConstructBulkString(geo.Hash(uint64(score))))
+	}
+	return datatypes.ConstructArrayFromData(out), nil
+}
+
+// empty search result deletes destination
+func (this *executor) deleteStoreKey(caller *client.Client, key string) {
+	if this.db(caller).Delete(key) {
+		this.notifyKey(caller, notify.Generic, "del", key)
+	}
+}
+
+// executes GEOSEARCH, GEOSEARCHSTORE and GEORADIUS family, members are collected from geohash cells covering searched shape
+func (this *executor) ExecuteGeosearch(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var query geocommand.SearchQuery
+	searchArg, _ := cmd.Args.GetArgValue(geocommand.Search)
+	searchArg.ToType(&query)
+	db := this.db(caller)
+	zset, err := this.getZSet(caller, query.Key)
+	if err != nil {
+		return nil, err
+	}
+	if zset == nil {
+		if query.StoreKey != "" {
+			this.deleteStoreKey(caller, query.StoreKey)
+			return datatypes.ConstructInt(0), nil
+		}
+		return datatypes.ConstructArray([]string{}), nil
+	}
+
+	shape := query.Shape
+	if query.FromMember {
+		score, ok := zset.Score(query.Member)
+		if !ok {
+			return nil, MemberDecodeError
+		}
+		shape.Lon, shape.Lat = geo.Decode(uint64(score))
+	}
+	// closest members are returned when COUNT is given without order
+	order := query.Sort
+	if query.Count > 0 && order == geocommand.SortNone && !query.Any {
+		order = geocommand.SortAsc
+	}
+	limit := 0
+	if query.Any {
+		limit = query.Count
+	}
+
+	points := []geoPoint{}
+	for _, r := range shape.Ranges() {
+		if limit > 0 && len(points) >= limit {
+			break
+		}
+		zset.RangeByScore(float64(r[0]), float64(r[1]), func(member string, score float64) bool {
+			lon, lat := geo.Decode(uint64(score))
+			dist, ok := shape.Contains(lon, lat)
+			if ok {
+				points = append(points, geoPoint{member: member, score: score, dist: dist, lon: lon, lat: lat})
+			}
+			return limit == 0 || len(points) < limit
+		})
+	}
+
+	switch order {
+	case geocommand.SortAsc:
+		sort.SliceStable(points, func(i, j int) bool { return points[i].dist < points[j].dist })
+	case geocommand.SortDesc:
+		sort.SliceStable(points, func(i, j int) bool { return points[i].dist > points[j].dist })
+	}
+	if query.Count > 0 && len(points) > query.Count {
+		points = points[:query.Count]
+	}
+	for i := range points {
+		points[i].dist /= shape.Conversion
+	}
+
+	if query.StoreKey != "" {
+		if len(points) == 0 {
+			this.deleteStoreKey(caller, query.StoreKey)
+			return datatypes.ConstructInt(0), nil
+		}
+		result := sortedset.NewSortedSet()
+		for _, p := range points {
+			score := p.score
+			if query.StoreDist {
+				score = p.dist
+			}
+			result.Add(p.member, score)
+		}
+		db.SetMany([]string{query.StoreKey}, []storage.StorageValue{storage.NewZSetValue(result)}, false)
+		event := "georadiusstore"
+		if cmd.Type == command.GEOSEARCHSTORE {
+			event = "geosearchstore"
+		}
+		this.notifyKey(caller, notify.ZSet, event, query.StoreKey)
+		return datatypes.ConstructInt(len(points)), nil
+	}
+
+	out := make([]*datatypes.Data, 0, len(points))
+	for _, p := range points {
+		if !query.WithDist && !query.WithHash && !query.WithCoord {
+			out = append(out, datatypes.ConstructBulkString(p.member))
+			continue
+		}
+		item := []*datatypes.Data{datatypes.ConstructBulkString(p.member)}
+		if query.WithDist {
+			item = append(item, datatypes.ConstructBulkString(fmt.Sprintf("%.4f", p.dist)))
+		}
+		if query.WithHash {
+			item = append(item, datatypes.ConstructInt(int(p.score)))
+		}
+		if query.WithCoord {
+			item = append(item, constructCoords(p.lon, p.lat))
+		}
+		out = append(out, datatypes.ConstructArrayFromData(item))
+	}
+	return datatypes.ConstructArrayFromData(out), nil
+}
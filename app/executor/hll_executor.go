@@ -0,0 +1,188 @@
+package executor
+
+import (
+	"github.com/codecrafters-io/redis-starter-go/app/bitmap"
+	"github.com/codecrafters-io/redis-starter-go/app/client"
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+	hllcommand "github.com/codecrafters-io/redis-starter-go/app/commands/hll_command"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+	"github.com/codecrafters-io/redis-starter-go/app/hyperloglog"
+	"github.com/codecrafters-io/redis-starter-go/app/notify"
+	"github.com/codecrafters-io/redis-starter-go/app/storage"
+)
+
+// returns read only view of HyperLogLog held by value, fails for other types and strings in other format
+func hllView(entrie *storage.StorageValue) ([]byte, error) {
+	str, ok := entrie.AsString()
+	if !ok {
+		return nil, WrongTypeError
+	}
+	b := bitmap.Bytes(str)
+	err := hyperloglog.Validate(b)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// replies 1 if any register was changed or key was created
+func (this *executor) ExecutePfadd(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var key string
+	var elements []string
+	err := requiredArg(cmd, hllcommand.Key, &key)
+	if err != nil {
+		return nil, err
+	}
+	err = requiredArg(cmd, hllcommand.Elements, &elements)
+	if err != nil {
+		return nil, err
+	}
+	changed := false
+	err = this.db(caller).Update(key, func(entrie *storage.StorageValue) (*storage.StorageValue, error) {
+		b := hyperloglog.New()
+		created := entrie == nil
+		if !created {
+			view, err := hllView(entrie)
+			if err != nil {
+				return nil, err
+			}
+			b = append([]byte{}, view...)
+		}
+		b, updated, err := hyperloglog.Add(b, elements, this.config.GetHllSparseMaxBytes())
+		if err != nil {
+			return nil, err
+		}
+		changed = created || updated
+		if !changed {
+			return nil, nil
+		}
+		value := storage.NewStringValue(string(b))
+		return &value, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		this.notifyKey(caller, notify.String, "pfadd", key)
+		return datatypes.ConstructInt(1), nil
+	}
+	return datatypes.ConstructInt(0), nil
+}
+
+// cardinality of single key is cached in its header, union of several keys is computed on temporary registers
+func (this *executor) ExecutePfcount(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var keys []string
+	err := requiredArg(cmd, hllcommand.Keys, &keys)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) > 1 {
+		regs := make([]uint8, hyperloglog.RegistersCount)
+		for _, key := range keys {
+			entrie, exists := this.db(caller).GetEntrie(key)
+			if !exists {
+				this.notifyKey(caller, notify.KeyMiss, "keymiss", key)
+				continue
+			}
+			b, err := hllView(entrie)
+			if err != nil {
+				return nil, err
+			}
+			err = hyperloglog.MergeRegisters(regs, b)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return datatypes.ConstructInt(int(hyperloglog.CountRegisters(regs))), nil
+	}
+
+	var card uint64
+	missed := false
+	err = this.db(caller).Update(keys[0], func(entrie *storage.StorageValue) (*storage.StorageValue, error) {
+		if entrie == nil {
+			missed = true
+			return nil, nil
+		}
+		b, err := hllView(entrie)
+		if err != nil {
+			return nil, err
+		}
+		cached, ok := hyperloglog.CachedCount(b)
+		if ok {
+			card = cached
+			return nil, nil
+		}
+		card, err = hyperloglog.Count(b)
+		if err != nil {
+			return nil, err
+		}
+		b = append([]byte{}, b...)
+		hyperloglog.SetCachedCount(b, card)
+		value := storage.NewStringValue(string(b))
+		return &value, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if missed {
+		this.notifyKey(caller, notify.KeyMiss, "keymiss", keys[0])
+	}
+	return datatypes.ConstructInt(int(card)), nil
+}
+
+// destination holds union of itself and sources, it is dense if any of merged HyperLogLogs is dense
+func (this *executor) ExecutePfmerge(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var destKey string
+	var keys []string
+	err := requiredArg(cmd, hllcommand.DestKey, &destKey)
+	if err != nil {
+		return nil, err
+	}
+	err = requiredArg(cmd, hllcommand.Keys, &keys)
+	if err != nil {
+		return nil, err
+	}
+	regs := make([]uint8, hyperloglog.RegistersCount)
+	dense := false
+	for _, key := range keys {
+		entrie, exists := this.db(caller).GetEntrie(key)
+		if !exists {
+			this.notifyKey(caller, notify.KeyMiss, "keymiss", key)
+			continue
+		}
+		b, err := hllView(entrie)
+		if err != nil {
+			return nil, err
+		}
+		err = hyperloglog.MergeRegisters(regs, b)
+		if err != nil {
+			return nil, err
+		}
+		dense = dense || hyperloglog.IsDense(b)
+	}
+	err = this.db(caller).Update(destKey, func(entrie *storage.StorageValue) (*storage.StorageValue, error) {
+		b := hyperloglog.New()
+		if entrie != nil {
+			view, err := hllView(entrie)
+			if err != nil {
+				return nil, err
+			}
+			err = hyperloglog.MergeRegisters(regs, view)
+			if err != nil {
+				return nil, err
+			}
+			b = append([]byte{}, view...)
+		}
+		b, err := hyperloglog.SetRegisters(b, regs, dense || hyperloglog.IsDense(b), this.config.GetHllSparseMaxBytes())
+		if err != nil {
+			return nil, err
+		}
+		value := storage.NewStringValue(string(b))
+		return &value, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	this.notifyKey(caller, notify.String, "pfadd", destKey)
+	return datatypes.ConstructSimpleString("OK"), nil
+}
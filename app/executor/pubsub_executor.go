@@ -0,0 +1,144 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/codecrafters-io/redis-starter-go/app/client"
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+	pubsubcommand "github.com/codecrafters-io/redis-starter-go/app/commands/pubsub_command"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+	"github.com/codecrafters-io/redis-starter-go/app/pubsub"
+	"github.com/codecrafters-io/redis-starter-go/app/slots"
+)
+
+var SlotNotServedError = errors.New("CLUSTERDOWN Hash slot not served")
+
+// (P|S)SUBSCRIBE and (P|S)UNSUBSCRIBE confirm every channel by separate message, messages are joined into single reply
+func joinMessages(messages []*datatypes.Data) *datatypes.Data {
+	raw := []byte{}
+	for _, m := range messages {
+		raw = append(raw, m.Marshall()...)
+	}
+	return &datatypes.Data{Type: messages[0].Type, Raw: raw}
+}
+
+func subscriptionMessage(caller *client.Client, kind string, name *datatypes.Data, count int) *datatypes.Data {
+	return pubsub.NewMessage(caller.GetResp(), datatypes.ConstructBulkString(kind), name, datatypes.ConstructInt(count))
+}
+
+// returns SlotNotServedError if slot of any shard channel is not served by this server
+func (this *executor) checkShardChannels(channels ...string) error {
+	for _, channel := range channels {
+		if !this.slots.Serves(slots.KeySlot(channel)) {
+			return SlotNotServedError
+		}
+	}
+	return nil
+}
+
+// SUBSCRIBE channel [channel ...], PSUBSCRIBE pattern [pattern ...], SSUBSCRIBE shardchannel [shardchannel ...]
+func (this *executor) ExecuteSubscribe(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var names []string
+	namesArg, _ := cmd.Args.GetArgValue(pubsubcommand.Names)
+	namesArg.ToType(&names)
+	kind, subscribe := pubsub.SubscribeKind, this.pubsub.Subscribe
+	switch cmd.Type {
+	case command.PSUBSCRIBE:
+		kind, subscribe = pubsub.PSubscribeKind, this.pubsub.PSubscribe
+	case command.SSUBSCRIBE:
+		kind, subscribe = pubsub.SSubscribeKind, this.pubsub.SSubscribe
+		if err := this.checkShardChannels(names...); err != nil {
+			return nil, err
+		}
+	}
+	messages := make([]*datatypes.Data, len(names))
+	for i, name := range names {
+		count := subscribe(caller, name)
+		messages[i] = subscriptionMessage(caller, kind, datatypes.ConstructBulkString(name), count)
+	}
+	return joinMessages(messages), nil
+}
+
+// UNSUBSCRIBE [channel ...], PUNSUBSCRIBE [pattern ...], SUNSUBSCRIBE [shardchannel ...],
+// without arguments client is unsubscribed from every channel of this kind
+func (this *executor) ExecuteUnsubscribe(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var names []string
+	namesArg, _ := cmd.Args.GetArgValue(pubsubcommand.Names)
+	namesArg.ToType(&names)
+	kind, unsubscribe, subscribed, count := pubsub.UnsubscribeKind, this.pubsub.Unsubscribe, this.pubsub.Channels, this.pubsub.Count
+	switch cmd.Type {
+	case command.PUNSUBSCRIBE:
+		kind, unsubscribe, subscribed = pubsub.PUnsubscribeKind, this.pubsub.PUnsubscribe, this.pubsub.Patterns
+	case command.SUNSUBSCRIBE:
+		kind, unsubscribe, subscribed, count = pubsub.SUnsubscribeKind, this.pubsub.SUnsubscribe, this.pubsub.ShardChannels, this.pubsub.ShardCount
+	}
+	if len(names) == 0 {
+		names = subscribed(caller)
+	}
+	if len(names) == 0 {
+		return subscriptionMessage(caller, kind, datatypes.ConstructNull(), count(caller)), nil
+	}
+	messages := make([]*datatypes.Data, len(names))
+	for i, name := range names {
+		count := unsubscribe(caller, name)
+		messages[i] = subscriptionMessage(caller, kind, datatypes.ConstructBulkString(name), count)
+	}
+	return joinMessages(messages), nil
+}
+
+// PUBLISH channel message, SPUBLISH shardchannel message, replies with amount of clients that received message
+func (this *executor) ExecutePublish(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var channel, message string
+	channelArg, _ := cmd.Args.GetArgValue(pubsubcommand.Channel)
+	channelArg.ToType(&channel)
+	messageArg, _ := cmd.Args.GetArgValue(pubsubcommand.Message)
+	messageArg.ToType(&message)
+	if cmd.Type == command.SPUBLISH {
+		if err := this.checkShardChannels(channel); err != nil {
+			return nil, err
+		}
+		return datatypes.ConstructInt(this.pubsub.SPublish(channel, message)), nil
+	}
+	return datatypes.ConstructInt(this.pubsub.Publish(channel, message)), nil
+}
+
+func (this *executor) ExecutePubsub(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var subcommand string
+	var args []string
+	subcommandArg, _ := cmd.Args.GetArgValue(pubsubcommand.Subcommand)
+	err := subcommandArg.ToType(&subcommand)
+	if err != nil {
+		return nil, fmt.Errorf("Error casting pubsub subcommand: %w", err)
+	}
+	argsArg, _ := cmd.Args.GetArgValue(pubsubcommand.Args)
+	err = argsArg.ToType(&args)
+	if err != nil {
+		return nil, fmt.Errorf("Error casting pubsub args: %w", err)
+	}
+
+	switch subcommand {
+	case pubsubcommand.Channels, pubsubcommand.Shardchannels:
+		pattern := ""
+		if len(args) > 0 {
+			pattern = args[0]
+		}
+		if subcommand == pubsubcommand.Shardchannels {
+			return datatypes.ConstructArray(this.pubsub.ActiveShardChannels(pattern)), nil
+		}
+		return datatypes.ConstructArray(this.pubsub.ActiveChannels(pattern)), nil
+	case pubsubcommand.Numsub, pubsubcommand.Shardnumsub:
+		numSub := this.pubsub.NumSub
+		if subcommand == pubsubcommand.Shardnumsub {
+			numSub = this.pubsub.ShardNumSub
+		}
+		out := make([]*datatypes.Data, 0, len(args)*2)
+		for _, channel := range args {
+			out = append(out, datatypes.ConstructBulkString(channel), datatypes.ConstructInt(numSub(channel)))
+		}
+		return datatypes.ConstructArrayFromData(out), nil
+	case pubsubcommand.Numpat:
+		return datatypes.ConstructInt(this.pubsub.NumPat()), nil
+	}
+	return nil, fmt.Errorf("ERR unknown subcommand '%v'. Try PUBSUB HELP.", subcommand)
+}
@@ -0,0 +1,121 @@
+package executor
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/app/client"
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+	scancommand "github.com/codecrafters-io/redis-starter-go/app/commands/scan_command"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+	"github.com/codecrafters-io/redis-starter-go/app/glob"
+	"github.com/codecrafters-io/redis-starter-go/app/sortedset"
+	"github.com/codecrafters-io/redis-starter-go/app/storage"
+)
+
+var WrongTypeError = errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+
+func (this *executor) ExecuteScan(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var cursor, count int
+	cursorArg, _ := cmd.Args.GetArgValue(scancommand.Cursor)
+	cursorArg.ToType(&cursor)
+	countArg, _ := cmd.Args.GetArgValue(scancommand.Count)
+	countArg.ToType(&count)
+	var pattern, typeName string
+	matchArg, hasMatch := cmd.Args.GetArgValue(scancommand.Match)
+	if hasMatch {
+		matchArg.ToType(&pattern)
+		// like in redis, * is not matched, so it includes empty key too
+		hasMatch = pattern != "*"
+	}
+	typeArg, hasType := cmd.Args.GetArgValue(scancommand.Type)
+	if hasType {
+		typeArg.ToType(&typeName)
+		typeName = strings.ToLower(typeName)
+	}
+
+	keys := []string{}
+	next := this.db(caller).Scan(uint64(cursor), count, func(key string, val storage.StorageValue) {
+		if hasType && scanTypeName(val.GetType()) != typeName {
+			return
+		}
+		if hasMatch && !glob.Match(pattern, key, false) {
+			return
+		}
+		keys = append(keys, key)
+	})
+	return constructScanReply(next, keys), nil
+}
+
+// hashes and sets are not supported by storage yet, so they are scanned as not existing keys,
+// sorted sets small enough to be kept in listpack by redis are returned in single call as redis does
+func (this *executor) ExecuteKeyScan(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var key string
+	keyArg, _ := cmd.Args.GetArgValue(scancommand.Key)
+	keyArg.ToType(&key)
+	entrie, ok := this.db(caller).GetEntrie(key)
+	if !ok {
+		return constructScanReply(0, []string{}), nil
+	}
+	if cmd.Type != command.ZSCAN || entrie.GetType() != storage.ZSet {
+		return nil, WrongTypeError
+	}
+	var cursor, count int
+	cursorArg, _ := cmd.Args.GetArgValue(scancommand.Cursor)
+	cursorArg.ToType(&cursor)
+	countArg, _ := cmd.Args.GetArgValue(scancommand.Count)
+	countArg.ToType(&count)
+	var pattern string
+	matchArg, hasMatch := cmd.Args.GetArgValue(scancommand.Match)
+	if hasMatch {
+		matchArg.ToType(&pattern)
+		// like in redis, * is not matched, so it includes empty key too
+		hasMatch = pattern != "*"
+	}
+	zset, err := entrie.ToZSet()
+	if err != nil {
+		return nil, err
+	}
+	elements := []string{}
+	add := func(member string, score float64) {
+		if hasMatch && !glob.Match(pattern, member, false) {
+			return
+		}
+		elements = append(elements, member, sortedset.FormatScore(score))
+	}
+	if this.isListpackZSet(zset) {
+		zset.ForEach(add)
+		return constructScanReply(0, elements), nil
+	}
+	next := zset.Scan(uint64(cursor), count, add)
+	return constructScanReply(next, elements), nil
+}
+
+// reports would redis keep sorted set in listpack encoding
+func (this *executor) isListpackZSet(zset sortedset.SortedSet) bool {
+	if zset.Len() > this.config.GetZSetMaxListpackEntries() {
+		return false
+	}
+	maxValue := this.config.GetZSetMaxListpackValue()
+	small := true
+	zset.ForEach(func(member string, _ float64) {
+		small = small && len(member) <= maxValue
+	})
+	return small
+}
+
+// integers are stored as separate type but are strings for clients
+func scanTypeName(t storage.DataTypes) string {
+	if t == storage.Int {
+		return storage.String
+	}
+	return string(t)
+}
+
+func constructScanReply(cursor uint64, elements []string) *datatypes.Data {
+	return datatypes.ConstructArrayFromData([]*datatypes.Data{
+		datatypes.ConstructBulkString(strconv.FormatUint(cursor, 10)),
+		datatypes.ConstructArray(elements),
+	})
+}
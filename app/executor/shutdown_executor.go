@@ -0,0 +1,32 @@
+package executor
+
+import (
+	"github.com/codecrafters-io/redis-starter-go/app/client"
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+	shutdowncommand "github.com/codecrafters-io/redis-starter-go/app/commands/shutdown_command"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+	"github.com/codecrafters-io/redis-starter-go/app/shutdown"
+)
+
+func (this *executor) ExecuteShutdown(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	if _, ok := cmd.Args.GetArgValue(shutdowncommand.Abort); ok {
+		err := this.shutdown.Abort()
+		if err != nil {
+			return nil, err
+		}
+		return datatypes.ConstructSimpleString("OK"), nil
+	}
+	var saveMode string
+	saveModeArg, _ := cmd.Args.GetArgValue(shutdowncommand.SaveMode)
+	saveModeArg.ToType(&saveMode)
+	_, now := cmd.Args.GetArgValue(shutdowncommand.Now)
+	_, force := cmd.Args.GetArgValue(shutdowncommand.Force)
+
+	err := this.shutdown.Run(shutdown.Options{
+		Save:   saveMode == shutdowncommand.Save,
+		NoSave: saveMode == shutdowncommand.NoSave,
+		Now:    now,
+		Force:  force,
+	})
+	return nil, err
+}
@@ -0,0 +1,214 @@
+package executor
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/client"
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+	xaddcommand "github.com/codecrafters-io/redis-starter-go/app/commands/xadd_command"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+	"github.com/codecrafters-io/redis-starter-go/app/notify"
+	"github.com/codecrafters-io/redis-starter-go/app/stream"
+)
+
+var NoSuchKeyError = errors.New("ERR no such key")
+
+// replies number of entries in stream, 0 if key does not exist
+func (this *executor) ExecuteXlen(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var key string
+	keyArg, _ := cmd.Args.GetArgValue(xaddcommand.Key)
+	keyArg.ToType(&key)
+	s, err := this.readStream(caller, key)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return datatypes.ConstructInt(0), nil
+	}
+	return datatypes.ConstructInt(s.Len()), nil
+}
+
+// replies number of deleted entries, deleted ids are remembered to keep consumer group lag correct
+func (this *executor) ExecuteXdel(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var key string
+	var ids []stream.StreamEntrieId
+	keyArg, _ := cmd.Args.GetArgValue(xaddcommand.Key)
+	keyArg.ToType(&key)
+	idsArg, _ := cmd.Args.GetArgValue(xaddcommand.Ids)
+	idsArg.ToType(&ids)
+	s, err := this.getStream(caller, key)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return datatypes.ConstructInt(0), nil
+	}
+	deleted := s.Delete(ids)
+	if deleted > 0 {
+		this.notifyKey(caller, notify.Stream, "xdel", key)
+	}
+	return datatypes.ConstructInt(deleted), nil
+}
+
+// replies number of trimmed entries
+func (this *executor) ExecuteXtrim(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var key string
+	var trim stream.TrimOptions
+	keyArg, _ := cmd.Args.GetArgValue(xaddcommand.Key)
+	keyArg.ToType(&key)
+	trimArg, _ := cmd.Args.GetArgValue(xaddcommand.Trim)
+	trimArg.ToType(&trim)
+	s, err := this.getStream(caller, key)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return datatypes.ConstructInt(0), nil
+	}
+	trimmed := s.Trim(trim)
+	if trimmed > 0 {
+		this.notifyKey(caller, notify.Stream, "xtrim", key)
+		// approximate trimming depends on node layout, so replicas trim to the resulting length
+		cmd.Rewrite(command.XTRIM, key, "MAXLEN", "=", strconv.Itoa(s.Len()))
+	}
+	return datatypes.ConstructInt(trimmed), nil
+}
+
+// sets last generated id of stream and optionally its entries added counter and max deleted id
+func (this *executor) ExecuteXsetid(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var key string
+	var query xaddcommand.SetIdQuery
+	keyArg, _ := cmd.Args.GetArgValue(xaddcommand.Key)
+	keyArg.ToType(&key)
+	queryArg, _ := cmd.Args.GetArgValue(xaddcommand.SetId)
+	queryArg.ToType(&query)
+	s, err := this.getStream(caller, key)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, NoSuchKeyError
+	}
+	if err := s.SetId(query.Id, query.EntriesAdded, query.MaxDeletedId); err != nil {
+		return nil, err
+	}
+	this.notifyKey(caller, notify.Stream, "xsetid", key)
+	return datatypes.ConstructSimpleString("OK"), nil
+}
+
+type streamWaitKey struct {
+	db  int
+	key string
+}
+
+// wakes readers blocked on keys which do not hold a stream yet
+type streamKeyWaiters struct {
+	mu      sync.Mutex
+	next    int
+	waiters map[streamWaitKey]map[int]chan<- struct{}
+}
+
+func newStreamKeyWaiters() *streamKeyWaiters {
+	return &streamKeyWaiters{waiters: map[streamWaitKey]map[int]chan<- struct{}{}}
+}
+
+// registers chan which receives value when stream is created at key, returned func unregisters it
+func (this *streamKeyWaiters) watch(db int, key string, c chan<- struct{}) func() {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	k := streamWaitKey{db: db, key: key}
+	if this.waiters[k] == nil {
+		this.waiters[k] = map[int]chan<- struct{}{}
+	}
+	id := this.next
+	this.next++
+	this.waiters[k][id] = c
+	return func() {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+		delete(this.waiters[k], id)
+		if len(this.waiters[k]) == 0 {
+			delete(this.waiters, k)
+		}
+	}
+}
+
+func (this *streamKeyWaiters) notify(db int, key string) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	for _, c := range this.waiters[streamWaitKey{db: db, key: key}] {
+		select {
+		case c <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// blocks client of XREAD or XREADGROUP until one of watched streams is changed
+type streamBlocker struct {
+	caller  *client.Client
+	changed chan struct{}
+	// 0 means forever
+	timeout   int
+	deadline  <-chan time.Time
+	unblocked <-chan struct{}
+	watched   map[stream.Stream]bool
+	cancels   []func()
+}
+
+func newStreamBlocker(caller *client.Client, timeout int) *streamBlocker {
+	return &streamBlocker{
+		caller:  caller,
+		changed: make(chan struct{}, 1),
+		timeout: timeout,
+		watched: map[stream.Stream]bool{},
+	}
+}
+
+func (this *streamBlocker) add(cancel func()) {
+	this.cancels = append(this.cancels, cancel)
+}
+
+// starts watching stream, it should be called before reading it to not miss changes made in between
+func (this *streamBlocker) watch(s stream.Stream) {
+	if this.watched[s] {
+		return
+	}
+	this.watched[s] = true
+	this.add(s.NotifyOnChange(this.changed))
+}
+
+// waits for change, reports false on timeout or when client is unblocked by CLIENT UNBLOCK TIMEOUT,
+// client unblocked by CLIENT UNBLOCK ERROR gets UnblockedError
+func (this *streamBlocker) wait() (bool, error) {
+	if this.unblocked == nil {
+		this.unblocked = this.caller.Block()
+		if this.timeout > 0 {
+			this.deadline = time.After(time.Duration(this.timeout) * time.Millisecond)
+		}
+	}
+	select {
+	case <-this.changed:
+		return true, nil
+	case <-this.deadline:
+		return false, nil
+	case <-this.unblocked:
+		if this.caller.EndBlock() == client.UnblockError {
+			return false, client.UnblockedError
+		}
+		return false, nil
+	}
+}
+
+// unregisters waiters, so abandoned readers are not notified
+func (this *streamBlocker) close() {
+	for _, cancel := range this.cancels {
+		cancel()
+	}
+	if this.unblocked != nil {
+		this.caller.EndBlock()
+	}
+}
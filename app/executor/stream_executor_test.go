@@ -0,0 +1,240 @@
+package executor
+
+import (
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/acl"
+	"github.com/codecrafters-io/redis-starter-go/app/client"
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+	"github.com/codecrafters-io/redis-starter-go/app/config"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+	"github.com/codecrafters-io/redis-starter-go/app/logger"
+	"github.com/codecrafters-io/redis-starter-go/app/offset_counter"
+	"github.com/codecrafters-io/redis-starter-go/app/pubsub"
+	"github.com/codecrafters-io/redis-starter-go/app/replicas_storage"
+	"github.com/codecrafters-io/redis-starter-go/app/slots"
+	"github.com/codecrafters-io/redis-starter-go/app/storage"
+	"go.uber.org/zap"
+)
+
+var (
+	testConfig     *config.Config
+	testConfigErr  error
+	testConfigOnce sync.Once
+)
+
+type testConn struct{}
+
+func (testConn) Write(p []byte) (int, error) { return len(p), nil }
+func (testConn) Close() error                { return nil }
+func (testConn) RemoteAddr() net.Addr        { return &net.TCPAddr{} }
+func (testConn) LocalAddr() net.Addr         { return &net.TCPAddr{} }
+
+type testServer struct {
+	t       *testing.T
+	exec    *executor
+	acl     *acl.Acl
+	clients *client.Table
+}
+
+// config registers command line flags, so it is created once, the rest of server state is fresh for every test
+func newTestServer(t *testing.T) *testServer {
+	testConfigOnce.Do(func() {
+		logger.Logger = zap.NewNop()
+		testConfig, testConfigErr = config.New()
+	})
+	if testConfigErr != nil {
+		t.Fatalf("expected no error, got %v", testConfigErr)
+	}
+	accessList, err := acl.New(testConfig)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	clients := client.NewTable()
+	exec := New(offset_counter.New(), replicas_storage.New(testConfig), storage.NewDatabases(16), testConfig, accessList, clients, nil, pubsub.New(), slots.NewTable(), nil)
+	return &testServer{t: t, exec: exec.(*executor), acl: accessList, clients: clients}
+}
+
+func (this *testServer) newClient() *client.Client {
+	user, authenticated := this.acl.GetDefaultUser()
+	c, err := this.clients.Register(testConn{}, client.NormalType, user, authenticated, 0)
+	if err != nil {
+		this.t.Fatalf("expected no error, got %v", err)
+	}
+	return c
+}
+
+func (this *testServer) run(c *client.Client, args ...string) *datatypes.Data {
+	cmd, err := command.DataTypeToCommand(datatypes.ConstructArray(args))
+	if err != nil {
+		this.t.Fatalf("expected no error parsing %v, got %v", args, err)
+	}
+	return this.exec.ExecuteCmd(c, cmd, true)
+}
+
+// runs blocking command aside and waits until its client is blocked
+func (this *testServer) runBlocked(c *client.Client, args ...string) <-chan *datatypes.Data {
+	out := make(chan *datatypes.Data, 1)
+	go func() {
+		out <- this.run(c, args...)
+	}()
+	deadline := time.Now().Add(2 * time.Second)
+	for !c.IsBlocked() {
+		if time.Now().After(deadline) {
+			this.t.Fatalf("expected %v to block", args)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return out
+}
+
+func (this *testServer) reply(out <-chan *datatypes.Data) *datatypes.Data {
+	select {
+	case res := <-out:
+		return res
+	case <-time.After(2 * time.Second):
+		this.t.Fatalf("expected blocked command to reply")
+		return nil
+	}
+}
+
+// formats XREAD reply as "key:id,id key:id"
+func readedIds(res *datatypes.Data) string {
+	if res.Type != datatypes.ARRAY {
+		return string(res.Marshall())
+	}
+	streams := []string{}
+	for _, s := range res.Values {
+		ids := []string{}
+		for _, e := range s.Values[1].Values {
+			ids = append(ids, e.Values[0].Value)
+		}
+		streams = append(streams, s.Values[0].Value+":"+strings.Join(ids, ","))
+	}
+	return strings.Join(streams, " ")
+}
+
+// reports are waiters of keys and of streams under keys unregistered
+func (this *testServer) hasWaiters(c *client.Client, keys ...string) bool {
+	this.exec.streamWaiters.mu.Lock()
+	keyWaiters := len(this.exec.streamWaiters.waiters)
+	this.exec.streamWaiters.mu.Unlock()
+	if keyWaiters > 0 {
+		return true
+	}
+	for _, key := range keys {
+		s, err := this.exec.getStream(c, key)
+		if err != nil {
+			this.t.Fatalf("expected no error, got %v", err)
+		}
+		if s != nil && reflect.ValueOf(s).Elem().FieldByName("waiters").Len() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func TestXRead_ReturnsEveryStreamWithData(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.newClient()
+	for _, id := range []string{"1-1", "2-1", "3-1"} {
+		srv.run(c, "XADD", "a", id, "f", "v")
+	}
+	srv.run(c, "XADD", "b", "2-1", "f", "v")
+
+	cases := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"XREAD", "STREAMS", "a", "b", "0", "0"}, "a:1-1,2-1,3-1 b:2-1"},
+		{[]string{"XREAD", "STREAMS", "a", "b", "1-1", "2-1"}, "a:2-1,3-1"},
+		{[]string{"XREAD", "COUNT", "2", "STREAMS", "a", "b", "0", "0"}, "a:1-1,2-1 b:2-1"},
+		{[]string{"XREAD", "COUNT", "1", "STREAMS", "a", "1-1"}, "a:2-1"},
+		{[]string{"XREAD", "STREAMS", "a", "b", "missing", "+", "+", "+"}, "a:3-1 b:2-1"},
+		{[]string{"XREAD", "BLOCK", "0", "STREAMS", "a", "b", "0", "$"}, "a:1-1,2-1,3-1"},
+		{[]string{"XREAD", "STREAMS", "a", "b", "$", "$"}, "*-1\r\n"},
+		{[]string{"XREAD", "STREAMS", "missing", "0"}, "*-1\r\n"},
+	}
+	for _, tc := range cases {
+		if got := readedIds(srv.run(c, tc.args...)); got != tc.want {
+			t.Errorf("expected %v to read %q, got %q", tc.args, tc.want, got)
+		}
+	}
+}
+
+func TestXRead_BlockedOnSeveralStreamsWakesOnAny(t *testing.T) {
+	for _, woken := range []string{"a", "b", "c"} {
+		srv := newTestServer(t)
+		reader, writer := srv.newClient(), srv.newClient()
+		srv.run(writer, "XADD", "a", "1-1", "f", "v")
+		srv.run(writer, "XADD", "b", "1-1", "f", "v")
+
+		// c does not exist yet, so it is created while reader is blocked
+		out := srv.runBlocked(reader, "XREAD", "BLOCK", "0", "COUNT", "1", "STREAMS", "a", "b", "c", "$", "$", "$")
+		srv.run(writer, "XADD", woken, "5-1", "f", "v")
+		srv.run(writer, "XADD", woken, "6-1", "f", "v")
+		if got := readedIds(srv.reply(out)); got != woken+":5-1" {
+			t.Errorf("expected reader to be woken by %v, got %q", woken, got)
+		}
+		if srv.hasWaiters(reader, "a", "b", "c") {
+			t.Errorf("expected waiters to be unregistered after %v woke reader", woken)
+		}
+	}
+}
+
+func TestXRead_BlockedWithLastEntryIdReadsNewEntry(t *testing.T) {
+	srv := newTestServer(t)
+	reader, writer := srv.newClient(), srv.newClient()
+	out := srv.runBlocked(reader, "XREAD", "BLOCK", "0", "STREAMS", "s", "+")
+	srv.run(writer, "XADD", "s", "1-1", "f", "v")
+	if got := readedIds(srv.reply(out)); got != "s:1-1" {
+		t.Errorf("expected entry added while blocked to be read, got %q", got)
+	}
+	// + of existing stream is its last entry, so reader does not block
+	srv.run(writer, "XADD", "s", "2-1", "f", "v")
+	if got := readedIds(srv.run(reader, "XREAD", "BLOCK", "0", "STREAMS", "s", "+")); got != "s:2-1" {
+		t.Errorf("expected last entry to be read, got %q", got)
+	}
+}
+
+func TestXRead_TimeoutUnregistersWaiters(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.newClient()
+	srv.run(c, "XADD", "a", "1-1", "f", "v")
+	start := time.Now()
+	res := srv.run(c, "XREAD", "BLOCK", "50", "STREAMS", "a", "missing", "$", "$")
+	if got := readedIds(res); got != "*-1\r\n" || time.Since(start) < 50*time.Millisecond {
+		t.Fatalf("expected null reply after timeout, got %q after %v", got, time.Since(start))
+	}
+	if c.IsBlocked() || srv.hasWaiters(c, "a") {
+		t.Fatalf("expected client to be unblocked and waiters to be unregistered after timeout")
+	}
+}
+
+func TestXRead_ClientUnblockUnregistersWaiters(t *testing.T) {
+	cases := map[string]string{
+		"TIMEOUT": "*-1\r\n",
+		"ERROR":   "-" + client.UnblockedError.Error() + "\r\n",
+	}
+	for reason, want := range cases {
+		srv := newTestServer(t)
+		reader, admin := srv.newClient(), srv.newClient()
+		srv.run(admin, "XADD", "a", "1-1", "f", "v")
+		out := srv.runBlocked(reader, "XREAD", "BLOCK", "0", "STREAMS", "a", "missing", "$", "$")
+		if got := srv.run(admin, "CLIENT", "UNBLOCK", strconv.FormatInt(reader.GetId(), 10), reason); string(got.Marshall()) != ":1\r\n" {
+			t.Fatalf("expected client to be unblocked, got %q", got.Marshall())
+		}
+		if got := readedIds(srv.reply(out)); got != want {
+			t.Errorf("expected %q reply after CLIENT UNBLOCK %v, got %q", want, reason, got)
+		}
+		if reader.IsBlocked() || srv.hasWaiters(reader, "a") {
+			t.Errorf("expected waiters to be unregistered after CLIENT UNBLOCK %v", reason)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package executor
+
+import (
+	"errors"
+
+	"github.com/codecrafters-io/redis-starter-go/app/client"
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+	stringcommand "github.com/codecrafters-io/redis-starter-go/app/commands/string_command"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+	"github.com/codecrafters-io/redis-starter-go/app/notify"
+	"github.com/codecrafters-io/redis-starter-go/app/storage"
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+var StringTooLongError = errors.New("ERR string exceeds maximum allowed size (proto-max-bulk-len)")
+
+// returns string held by key for read only command, missing key is empty string, ok is false for keys of other types
+func (this *executor) getString(caller *client.Client, key string) (string, bool) {
+	entrie, exists := this.db(caller).GetEntrie(key)
+	if !exists {
+		this.notifyKey(caller, notify.KeyMiss, "keymiss", key)
+		return "", true
+	}
+	return entrie.AsString()
+}
+
+func (this *executor) checkStringLen(length int) error {
+	if int64(length) > this.config.GetProtoMaxBulkLen() {
+		return StringTooLongError
+	}
+	return nil
+}
+
+// checks that string written at offset fits proto-max-bulk-len, offset near MaxInt64 is not added to length,
+// so the check can not be passed by overflow
+func (this *executor) checkStringRange(offset int, length int) error {
+	if int64(offset) > this.config.GetProtoMaxBulkLen()-int64(length) {
+		return StringTooLongError
+	}
+	return nil
+}
+
+// integer encoded values are appended as their string representation and stored raw afterwards
+func (this *executor) ExecuteAppend(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var key, value string
+	keyArg, _ := cmd.Args.GetArgValue(stringcommand.Key)
+	keyArg.ToType(&key)
+	valueArg, _ := cmd.Args.GetArgValue(stringcommand.Value)
+	valueArg.ToType(&value)
+	var length int
+	err := this.db(caller).Update(key, func(entrie *storage.StorageValue) (*storage.StorageValue, error) {
+		current := ""
+		if entrie != nil {
+			str, ok := entrie.AsString()
+			if !ok {
+				return nil, WrongTypeError
+			}
+			current = str
+		}
+		length = len(current) + len(value)
+		err := this.checkStringLen(length)
+		if err != nil {
+			return nil, err
+		}
+		updated := storage.NewStringValue(current + value)
+		return &updated, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	this.notifyKey(caller, notify.String, "append", key)
+	return datatypes.ConstructInt(length), nil
+}
+
+func (this *executor) ExecuteStrlen(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var key string
+	keyArg, _ := cmd.Args.GetArgValue(stringcommand.Key)
+	keyArg.ToType(&key)
+	str, ok := this.getString(caller, key)
+	if !ok {
+		return nil, WrongTypeError
+	}
+	return datatypes.ConstructInt(len(str)), nil
+}
+
+// negative offsets count from the end of string, range is clamped to string bounds
+func (this *executor) ExecuteGetrange(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var key string
+	var start, end int
+	keyArg, _ := cmd.Args.GetArgValue(stringcommand.Key)
+	keyArg.ToType(&key)
+	startArg, _ := cmd.Args.GetArgValue(stringcommand.Start)
+	startArg.ToType(&start)
+	endArg, _ := cmd.Args.GetArgValue(stringcommand.End)
+	endArg.ToType(&end)
+	str, ok := this.getString(caller, key)
+	if !ok {
+		return nil, WrongTypeError
+	}
+	if start < 0 && end < 0 && start > end {
+		return datatypes.ConstructBulkString(""), nil
+	}
+	if start < 0 {
+		start = len(str) + start
+	}
+	if end < 0 {
+		end = len(str) + end
+	}
+	start = max(start, 0)
+	end = min(max(end, 0), len(str)-1)
+	if start > end || len(str) == 0 {
+		return datatypes.ConstructBulkString(""), nil
+	}
+	return datatypes.ConstructBulkString(str[start : end+1]), nil
+}
+
+// string is padded with zero bytes up to offset, empty value does not create missing key
+func (this *executor) ExecuteSetrange(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var key, value string
+	var offset int
+	keyArg, _ := cmd.Args.GetArgValue(stringcommand.Key)
+	keyArg.ToType(&key)
+	offsetArg, _ := cmd.Args.GetArgValue(stringcommand.Offset)
+	offsetArg.ToType(&offset)
+	valueArg, _ := cmd.Args.GetArgValue(stringcommand.Value)
+	valueArg.ToType(&value)
+	var length int
+	err := this.db(caller).Update(key, func(entrie *storage.StorageValue) (*storage.StorageValue, error) {
+		current := ""
+		if entrie != nil {
+			str, ok := entrie.AsString()
+			if !ok {
+				return nil, WrongTypeError
+			}
+			current = str
+		}
+		length = len(current)
+		if len(value) == 0 {
+			return nil, nil
+		}
+		err := this.checkStringRange(offset, len(value))
+		if err != nil {
+			return nil, err
+		}
+		buf := []byte(current)
+		if len(buf) < offset+len(value) {
+			buf = append(buf, make([]byte, offset+len(value)-len(buf))...)
+		}
+		copy(buf[offset:], value)
+		length = len(buf)
+		updated := storage.NewStringValue(string(buf))
+		return &updated, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(value) > 0 {
+		this.notifyKey(caller, notify.String, "setrange", key)
+	}
+	return datatypes.ConstructInt(length), nil
+}
+
+// missing keys and keys of other types are returned as nulls
+func (this *executor) ExecuteMget(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var keys []string
+	keysArg, _ := cmd.Args.GetArgValue(stringcommand.Keys)
+	keysArg.ToType(&keys)
+	res := make([]*datatypes.Data, 0, len(keys))
+	for _, key := range keys {
+		entrie, ok := this.db(caller).GetEntrie(key)
+		if !ok {
+			this.notifyKey(caller, notify.KeyMiss, "keymiss", key)
+			res = append(res, datatypes.ConstructNull())
+			continue
+		}
+		str, ok := entrie.AsString()
+		if !ok {
+			res = append(res, datatypes.ConstructNull())
+			continue
+		}
+		res = append(res, datatypes.ConstructBulkString(str))
+	}
+	return datatypes.ConstructArrayFromData(res), nil
+}
+
+func (this *executor) ExecuteMset(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	this.mset(caller, cmd, false)
+	return datatypes.ConstructSimpleString("OK"), nil
+}
+
+func (this *executor) ExecuteMsetnx(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	if this.mset(caller, cmd, true) {
+		return datatypes.ConstructInt(1), nil
+	}
+	return datatypes.ConstructInt(0), nil
+}
+
+func (this *executor) mset(caller *client.Client, cmd *command.Command, nx bool) bool {
+	var kvs []types.Kv
+	kvsArg, _ := cmd.Args.GetArgValue(stringcommand.Kvs)
+	kvsArg.ToType(&kvs)
+	keys := make([]string, 0, len(kvs))
+	vals := make([]storage.StorageValue, 0, len(kvs))
+	for _, kv := range kvs {
+		keys = append(keys, kv[0])
+		vals = append(vals, storage.NewValueFromString(kv[1]))
+	}
+	if !this.db(caller).SetMany(keys, vals, nx) {
+		return false
+	}
+	for _, key := range keys {
+		this.notifyKey(caller, notify.String, "set", key)
+	}
+	return true
+}
+
+// longest common subsequence computed with dynamic programming table of (len(a)+1)*(len(b)+1) lengths,
+// IDX walks table back from the end and reports matched ranges from last to first
+func (this *executor) ExecuteLcs(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var keys []string
+	keysArg, _ := cmd.Args.GetArgValue(stringcommand.Keys)
+	keysArg.ToType(&keys)
+	a, okA := this.getString(caller, keys[0])
+	b, okB := this.getString(caller, keys[1])
+	if !okA || !okB {
+		return nil, errors.New("ERR The specified keys must contain string values")
+	}
+	_, getLen := cmd.Args.GetArgValue(stringcommand.Len)
+	_, getIdx := cmd.Args.GetArgValue(stringcommand.Idx)
+	_, withMatchLen := cmd.Args.GetArgValue(stringcommand.WithMatchLen)
+	minMatchLen := 0
+	if minArg, ok := cmd.Args.GetArgValue(stringcommand.MinMatchLen); ok {
+		minArg.ToType(&minMatchLen)
+	}
+
+	if int64(len(a)+1)*int64(len(b)+1)*4 > this.config.GetProtoMaxBulkLen() {
+		return nil, errors.New("ERR Insufficient memory, transient memory for LCS exceeds proto-max-bulk-len")
+	}
+	width := len(b) + 1
+	lcs := make([]uint32, (len(a)+1)*width)
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			if a[i-1] == b[j-1] {
+				lcs[i*width+j] = lcs[(i-1)*width+j-1] + 1
+			} else {
+				lcs[i*width+j] = max(lcs[(i-1)*width+j], lcs[i*width+j-1])
+			}
+		}
+	}
+	total := int(lcs[len(a)*width+len(b)])
+	if getLen {
+		return datatypes.ConstructInt(total), nil
+	}
+
+	result := make([]byte, total)
+	matches := []*datatypes.Data{}
+	idx := total
+	// start of current range is len(a) while no range is tracked
+	aStart, aEnd, bStart, bEnd := len(a), 0, 0, 0
+	for i, j := len(a), len(b); i > 0 && j > 0; {
+		emit := false
+		if a[i-1] == b[j-1] {
+			result[idx-1] = a[i-1]
+			if aStart == len(a) {
+				aStart, aEnd, bStart, bEnd = i-1, i-1, j-1, j-1
+			} else if aStart == i && bStart == j {
+				aStart--
+				bStart--
+			} else {
+				emit = true
+			}
+			// range can not be extended past first byte of one of strings
+			if aStart == 0 || bStart == 0 {
+				emit = true
+			}
+			idx--
+			i--
+			j--
+		} else {
+			if lcs[(i-1)*width+j] > lcs[i*width+j-1] {
+				i--
+			} else {
+				j--
+			}
+			if aStart != len(a) {
+				emit = true
+			}
+		}
+		if !emit {
+			continue
+		}
+		matchLen := aEnd - aStart + 1
+		if getIdx && matchLen >= minMatchLen {
+			match := []*datatypes.Data{
+				datatypes.ConstructArrayFromData([]*datatypes.Data{datatypes.ConstructInt(aStart), datatypes.ConstructInt(aEnd)}),
+				datatypes.ConstructArrayFromData([]*datatypes.Data{datatypes.ConstructInt(bStart), datatypes.ConstructInt(bEnd)}),
+			}
+			if withMatchLen {
+				match = append(match, datatypes.ConstructInt(matchLen))
+			}
+			matches = append(matches, datatypes.ConstructArrayFromData(match))
+		}
+		aStart = len(a)
+	}
+
+	if getIdx {
+		return datatypes.ConstructArrayFromData([]*datatypes.Data{
+			datatypes.ConstructBulkString("matches"),
+			datatypes.ConstructArrayFromData(matches),
+			datatypes.ConstructBulkString("len"),
+			datatypes.ConstructInt(total),
+		}), nil
+	}
+	return datatypes.ConstructBulkString(string(result)), nil
+}
@@ -0,0 +1,34 @@
+package executor
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestSetrange(t *testing.T) {
+	srv := newTestServer(t)
+	c := srv.newClient()
+	maxLen := strconv.FormatInt(testConfig.GetProtoMaxBulkLen(), 10)
+	tooLong := "-" + StringTooLongError.Error() + "\r\n"
+	cases := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"SET", "a", "hello"}, "+OK\r\n"},
+		{[]string{"SETRANGE", "a", "1", "EL"}, ":5\r\n"},
+		{[]string{"SETRANGE", "a", "7", "!"}, ":8\r\n"},
+		{[]string{"GET", "a"}, "$8\r\nhELlo\x00\x00!\r\n"},
+		// offset + length overflowed int and passed the check, then slicing panicked
+		{[]string{"SETRANGE", "a", "9223372036854775807", "x"}, tooLong},
+		{[]string{"SETRANGE", "a", "9223372036854775806", "xyz"}, tooLong},
+		{[]string{"SETRANGE", "missing", "9223372036854775807", "x"}, tooLong},
+		{[]string{"SETRANGE", "a", maxLen, "x"}, tooLong},
+		{[]string{"SETRANGE", "a", "9223372036854775807", ""}, ":8\r\n"},
+		{[]string{"GET", "a"}, "$8\r\nhELlo\x00\x00!\r\n"},
+	}
+	for _, tc := range cases {
+		if got := string(srv.run(c, tc.args...).Marshall()); got != tc.want {
+			t.Errorf("expected %v to reply %q, got %q", tc.args, tc.want, got)
+		}
+	}
+}
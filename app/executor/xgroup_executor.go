@@ -0,0 +1,376 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/client"
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+	xgroupcommand "github.com/codecrafters-io/redis-starter-go/app/commands/xgroup_command"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+	"github.com/codecrafters-io/redis-starter-go/app/notify"
+	"github.com/codecrafters-io/redis-starter-go/app/storage"
+	"github.com/codecrafters-io/redis-starter-go/app/stream"
+)
+
+var XgroupNoKeyError = errors.New("ERR The XGROUP subcommand requires the key to exist. Note that for CREATE you may want to use the MKSTREAM option to create an empty stream automatically.")
+var GroupDestroyedError = errors.New("NOGROUP the consumer group this client was blocked on no longer exists")
+
+// returns stream stored at key, nil if key does not exist
+func (this *executor) getStream(caller *client.Client, key string) (stream.Stream, error) {
+	entrie, exists := this.db(caller).GetEntrie(key)
+	if !exists {
+		return nil, nil
+	}
+	if entrie.GetType() != storage.Stream {
+		return nil, WrongTypeError
+	}
+	return entrie.ToStream()
+}
+
+// returns stream stored at key for read only command, missing key is reported as key miss event
+func (this *executor) readStream(caller *client.Client, key string) (stream.Stream, error) {
+	s, err := this.getStream(caller, key)
+	if s == nil && err == nil {
+		this.notifyKey(caller, notify.KeyMiss, "keymiss", key)
+	}
+	return s, err
+}
+
+// consumer is created on first use by XREADGROUP, XCLAIM and XAUTOCLAIM, like by XGROUP CREATECONSUMER
+func (this *executor) createConsumer(caller *client.Client, cmd *command.Command, key string, s stream.Stream, group string, consumer string) {
+	created, err := s.CreateConsumer(group, consumer, time.Now().UnixMilli())
+	if err == nil && created {
+		this.notifyKey(caller, notify.Stream, "xgroup-createconsumer", key)
+		cmd.Rewrite(command.XGROUP, xgroupcommand.CreateConsumer, key, group, consumer)
+	}
+}
+
+// delivered and claimed entries depend on time of master, so like in redis they are propagated
+// as forced claims which set delivery state of each pending entrie
+func rewriteClaims(cmd *command.Command, key string, group string, consumer string, deliveries []stream.Delivery) {
+	for _, d := range deliveries {
+		if d.Entrie == nil {
+			continue
+		}
+		cmd.Rewrite(command.XCLAIM, key, group, consumer, "0", d.Id.String(),
+			"TIME", strconv.FormatInt(d.DeliveryTime, 10), "RETRYCOUNT", strconv.FormatInt(d.DeliveryCount, 10), "FORCE", "JUSTID")
+	}
+}
+
+// propagates pending entries of deleted stream entries dropped by claim
+func rewriteDeleted(cmd *command.Command, key string, group string, deleted []stream.StreamEntrieId) {
+	if len(deleted) == 0 {
+		return
+	}
+	args := []string{key, group}
+	for _, id := range deleted {
+		args = append(args, id.String())
+	}
+	cmd.Rewrite(command.XACK, args...)
+}
+
+// propagates last delivered id of group with its entries read counter
+func rewriteGroupId(cmd *command.Command, key string, s stream.Stream, group string) {
+	lastId, entriesRead, err := s.GroupPosition(group)
+	if err != nil {
+		return
+	}
+	cmd.Rewrite(command.XGROUP, xgroupcommand.SetId, key, group, lastId.String(), "ENTRIESREAD", strconv.FormatInt(entriesRead, 10))
+}
+
+func noGroupError(key string, group string) error {
+	return fmt.Errorf("NOGROUP No such key '%v' or consumer group '%v'", key, group)
+}
+
+func constructDeliveries(deliveries []stream.Delivery, justId bool) *datatypes.Data {
+	out := make([]*datatypes.Data, 0, len(deliveries))
+	for _, d := range deliveries {
+		switch {
+		case justId:
+			out = append(out, datatypes.ConstructBulkString(d.Id.String()))
+		case d.Entrie == nil:
+			out = append(out, datatypes.ConstructArrayFromData([]*datatypes.Data{
+				datatypes.ConstructBulkString(d.Id.String()),
+				datatypes.ConstructNullArray(),
+			}))
+		default:
+			out = append(out, d.Entrie.ToDataType())
+		}
+	}
+	return datatypes.ConstructArrayFromData(out)
+}
+
+func constructIds(ids []stream.StreamEntrieId) *datatypes.Data {
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, id.String())
+	}
+	return datatypes.ConstructArray(out)
+}
+
+func (this *executor) ExecuteXgroup(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var query xgroupcommand.GroupQuery
+	queryArg, _ := cmd.Args.GetArgValue(xgroupcommand.Query)
+	queryArg.ToType(&query)
+
+	s, err := this.getStream(caller, query.Key)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		if query.Subcommand != xgroupcommand.Create || !query.MkStream {
+			return nil, XgroupNoKeyError
+		}
+		s = stream.NewStream()
+		this.db(caller).Set(query.Key, storage.NewStreamValue(s))
+	}
+	// events of subcommands are named like xgroup-create
+	event := "xgroup-" + strings.ToLower(query.Subcommand)
+	noGroup := fmt.Errorf("NOGROUP No such consumer group '%v' for key name '%v'", query.Group, query.Key)
+	now := time.Now().UnixMilli()
+
+	switch query.Subcommand {
+	case xgroupcommand.Create:
+		err := s.CreateGroup(query.Group, query.Id, query.EntriesRead)
+		if err != nil {
+			return nil, err
+		}
+		this.notifyKey(caller, notify.Stream, event, query.Key)
+		return datatypes.ConstructSimpleString("OK"), nil
+	case xgroupcommand.SetId:
+		err := s.SetGroupId(query.Group, query.Id, query.EntriesRead)
+		if err != nil {
+			return nil, noGroup
+		}
+		this.notifyKey(caller, notify.Stream, event, query.Key)
+		return datatypes.ConstructSimpleString("OK"), nil
+	case xgroupcommand.Destroy:
+		if s.DestroyGroup(query.Group) {
+			this.notifyKey(caller, notify.Stream, event, query.Key)
+			return datatypes.ConstructInt(1), nil
+		}
+		return datatypes.ConstructInt(0), nil
+	case xgroupcommand.CreateConsumer:
+		created, err := s.CreateConsumer(query.Group, query.Consumer, now)
+		if err != nil {
+			return nil, noGroup
+		}
+		if created {
+			this.notifyKey(caller, notify.Stream, event, query.Key)
+			return datatypes.ConstructInt(1), nil
+		}
+		return datatypes.ConstructInt(0), nil
+	case xgroupcommand.DelConsumer:
+		pending, err := s.DeleteConsumer(query.Group, query.Consumer)
+		if err != nil {
+			return nil, noGroup
+		}
+		this.notifyKey(caller, notify.Stream, event, query.Key)
+		return datatypes.ConstructInt(pending), nil
+	}
+	return nil, fmt.Errorf("ERR unknown subcommand '%v'. Try XGROUP HELP.", query.Subcommand)
+}
+
+// reads streams for consumer of group, with BLOCK waits until new entrie is delivered when nothing is served
+func (this *executor) ExecuteXreadgroup(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var query xgroupcommand.ReadGroupQuery
+	queryArg, _ := cmd.Args.GetArgValue(xgroupcommand.Query)
+	queryArg.ToType(&query)
+
+	streams := make([]stream.Stream, len(query.Keys))
+	for i, key := range query.Keys {
+		s, err := this.getStream(caller, key)
+		if err != nil {
+			return nil, err
+		}
+		if s == nil || !s.HasGroup(query.Group) {
+			return nil, fmt.Errorf("NOGROUP No such key '%v' or consumer group '%v' in XREADGROUP with GROUP option", key, query.Group)
+		}
+		streams[i] = s
+	}
+	for i, s := range streams {
+		this.createConsumer(caller, cmd, query.Keys[i], s, query.Group, query.Consumer)
+	}
+
+	// waiters are registered before the first read to not miss entries added in between
+	blocker := newStreamBlocker(caller, query.Timeout)
+	defer blocker.close()
+	if query.Block {
+		for _, s := range streams {
+			blocker.watch(s)
+		}
+	}
+	for {
+		results := []*datatypes.Data{}
+		for i, s := range streams {
+			deliveries, served, err := s.ReadGroup(query.Group, query.Consumer, query.Ids[i], query.Count, query.NoAck, time.Now().UnixMilli())
+			if err != nil {
+				return nil, GroupDestroyedError
+			}
+			if !served {
+				continue
+			}
+			if query.Ids[i] != nil || !query.NoAck {
+				rewriteClaims(cmd, query.Keys[i], query.Group, query.Consumer, deliveries)
+			}
+			if query.Ids[i] == nil {
+				rewriteGroupId(cmd, query.Keys[i], s, query.Group)
+			}
+			results = append(results, datatypes.ConstructArrayFromData([]*datatypes.Data{
+				datatypes.ConstructBulkString(query.Keys[i]),
+				constructDeliveries(deliveries, false),
+			}))
+		}
+		if len(results) > 0 {
+			return datatypes.ConstructArrayFromData(results), nil
+		}
+		if !query.Block {
+			return datatypes.ConstructNullArray(), nil
+		}
+		changed, err := blocker.wait()
+		if err != nil {
+			return nil, err
+		}
+		if !changed {
+			return datatypes.ConstructNullArray(), nil
+		}
+	}
+}
+
+// replies number of acknowledged entries, 0 if key or group does not exist
+func (this *executor) ExecuteXack(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var query xgroupcommand.AckQuery
+	queryArg, _ := cmd.Args.GetArgValue(xgroupcommand.Query)
+	queryArg.ToType(&query)
+	s, err := this.getStream(caller, query.Key)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return datatypes.ConstructInt(0), nil
+	}
+	acked, err := s.Ack(query.Group, query.Ids)
+	if err != nil {
+		return datatypes.ConstructInt(0), nil
+	}
+	return datatypes.ConstructInt(acked), nil
+}
+
+func (this *executor) ExecuteXpending(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var query xgroupcommand.PendingQuery
+	queryArg, _ := cmd.Args.GetArgValue(xgroupcommand.Query)
+	queryArg.ToType(&query)
+	s, err := this.readStream(caller, query.Key)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil || !s.HasGroup(query.Group) {
+		return nil, noGroupError(query.Key, query.Group)
+	}
+
+	if !query.Extended {
+		summary, err := s.PendingSummary(query.Group)
+		if err != nil {
+			return nil, noGroupError(query.Key, query.Group)
+		}
+		if summary.Count == 0 {
+			return datatypes.ConstructArrayFromData([]*datatypes.Data{
+				datatypes.ConstructInt(0),
+				datatypes.ConstructNull(),
+				datatypes.ConstructNull(),
+				datatypes.ConstructNullArray(),
+			}), nil
+		}
+		consumers := make([]*datatypes.Data, 0, len(summary.Consumers))
+		for _, c := range summary.Consumers {
+			consumers = append(consumers, datatypes.ConstructArray([]string{c.Name, fmt.Sprint(c.Count)}))
+		}
+		return datatypes.ConstructArrayFromData([]*datatypes.Data{
+			datatypes.ConstructInt(summary.Count),
+			datatypes.ConstructBulkString(summary.First.String()),
+			datatypes.ConstructBulkString(summary.Last.String()),
+			datatypes.ConstructArrayFromData(consumers),
+		}), nil
+	}
+
+	pending, err := s.PendingRange(query.Group, query.Consumer, query.Start, query.End, query.Count, query.MinIdle, time.Now().UnixMilli())
+	if err != nil {
+		return nil, noGroupError(query.Key, query.Group)
+	}
+	out := make([]*datatypes.Data, 0, len(pending))
+	for _, p := range pending {
+		out = append(out, datatypes.ConstructArrayFromData([]*datatypes.Data{
+			datatypes.ConstructBulkString(p.Id.String()),
+			datatypes.ConstructBulkString(p.Consumer),
+			datatypes.ConstructInt(int(p.Idle)),
+			datatypes.ConstructInt(int(p.DeliveryCount)),
+		}))
+	}
+	return datatypes.ConstructArrayFromData(out), nil
+}
+
+// replies claimed entries, with JUSTID only their ids
+func (this *executor) ExecuteXclaim(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var query xgroupcommand.ClaimQuery
+	queryArg, _ := cmd.Args.GetArgValue(xgroupcommand.Query)
+	queryArg.ToType(&query)
+	s, err := this.getStream(caller, query.Key)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, noGroupError(query.Key, query.Group)
+	}
+	this.createConsumer(caller, cmd, query.Key, s, query.Group, query.Consumer)
+	now := time.Now().UnixMilli()
+	opts := stream.ClaimOptions{
+		DeliveryTime: query.Time,
+		RetryCount:   query.RetryCount,
+		Force:        query.Force,
+		JustId:       query.JustId,
+		LastId:       query.LastId,
+	}
+	if query.Idle >= 0 {
+		opts.DeliveryTime = now - query.Idle
+	}
+	claimed, deleted, err := s.Claim(query.Group, query.Consumer, query.Ids, query.MinIdle, opts, now)
+	if err != nil {
+		return nil, noGroupError(query.Key, query.Group)
+	}
+	rewriteClaims(cmd, query.Key, query.Group, query.Consumer, claimed)
+	rewriteDeleted(cmd, query.Key, query.Group, deleted)
+	if !query.LastId.IsZero() {
+		rewriteGroupId(cmd, query.Key, s, query.Group)
+	}
+	return constructDeliveries(claimed, query.JustId), nil
+}
+
+// replies cursor to continue from, claimed entries and ids of deleted entries removed from pending entries list
+func (this *executor) ExecuteXautoclaim(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var query xgroupcommand.AutoClaimQuery
+	queryArg, _ := cmd.Args.GetArgValue(xgroupcommand.Query)
+	queryArg.ToType(&query)
+	s, err := this.getStream(caller, query.Key)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, noGroupError(query.Key, query.Group)
+	}
+	this.createConsumer(caller, cmd, query.Key, s, query.Group, query.Consumer)
+	next, claimed, deleted, err := s.AutoClaim(query.Group, query.Consumer, query.MinIdle, query.Start, query.Count, query.JustId, time.Now().UnixMilli())
+	if err != nil {
+		return nil, noGroupError(query.Key, query.Group)
+	}
+	rewriteClaims(cmd, query.Key, query.Group, query.Consumer, claimed)
+	rewriteDeleted(cmd, query.Key, query.Group, deleted)
+	return datatypes.ConstructArrayFromData([]*datatypes.Data{
+		datatypes.ConstructBulkString(next.String()),
+		constructDeliveries(claimed, query.JustId),
+		constructIds(deleted),
+	}), nil
+}
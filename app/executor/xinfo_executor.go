@@ -0,0 +1,159 @@
+package executor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/client"
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+	xinfocommand "github.com/codecrafters-io/redis-starter-go/app/commands/xinfo_command"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+	"github.com/codecrafters-io/redis-starter-go/app/stream"
+)
+
+// field value pairs of XINFO replies
+type infoFields []*datatypes.Data
+
+func (this infoFields) add(name string, value *datatypes.Data) infoFields {
+	return append(this, datatypes.ConstructBulkString(name), value)
+}
+
+func (this infoFields) construct() *datatypes.Data {
+	return datatypes.ConstructArrayFromData(this)
+}
+
+func constructId(id stream.StreamEntrieId) *datatypes.Data {
+	return datatypes.ConstructBulkString(id.String())
+}
+
+func constructEntrie(e *stream.StreamEntrie) *datatypes.Data {
+	if e == nil {
+		return datatypes.ConstructNull()
+	}
+	return e.ToDataType()
+}
+
+// entries read and lag are null when they are unknown
+func constructGroupPosition(fields infoFields, g stream.GroupInfo) infoFields {
+	if g.EntriesRead == stream.InvalidEntriesRead {
+		fields = fields.add("entries-read", datatypes.ConstructNull())
+	} else {
+		fields = fields.add("entries-read", datatypes.ConstructInt(int(g.EntriesRead)))
+	}
+	if g.LagUnknown {
+		return fields.add("lag", datatypes.ConstructNull())
+	}
+	return fields.add("lag", datatypes.ConstructInt(int(g.Lag)))
+}
+
+func constructPendingEntries(pending []stream.PendingEntryInfo, withConsumer bool) *datatypes.Data {
+	out := make([]*datatypes.Data, 0, len(pending))
+	for _, p := range pending {
+		entrie := []*datatypes.Data{constructId(p.Id)}
+		if withConsumer {
+			entrie = append(entrie, datatypes.ConstructBulkString(p.Consumer))
+		}
+		entrie = append(entrie, datatypes.ConstructInt(int(p.DeliveryTime)), datatypes.ConstructInt(int(p.DeliveryCount)))
+		out = append(out, datatypes.ConstructArrayFromData(entrie))
+	}
+	return datatypes.ConstructArrayFromData(out)
+}
+
+func constructStreamInfo(info stream.StreamInfo, full bool) *datatypes.Data {
+	fields := infoFields{}.
+		add("length", datatypes.ConstructInt(info.Length)).
+		add("radix-tree-keys", datatypes.ConstructInt(info.RadixTreeKeys)).
+		add("radix-tree-nodes", datatypes.ConstructInt(info.RadixTreeNodes)).
+		add("last-generated-id", constructId(info.LastGeneratedId)).
+		add("max-deleted-entry-id", constructId(info.MaxDeletedId)).
+		add("entries-added", datatypes.ConstructInt(int(info.EntriesAdded))).
+		add("recorded-first-entry-id", constructId(info.RecordedFirstEntryId))
+	if !full {
+		return fields.
+			add("groups", datatypes.ConstructInt(info.GroupsCount)).
+			add("first-entry", constructEntrie(info.First)).
+			add("last-entry", constructEntrie(info.Last)).
+			construct()
+	}
+	entries := make([]*datatypes.Data, 0, len(info.Entries))
+	for _, e := range info.Entries {
+		entries = append(entries, e.ToDataType())
+	}
+	groups := make([]*datatypes.Data, 0, len(info.Groups))
+	for _, g := range info.Groups {
+		consumers := make([]*datatypes.Data, 0, len(g.ConsumersInfo))
+		for _, c := range g.ConsumersInfo {
+			consumers = append(consumers, infoFields{}.
+				add("name", datatypes.ConstructBulkString(c.Name)).
+				add("seen-time", datatypes.ConstructInt(int(c.SeenTime))).
+				add("active-time", datatypes.ConstructInt(int(c.ActiveTime))).
+				add("pel-count", datatypes.ConstructInt(c.Pending)).
+				add("pending", constructPendingEntries(c.PendingEntries, false)).
+				construct())
+		}
+		groupFields := infoFields{}.
+			add("name", datatypes.ConstructBulkString(g.Name)).
+			add("last-delivered-id", constructId(g.LastDeliveredId))
+		groups = append(groups, constructGroupPosition(groupFields, g).
+			add("pel-count", datatypes.ConstructInt(g.Pending)).
+			add("pending", constructPendingEntries(g.PendingEntries, true)).
+			add("consumers", datatypes.ConstructArrayFromData(consumers)).
+			construct())
+	}
+	return fields.
+		add("entries", datatypes.ConstructArrayFromData(entries)).
+		add("groups", datatypes.ConstructArrayFromData(groups)).
+		construct()
+}
+
+// replies state of stream, its consumer groups or consumers of group
+func (this *executor) ExecuteXinfo(caller *client.Client, cmd *command.Command) (*datatypes.Data, error) {
+	var query xinfocommand.InfoQuery
+	queryArg, _ := cmd.Args.GetArgValue(xinfocommand.Query)
+	queryArg.ToType(&query)
+	s, err := this.readStream(caller, query.Key)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, NoSuchKeyError
+	}
+
+	switch query.Subcommand {
+	case xinfocommand.Stream:
+		return constructStreamInfo(s.Info(query.Full, query.Count), query.Full), nil
+	case xinfocommand.Groups:
+		groups := s.GroupsInfo()
+		out := make([]*datatypes.Data, 0, len(groups))
+		for _, g := range groups {
+			fields := infoFields{}.
+				add("name", datatypes.ConstructBulkString(g.Name)).
+				add("consumers", datatypes.ConstructInt(g.Consumers)).
+				add("pending", datatypes.ConstructInt(g.Pending)).
+				add("last-delivered-id", constructId(g.LastDeliveredId))
+			out = append(out, constructGroupPosition(fields, g).construct())
+		}
+		return datatypes.ConstructArrayFromData(out), nil
+	case xinfocommand.Consumers:
+		consumers, err := s.ConsumersInfo(query.Group)
+		if err != nil {
+			return nil, fmt.Errorf("NOGROUP No such consumer group '%v' for key name '%v'", query.Group, query.Key)
+		}
+		now := time.Now().UnixMilli()
+		out := make([]*datatypes.Data, 0, len(consumers))
+		for _, c := range consumers {
+			inactive := int64(-1)
+			if c.ActiveTime >= 0 {
+				inactive = max(now-c.ActiveTime, 0)
+			}
+			out = append(out, infoFields{}.
+				add("name", datatypes.ConstructBulkString(c.Name)).
+				add("pending", datatypes.ConstructInt(c.Pending)).
+				add("idle", datatypes.ConstructInt(int(max(now-c.SeenTime, 0)))).
+				add("inactive", datatypes.ConstructInt(int(inactive))).
+				construct())
+		}
+		return datatypes.ConstructArrayFromData(out), nil
+	}
+	return nil, fmt.Errorf("ERR unknown subcommand '%v'. Try XINFO HELP.", query.Subcommand)
+}
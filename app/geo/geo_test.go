@@ -0,0 +1,59 @@
+package geo
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestEncodeDecode_MatchesRedis(t *testing.T) {
+	score := Encode(13.361389, 38.115556)
+	if score != 3479099956230698 {
+		t.Fatalf("expected score 3479099956230698, got %d", score)
+	}
+	lon, lat := Decode(score)
+	got := fmt.Sprintf("%.17f %.17f", lon, lat)
+	if got != "13.36138933897018433 38.11555639549629859" {
+		t.Fatalf("unexpected coordinates %s", got)
+	}
+	if hash := Hash(score); hash != "sqc8b49rny0" {
+		t.Fatalf("expected hash sqc8b49rny0, got %s", hash)
+	}
+}
+
+func TestDistance(t *testing.T) {
+	palermo := Encode(13.361389, 38.115556)
+	catania := Encode(15.087269, 37.502669)
+	lon1, lat1 := Decode(palermo)
+	lon2, lat2 := Decode(catania)
+	got := fmt.Sprintf("%.4f", Distance(lon1, lat1, lon2, lat2))
+	if got != "166274.1516" {
+		t.Fatalf("expected distance 166274.1516, got %s", got)
+	}
+}
+
+func TestRanges_CoverShape(t *testing.T) {
+	shape := Shape{Lon: 15, Lat: 37, Radius: 200, Conversion: 1000}
+	for _, point := range [][2]float64{{13.361389, 38.115556}, {15.087269, 37.502669}, {15, 37}} {
+		score := Encode(point[0], point[1])
+		found := false
+		for _, r := range shape.Ranges() {
+			found = found || score >= r[0] && score < r[1]
+		}
+		if !found {
+			t.Fatalf("point %v is not covered by ranges", point)
+		}
+	}
+}
+
+func TestContains_Box(t *testing.T) {
+	shape := Shape{Lon: 15, Lat: 37, ByBox: true, Width: 400, Height: 400, Conversion: 1000}
+	// distances are measured from coordinates of stored score
+	distance, ok := shape.Contains(Decode(Encode(12.758489, 38.788135)))
+	if !ok || fmt.Sprintf("%.4f", distance/1000) != "279.7405" {
+		t.Fatalf("expected point inside of box at 279.7405 km, got %v %f", ok, distance)
+	}
+	_, ok = shape.Contains(15, 39.5)
+	if ok {
+		t.Fatalf("expected point outside of box")
+	}
+}
@@ -0,0 +1,171 @@
+package geo
+
+import "math"
+
+// points are indexed by 52 bit geohash interleaving 26 bits of latitude (even bits) and longitude (odd bits),
+// latitude is limited to range of web mercator projection
+
+const (
+	StepMax = 26
+	LonMin  = -180.0
+	LonMax  = 180.0
+	LatMin  = -85.05112878
+	LatMax  = 85.05112878
+)
+
+const geoAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+type hashRange struct {
+	min float64
+	max float64
+}
+
+var lonRange = hashRange{LonMin, LonMax}
+var latRange = hashRange{LatMin, LatMax}
+
+type hashBits struct {
+	bits uint64
+	step uint
+}
+
+func (this hashBits) isZero() bool {
+	return this.bits == 0 && this.step == 0
+}
+
+// aligns hash of any step to 52 bits, so it can be compared with scores
+func (this hashBits) align52() uint64 {
+	return this.bits << (52 - this.step*2)
+}
+
+type area struct {
+	lon hashRange
+	lat hashRange
+}
+
+// reports are coordinates inside of indexable range
+func ValidLonLat(lon float64, lat float64) bool {
+	return lon >= LonMin && lon <= LonMax && lat >= LatMin && lat <= LatMax
+}
+
+// returns 52 bit score of valid coordinates
+func Encode(lon float64, lat float64) uint64 {
+	return encode(lonRange, latRange, lon, lat, StepMax).bits
+}
+
+// returns coordinates of center of area described by score
+func Decode(score uint64) (float64, float64) {
+	return decode(lonRange, latRange, hashBits{bits: score, step: StepMax}).center()
+}
+
+// returns 11 characters standard geohash of score, which uses latitude range of -90..90
+func Hash(score uint64) string {
+	lon, lat := Decode(score)
+	hash := encode(hashRange{-180, 180}, hashRange{-90, 90}, lon, lat, StepMax)
+	buf := make([]byte, 11)
+	for i := range buf {
+		idx := uint64(0)
+		// 52 bits give only 10 characters, the last one is zero for compatibility
+		if i < 10 {
+			idx = (hash.bits >> (52 - (i+1)*5)) & 0x1f
+		}
+		buf[i] = geoAlphabet[idx]
+	}
+	return string(buf)
+}
+
+func encode(lonR hashRange, latR hashRange, lon float64, lat float64, step uint) hashBits {
+	latOffset := (lat - latR.min) / (latR.max - latR.min)
+	lonOffset := (lon - lonR.min) / (lonR.max - lonR.min)
+	latOffset *= float64(uint64(1) << step)
+	lonOffset *= float64(uint64(1) << step)
+	return hashBits{bits: interleave64(uint32(latOffset), uint32(lonOffset)), step: step}
+}
+
+func decode(lonR hashRange, latR hashRange, hash hashBits) area {
+	sep := deinterleave64(hash.bits)
+	lat := uint32(sep)
+	lon := uint32(sep >> 32)
+	cells := float64(uint64(1) << hash.step)
+	latScale := latR.max - latR.min
+	lonScale := lonR.max - lonR.min
+	return area{
+		lat: hashRange{
+			min: latR.min + (float64(lat)/cells)*latScale,
+			max: latR.min + (float64(lat+1)/cells)*latScale,
+		},
+		lon: hashRange{
+			min: lonR.min + (float64(lon)/cells)*lonScale,
+			max: lonR.min + (float64(lon+1)/cells)*lonScale,
+		},
+	}
+}
+
+func (this area) center() (float64, float64) {
+	lon := math.Max(LonMin, math.Min(LonMax, (this.lon.min+this.lon.max)/2))
+	lat := math.Max(LatMin, math.Min(LatMax, (this.lat.min+this.lat.max)/2))
+	return lon, lat
+}
+
+// spreads bits of x to even positions and bits of y to odd positions
+func interleave64(xlo uint32, ylo uint32) uint64 {
+	b := [...]uint64{0x5555555555555555, 0x3333333333333333, 0x0F0F0F0F0F0F0F0F, 0x00FF00FF00FF00FF, 0x0000FFFF0000FFFF}
+	s := [...]uint{1, 2, 4, 8, 16}
+	x, y := uint64(xlo), uint64(ylo)
+	for i := len(s) - 1; i >= 0; i-- {
+		x = (x | (x << s[i])) & b[i]
+		y = (y | (y << s[i])) & b[i]
+	}
+	return x | (y << 1)
+}
+
+// reverse of interleave64, even bits are returned in low half and odd bits in high half
+func deinterleave64(interleaved uint64) uint64 {
+	b := [...]uint64{0x5555555555555555, 0x3333333333333333, 0x0F0F0F0F0F0F0F0F, 0x00FF00FF00FF00FF, 0x0000FFFF0000FFFF, 0x00000000FFFFFFFF}
+	s := [...]uint{0, 1, 2, 4, 8, 16}
+	x, y := interleaved, interleaved>>1
+	for i := range s {
+		x = (x | (x >> s[i])) & b[i]
+		y = (y | (y >> s[i])) & b[i]
+	}
+	return x | (y << 32)
+}
+
+// moves hash by d cells along longitude, wrapping around at the edges
+func (this hashBits) moveX(d int) hashBits {
+	if d == 0 {
+		return this
+	}
+	x := this.bits & 0xaaaaaaaaaaaaaaaa
+	y := this.bits & 0x5555555555555555
+	zz := uint64(0x5555555555555555) >> (64 - this.step*2)
+	if d > 0 {
+		x = x + (zz + 1)
+	} else {
+		x = x | zz
+		x = x - (zz + 1)
+	}
+	x &= 0xaaaaaaaaaaaaaaaa >> (64 - this.step*2)
+	return hashBits{bits: x | y, step: this.step}
+}
+
+// moves hash by d cells along latitude, wrapping around at the edges
+func (this hashBits) moveY(d int) hashBits {
+	if d == 0 {
+		return this
+	}
+	x := this.bits & 0xaaaaaaaaaaaaaaaa
+	y := this.bits & 0x5555555555555555
+	zz := uint64(0xaaaaaaaaaaaaaaaa) >> (64 - this.step*2)
+	if d > 0 {
+		y = y + (zz + 1)
+	} else {
+		y = y | zz
+		y = y - (zz + 1)
+	}
+	y &= 0x5555555555555555 >> (64 - this.step*2)
+	return hashBits{bits: x | y, step: this.step}
+}
+
+func (this hashBits) move(dx int, dy int) hashBits {
+	return this.moveX(dx).moveY(dy)
+}
@@ -0,0 +1,171 @@
+package geo
+
+import "math"
+
+const (
+	earthRadius = 6372797.560856
+	mercatorMax = 20037726.37
+	degToRad    = math.Pi / 180.0
+)
+
+// area of GEOSEARCH, circle is described by radius and box by width and height,
+// sizes are given in units which are converted to meters with conversion
+type Shape struct {
+	Lon        float64
+	Lat        float64
+	ByBox      bool
+	Radius     float64
+	Width      float64
+	Height     float64
+	Conversion float64
+}
+
+// returns great circle distance in meters between two points using haversine formula
+func Distance(lon1 float64, lat1 float64, lon2 float64, lat2 float64) float64 {
+	lon1r, lon2r := lon1*degToRad, lon2*degToRad
+	v := math.Sin((lon2r - lon1r) / 2)
+	// points on the same meridian differ only by latitude
+	if v == 0 {
+		return latDistance(lat1, lat2)
+	}
+	lat1r, lat2r := lat1*degToRad, lat2*degToRad
+	u := math.Sin((lat2r - lat1r) / 2)
+	a := u*u + math.Cos(lat1r)*math.Cos(lat2r)*v*v
+	return 2.0 * earthRadius * math.Asin(math.Sqrt(a))
+}
+
+func latDistance(lat1 float64, lat2 float64) float64 {
+	return earthRadius * math.Abs(lat2*degToRad-lat1*degToRad)
+}
+
+// returns distance of point from center in meters, ok is false if point is outside of shape
+func (this *Shape) Contains(lon float64, lat float64) (float64, bool) {
+	if !this.ByBox {
+		distance := Distance(this.Lon, this.Lat, lon, lat)
+		return distance, distance <= this.Radius*this.Conversion
+	}
+	// latitude distance is cheaper, so it is checked first
+	if latDistance(lat, this.Lat) > this.Height*this.Conversion/2 {
+		return 0, false
+	}
+	if Distance(lon, lat, this.Lon, lat) > this.Width*this.Conversion/2 {
+		return 0, false
+	}
+	return Distance(this.Lon, this.Lat, lon, lat), true
+}
+
+// returns min lon, min lat, max lon and max lat of rectangle containing shape
+func (this *Shape) boundingBox() (float64, float64, float64, float64) {
+	height, width := this.Radius, this.Radius
+	if this.ByBox {
+		height, width = this.Height/2, this.Width/2
+	}
+	height *= this.Conversion
+	width *= this.Conversion
+	latDelta := height / earthRadius / degToRad
+	lonDeltaTop := width / earthRadius / math.Cos((this.Lat+latDelta)*degToRad) / degToRad
+	lonDeltaBottom := width / earthRadius / math.Cos((this.Lat-latDelta)*degToRad) / degToRad
+	// on southern hemisphere the widest part of the box is its top
+	lonDelta := lonDeltaTop
+	if this.Lat < 0 {
+		lonDelta = lonDeltaBottom
+	}
+	return this.Lon - lonDelta, this.Lat - latDelta, this.Lon + lonDelta, this.Lat + latDelta
+}
+
+// returns precision of geohash whose cells are big enough to cover radius with neighbour cells
+func estimateSteps(radius float64, lat float64) uint {
+	if radius == 0 {
+		return StepMax
+	}
+	step := 1
+	for radius < mercatorMax {
+		radius *= 2
+		step++
+	}
+	step -= 2
+	// cells are narrower towards the poles
+	if lat > 66 || lat < -66 {
+		step--
+		if lat > 80 || lat < -80 {
+			step--
+		}
+	}
+	return uint(max(1, min(StepMax, step)))
+}
+
+// returns score ranges [min, max) of geohash cells covering shape in the order they are searched:
+// center cell followed by north, south, east, west, north east, north west, south east and south west neighbours
+func (this *Shape) Ranges() [][2]uint64 {
+	minLon, minLat, maxLon, maxLat := this.boundingBox()
+	radius := this.Radius
+	if this.ByBox {
+		radius = math.Sqrt((this.Width/2)*(this.Width/2) + (this.Height/2)*(this.Height/2))
+	}
+	steps := estimateSteps(radius*this.Conversion, this.Lat)
+
+	hash := encode(lonRange, latRange, this.Lon, this.Lat, steps)
+	cells := neighbours(hash)
+	// cells next to center cell should reach the edges of the searched area, otherwise bigger cells are used
+	north, south := decode(lonRange, latRange, cells[1]), decode(lonRange, latRange, cells[2])
+	east, west := decode(lonRange, latRange, cells[3]), decode(lonRange, latRange, cells[4])
+	if steps > 1 && (north.lat.max < maxLat || south.lat.min > minLat || east.lon.max < maxLon || west.lon.min > minLon) {
+		steps--
+		hash = encode(lonRange, latRange, this.Lon, this.Lat, steps)
+		cells = neighbours(hash)
+	}
+
+	// neighbours outside of searched area are excluded
+	if steps >= 2 {
+		center := decode(lonRange, latRange, hash)
+		exclude := func(idx ...int) {
+			for _, i := range idx {
+				cells[i] = hashBits{}
+			}
+		}
+		if center.lat.min < minLat {
+			exclude(2, 8, 7)
+		}
+		if center.lat.max > maxLat {
+			exclude(1, 5, 6)
+		}
+		if center.lon.min < minLon {
+			exclude(4, 8, 6)
+		}
+		if center.lon.max > maxLon {
+			exclude(3, 7, 5)
+		}
+	}
+
+	ranges := make([][2]uint64, 0, len(cells))
+	last := 0
+	for i, cell := range cells {
+		if cell.isZero() {
+			continue
+		}
+		// with huge radius adjacent neighbours can be the same cell, the center cell is never compared
+		if last != 0 && cell == cells[last] {
+			continue
+		}
+		next := cell
+		next.bits++
+		ranges = append(ranges, [2]uint64{cell.align52(), next.align52()})
+		last = i
+	}
+	return ranges
+}
+
+// returns cell followed by its north, south, east, west, north east, north west, south east and south west neighbours
+func neighbours(hash hashBits) [9]hashBits {
+	return [9]hashBits{
+		hash,
+		hash.move(0, 1),
+		hash.move(0, -1),
+		hash.move(1, 0),
+		hash.move(-1, 0),
+		hash.move(1, 1),
+		hash.move(-1, 1),
+		hash.move(1, -1),
+		hash.move(-1, -1),
+	}
+}
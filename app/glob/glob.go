@@ -0,0 +1,121 @@
+// redis compatible glob style pattern matching
+package glob
+
+// reports whether str matches pattern, supported syntax:
+// * any sequence, ? any single char, [abc] set, [^abc] negated set, [a-z] range, \x escaped char.
+// matching is iterative and keeps only last star as backtracking point, any earlier star can not produce match that last
+// star can not, so time is bounded by len(pattern)*len(str) even for patterns like a*a*a*a*b.
+// like in redis, empty str matches only empty pattern, not even *, callers skip matching for * where redis does
+func Match(pattern string, str string, nocase bool) bool {
+	if len(str) == 0 {
+		return len(pattern) == 0
+	}
+	p, s := 0, 0
+	// position of pattern after last star and position of str matched by rest of pattern, star is -1 until star is seen
+	star, starS := -1, 0
+	for s < len(str) {
+		if p < len(pattern) {
+			if pattern[p] == '*' {
+				for p < len(pattern) && pattern[p] == '*' {
+					p++
+				}
+				if p == len(pattern) {
+					return true
+				}
+				star, starS = p, s
+				continue
+			}
+			next, ok := matchOne(pattern, p, str[s], nocase)
+			if ok {
+				p = next
+				s++
+				continue
+			}
+		}
+		if star == -1 {
+			return false
+		}
+		// last star takes one more char and rest of pattern is retried after it
+		starS++
+		p, s = star, starS
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// matches c against single char token of pattern at p, returns position of next token
+func matchOne(pattern string, p int, c byte, nocase bool) (int, bool) {
+	switch pattern[p] {
+	case '?':
+		return p + 1, true
+	case '[':
+		return matchClass(pattern, p, c, nocase)
+	case '\\':
+		if p+1 < len(pattern) {
+			p++
+		}
+	}
+	return p + 1, equalByte(pattern[p], c, nocase)
+}
+
+// matches c against class that starts at pattern[p] == '[', returns position after closing bracket
+func matchClass(pattern string, p int, c byte, nocase bool) (int, bool) {
+	p++
+	not := p < len(pattern) && pattern[p] == '^'
+	if not {
+		p++
+	}
+	matched := false
+	for {
+		if p >= len(pattern) {
+			// unterminated class, redis treats end of pattern as closing bracket
+			break
+		}
+		if pattern[p] == '\\' && p+1 < len(pattern) {
+			// escaped char of class is compared case sensitive even with nocase, like in redis
+			p++
+			if pattern[p] == c {
+				matched = true
+			}
+		} else if pattern[p] == ']' {
+			p++
+			break
+		} else if p+2 < len(pattern) && pattern[p+1] == '-' {
+			start, end := pattern[p], pattern[p+2]
+			if start > end {
+				start, end = end, start
+			}
+			lc := c
+			if nocase {
+				start, end, lc = toLower(start), toLower(end), toLower(c)
+			}
+			p += 2
+			if lc >= start && lc <= end {
+				matched = true
+			}
+		} else if equalByte(pattern[p], c, nocase) {
+			matched = true
+		}
+		p++
+	}
+	if not {
+		matched = !matched
+	}
+	return p, matched
+}
+
+func equalByte(a byte, b byte, nocase bool) bool {
+	if nocase {
+		return toLower(a) == toLower(b)
+	}
+	return a == b
+}
+
+func toLower(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + ('a' - 'A')
+	}
+	return c
+}
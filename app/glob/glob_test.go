@@ -0,0 +1,239 @@
+package glob
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		pattern string
+		str     string
+		nocase  bool
+		match   bool
+	}{
+		{"*", "anything", false, true},
+		{"h?llo", "hello", false, true},
+		{"h?llo", "hllo", false, false},
+		{"h*llo", "heeeello", false, true},
+		{"h[ae]llo", "hallo", false, true},
+		{"h[ae]llo", "hillo", false, false},
+		{"h[^e]llo", "hallo", false, true},
+		{"h[^e]llo", "hello", false, false},
+		{"h[a-b]llo", "hbllo", false, true},
+		{"h[b-a]llo", "hbllo", false, true},
+		{"h[a-b]llo", "hcllo", false, false},
+		{"h\\*llo", "h*llo", false, true},
+		{"h\\*llo", "hello", false, false},
+		{"[\\]]", "]", false, true},
+		{"user:*:name", "user:1000:name", false, true},
+		{"HELLO", "hello", true, true},
+		{"[A-C]x", "bx", true, true},
+		{"HELLO", "hello", false, false},
+		{"ab[", "ab", false, false},
+		{"a\\", "a\\", false, true},
+		// edge cases below follow redis stringmatchlen
+		{"*", "", false, false},
+		{"", "", false, true},
+		{"a*", "a", false, true},
+		{"\\?", "?", false, true},
+		{"\\?", "a", false, false},
+		{"\\[a]", "[a]", false, true},
+		{"\\A", "a", true, true},
+		{"a\\", "a", false, false},
+		{"[^a-c]", "d", false, true},
+		{"[^a-c]", "b", false, false},
+		{"[^a-c]", "B", true, false},
+		{"[^]", "x", false, true},
+		{"[]", "]", false, false},
+		{"[z-a]", "m", false, true},
+		{"[z-a]", "M", false, false},
+		{"[z-a]", "M", true, true},
+		{"[a-]", "_", false, true},
+		{"[a\\", "\\", false, true},
+		{"[\\A]", "a", true, false},
+		{"[\\A]", "A", true, true},
+		{"[A]", "a", true, true},
+		{"a[b", "ab", false, true},
+		{"a[^b", "ac", false, true},
+		{"a[^b", "ab", false, false},
+	}
+	for _, c := range cases {
+		if got := Match(c.pattern, c.str, c.nocase); got != c.match {
+			t.Fatalf("expected Match(%q, %q, %v) to be %v, got %v", c.pattern, c.str, c.nocase, c.match, got)
+		}
+	}
+}
+
+// patterns with many stars take exponential time with naive backtracking
+func TestMatch_PathologicalPattern(t *testing.T) {
+	pattern := strings.Repeat("a*", 30) + "b"
+	str := strings.Repeat("a", 10000)
+	start := time.Now()
+	if Match(pattern, str, false) {
+		t.Fatalf("expected %q not to match", pattern)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected match to be fast, took %v", elapsed)
+	}
+}
+
+// compares matcher with regexp translation of pattern on random patterns over small alphabet
+func TestMatch_AgainstRegexp(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	patternChars := "ab*?[]^-\\A"
+	strChars := "ab-]^\\A"
+	random := func(chars string, maxLen int) string {
+		b := make([]byte, r.Intn(maxLen+1))
+		for i := range b {
+			b[i] = chars[r.Intn(len(chars))]
+		}
+		return string(b)
+	}
+	for i := 0; i < 200000; i++ {
+		pattern, str, nocase := random(patternChars, 8), random(strChars, 8), r.Intn(2) == 0
+		if Match(pattern, str, nocase) != regexpMatch(pattern, str, nocase) {
+			t.Fatalf("Match(%q, %q, %v) differs from regexp", pattern, str, nocase)
+		}
+	}
+}
+
+func FuzzMatch(f *testing.F) {
+	f.Add("h?llo", "hello", false)
+	f.Add("*[^a-c]*\\?", "xxdy?", true)
+	f.Add("a*a*a*b", "aaaaaaaa", false)
+	f.Add("[\\]a-", "-", false)
+	f.Fuzz(func(t *testing.T, pattern string, str string, nocase bool) {
+		if Match(pattern, str, nocase) != regexpMatch(pattern, str, nocase) {
+			t.Fatalf("Match(%q, %q, %v) differs from regexp", pattern, str, nocase)
+		}
+	})
+}
+
+// oracle that shares no code with matcher, pattern is translated to regexp, every class to explicit set of bytes.
+// regexp matches runes, so every byte is mapped to rune of the same value
+func regexpMatch(pattern string, str string, nocase bool) bool {
+	// redis does not enter its matching loop for empty string, so it matches only empty pattern
+	if str == "" {
+		return pattern == ""
+	}
+	var expr strings.Builder
+	expr.WriteString("^(?s:")
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		case '[':
+			var set [256]bool
+			i, set = parseClass(pattern, i+1, nocase)
+			writeSet(&expr, set)
+		case '\\':
+			// trailing backslash is literal backslash
+			if i+1 < len(pattern) {
+				i++
+			}
+			writeSet(&expr, literalSet(pattern[i], nocase))
+		default:
+			writeSet(&expr, literalSet(pattern[i], nocase))
+		}
+	}
+	expr.WriteString(")$")
+	runes := make([]rune, len(str))
+	for i := 0; i < len(str); i++ {
+		runes[i] = rune(str[i])
+	}
+	return regexp.MustCompile(expr.String()).MatchString(string(runes))
+}
+
+// parses class body that starts at i, returns index of its last byte and bytes class matches.
+// class is closed by ] or by end of pattern, \x is x compared case sensitive even with nocase,
+// a-b is range that is swapped if a > b, ] right after [ or [^ closes empty class
+func parseClass(pattern string, i int, nocase bool) (int, [256]bool) {
+	var set [256]bool
+	not := i < len(pattern) && pattern[i] == '^'
+	if not {
+		i++
+	}
+	for ; i < len(pattern) && pattern[i] != ']'; i++ {
+		switch {
+		case pattern[i] == '\\' && i+1 < len(pattern):
+			i++
+			set[pattern[i]] = true
+		case i+2 < len(pattern) && pattern[i+1] == '-':
+			lo, hi := pattern[i], pattern[i+2]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			for c := 0; c < 256; c++ {
+				b := byte(c)
+				if nocase {
+					if lower(b) >= lower(lo) && lower(b) <= lower(hi) {
+						set[c] = true
+					}
+				} else if b >= lo && b <= hi {
+					set[c] = true
+				}
+			}
+			i += 2
+		default:
+			for c, ok := range literalSet(pattern[i], nocase) {
+				set[c] = set[c] || ok
+			}
+		}
+	}
+	if not {
+		for c := range set {
+			set[c] = !set[c]
+		}
+	}
+	return i, set
+}
+
+func literalSet(c byte, nocase bool) [256]bool {
+	var set [256]bool
+	set[c] = true
+	if nocase {
+		set[lower(c)] = true
+		set[upper(c)] = true
+	}
+	return set
+}
+
+// redis lowers only ascii letters
+func lower(c byte) byte {
+	if c >= utf8.RuneSelf {
+		return c
+	}
+	return byte(unicode.ToLower(rune(c)))
+}
+
+func upper(c byte) byte {
+	if c >= utf8.RuneSelf {
+		return c
+	}
+	return byte(unicode.ToUpper(rune(c)))
+}
+
+// writes set as regexp class of runes, empty set is class that matches nothing
+func writeSet(expr *strings.Builder, set [256]bool) {
+	expr.WriteString("[")
+	empty := true
+	for c, ok := range set {
+		if ok {
+			fmt.Fprintf(expr, "\\x{%x}", c)
+			empty = false
+		}
+	}
+	if empty {
+		expr.WriteString("^\\x{0}-\\x{10ffff}")
+	}
+	expr.WriteString("]")
+}
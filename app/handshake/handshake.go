@@ -5,12 +5,15 @@ import (
 	"fmt"
 	"net"
 	"strconv"
+	"strings"
 
 	"github.com/codecrafters-io/redis-starter-go/app/command"
 	replconfcommand "github.com/codecrafters-io/redis-starter-go/app/commands/repl_conf_command"
 	"github.com/codecrafters-io/redis-starter-go/app/config"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
 	"github.com/codecrafters-io/redis-starter-go/app/logger"
 	"github.com/codecrafters-io/redis-starter-go/app/reader"
+	tlscontext "github.com/codecrafters-io/redis-starter-go/app/tls_context"
 )
 
 type Handshake struct {
@@ -19,13 +22,13 @@ type Handshake struct {
 	reader reader.Reader
 }
 
-func SendHandshake(config *config.Config) (net.Conn, *reader.Reader, error) {
+func SendHandshake(config *config.Config, tlsContext *tlscontext.TlsContext) (net.Conn, *reader.Reader, error) {
 	logger.Logger.Info("start sending handshake")
 	slaveInfo := config.GetReplicationSlaveInfo()
 	if slaveInfo == nil {
 		return nil, nil, fmt.Errorf("slave info is nil")
 	}
-	conn, err := net.Dial("tcp", fmt.Sprintf("%v:%v", slaveInfo.GetHost(), slaveInfo.GetPort()))
+	conn, err := dialMaster(config, tlsContext, net.JoinHostPort(slaveInfo.GetHost(), strconv.Itoa(int(slaveInfo.GetPort()))))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -44,6 +47,10 @@ func SendHandshake(config *config.Config) (net.Conn, *reader.Reader, error) {
 	if err != nil {
 		return nil, nil, err
 	}
+	err = handShake.SendHandshakeAuth()
+	if err != nil {
+		return nil, nil, err
+	}
 	err = handShake.SendHandshakeReplcConf()
 	if err != nil {
 		return nil, nil, err
@@ -60,6 +67,18 @@ func SendHandshake(config *config.Config) (net.Conn, *reader.Reader, error) {
 	return conn, &reader, nil
 }
 
+func dialMaster(config *config.Config, tlsContext *tlscontext.TlsContext, addr string) (net.Conn, error) {
+	if !config.GetTlsReplication() {
+		return net.Dial("tcp", addr)
+	}
+	logger.Logger.Info("connecting to master over tls", logger.String("addr", addr))
+	conn, err := tlsContext.Dial(addr)
+	if err != nil {
+		return nil, fmt.Errorf("Error establishing tls connection with master: %w", err)
+	}
+	return conn, nil
+}
+
 func (this *Handshake) SendHandshakeStagePing() error {
 	logger.Logger.Info("start sending ping")
 	wrt := command.ConstructPing().Marshall()
@@ -69,7 +88,15 @@ func (this *Handshake) SendHandshakeStagePing() error {
 		return err
 	}
 	data, err := this.reader.ParseDataType()
+	if err != nil {
+		logger.Logger.Error("error while reading handshake ping response", logger.String("error", err.Error()))
+		return err
+	}
 	logger.Logger.Debug("Readed data:", logger.String("data", data.String()))
+	// like redis, master that requires authentication is accepted, credentials are sent on the next stage
+	if data.Type == datatypes.SIMPLE_ERROR && strings.HasPrefix(data.Value, "NOAUTH") && this.config.GetMasterAuth() != "" {
+		return nil
+	}
 	cmd, err := command.DataTypeToCommand(data)
 	if err != nil {
 		logger.Logger.Error("error while parsing handshake ping command", logger.String("error", err.Error()))
@@ -83,6 +110,28 @@ func (this *Handshake) SendHandshakeStagePing() error {
 	return nil
 }
 
+func (this *Handshake) SendHandshakeAuth() error {
+	password := this.config.GetMasterAuth()
+	if password == "" {
+		return nil
+	}
+	logger.Logger.Info("start sending auth")
+	_, err := this.con.Write(command.ConstructAuth(this.config.GetMasterUser(), password).Marshall())
+	if err != nil {
+		logger.Logger.Error("Error writing auth handshake", logger.String("error", err.Error()))
+		return err
+	}
+	data, err := this.reader.ParseDataType()
+	if err != nil {
+		logger.Logger.Error("Error reading auth handshake resp", logger.String("error", err.Error()))
+		return err
+	}
+	if data.Type == datatypes.SIMPLE_ERROR {
+		return fmt.Errorf("Error authenticating with master: %v", data.Value)
+	}
+	return nil
+}
+
 func (this *Handshake) SendHandshakeReplcConf() error {
 	strPort := strconv.Itoa(int(this.config.GetServerPort()))
 	_, err := this.con.Write(command.ConstructReplConf(replconfcommand.ListeningPort, strPort).Marshall())
@@ -97,6 +146,9 @@ func (this *Handshake) SendHandshakeReplcConf() error {
 	}
 
 	cmd, err := command.DataTypeToCommand(data)
+	if err != nil {
+		return fmt.Errorf("Recieve non ok response on replconf: %v", data.Value)
+	}
 	if cmd.Type != command.OK {
 		return fmt.Errorf("Recieve non ok response on replconf: %v", cmd.Type)
 	}
@@ -112,6 +164,9 @@ func (this *Handshake) SendHandshakeReplcConf() error {
 	}
 
 	cmd, err = command.DataTypeToCommand(data)
+	if err != nil {
+		return fmt.Errorf("Recieve non ok response on replconf: %v", data.Value)
+	}
 	if cmd.Type != command.OK {
 		return fmt.Errorf("Recieve non ok response on replconf: %v", cmd.Type)
 	}
@@ -0,0 +1,6 @@
+package hyperloglog
+
+import "errors"
+
+var InvalidHllError = errors.New("WRONGTYPE Key is not a valid HyperLogLog string value.")
+var CorruptedHllError = errors.New("INVALIDOBJ Corrupted HLL object detected")
@@ -0,0 +1,292 @@
+package hyperloglog
+
+import (
+	"encoding/binary"
+	"math"
+	"math/bits"
+	"unsafe"
+)
+
+// HyperLogLog is stored as string in redis format: 16 bytes header of "HYLL" magic, encoding byte,
+// 3 unused bytes and little endian cached cardinality, followed by registers in dense or sparse encoding
+
+const (
+	// bits of hash used to select register
+	p              = 14
+	RegistersCount = 1 << p
+	// bits of hash used to count run of zeros
+	q            = 64 - p
+	registerBits = 6
+	registerMax  = 1<<registerBits - 1
+	headerSize   = 16
+	denseSize    = headerSize + (RegistersCount*registerBits+7)/8
+	magic        = "HYLL"
+	hashSeed     = 0xadc83b19
+	alphaInf     = 0.721347520444481703680
+)
+
+type encodingEnum byte
+
+const (
+	denseEncoding  encodingEnum = 0
+	sparseEncoding encodingEnum = 1
+)
+
+// returns empty HyperLogLog in sparse encoding with valid cached cardinality of 0
+func New() []byte {
+	b := make([]byte, headerSize, headerSize+2)
+	copy(b, magic)
+	b[4] = byte(sparseEncoding)
+	return appendZeros(b, RegistersCount)
+}
+
+// checks header and size of string value, sparse registers are checked when they are read
+func Validate(b []byte) error {
+	if len(b) < headerSize || string(b[:len(magic)]) != magic || b[4] > byte(sparseEncoding) {
+		return InvalidHllError
+	}
+	if IsDense(b) && len(b) != denseSize {
+		return InvalidHllError
+	}
+	return nil
+}
+
+func IsDense(b []byte) bool {
+	return encodingEnum(b[4]) == denseEncoding
+}
+
+// adds elements to b, which is either changed in place or reallocated, sparse encoding is promoted to dense
+// when it grows beyond sparseMaxBytes, changed reports is any register updated
+func Add(b []byte, elements []string, sparseMaxBytes int) ([]byte, bool, error) {
+	changed := false
+	for _, element := range elements {
+		index, count := patLen(element)
+		var updated bool
+		var err error
+		b, updated, err = set(b, index, count, sparseMaxBytes)
+		if err != nil {
+			return b, changed, err
+		}
+		changed = changed || updated
+	}
+	if changed {
+		invalidateCache(b)
+	}
+	return b, changed, nil
+}
+
+// sets register to max of its value and count
+func set(b []byte, index int, count uint8, sparseMaxBytes int) ([]byte, bool, error) {
+	if IsDense(b) {
+		return b, denseSet(b[headerSize:], index, count), nil
+	}
+	return sparseSet(b, index, count, sparseMaxBytes)
+}
+
+// returns register selected by element and position of first set bit in rest of its hash
+func patLen(element string) (int, uint8) {
+	hash := murmurHash64A(unsafe.Slice(unsafe.StringData(element), len(element)), hashSeed)
+	index := int(hash & (RegistersCount - 1))
+	hash >>= p
+	// guarantees loop end when rest of hash is all zeros
+	hash |= 1 << q
+	return index, uint8(bits.TrailingZeros64(hash) + 1)
+}
+
+func denseGet(regs []byte, index int) uint8 {
+	byteIndex := index * registerBits / 8
+	fb := uint(index * registerBits & 7)
+	v := uint(regs[byteIndex]) >> fb
+	if byteIndex+1 < len(regs) {
+		v |= uint(regs[byteIndex+1]) << (8 - fb)
+	}
+	return uint8(v & registerMax)
+}
+
+// sets register if count is greater than current value, reports is register changed
+func denseSet(regs []byte, index int, count uint8) bool {
+	if denseGet(regs, index) >= count {
+		return false
+	}
+	byteIndex := index * registerBits / 8
+	fb := uint(index * registerBits & 7)
+	regs[byteIndex] &^= byte(registerMax << fb)
+	regs[byteIndex] |= byte(uint(count) << fb)
+	if byteIndex+1 < len(regs) {
+		regs[byteIndex+1] &^= byte(registerMax >> (8 - fb))
+		regs[byteIndex+1] |= byte(uint(count) >> (8 - fb))
+	}
+	return true
+}
+
+// returns copy of sparse HyperLogLog converted to dense encoding, header with cached cardinality is preserved
+func toDense(b []byte) ([]byte, error) {
+	dense := make([]byte, denseSize)
+	copy(dense, b[:headerSize])
+	dense[4] = byte(denseEncoding)
+	regs := dense[headerSize:]
+	err := forEachRun(b[headerSize:], func(index int, length int, value uint8) {
+		if value == 0 {
+			return
+		}
+		for i := index; i < index+length; i++ {
+			denseSet(regs, i, value)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dense, nil
+}
+
+// returns cached cardinality, ok is false if cache was invalidated by write
+func CachedCount(b []byte) (uint64, bool) {
+	if b[15]&(1<<7) != 0 {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint64(b[8:headerSize]), true
+}
+
+func SetCachedCount(b []byte, card uint64) {
+	binary.LittleEndian.PutUint64(b[8:headerSize], card)
+}
+
+func invalidateCache(b []byte) {
+	b[15] |= 1 << 7
+}
+
+// returns estimated cardinality computed from registers, cached value is ignored
+func Count(b []byte) (uint64, error) {
+	var histogram [64]int
+	if IsDense(b) {
+		regs := b[headerSize:]
+		for i := 0; i < RegistersCount; i++ {
+			histogram[denseGet(regs, i)]++
+		}
+		return estimate(&histogram), nil
+	}
+	err := forEachRun(b[headerSize:], func(index int, length int, value uint8) {
+		histogram[value] += length
+	})
+	if err != nil {
+		return 0, err
+	}
+	return estimate(&histogram), nil
+}
+
+// merges registers of b into regs, so each register of regs holds max of both values
+func MergeRegisters(regs []uint8, b []byte) error {
+	if IsDense(b) {
+		dense := b[headerSize:]
+		for i := range regs {
+			regs[i] = max(regs[i], denseGet(dense, i))
+		}
+		return nil
+	}
+	return forEachRun(b[headerSize:], func(index int, length int, value uint8) {
+		for i := index; i < index+length; i++ {
+			regs[i] = max(regs[i], value)
+		}
+	})
+}
+
+// returns estimated cardinality of registers merged with MergeRegisters
+func CountRegisters(regs []uint8) uint64 {
+	var histogram [64]int
+	for _, reg := range regs {
+		histogram[reg]++
+	}
+	return estimate(&histogram)
+}
+
+// sets registers of b to max of its values and regs, b is converted to dense encoding if dense is set,
+// cached cardinality is invalidated
+func SetRegisters(b []byte, regs []uint8, dense bool, sparseMaxBytes int) ([]byte, error) {
+	var err error
+	if dense && !IsDense(b) {
+		b, err = toDense(b)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for i, reg := range regs {
+		if reg == 0 {
+			continue
+		}
+		b, _, err = set(b, i, reg, sparseMaxBytes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	invalidateCache(b)
+	return b, nil
+}
+
+// estimates cardinality from histogram of register values with improved estimator of Otmar Ertl
+func estimate(histogram *[64]int) uint64 {
+	m := float64(RegistersCount)
+	z := m * tau((m-float64(histogram[q+1]))/m)
+	for j := q; j >= 1; j-- {
+		z += float64(histogram[j])
+		z *= 0.5
+	}
+	z += m * sigma(float64(histogram[0])/m)
+	return uint64(math.Round(alphaInf * m * m / z))
+}
+
+func sigma(x float64) float64 {
+	if x == 1 {
+		return math.Inf(1)
+	}
+	y, z := 1.0, x
+	for {
+		x *= x
+		prev := z
+		z += x * y
+		y += y
+		if prev == z {
+			return z
+		}
+	}
+}
+
+func tau(x float64) float64 {
+	if x == 0 || x == 1 {
+		return 0
+	}
+	y, z := 1.0, 1-x
+	for {
+		x = math.Sqrt(x)
+		prev := z
+		y *= 0.5
+		z -= math.Pow(1-x, 2) * y
+		if prev == z {
+			return z / 3
+		}
+	}
+}
+
+// 64 bit hash of Austin Appleby used by redis, reads blocks in little endian
+func murmurHash64A(data []byte, seed uint64) uint64 {
+	const m = 0xc6a4a7935bd1e995
+	const r = 47
+	h := seed ^ uint64(len(data))*m
+	for ; len(data) >= 8; data = data[8:] {
+		k := binary.LittleEndian.Uint64(data)
+		k *= m
+		k ^= k >> r
+		k *= m
+		h ^= k
+		h *= m
+	}
+	if len(data) > 0 {
+		for i := len(data) - 1; i >= 0; i-- {
+			h ^= uint64(data[i]) << (8 * i)
+		}
+		h *= m
+	}
+	h ^= h >> r
+	h *= m
+	h ^= h >> r
+	return h
+}
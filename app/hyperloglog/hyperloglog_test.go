@@ -0,0 +1,225 @@
+package hyperloglog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"strconv"
+	"testing"
+)
+
+func registers(t *testing.T, b []byte) []uint8 {
+	regs := make([]uint8, RegistersCount)
+	if err := MergeRegisters(regs, b); err != nil {
+		t.Fatalf("unexpected error reading registers: %v", err)
+	}
+	return regs
+}
+
+func TestAdd_SparseMatchesDense(t *testing.T) {
+	sparse := New()
+	dense, err := toDense(New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3000; i++ {
+		elements := []string{"element:" + strconv.Itoa(i)}
+		sparse, _, err = Add(sparse, elements, math.MaxInt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dense, _, err = Add(dense, elements, math.MaxInt)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if IsDense(sparse) {
+		t.Fatalf("expected sparse encoding to be kept without size limit")
+	}
+	sparseRegs, denseRegs := registers(t, sparse), registers(t, dense)
+	for i := range sparseRegs {
+		if sparseRegs[i] != denseRegs[i] {
+			t.Fatalf("expected register %v to be %v, got %v", i, denseRegs[i], sparseRegs[i])
+		}
+	}
+	sparseCount, _ := Count(sparse)
+	denseCount, _ := Count(dense)
+	if sparseCount != denseCount {
+		t.Fatalf("expected sparse count %v to match dense count %v", sparseCount, denseCount)
+	}
+}
+
+func TestAdd_PromotesAndEstimates(t *testing.T) {
+	b := New()
+	if card, ok := CachedCount(b); !ok || card != 0 {
+		t.Fatalf("expected valid cached cardinality 0, got %v %v", card, ok)
+	}
+	for _, n := range []int{1, 10, 100, 1000, 100000} {
+		b = New()
+		for i := 0; i < n; i++ {
+			var err error
+			b, _, err = Add(b, []string{strconv.Itoa(i)}, 3000)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if !IsDense(b) && len(b) > 3000 {
+			t.Fatalf("expected sparse encoding to be limited by 3000 bytes, got %v", len(b))
+		}
+		if err := Validate(b); err != nil {
+			t.Fatalf("expected valid HyperLogLog, got %v", err)
+		}
+		if _, ok := CachedCount(b); ok {
+			t.Fatalf("expected cached cardinality to be invalidated")
+		}
+		card, err := Count(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(float64(card)-float64(n)) > float64(n)*0.02+1 {
+			t.Fatalf("expected estimate of %v, got %v", n, card)
+		}
+	}
+	if !IsDense(b) {
+		t.Fatalf("expected 100000 elements to promote HyperLogLog to dense")
+	}
+}
+
+func TestSetRegisters_Merges(t *testing.T) {
+	a, _, _ := Add(New(), []string{"a", "b", "c"}, 3000)
+	b, _, _ := Add(New(), []string{"c", "d"}, 3000)
+	regs := registers(t, a)
+	merged, err := SetRegisters(b, regs, false, 3000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if card, _ := Count(merged); card != 4 {
+		t.Fatalf("expected merged cardinality 4, got %v", card)
+	}
+	merged, err = SetRegisters(merged, regs, true, 3000)
+	if err != nil || !IsDense(merged) {
+		t.Fatalf("expected dense HyperLogLog, got %v", err)
+	}
+	if card, _ := Count(merged); card != 4 {
+		t.Fatalf("expected dense merged cardinality 4, got %v", card)
+	}
+}
+
+func TestValidate_DetectsCorruption(t *testing.T) {
+	for _, b := range [][]byte{[]byte("HYLL"), []byte("HYLX000000000000\x7f\xff"), append([]byte("HYLL\x00"), make([]byte, 20)...)} {
+		if err := Validate(b); err != InvalidHllError {
+			t.Fatalf("expected %q to be invalid, got %v", b, err)
+		}
+	}
+	b, _, _ := Add(New(), []string{"a"}, 3000)
+	b = append(b, 0x80)
+	if _, err := Count(b); err != CorruptedHllError {
+		t.Fatalf("expected corrupted error for extra opcode, got %v", err)
+	}
+	if _, _, err := Add(New()[:headerSize+1], []string{"a"}, 3000); err != CorruptedHllError {
+		t.Fatalf("expected corrupted error for truncated opcode, got %v", err)
+	}
+}
+
+func elements(n int) []string {
+	out := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		out = append(out, "elem:"+strconv.Itoa(i))
+	}
+	return out
+}
+
+// expected bytes were NOT captured from redis-server, none was available, they were computed by
+// standalone transcription of redis src/hyperloglog.c (MurmurHash64A, sparse opcodes splitting and merging,
+// promotion, estimator), written apart from this package; recapture them with GET after PFADD on redis
+// when possible, e.g. redis-cli --no-raw GET key, and sha256 of dense value for larger sets
+func TestAdd_MatchesRedisSparseBytes(t *testing.T) {
+	cases := []struct {
+		elements []string
+		want     string
+	}{
+		{[]string{"a", "b", "c"}, "48594c4c01000000000000000000008060f38050b1844bfb80425a"},
+		{[]string{"hello"}, "48594c4c01000000000000000000008063ff805bfe"},
+		{elements(40), "48594c4c01000000000000000000008044bc840884405980426184415c8041bf8042f780438c840994424384288442fb8844808c41f7844272803c80338017842988413b80406c8040a7a0405f84420d8440948c42588042ac8040a884431680418f8442bd8040598440ba8040a58c404f8841988444d284404d8008844204804266"},
+	}
+	for _, c := range cases {
+		b, updated, err := Add(New(), c.elements, 3000)
+		if err != nil || !updated {
+			t.Fatalf("expected %v elements to update HyperLogLog, got %v with error %v", len(c.elements), updated, err)
+		}
+		if got := hex.EncodeToString(b); got != c.want {
+			t.Errorf("expected PFADD of %v elements to produce\n%v, got\n%v", len(c.elements), c.want, got)
+		}
+		want, _ := hex.DecodeString(c.want)
+		card, err := Count(want)
+		if err != nil || card != uint64(len(c.elements)) {
+			t.Errorf("expected fixture of %v elements to count %v, got %v with error %v", len(c.elements), len(c.elements), card, err)
+		}
+		if _, updated, _ := Add(b, c.elements, 3000); updated {
+			t.Errorf("expected adding the same %v elements again to change nothing", len(c.elements))
+		}
+	}
+}
+
+// fixtures have the same origin as above, sha256 is compared for values too long to be inlined,
+// cached sha256 is of value after PFCOUNT stored estimate in header
+func TestAdd_MatchesRedisBytesAndCount(t *testing.T) {
+	cases := []struct {
+		n              int
+		sparseMaxBytes int
+		dense          bool
+		want           string
+		card           uint64
+		cached         string
+	}{
+		{300, 3000, false, "632494ff4ad68bac3772ab18eab7e55401cc119c9b3054b3897a9bd9db4f5add", 302, "caeec4044f9b597b1802dc0f005f72efe2b232e714bd61f20210765b37c5f15e"},
+		// promoted by hll-sparse-max-bytes
+		{300, 200, true, "6c198c54a213f9eb7430717601134f05936f0f74aed2fabff5cec63b98889956", 302, "b8dfbdc42079bb3879c2b450a1101146b20ec90292672b4ac728841a7eb1a90c"},
+		{5000, 3000, true, "f69f24de6ae684a675686e245a7f985903165fb40ac8c7c405a2909d1ce84f39", 4973, "199fcc954ab0bea221a6a9d8cb5a714845ffd67d18f5f60fe7cca19b6f124147"},
+		{20000, 3000, true, "c764ec7c4dcec499bb8596e43ce77b86564f9cc907edf2c2cd4b730ff007ef28", 19603, "b5ae45352d3d1eabd6c4de7bdba863cd990d06cc3c147d514f797184d873f3ac"},
+	}
+	for _, c := range cases {
+		b, _, err := Add(New(), elements(c.n), c.sparseMaxBytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if IsDense(b) != c.dense {
+			t.Errorf("expected %v elements with sparse max bytes %v to be dense %v", c.n, c.sparseMaxBytes, c.dense)
+		}
+		sum := sha256.Sum256(b)
+		if got := hex.EncodeToString(sum[:]); got != c.want {
+			t.Errorf("expected PFADD of %v elements to produce value with sha256 %v, got %v", c.n, c.want, got)
+		}
+		card, err := Count(b)
+		if err != nil || card != c.card {
+			t.Errorf("expected %v elements to count %v, got %v with error %v", c.n, c.card, card, err)
+		}
+		SetCachedCount(b, card)
+		sum = sha256.Sum256(b)
+		if got := hex.EncodeToString(sum[:]); got != c.cached {
+			t.Errorf("expected value of %v elements with cached count to have sha256 %v, got %v", c.n, c.cached, got)
+		}
+	}
+}
+
+// examples of PFADD and PFCOUNT pages of redis documentation
+func TestCount_MatchesRedisDocumentation(t *testing.T) {
+	b, _, _ := Add(New(), []string{"a", "b", "c", "d", "e", "f", "g"}, 3000)
+	if card, _ := Count(b); card != 7 {
+		t.Errorf("expected 7, got %v", card)
+	}
+	hll, _, _ := Add(New(), []string{"foo", "bar", "zap"}, 3000)
+	hll, _, _ = Add(hll, []string{"zap", "zap", "zap"}, 3000)
+	hll, _, _ = Add(hll, []string{"foo", "bar"}, 3000)
+	if card, _ := Count(hll); card != 3 {
+		t.Errorf("expected 3, got %v", card)
+	}
+	other, _, _ := Add(New(), []string{"1", "2", "3"}, 3000)
+	regs := registers(t, hll)
+	if err := MergeRegisters(regs, other); err != nil {
+		t.Fatal(err)
+	}
+	if card := CountRegisters(regs); card != 6 {
+		t.Errorf("expected union to count 6, got %v", card)
+	}
+}
@@ -0,0 +1,201 @@
+package hyperloglog
+
+import "slices"
+
+// sparse encoding is sequence of opcodes describing runs of registers:
+// ZERO 00xxxxxx is run of up to 64 zero registers,
+// XZERO 01xxxxxx xxxxxxxx is run of up to 16384 zero registers,
+// VAL 1vvvvvxx is run of up to 4 registers with value up to 32
+const (
+	sparseZeroMaxLen  = 64
+	sparseValMaxValue = 32
+	sparseValMaxLen   = 4
+	// amount of opcodes checked for merge after register is updated
+	sparseMergeScan = 5
+)
+
+func isZero(op byte) bool {
+	return op&0xc0 == 0
+}
+
+func isXzero(op byte) bool {
+	return op&0xc0 == 0x40
+}
+
+func isVal(op byte) bool {
+	return op&0x80 != 0
+}
+
+func zeroLen(op byte) int {
+	return int(op&0x3f) + 1
+}
+
+func xzeroLen(op byte, next byte) int {
+	return (int(op&0x3f)<<8 | int(next)) + 1
+}
+
+func valValue(op byte) uint8 {
+	return (op>>2)&0x1f + 1
+}
+
+func valLen(op byte) int {
+	return int(op&0x3) + 1
+}
+
+func valOp(value uint8, length int) byte {
+	return 0x80 | (value-1)<<2 | byte(length-1)
+}
+
+// appends ZERO or XZERO opcode for run of length zero registers
+func appendZeros(b []byte, length int) []byte {
+	if length > sparseZeroMaxLen {
+		return append(b, 0x40|byte((length-1)>>8), byte(length-1))
+	}
+	return append(b, byte(length-1))
+}
+
+// calls fn for every run of registers, fails if runs do not cover exactly all registers
+func forEachRun(ops []byte, fn func(index int, length int, value uint8)) error {
+	index := 0
+	for i := 0; i < len(ops); {
+		op := ops[i]
+		length, value := 0, uint8(0)
+		switch {
+		case isZero(op):
+			length = zeroLen(op)
+			i++
+		case isXzero(op):
+			if i+1 >= len(ops) {
+				return CorruptedHllError
+			}
+			length = xzeroLen(op, ops[i+1])
+			i += 2
+		default:
+			length, value = valLen(op), valValue(op)
+			i++
+		}
+		if index+length > RegistersCount {
+			return CorruptedHllError
+		}
+		fn(index, length, value)
+		index += length
+	}
+	if index != RegistersCount {
+		return CorruptedHllError
+	}
+	return nil
+}
+
+// sets register if count is greater than current value, opcode holding register is split in place,
+// b is promoted to dense encoding if count does not fit VAL opcode or b grows beyond sparseMaxBytes
+func sparseSet(b []byte, index int, count uint8, sparseMaxBytes int) ([]byte, bool, error) {
+	if count > sparseValMaxValue {
+		return promote(b, index, count)
+	}
+
+	// find opcode holding register, first is index of first register of opcode
+	pos, prev, first, span := headerSize, -1, 0, 0
+	for pos < len(b) {
+		opLen := 1
+		switch op := b[pos]; {
+		case isZero(op):
+			span = zeroLen(op)
+		case isVal(op):
+			span = valLen(op)
+		default:
+			if pos+1 >= len(b) {
+				return b, false, CorruptedHllError
+			}
+			span = xzeroLen(op, b[pos+1])
+			opLen = 2
+		}
+		if index <= first+span-1 {
+			break
+		}
+		prev = pos
+		pos += opLen
+		first += span
+	}
+	if span == 0 || pos >= len(b) {
+		return b, false, CorruptedHllError
+	}
+
+	op := b[pos]
+	if isVal(op) && valValue(op) >= count {
+		return b, false, nil
+	}
+	// run of single register is updated in place
+	if span == 1 && !isXzero(op) {
+		b[pos] = valOp(count, 1)
+		return mergeVals(b, prev), true, nil
+	}
+
+	// run is split into runs before register, register itself and runs after register
+	seq := make([]byte, 0, 5)
+	last := first + span - 1
+	if isVal(op) {
+		value := valValue(op)
+		if index != first {
+			seq = append(seq, valOp(value, index-first))
+		}
+		seq = append(seq, valOp(count, 1))
+		if index != last {
+			seq = append(seq, valOp(value, last-index))
+		}
+	} else {
+		if index != first {
+			seq = appendZeros(seq, index-first)
+		}
+		seq = append(seq, valOp(count, 1))
+		if index != last {
+			seq = appendZeros(seq, last-index)
+		}
+	}
+	opLen := 1
+	if isXzero(op) {
+		opLen = 2
+	}
+	if delta := len(seq) - opLen; delta > 0 && len(b)+delta > sparseMaxBytes {
+		return promote(b, index, count)
+	}
+	b = slices.Replace(b, pos, pos+opLen, seq...)
+	return mergeVals(b, prev), true, nil
+}
+
+// merges adjacent VAL opcodes with the same value, starting from opcode preceding updated one
+func mergeVals(b []byte, prev int) []byte {
+	pos := prev
+	if pos < 0 {
+		pos = headerSize
+	}
+	for scan := sparseMergeScan; pos < len(b) && scan > 0; scan-- {
+		op := b[pos]
+		if isXzero(op) {
+			pos += 2
+			continue
+		}
+		if isZero(op) {
+			pos++
+			continue
+		}
+		if pos+1 < len(b) && isVal(b[pos+1]) && valValue(op) == valValue(b[pos+1]) {
+			if length := valLen(op) + valLen(b[pos+1]); length <= sparseValMaxLen {
+				b[pos+1] = valOp(valValue(op), length)
+				b = slices.Delete(b, pos, pos+1)
+				// merged opcode can be merged with the next one
+				continue
+			}
+		}
+		pos++
+	}
+	return b
+}
+
+func promote(b []byte, index int, count uint8) ([]byte, bool, error) {
+	dense, err := toDense(b)
+	if err != nil {
+		return b, false, err
+	}
+	denseSet(dense[headerSize:], index, count)
+	return dense, true, nil
+}
@@ -0,0 +1,288 @@
+package listpack
+
+import (
+	"encoding/binary"
+	"strconv"
+)
+
+// listpack is stored in redis format: 6 bytes header of little endian total bytes and number of elements,
+// followed by elements and terminating 0xFF byte. Element is encoding with data followed by backlen,
+// which is length of encoding and data written so it can be read from the right, to iterate backwards
+
+const (
+	headerSize = 6
+	eof        = 0xFF
+	// number of elements is not stored in header when it reaches this value
+	unknownLen = 0xFFFF
+
+	encUint7Mask = 0x80
+	encUint7     = 0x00
+	encStr6Mask  = 0xC0
+	encStr6      = 0x80
+	encInt13Mask = 0xE0
+	encInt13     = 0xC0
+	encStr12Mask = 0xF0
+	encStr12     = 0xE0
+	encInt16     = 0xF1
+	encInt24     = 0xF2
+	encInt32     = 0xF3
+	encInt64     = 0xF4
+	encStr32     = 0xF0
+	str6MaxLen   = 1<<6 - 1
+	str12MaxLen  = 1<<12 - 1
+)
+
+// returns empty listpack
+func New() []byte {
+	lp := make([]byte, headerSize+1)
+	binary.LittleEndian.PutUint32(lp, uint32(len(lp)))
+	lp[headerSize] = eof
+	return lp
+}
+
+// returns number of elements
+func Len(lp []byte) int {
+	n := int(binary.LittleEndian.Uint16(lp[4:]))
+	if n != unknownLen {
+		return n
+	}
+	n = 0
+	for p := First(lp); p >= 0; p = Next(lp, p) {
+		n++
+	}
+	return n
+}
+
+// returns total size of listpack in bytes
+func Bytes(lp []byte) int {
+	return int(binary.LittleEndian.Uint32(lp))
+}
+
+// returns offset of the first element, -1 if listpack is empty
+func First(lp []byte) int {
+	if lp[headerSize] == eof {
+		return -1
+	}
+	return headerSize
+}
+
+// returns offset of the last element, -1 if listpack is empty
+func Last(lp []byte) int {
+	return Prev(lp, len(lp)-1)
+}
+
+// returns offset of element after element at p, -1 if p is the last one
+func Next(lp []byte, p int) int {
+	size := encodedSize(lp[p:])
+	p += size + backlenSize(size)
+	if lp[p] == eof {
+		return -1
+	}
+	return p
+}
+
+// returns offset of element before element at p, p may be offset of terminating byte, -1 if p is the first one
+func Prev(lp []byte, p int) int {
+	if p <= headerSize {
+		return -1
+	}
+	p--
+	size, shift := 0, 0
+	for {
+		size |= int(lp[p]&127) << shift
+		if lp[p]&128 == 0 {
+			break
+		}
+		shift += 7
+		p--
+	}
+	return p - size
+}
+
+// returns element at p, integers are returned in decimal form
+func Get(lp []byte, p int) string {
+	if v, ok := getInt(lp[p:]); ok {
+		return strconv.FormatInt(v, 10)
+	}
+	return string(stringData(lp[p:]))
+}
+
+// returns element at p as integer, ok is false if element is string which is not a valid integer
+func GetInt(lp []byte, p int) (int64, bool) {
+	if v, ok := getInt(lp[p:]); ok {
+		return v, true
+	}
+	v, err := strconv.ParseInt(string(stringData(lp[p:])), 10, 64)
+	return v, err == nil
+}
+
+// appends element, strings which are integers in canonical form are stored in integer encoding,
+// lp is either changed in place or reallocated
+func Append(lp []byte, value string) []byte {
+	return appendElement(lp, encode(value))
+}
+
+func AppendInt(lp []byte, value int64) []byte {
+	return appendElement(lp, encodeInt(value))
+}
+
+// replaces element at p, returned listpack may be reallocated
+func Replace(lp []byte, p int, value string) []byte {
+	return replace(lp, p, encode(value))
+}
+
+func ReplaceInt(lp []byte, p int, value int64) []byte {
+	return replace(lp, p, encodeInt(value))
+}
+
+func replace(lp []byte, p int, element []byte) []byte {
+	size := encodedSize(lp[p:])
+	old := size + backlenSize(size)
+	if old == len(element) {
+		copy(lp[p:], element)
+		return lp
+	}
+	lp = append(lp[:p], append(element, lp[p+old:]...)...)
+	binary.LittleEndian.PutUint32(lp, uint32(len(lp)))
+	return lp
+}
+
+func appendElement(lp []byte, element []byte) []byte {
+	lp = append(lp[:len(lp)-1], element...)
+	lp = append(lp, eof)
+	binary.LittleEndian.PutUint32(lp, uint32(len(lp)))
+	if n := binary.LittleEndian.Uint16(lp[4:]); n != unknownLen {
+		binary.LittleEndian.PutUint16(lp[4:], n+1)
+	}
+	return lp
+}
+
+// returns encoded element with backlen
+func encode(value string) []byte {
+	if v, err := strconv.ParseInt(value, 10, 64); err == nil && strconv.FormatInt(v, 10) == value {
+		return encodeInt(v)
+	}
+	n := len(value)
+	var out []byte
+	switch {
+	case n <= str6MaxLen:
+		out = append(make([]byte, 0, n+2), encStr6|byte(n))
+	case n <= str12MaxLen:
+		out = append(make([]byte, 0, n+4), encStr12|byte(n>>8), byte(n))
+	default:
+		out = make([]byte, 5, n+10)
+		out[0] = encStr32
+		binary.LittleEndian.PutUint32(out[1:], uint32(n))
+	}
+	out = append(out, value...)
+	return appendBacklen(out, len(out))
+}
+
+func encodeInt(v int64) []byte {
+	var out []byte
+	switch {
+	case v >= 0 && v <= 127:
+		out = []byte{encUint7 | byte(v)}
+	case v >= -(1<<12) && v < 1<<12:
+		u := uint16(v) & 0x1FFF
+		out = []byte{encInt13 | byte(u>>8), byte(u)}
+	case v >= -(1<<15) && v < 1<<15:
+		out = binary.LittleEndian.AppendUint16([]byte{encInt16}, uint16(v))
+	case v >= -(1<<23) && v < 1<<23:
+		u := uint32(v)
+		out = []byte{encInt24, byte(u), byte(u >> 8), byte(u >> 16)}
+	case v >= -(1<<31) && v < 1<<31:
+		out = binary.LittleEndian.AppendUint32([]byte{encInt32}, uint32(v))
+	default:
+		out = binary.LittleEndian.AppendUint64([]byte{encInt64}, uint64(v))
+	}
+	return appendBacklen(out, len(out))
+}
+
+// backlen is written in 7 bit groups, the most significant first, every byte except the first one has high bit set
+func appendBacklen(out []byte, size int) []byte {
+	n := backlenSize(size)
+	for i := n - 1; i >= 0; i-- {
+		b := byte(size>>(7*i)) & 127
+		if i != n-1 {
+			b |= 128
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+func backlenSize(size int) int {
+	n := 1
+	for size > 127 {
+		size >>= 7
+		n++
+	}
+	return n
+}
+
+// returns size of encoding and data of element
+func encodedSize(e []byte) int {
+	switch {
+	case e[0]&encUint7Mask == encUint7:
+		return 1
+	case e[0]&encStr6Mask == encStr6:
+		return 1 + int(e[0]&^encStr6Mask)
+	case e[0]&encInt13Mask == encInt13:
+		return 2
+	case e[0]&encStr12Mask == encStr12:
+		return 2 + (int(e[0]&^encStr12Mask)<<8 | int(e[1]))
+	}
+	switch e[0] {
+	case encInt16:
+		return 3
+	case encInt24:
+		return 4
+	case encInt32:
+		return 5
+	case encInt64:
+		return 9
+	case encStr32:
+		return 5 + int(binary.LittleEndian.Uint32(e[1:]))
+	}
+	panic("listpack: invalid element encoding")
+}
+
+func getInt(e []byte) (int64, bool) {
+	switch {
+	case e[0]&encUint7Mask == encUint7:
+		return int64(e[0] & 127), true
+	case e[0]&encStr6Mask == encStr6:
+		return 0, false
+	case e[0]&encInt13Mask == encInt13:
+		u := uint16(e[0]&^encInt13Mask)<<8 | uint16(e[1])
+		// sign extension of 13 bit value
+		return int64(int16(u<<3) >> 3), true
+	case e[0]&encStr12Mask == encStr12:
+		return 0, false
+	}
+	switch e[0] {
+	case encInt16:
+		return int64(int16(binary.LittleEndian.Uint16(e[1:]))), true
+	case encInt24:
+		u := uint32(e[1]) | uint32(e[2])<<8 | uint32(e[3])<<16
+		return int64(int32(u<<8) >> 8), true
+	case encInt32:
+		return int64(int32(binary.LittleEndian.Uint32(e[1:]))), true
+	case encInt64:
+		return int64(binary.LittleEndian.Uint64(e[1:])), true
+	}
+	return 0, false
+}
+
+func stringData(e []byte) []byte {
+	switch {
+	case e[0]&encStr6Mask == encStr6:
+		return e[1 : 1+int(e[0]&^encStr6Mask)]
+	case e[0]&encStr12Mask == encStr12:
+		n := int(e[0]&^encStr12Mask)<<8 | int(e[1])
+		return e[2 : 2+n]
+	}
+	n := int(binary.LittleEndian.Uint32(e[1:]))
+	return e[5 : 5+n]
+}
@@ -0,0 +1,46 @@
+package listpack
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAppend_IteratesInBothDirections(t *testing.T) {
+	values := []string{"0", "127", "128", "-1", "-4096", "4095", "-32768", "8388607", "-2147483648", "9223372036854775807",
+		"", "field", "007", "+1", strings.Repeat("a", 63), strings.Repeat("b", 64), strings.Repeat("c", 5000)}
+	lp := New()
+	for _, v := range values {
+		lp = Append(lp, v)
+	}
+	if Len(lp) != len(values) || Bytes(lp) != len(lp) {
+		t.Fatalf("expected %v elements and %v bytes, got %v and %v", len(values), len(lp), Len(lp), Bytes(lp))
+	}
+	i := 0
+	for p := First(lp); p >= 0; p = Next(lp, p) {
+		if got := Get(lp, p); got != values[i] {
+			t.Fatalf("element %v: expected %q, got %q", i, values[i], got)
+		}
+		i++
+	}
+	for p := Last(lp); p >= 0; p = Prev(lp, p) {
+		i--
+		if got := Get(lp, p); got != values[i] {
+			t.Fatalf("element %v backwards: expected %q, got %q", i, values[i], got)
+		}
+	}
+	if i != 0 {
+		t.Fatalf("expected to iterate back to the first element, stopped at %v", i)
+	}
+}
+
+func TestReplace_ChangesEncodingSize(t *testing.T) {
+	lp := AppendInt(AppendInt(New(), 1), 2)
+	lp = ReplaceInt(lp, First(lp), 100000)
+	second := Next(lp, First(lp))
+	if v, ok := GetInt(lp, First(lp)); !ok || v != 100000 {
+		t.Fatalf("expected replaced value 100000, got %v %v", v, ok)
+	}
+	if v, ok := GetInt(lp, second); !ok || v != 2 || Next(lp, second) != -1 || Bytes(lp) != len(lp) {
+		t.Fatalf("expected second element to stay intact, got %v %v", v, ok)
+	}
+}
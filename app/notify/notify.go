@@ -0,0 +1,127 @@
+// keyspace notifications: events about changes of keys published to __keyspace@<db>__:<key> and __keyevent@<db>__:<event> channels
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var InvalidClassError = errors.New("Invalid event class character. Use 'Ag$lshzxeKEtmdn'.")
+
+// set of event classes enabled by notify-keyspace-events
+type Class int
+
+const (
+	// channels events are published to
+	Keyspace Class = 1 << iota
+	Keyevent
+	// classes of events
+	Generic
+	String
+	List
+	Set
+	Hash
+	ZSet
+	Expired
+	Evicted
+	Stream
+	KeyMiss
+	Module
+	NewKey
+	// alias A, key miss and new key events are not included
+	All = Generic | String | List | Set | Hash | ZSet | Expired | Evicted | Stream | Module
+)
+
+// flag characters in order used by String
+var classFlags = []struct {
+	flag  byte
+	class Class
+}{
+	{'g', Generic},
+	{'$', String},
+	{'l', List},
+	{'s', Set},
+	{'h', Hash},
+	{'z', ZSet},
+	{'x', Expired},
+	{'e', Evicted},
+	{'t', Stream},
+	{'d', Module},
+	{'K', Keyspace},
+	{'E', Keyevent},
+	{'m', KeyMiss},
+	{'n', NewKey},
+}
+
+// parses classes in notify-keyspace-events format, e.g. "KEA" or "Kx"
+func ParseClasses(value string) (Class, error) {
+	var classes Class
+outer:
+	for i := 0; i < len(value); i++ {
+		if value[i] == 'A' {
+			classes |= All
+			continue
+		}
+		for _, f := range classFlags {
+			if f.flag == value[i] {
+				classes |= f.class
+				continue outer
+			}
+		}
+		return 0, InvalidClassError
+	}
+	return classes, nil
+}
+
+// formats classes back to flags, all classes of A are formatted as A
+func (this Class) String() string {
+	var out strings.Builder
+	for _, f := range classFlags {
+		if this&All == All && All&f.class != 0 {
+			if f.class == Generic {
+				out.WriteByte('A')
+			}
+			continue
+		}
+		if this&f.class != 0 {
+			out.WriteByte(f.flag)
+		}
+	}
+	return out.String()
+}
+
+// sends message to subscribers of channel
+type Publisher interface {
+	Publish(channel string, message string) int
+}
+
+type Notifier struct {
+	publisher Publisher
+	// classes are read on every event so CONFIG SET applies at runtime
+	classes func() Class
+}
+
+func New(publisher Publisher, classes func() Class) *Notifier {
+	return &Notifier{
+		publisher: publisher,
+		classes:   classes,
+	}
+}
+
+// publishes event of given class about key of database, event is dropped if class or both channels are disabled
+func (this *Notifier) Notify(class Class, event string, key string, db int) {
+	if this == nil {
+		return
+	}
+	classes := this.classes()
+	if classes&class == 0 {
+		return
+	}
+	if classes&Keyspace != 0 {
+		this.publisher.Publish(fmt.Sprintf("__keyspace@%v__:%v", db, key), event)
+	}
+	if classes&Keyevent != 0 {
+		this.publisher.Publish(fmt.Sprintf("__keyevent@%v__:%v", db, event), key)
+	}
+}
@@ -0,0 +1,64 @@
+package notify
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testPublisher struct {
+	published [][2]string
+}
+
+func (this *testPublisher) Publish(channel string, message string) int {
+	this.published = append(this.published, [2]string{channel, message})
+	return 1
+}
+
+func TestParseAndFormatClasses(t *testing.T) {
+	cases := []struct {
+		value     string
+		formatted string
+	}{
+		{"", ""},
+		{"KEA", "AKE"},
+		{"Ex$", "$xE"},
+		{"g$lshzxetdKEmn", "AKEmn"},
+		{"Kgn", "gKn"},
+	}
+	for _, c := range cases {
+		classes, err := ParseClasses(c.value)
+		if err != nil {
+			t.Fatalf("expected no error for %q, got %v", c.value, err)
+		}
+		if formatted := classes.String(); formatted != c.formatted {
+			t.Fatalf("expected %q to be formatted as %q, got %q", c.value, c.formatted, formatted)
+		}
+	}
+	if _, err := ParseClasses("KQ"); err != InvalidClassError {
+		t.Fatalf("expected invalid class error, got %v", err)
+	}
+}
+
+func TestNotifyPublishesEnabledClasses(t *testing.T) {
+	publisher := &testPublisher{}
+	classes := Keyspace | Keyevent | String
+	notifier := New(publisher, func() Class { return classes })
+
+	notifier.Notify(String, "set", "user", 2)
+	notifier.Notify(Generic, "del", "user", 2)
+	expected := [][2]string{
+		{"__keyspace@2__:user", "set"},
+		{"__keyevent@2__:set", "user"},
+	}
+	if !reflect.DeepEqual(publisher.published, expected) {
+		t.Fatalf("expected %v, got %v", expected, publisher.published)
+	}
+
+	// class without channel does not publish anything
+	classes = All
+	publisher.published = nil
+	notifier.Notify(Generic, "del", "user", 0)
+	if len(publisher.published) != 0 {
+		t.Fatalf("expected nothing to be published, got %v", publisher.published)
+	}
+}
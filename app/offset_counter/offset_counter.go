@@ -2,6 +2,7 @@ package offset_counter
 
 import (
 	"strconv"
+	"sync"
 
 	"github.com/codecrafters-io/redis-starter-go/app/command"
 	replconfcommand "github.com/codecrafters-io/redis-starter-go/app/commands/repl_conf_command"
@@ -9,7 +10,9 @@ import (
 	"github.com/codecrafters-io/redis-starter-go/app/logger"
 )
 
+// blocking commands are executed aside of executor goroutine, so counter is guarded by mutex
 type Counter struct {
+	mu             sync.Mutex
 	bytesProcessed int
 }
 
@@ -18,6 +21,8 @@ func New() *Counter {
 }
 
 func (c *Counter) ProcessCmd(cmd *command.Command) (*datatypes.Data, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	var out *datatypes.Data = nil
 	if cmd.IsNeedToRepondeAck() {
 		logger.Logger.Info("Process replconf getAck command")
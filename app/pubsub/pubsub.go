@@ -0,0 +1,419 @@
+// publish/subscribe messaging: server wide registry of channel, pattern and shard channel subscriptions
+package pubsub
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/codecrafters-io/redis-starter-go/app/client"
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+	"github.com/codecrafters-io/redis-starter-go/app/glob"
+	"github.com/codecrafters-io/redis-starter-go/app/slots"
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+// kinds of messages, the first element of every message sent to subscriber
+const (
+	SubscribeKind    = "subscribe"
+	UnsubscribeKind  = "unsubscribe"
+	PSubscribeKind   = "psubscribe"
+	PUnsubscribeKind = "punsubscribe"
+	SSubscribeKind   = "ssubscribe"
+	SUnsubscribeKind = "sunsubscribe"
+	MessageKind      = "message"
+	PMessageKind     = "pmessage"
+	SMessageKind     = "smessage"
+)
+
+type subscriptionEnum int
+
+const (
+	channelSubscription subscriptionEnum = iota
+	patternSubscription
+	// shard channels are mapped to hash slots and accounted separately from global channels
+	shardSubscription
+)
+
+type clientSet map[*client.Client]struct{}
+
+// channels, patterns and shard channels client is subscribed to
+type subscriptions struct {
+	channels      map[string]struct{}
+	patterns      map[string]struct{}
+	shardChannels map[string]struct{}
+}
+
+type Pubsub struct {
+	mu sync.RWMutex
+	// subscribers by channel, by pattern and by shard channel
+	channels      map[string]clientSet
+	patterns      map[string]clientSet
+	shardChannels map[string]clientSet
+	// shard channels with subscribers by slot
+	slotChannels map[int]map[string]struct{}
+	// only clients with at least one subscription are kept
+	clients map[*client.Client]*subscriptions
+}
+
+func New() *Pubsub {
+	return &Pubsub{
+		channels:      map[string]clientSet{},
+		patterns:      map[string]clientSet{},
+		shardChannels: map[string]clientSet{},
+		slotChannels:  map[int]map[string]struct{}{},
+		clients:       map[*client.Client]*subscriptions{},
+	}
+}
+
+// returns message in protocol of receiver: push in RESP3 and array in RESP2
+func NewMessage(resp int, values ...*datatypes.Data) *datatypes.Data {
+	if resp == 3 {
+		return datatypes.ConstructPush(values)
+	}
+	return datatypes.ConstructArrayFromData(values)
+}
+
+// subscribes client to channel, returns amount of channels and patterns client is subscribed to
+func (this *Pubsub) Subscribe(c *client.Client, channel string) int {
+	return this.subscribe(c, channel, channelSubscription)
+}
+
+// unsubscribes client from channel, returns amount of channels and patterns client is still subscribed to
+func (this *Pubsub) Unsubscribe(c *client.Client, channel string) int {
+	return this.unsubscribe(c, channel, channelSubscription)
+}
+
+func (this *Pubsub) PSubscribe(c *client.Client, pattern string) int {
+	return this.subscribe(c, pattern, patternSubscription)
+}
+
+func (this *Pubsub) PUnsubscribe(c *client.Client, pattern string) int {
+	return this.unsubscribe(c, pattern, patternSubscription)
+}
+
+// subscribes client to shard channel, returns amount of shard channels client is subscribed to
+func (this *Pubsub) SSubscribe(c *client.Client, channel string) int {
+	return this.subscribe(c, channel, shardSubscription)
+}
+
+// unsubscribes client from shard channel, returns amount of shard channels client is still subscribed to
+func (this *Pubsub) SUnsubscribe(c *client.Client, channel string) int {
+	return this.unsubscribe(c, channel, shardSubscription)
+}
+
+// returns registry and client subscriptions of given type, should be called under lock
+func (this *Pubsub) sets(subs *subscriptions, typ subscriptionEnum) (map[string]clientSet, map[string]struct{}) {
+	switch typ {
+	case patternSubscription:
+		return this.patterns, subs.patterns
+	case shardSubscription:
+		return this.shardChannels, subs.shardChannels
+	}
+	return this.channels, subs.channels
+}
+
+func (this *Pubsub) subscribe(c *client.Client, name string, typ subscriptionEnum) int {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	subs, ok := this.clients[c]
+	if !ok {
+		subs = &subscriptions{
+			channels:      map[string]struct{}{},
+			patterns:      map[string]struct{}{},
+			shardChannels: map[string]struct{}{},
+		}
+		this.clients[c] = subs
+	}
+	registry, own := this.sets(subs, typ)
+	if _, ok := own[name]; !ok {
+		own[name] = struct{}{}
+		if registry[name] == nil {
+			registry[name] = clientSet{}
+			if typ == shardSubscription {
+				this.addSlotChannel(name)
+			}
+		}
+		registry[name][c] = struct{}{}
+	}
+	this.update(c, subs)
+	return subs.count(typ)
+}
+
+func (this *Pubsub) unsubscribe(c *client.Client, name string, typ subscriptionEnum) int {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	subs, ok := this.clients[c]
+	if !ok {
+		return 0
+	}
+	this.remove(c, subs, name, typ)
+	this.update(c, subs)
+	return subs.count(typ)
+}
+
+// should be called under lock
+func (this *Pubsub) remove(c *client.Client, subs *subscriptions, name string, typ subscriptionEnum) {
+	registry, own := this.sets(subs, typ)
+	if _, ok := own[name]; !ok {
+		return
+	}
+	delete(own, name)
+	delete(registry[name], c)
+	if len(registry[name]) == 0 {
+		delete(registry, name)
+		if typ == shardSubscription {
+			this.removeSlotChannel(name)
+		}
+	}
+}
+
+func (this *Pubsub) addSlotChannel(channel string) {
+	slot := slots.KeySlot(channel)
+	if this.slotChannels[slot] == nil {
+		this.slotChannels[slot] = map[string]struct{}{}
+	}
+	this.slotChannels[slot][channel] = struct{}{}
+}
+
+func (this *Pubsub) removeSlotChannel(channel string) {
+	slot := slots.KeySlot(channel)
+	delete(this.slotChannels[slot], channel)
+	if len(this.slotChannels[slot]) == 0 {
+		delete(this.slotChannels, slot)
+	}
+}
+
+// applies changed subscriptions to client, should be called under lock
+func (this *Pubsub) update(c *client.Client, subs *subscriptions) {
+	c.SetSubscriptions(len(subs.channels), len(subs.patterns), len(subs.shardChannels))
+	if len(subs.channels)+len(subs.patterns)+len(subs.shardChannels) == 0 {
+		delete(this.clients, c)
+	}
+}
+
+// amount of subscriptions reported to client, shard channels are counted separately from channels and patterns
+func (this *subscriptions) count(typ subscriptionEnum) int {
+	if typ == shardSubscription {
+		return len(this.shardChannels)
+	}
+	return len(this.channels) + len(this.patterns)
+}
+
+// returns amount of channels and patterns client is subscribed to
+func (this *Pubsub) Count(c *client.Client) int {
+	return this.countOf(c, channelSubscription)
+}
+
+// returns amount of shard channels client is subscribed to
+func (this *Pubsub) ShardCount(c *client.Client) int {
+	return this.countOf(c, shardSubscription)
+}
+
+func (this *Pubsub) countOf(c *client.Client, typ subscriptionEnum) int {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	subs, ok := this.clients[c]
+	if !ok {
+		return 0
+	}
+	return subs.count(typ)
+}
+
+// returns channels client is subscribed to in lexicographic order
+func (this *Pubsub) Channels(c *client.Client) []string {
+	return this.names(c, channelSubscription)
+}
+
+// returns patterns client is subscribed to in lexicographic order
+func (this *Pubsub) Patterns(c *client.Client) []string {
+	return this.names(c, patternSubscription)
+}
+
+// returns shard channels client is subscribed to in lexicographic order
+func (this *Pubsub) ShardChannels(c *client.Client) []string {
+	return this.names(c, shardSubscription)
+}
+
+func (this *Pubsub) names(c *client.Client, typ subscriptionEnum) []string {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	subs, ok := this.clients[c]
+	if !ok {
+		return nil
+	}
+	_, own := this.sets(subs, typ)
+	out := make([]string, 0, len(own))
+	for name := range own {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// removes every subscription of client without notifying it, e.g. when connection is closed
+func (this *Pubsub) UnsubscribeAll(c *client.Client) {
+	for _, channel := range this.Channels(c) {
+		this.Unsubscribe(c, channel)
+	}
+	for _, pattern := range this.Patterns(c) {
+		this.PUnsubscribe(c, pattern)
+	}
+	for _, channel := range this.ShardChannels(c) {
+		this.SUnsubscribe(c, channel)
+	}
+}
+
+// unsubscribes clients from shard channels of slots that stopped being served locally,
+// every client is notified by sunsubscribe message like after SUNSUBSCRIBE
+func (this *Pubsub) UnsubscribeSlots(released []int) {
+	type notice struct {
+		c       *client.Client
+		channel string
+		count   int
+	}
+	notices := []notice{}
+	this.mu.Lock()
+	for _, slot := range released {
+		channels := make([]string, 0, len(this.slotChannels[slot]))
+		for channel := range this.slotChannels[slot] {
+			channels = append(channels, channel)
+		}
+		sort.Strings(channels)
+		for _, channel := range channels {
+			for c := range this.shardChannels[channel] {
+				subs := this.clients[c]
+				this.remove(c, subs, channel, shardSubscription)
+				this.update(c, subs)
+				notices = append(notices, notice{c: c, channel: channel, count: subs.count(shardSubscription)})
+			}
+		}
+	}
+	this.mu.Unlock()
+
+	for _, n := range notices {
+		n.c.Push(NewMessage(n.c.GetResp(),
+			datatypes.ConstructBulkString(SUnsubscribeKind),
+			datatypes.ConstructBulkString(n.channel),
+			datatypes.ConstructInt(n.count),
+		))
+	}
+}
+
+type delivery struct {
+	c       *client.Client
+	pattern string
+}
+
+// sends message to subscribers of channel and of patterns matching it,
+// returns amount of receivers, client subscribed several times receives message several times
+func (this *Pubsub) Publish(channel string, message string) int {
+	this.mu.RLock()
+	deliveries := []delivery{}
+	for c := range this.channels[channel] {
+		deliveries = append(deliveries, delivery{c: c})
+	}
+	patternsFrom := len(deliveries)
+	for pattern, set := range this.patterns {
+		if !glob.Match(pattern, channel, false) {
+			continue
+		}
+		for c := range set {
+			deliveries = append(deliveries, delivery{c: c, pattern: pattern})
+		}
+	}
+	this.mu.RUnlock()
+
+	for i, d := range deliveries {
+		if i < patternsFrom {
+			d.c.Push(NewMessage(d.c.GetResp(),
+				datatypes.ConstructBulkString(MessageKind),
+				datatypes.ConstructBulkString(channel),
+				datatypes.ConstructBulkString(message),
+			))
+			continue
+		}
+		d.c.Push(NewMessage(d.c.GetResp(),
+			datatypes.ConstructBulkString(PMessageKind),
+			datatypes.ConstructBulkString(d.pattern),
+			datatypes.ConstructBulkString(channel),
+			datatypes.ConstructBulkString(message),
+		))
+	}
+	return len(deliveries)
+}
+
+// sends message to subscribers of shard channel, patterns are not matched against shard channels,
+// returns amount of receivers
+func (this *Pubsub) SPublish(channel string, message string) int {
+	this.mu.RLock()
+	receivers := make([]*client.Client, 0, len(this.shardChannels[channel]))
+	for c := range this.shardChannels[channel] {
+		receivers = append(receivers, c)
+	}
+	this.mu.RUnlock()
+
+	for _, c := range receivers {
+		c.Push(NewMessage(c.GetResp(),
+			datatypes.ConstructBulkString(SMessageKind),
+			datatypes.ConstructBulkString(channel),
+			datatypes.ConstructBulkString(message),
+		))
+	}
+	return len(receivers)
+}
+
+// returns channels with at least one subscriber matching pattern in lexicographic order, empty pattern matches every channel
+func (this *Pubsub) ActiveChannels(pattern string) []string {
+	return this.active(this.channels, pattern)
+}
+
+// returns shard channels with at least one subscriber matching pattern in lexicographic order
+func (this *Pubsub) ActiveShardChannels(pattern string) []string {
+	return this.active(this.shardChannels, pattern)
+}
+
+func (this *Pubsub) active(registry map[string]clientSet, pattern string) []string {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	out := []string{}
+	for channel := range registry {
+		if pattern == "" || glob.Match(pattern, channel, false) {
+			out = append(out, channel)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// returns amount of subscribers of channel, pattern subscribers are not counted
+func (this *Pubsub) NumSub(channel string) int {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return len(this.channels[channel])
+}
+
+// returns amount of subscribers of shard channel
+func (this *Pubsub) ShardNumSub(channel string) int {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return len(this.shardChannels[channel])
+}
+
+// returns amount of unique patterns subscribed by clients
+func (this *Pubsub) NumPat() int {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return len(this.patterns)
+}
+
+// counters shown by INFO stats
+func (this *Pubsub) GetInfo() []types.Kv {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return []types.Kv{
+		{"pubsub_channels", strconv.Itoa(len(this.channels))},
+		{"pubsub_patterns", strconv.Itoa(len(this.patterns))},
+		{"pubsubshard_channels", strconv.Itoa(len(this.shardChannels))},
+	}
+}
@@ -0,0 +1,148 @@
+package pubsub
+
+import (
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/app/client"
+	"github.com/codecrafters-io/redis-starter-go/app/slots"
+)
+
+type testConn struct {
+	mu      sync.Mutex
+	written []string
+}
+
+func (this *testConn) Write(p []byte) (int, error) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.written = append(this.written, string(p))
+	return len(p), nil
+}
+func (this *testConn) Close() error { return nil }
+func (this *testConn) RemoteAddr() net.Addr {
+	return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 5000}
+}
+func (this *testConn) LocalAddr() net.Addr {
+	return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 6379}
+}
+
+func newTestClient(t *testing.T, table *client.Table) (*client.Client, *testConn) {
+	conn := &testConn{}
+	c, err := table.Register(conn, client.NormalType, nil, true, 0)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return c, conn
+}
+
+func TestPublishToChannelAndPatternSubscribers(t *testing.T) {
+	table := client.NewTable()
+	ps := New()
+	a, aConn := newTestClient(t, table)
+	b, bConn := newTestClient(t, table)
+
+	if count := ps.Subscribe(a, "news"); count != 1 {
+		t.Fatalf("expected 1 subscription, got %v", count)
+	}
+	if count := ps.PSubscribe(a, "n*"); count != 2 {
+		t.Fatalf("expected 2 subscriptions, got %v", count)
+	}
+	ps.PSubscribe(b, "n*")
+	b.SetResp(3)
+
+	if receivers := ps.Publish("news", "hi"); receivers != 3 {
+		t.Fatalf("expected 3 receivers, got %v", receivers)
+	}
+	expectedA := []string{
+		"*3\r\n$7\r\nmessage\r\n$4\r\nnews\r\n$2\r\nhi\r\n",
+		"*4\r\n$8\r\npmessage\r\n$2\r\nn*\r\n$4\r\nnews\r\n$2\r\nhi\r\n",
+	}
+	if !reflect.DeepEqual(aConn.written, expectedA) {
+		t.Fatalf("expected %q, got %q", expectedA, aConn.written)
+	}
+	expectedB := []string{">4\r\n$8\r\npmessage\r\n$2\r\nn*\r\n$4\r\nnews\r\n$2\r\nhi\r\n"}
+	if !reflect.DeepEqual(bConn.written, expectedB) {
+		t.Fatalf("expected %q, got %q", expectedB, bConn.written)
+	}
+	if receivers := ps.Publish("other", "hi"); receivers != 0 {
+		t.Fatalf("expected no receivers, got %v", receivers)
+	}
+}
+
+func TestUnsubscribeLeavesPubsubMode(t *testing.T) {
+	table := client.NewTable()
+	ps := New()
+	c, _ := newTestClient(t, table)
+
+	ps.Subscribe(c, "a")
+	ps.Subscribe(c, "b")
+	ps.PSubscribe(c, "c*")
+	if c.GetType() != client.PubsubType || !c.IsSubscribedMode() {
+		t.Fatalf("expected client in pubsub mode")
+	}
+	if channels := ps.ActiveChannels(""); !reflect.DeepEqual(channels, []string{"a", "b"}) {
+		t.Fatalf("expected channels a and b, got %v", channels)
+	}
+	if channels := ps.ActiveChannels("b*"); !reflect.DeepEqual(channels, []string{"b"}) {
+		t.Fatalf("expected channel b, got %v", channels)
+	}
+	if ps.NumSub("a") != 1 || ps.NumPat() != 1 {
+		t.Fatalf("expected 1 subscriber and 1 pattern, got %v and %v", ps.NumSub("a"), ps.NumPat())
+	}
+	if count := ps.Unsubscribe(c, "a"); count != 2 {
+		t.Fatalf("expected 2 subscriptions, got %v", count)
+	}
+	ps.UnsubscribeAll(c)
+	if ps.Count(c) != 0 || ps.NumPat() != 0 || len(ps.ActiveChannels("")) != 0 {
+		t.Fatalf("expected no subscriptions left")
+	}
+	if c.GetType() != client.NormalType {
+		t.Fatalf("expected normal client, got %v", c.GetType())
+	}
+}
+
+func TestShardChannelsReleasedWithSlot(t *testing.T) {
+	table := client.NewTable()
+	ps := New()
+	c, conn := newTestClient(t, table)
+
+	ps.Subscribe(c, "news")
+	if count := ps.SSubscribe(c, "{user}.a"); count != 1 {
+		t.Fatalf("expected 1 shard subscription, got %v", count)
+	}
+	ps.SSubscribe(c, "{user}.b")
+	ps.SSubscribe(c, "other")
+	if ps.Count(c) != 1 || ps.ShardCount(c) != 3 {
+		t.Fatalf("expected 1 subscription and 3 shard subscriptions, got %v and %v", ps.Count(c), ps.ShardCount(c))
+	}
+	if channels := ps.ActiveChannels(""); !reflect.DeepEqual(channels, []string{"news"}) {
+		t.Fatalf("expected channel news, got %v", channels)
+	}
+	if ps.NumSub("other") != 0 || ps.ShardNumSub("other") != 1 {
+		t.Fatalf("expected shard channel to be accounted separately")
+	}
+	if receivers := ps.SPublish("{user}.a", "hi"); receivers != 1 {
+		t.Fatalf("expected 1 receiver, got %v", receivers)
+	}
+
+	conn.written = nil
+	ps.UnsubscribeSlots([]int{slots.KeySlot("user")})
+	expected := []string{
+		"*3\r\n$12\r\nsunsubscribe\r\n$8\r\n{user}.a\r\n:2\r\n",
+		"*3\r\n$12\r\nsunsubscribe\r\n$8\r\n{user}.b\r\n:1\r\n",
+	}
+	if !reflect.DeepEqual(conn.written, expected) {
+		t.Fatalf("expected %q, got %q", expected, conn.written)
+	}
+	if channels := ps.ActiveShardChannels(""); !reflect.DeepEqual(channels, []string{"other"}) {
+		t.Fatalf("expected shard channel other, got %v", channels)
+	}
+	ps.Unsubscribe(c, "news")
+	ps.SUnsubscribe(c, "other")
+	if c.GetType() != client.NormalType {
+		t.Fatalf("expected normal client, got %v", c.GetType())
+	}
+}
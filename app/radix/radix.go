@@ -0,0 +1,304 @@
+package radix
+
+import (
+	"bytes"
+	"sort"
+)
+
+// compressed radix tree mapping byte string keys to byte string values, keys are kept in lexicographic order
+
+type node struct {
+	// part of key on the edge leading to node, empty only for root
+	prefix []byte
+	// ordered by the first byte of prefix
+	children []*node
+	isKey    bool
+	value    []byte
+}
+
+type Tree struct {
+	root  *node
+	keys  int
+	nodes int
+}
+
+func New() *Tree {
+	return &Tree{root: &node{}, nodes: 1}
+}
+
+// returns number of keys
+func (this *Tree) Len() int {
+	return this.keys
+}
+
+// returns number of nodes including root
+func (this *Tree) Nodes() int {
+	return this.nodes
+}
+
+func commonPrefix(a []byte, b []byte) int {
+	n := min(len(a), len(b))
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
+
+// returns index of child which prefix starts with b, or index where such child should be inserted
+func (this *node) childIndex(b byte) (int, bool) {
+	i := sort.Search(len(this.children), func(i int) bool {
+		return this.children[i].prefix[0] >= b
+	})
+	return i, i < len(this.children) && this.children[i].prefix[0] == b
+}
+
+// sets value of key, reports was key added
+func (this *Tree) Insert(key []byte, value []byte) bool {
+	n := this.root
+	for len(key) > 0 {
+		i, ok := n.childIndex(key[0])
+		if !ok {
+			child := &node{prefix: bytes.Clone(key), isKey: true, value: value}
+			n.children = append(n.children, nil)
+			copy(n.children[i+1:], n.children[i:])
+			n.children[i] = child
+			this.nodes++
+			this.keys++
+			return true
+		}
+		child := n.children[i]
+		l := commonPrefix(child.prefix, key)
+		if l < len(child.prefix) {
+			// edge is split by node holding common part of prefixes
+			mid := &node{prefix: child.prefix[:l:l], children: []*node{child}}
+			child.prefix = child.prefix[l:]
+			n.children[i] = mid
+			this.nodes++
+			child = mid
+		}
+		n = child
+		key = key[l:]
+	}
+	added := !n.isKey
+	if added {
+		this.keys++
+	}
+	n.isKey = true
+	n.value = value
+	return added
+}
+
+func (this *Tree) Find(key []byte) ([]byte, bool) {
+	n := this.root
+	for len(key) > 0 {
+		i, ok := n.childIndex(key[0])
+		if !ok || !bytes.HasPrefix(key, n.children[i].prefix) {
+			return nil, false
+		}
+		key = key[len(n.children[i].prefix):]
+		n = n.children[i]
+	}
+	return n.value, n.isKey
+}
+
+// removes key, reports was it present, nodes left without keys are removed and chains of single children are merged
+func (this *Tree) Remove(key []byte) bool {
+	path := []*node{this.root}
+	n := this.root
+	for len(key) > 0 {
+		i, ok := n.childIndex(key[0])
+		if !ok || !bytes.HasPrefix(key, n.children[i].prefix) {
+			return false
+		}
+		key = key[len(n.children[i].prefix):]
+		n = n.children[i]
+		path = append(path, n)
+	}
+	if !n.isKey {
+		return false
+	}
+	n.isKey = false
+	n.value = nil
+	this.keys--
+
+	if n != this.root && len(n.children) == 0 {
+		parent := path[len(path)-2]
+		i, _ := parent.childIndex(n.prefix[0])
+		parent.children = append(parent.children[:i], parent.children[i+1:]...)
+		this.nodes--
+		n = parent
+	}
+	this.compress(n)
+	return true
+}
+
+// merges node which is not a key with its only child
+func (this *Tree) compress(n *node) {
+	if n == this.root || n.isKey || len(n.children) != 1 {
+		return
+	}
+	child := n.children[0]
+	n.prefix = append(bytes.Clone(n.prefix), child.prefix...)
+	n.children = child.children
+	n.isKey = child.isKey
+	n.value = child.value
+	this.nodes--
+}
+
+// returns the smallest key in subtree of n, path is key of n
+func (this *node) min(path []byte) ([]byte, *node) {
+	for !this.isKey {
+		this = this.children[0]
+		path = append(path, this.prefix...)
+	}
+	return path, this
+}
+
+// returns the greatest key in subtree of n, path is key of n
+func (this *node) max(path []byte) ([]byte, *node) {
+	for len(this.children) > 0 {
+		this = this.children[len(this.children)-1]
+		path = append(path, this.prefix...)
+	}
+	return path, this
+}
+
+// returns the smallest key in subtree of n not less than path followed by rest, with strict the key should be greater
+func (this *node) seekUp(path []byte, rest []byte, strict bool) ([]byte, *node) {
+	if len(rest) == 0 {
+		if this.isKey && !strict {
+			return path, this
+		}
+		if len(this.children) == 0 {
+			return nil, nil
+		}
+		child := this.children[0]
+		return child.min(append(path, child.prefix...))
+	}
+	i, _ := this.childIndex(rest[0])
+	for ; i < len(this.children); i++ {
+		child := this.children[i]
+		childPath := append(path[:len(path):len(path)], child.prefix...)
+		l := commonPrefix(child.prefix, rest)
+		switch {
+		case l == len(child.prefix):
+			if key, n := child.seekUp(childPath, rest[l:], strict); n != nil {
+				return key, n
+			}
+		case l == len(rest) || child.prefix[l] > rest[l]:
+			return child.min(childPath)
+		}
+	}
+	return nil, nil
+}
+
+// returns the greatest key in subtree of n not greater than path followed by rest, with strict the key should be less
+func (this *node) seekDown(path []byte, rest []byte, strict bool) ([]byte, *node) {
+	if len(rest) == 0 {
+		if this.isKey && !strict {
+			return path, this
+		}
+		return nil, nil
+	}
+	i, ok := this.childIndex(rest[0])
+	if !ok {
+		i--
+	}
+	for ; i >= 0; i-- {
+		child := this.children[i]
+		childPath := append(path[:len(path):len(path)], child.prefix...)
+		l := commonPrefix(child.prefix, rest)
+		switch {
+		case l == len(child.prefix):
+			if key, n := child.seekDown(childPath, rest[l:], strict); n != nil {
+				return key, n
+			}
+		case l < len(rest) && child.prefix[l] < rest[l]:
+			return child.max(childPath)
+		}
+	}
+	if this.isKey {
+		return path, this
+	}
+	return nil, nil
+}
+
+// iterates keys in order, positioned by Seek, Next and Prev return the sought key first
+type Iterator struct {
+	tree  *Tree
+	key   []byte
+	value []byte
+	// sought key is not returned yet
+	pending bool
+	valid   bool
+}
+
+func (this *Tree) Iterator() *Iterator {
+	return &Iterator{tree: this}
+}
+
+// positions iterator, op is one of ^ (the first key), $ (the last key), =, >=, >, <=, <,
+// reports was such key found
+func (this *Iterator) Seek(op string, key []byte) bool {
+	root := this.tree.root
+	var n *node
+	switch op {
+	case "^":
+		this.key, n = root.seekUp(nil, nil, false)
+	case "$":
+		this.key, n = root.max(nil)
+		if !n.isKey {
+			n = nil
+		}
+	case "=":
+		this.key, n = root.seekUp(nil, key, false)
+		if n != nil && !bytes.Equal(this.key, key) {
+			n = nil
+		}
+	case ">=", ">":
+		this.key, n = root.seekUp(nil, key, op == ">")
+	case "<=", "<":
+		this.key, n = root.seekDown(nil, key, op == "<")
+	}
+	this.valid = n != nil
+	this.pending = this.valid
+	if this.valid {
+		this.value = n.value
+	}
+	return this.valid
+}
+
+// moves to the next key, tree may be changed between calls
+func (this *Iterator) Next() bool {
+	return this.step(">")
+}
+
+// moves to the previous key, tree may be changed between calls
+func (this *Iterator) Prev() bool {
+	return this.step("<")
+}
+
+func (this *Iterator) step(op string) bool {
+	if this.pending {
+		this.pending = false
+		return true
+	}
+	if !this.valid {
+		return false
+	}
+	if !this.Seek(op, this.key) {
+		return false
+	}
+	this.pending = false
+	return true
+}
+
+func (this *Iterator) Key() []byte {
+	return this.key
+}
+
+func (this *Iterator) Value() []byte {
+	return this.value
+}
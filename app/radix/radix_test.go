@@ -0,0 +1,88 @@
+package radix
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func randomKey(r *rand.Rand) []byte {
+	key := make([]byte, r.Intn(4))
+	for i := range key {
+		key[i] = byte('a' + r.Intn(3))
+	}
+	return key
+}
+
+func TestTree_SeekMatchesSortedKeys(t *testing.T) {
+	tree := New()
+	expected := map[string]bool{}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 3000; i++ {
+		key := randomKey(r)
+		if r.Intn(3) == 0 {
+			if removed := tree.Remove(key); removed != expected[string(key)] {
+				t.Fatalf("unexpected result of removing %q: %v", key, removed)
+			}
+			delete(expected, string(key))
+		} else {
+			if added := tree.Insert(key, key); added == expected[string(key)] {
+				t.Fatalf("unexpected result of inserting %q: %v", key, added)
+			}
+			expected[string(key)] = true
+		}
+		if tree.Len() != len(expected) {
+			t.Fatalf("expected %v keys, got %v", len(expected), tree.Len())
+		}
+	}
+	keys := make([]string, 0, len(expected))
+	for key := range expected {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	it := tree.Iterator()
+	i := 0
+	for it.Seek("^", nil); it.Next(); i++ {
+		if string(it.Key()) != keys[i] || !bytes.Equal(it.Value(), it.Key()) {
+			t.Fatalf("expected key %q at %v, got %q", keys[i], i, it.Key())
+		}
+	}
+	if i != len(keys) {
+		t.Fatalf("expected to iterate over %v keys, got %v", len(keys), i)
+	}
+	for it.Seek("$", nil); it.Prev(); {
+		i--
+		if string(it.Key()) != keys[i] {
+			t.Fatalf("expected key %q at %v backwards, got %q", keys[i], i, it.Key())
+		}
+	}
+	for j := 0; j < 200; j++ {
+		key := string(randomKey(r))
+		ge := sort.SearchStrings(keys, key)
+		if ok := it.Seek(">=", []byte(key)); ok != (ge < len(keys)) || ok && string(it.Key()) != keys[ge] {
+			t.Fatalf("unexpected >= seek of %q: %v %q", key, ok, it.Key())
+		}
+		le := ge - 1
+		if ge < len(keys) && keys[ge] == key {
+			le = ge
+		}
+		if ok := it.Seek("<=", []byte(key)); ok != (le >= 0) || ok && string(it.Key()) != keys[le] {
+			t.Fatalf("unexpected <= seek of %q: %v %q", key, ok, it.Key())
+		}
+		if ok := it.Seek("<", []byte(key)); ok != (ge > 0) || ok && string(it.Key()) != keys[ge-1] {
+			t.Fatalf("unexpected < seek of %q: %v %q", key, ok, it.Key())
+		}
+		gt := sort.Search(len(keys), func(i int) bool { return keys[i] > key })
+		if ok := it.Seek(">", []byte(key)); ok != (gt < len(keys)) || ok && string(it.Key()) != keys[gt] {
+			t.Fatalf("unexpected > seek of %q: %v %q", key, ok, it.Key())
+		}
+	}
+	for _, key := range keys {
+		tree.Remove([]byte(key))
+	}
+	if tree.Len() != 0 || tree.Nodes() != 1 {
+		t.Fatalf("expected only root after removing all keys, got %v keys and %v nodes", tree.Len(), tree.Nodes())
+	}
+}
@@ -0,0 +1,49 @@
+package rdb
+
+import "errors"
+
+var CorruptedLzfError = errors.New("Corrupted lzf compressed string")
+
+// decompresses lzf data used by redis for strings longer than 20 bytes, ctrl byte below 32 starts run of ctrl+1
+// literal bytes, otherwise its 3 high bits hold length and 5 low bits high part of offset of back reference
+func lzfDecompress(in []byte, outLen int) ([]byte, error) {
+	out := make([]byte, 0, outLen)
+	for i := 0; i < len(in); {
+		ctrl := int(in[i])
+		i++
+		if ctrl < 32 {
+			length := ctrl + 1
+			if i+length > len(in) || len(out)+length > outLen {
+				return nil, CorruptedLzfError
+			}
+			out = append(out, in[i:i+length]...)
+			i += length
+			continue
+		}
+		length := ctrl >> 5
+		if length == 7 {
+			if i >= len(in) {
+				return nil, CorruptedLzfError
+			}
+			length += int(in[i])
+			i++
+		}
+		if i >= len(in) {
+			return nil, CorruptedLzfError
+		}
+		ref := len(out) - (ctrl&0x1f)<<8 - int(in[i]) - 1
+		i++
+		length += 2
+		if ref < 0 || len(out)+length > outLen {
+			return nil, CorruptedLzfError
+		}
+		// reference can overlap bytes being written, so it is copied byte by byte
+		for j := 0; j < length; j++ {
+			out = append(out, out[ref+j])
+		}
+	}
+	if len(out) != outLen {
+		return nil, CorruptedLzfError
+	}
+	return out, nil
+}
@@ -0,0 +1,284 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/codecrafters-io/redis-starter-go/app/sortedset"
+	"github.com/codecrafters-io/redis-starter-go/app/storage"
+	"github.com/codecrafters-io/redis-starter-go/app/stream"
+)
+
+// sorted set: number of members, then every member with its score as little endian double
+func (this *rdbWriter) writeZSet(zset sortedset.SortedSet) {
+	this.writeLength(zset.Len())
+	zset.ForEach(func(member string, score float64) {
+		this.writeString(member)
+		this.writeUint64(math.Float64bits(score))
+	})
+}
+
+// stream: listpack nodes, stream metadata, then consumer groups with pending entries lists and consumers
+func (this *rdbWriter) writeStream(s stream.Stream) {
+	d := s.Dump()
+	this.writeLength(len(d.Nodes))
+	for _, node := range d.Nodes {
+		this.writeString(string(node.Key))
+		this.writeString(string(node.Listpack))
+	}
+	this.writeLength(d.Length)
+	this.writeId(d.LastId)
+	this.writeId(d.FirstId)
+	this.writeId(d.MaxDeletedId)
+	this.writeLength(int(d.EntriesAdded))
+	this.writeLength(len(d.Groups))
+	for _, g := range d.Groups {
+		this.writeString(g.Name)
+		this.writeId(g.LastId)
+		// -1 of unknown entries read is written as the max 64 bit length, like in redis
+		this.writeLength(int(g.EntriesRead))
+		this.writeLength(len(g.Pending))
+		for _, p := range g.Pending {
+			this.writeRawId(p.Id)
+			this.writeUint64(uint64(p.DeliveryTime))
+			this.writeLength(int(p.DeliveryCount))
+		}
+		this.writeLength(len(g.Consumers))
+		for _, c := range g.Consumers {
+			this.writeString(c.Name)
+			this.writeUint64(uint64(c.SeenTime))
+			this.writeUint64(uint64(c.ActiveTime))
+			this.writeLength(len(c.Pending))
+			for _, id := range c.Pending {
+				this.writeRawId(id)
+			}
+		}
+	}
+}
+
+func (this *rdbWriter) writeUint64(n uint64) {
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, n)
+	this.write(buf)
+}
+
+func (this *rdbWriter) writeId(id stream.StreamEntrieId) {
+	this.writeLength(int(id.Id))
+	this.writeLength(id.SequenceNumber)
+}
+
+// ids of pending entries are written as 128 bit big endian numbers
+func (this *rdbWriter) writeRawId(id stream.StreamEntrieId) {
+	buf := make([]byte, 16)
+	binary.BigEndian.PutUint64(buf, uint64(id.Id))
+	binary.BigEndian.PutUint64(buf[8:], uint64(id.SequenceNumber))
+	this.write(buf)
+}
+
+func (p *Parser) readZSet() (storage.StorageValue, error) {
+	n, err := p.readLength()
+	if err != nil {
+		return storage.StorageValue{}, err
+	}
+	zset := sortedset.NewSortedSet()
+	for i := 0; i < n; i++ {
+		member, err := p.readString()
+		if err != nil {
+			return storage.StorageValue{}, err
+		}
+		bits, err := p.readUint64()
+		if err != nil {
+			return storage.StorageValue{}, err
+		}
+		score := math.Float64frombits(bits)
+		if math.IsNaN(score) {
+			return storage.StorageValue{}, fmt.Errorf("Error reading zset: NaN score of member %v", member.str)
+		}
+		zset.Add(member.str, score)
+	}
+	return storage.NewZSetValue(zset), nil
+}
+
+func (p *Parser) readStream() (storage.StorageValue, error) {
+	d, err := p.readStreamDump()
+	if err != nil {
+		return storage.StorageValue{}, err
+	}
+	s, err := stream.Restore(d)
+	if err != nil {
+		return storage.StorageValue{}, err
+	}
+	return storage.NewStreamValue(s), nil
+}
+
+func (p *Parser) readStreamDump() (stream.Dump, error) {
+	d := stream.Dump{}
+	nodes, err := p.readLength()
+	if err != nil {
+		return d, err
+	}
+	for i := 0; i < nodes; i++ {
+		key, err := p.readString()
+		if err != nil {
+			return d, err
+		}
+		lp, err := p.readString()
+		if err != nil {
+			return d, err
+		}
+		d.Nodes = append(d.Nodes, stream.DumpNode{Key: []byte(key.str), Listpack: []byte(lp.str)})
+	}
+	if d.Length, err = p.readLength(); err != nil {
+		return d, err
+	}
+	if d.LastId, err = p.readId(); err != nil {
+		return d, err
+	}
+	if d.FirstId, err = p.readId(); err != nil {
+		return d, err
+	}
+	if d.MaxDeletedId, err = p.readId(); err != nil {
+		return d, err
+	}
+	entriesAdded, err := p.readLength()
+	if err != nil {
+		return d, err
+	}
+	d.EntriesAdded = int64(entriesAdded)
+	groups, err := p.readLength()
+	if err != nil {
+		return d, err
+	}
+	for i := 0; i < groups; i++ {
+		g, err := p.readGroup()
+		if err != nil {
+			return d, err
+		}
+		d.Groups = append(d.Groups, g)
+	}
+	return d, nil
+}
+
+func (p *Parser) readGroup() (stream.GroupDump, error) {
+	g := stream.GroupDump{}
+	name, err := p.readString()
+	if err != nil {
+		return g, err
+	}
+	g.Name = name.str
+	if g.LastId, err = p.readId(); err != nil {
+		return g, err
+	}
+	entriesRead, err := p.readLength()
+	if err != nil {
+		return g, err
+	}
+	g.EntriesRead = int64(entriesRead)
+	pending, err := p.readLength()
+	if err != nil {
+		return g, err
+	}
+	for i := 0; i < pending; i++ {
+		id, err := p.readRawId()
+		if err != nil {
+			return g, err
+		}
+		deliveryTime, err := p.readUint64()
+		if err != nil {
+			return g, err
+		}
+		deliveryCount, err := p.readLength()
+		if err != nil {
+			return g, err
+		}
+		g.Pending = append(g.Pending, stream.PendingDump{Id: id, DeliveryTime: int64(deliveryTime), DeliveryCount: int64(deliveryCount)})
+	}
+	consumers, err := p.readLength()
+	if err != nil {
+		return g, err
+	}
+	for i := 0; i < consumers; i++ {
+		c, err := p.readConsumer()
+		if err != nil {
+			return g, err
+		}
+		g.Consumers = append(g.Consumers, c)
+	}
+	return g, nil
+}
+
+func (p *Parser) readConsumer() (stream.ConsumerDump, error) {
+	c := stream.ConsumerDump{}
+	name, err := p.readString()
+	if err != nil {
+		return c, err
+	}
+	c.Name = name.str
+	seenTime, err := p.readUint64()
+	if err != nil {
+		return c, err
+	}
+	activeTime, err := p.readUint64()
+	if err != nil {
+		return c, err
+	}
+	c.SeenTime = int64(seenTime)
+	c.ActiveTime = int64(activeTime)
+	pending, err := p.readLength()
+	if err != nil {
+		return c, err
+	}
+	for i := 0; i < pending; i++ {
+		id, err := p.readRawId()
+		if err != nil {
+			return c, err
+		}
+		c.Pending = append(c.Pending, id)
+	}
+	return c, nil
+}
+
+// reads length which must not be special encoded
+func (p *Parser) readLength() (int, error) {
+	n, t, err := p.readLengthEncoded()
+	if err != nil {
+		return 0, err
+	}
+	if t != Int {
+		return 0, fmt.Errorf("Error reading length: unexpected special encoding %v", n)
+	}
+	return n, nil
+}
+
+func (p *Parser) readUint64() (uint64, error) {
+	buf := make([]byte, 8)
+	if _, err := io.ReadFull(p.reader, buf); err != nil {
+		return 0, fmt.Errorf("Error reading 64 bit value: %w", err)
+	}
+	return binary.LittleEndian.Uint64(buf), nil
+}
+
+func (p *Parser) readId() (stream.StreamEntrieId, error) {
+	ms, err := p.readLength()
+	if err != nil {
+		return stream.StreamEntrieId{}, err
+	}
+	seq, err := p.readLength()
+	if err != nil {
+		return stream.StreamEntrieId{}, err
+	}
+	return stream.StreamEntrieId{Id: int64(ms), SequenceNumber: seq}, nil
+}
+
+func (p *Parser) readRawId() (stream.StreamEntrieId, error) {
+	buf := make([]byte, 16)
+	if _, err := io.ReadFull(p.reader, buf); err != nil {
+		return stream.StreamEntrieId{}, fmt.Errorf("Error reading stream id: %w", err)
+	}
+	return stream.StreamEntrieId{
+		Id:             int64(binary.BigEndian.Uint64(buf)),
+		SequenceNumber: int(binary.BigEndian.Uint64(buf[8:])),
+	}, nil
+}
@@ -6,8 +6,10 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path"
+	"strconv"
 	"time"
 
 	"github.com/codecrafters-io/redis-starter-go/app/config"
@@ -45,6 +47,10 @@ const (
 	SortedSetZiplistEncoding RDBValueTypes = 12
 	HashZiplistEncoding      RDBValueTypes = 13 // Introduced in RDB version 4
 	ListQuicklistEncoding    RDBValueTypes = 14 // Introduced in RDB version 7
+	// binary double scores
+	SortedSet2Encoding RDBValueTypes = 5
+	// listpack nodes with consumer groups, consumers have active time since version 3
+	StreamListpacks3Encoding RDBValueTypes = 21
 )
 
 // use to determine is int parser return actual int or flag for spcial type encoded data
@@ -101,7 +107,7 @@ type Parser struct {
 
 func LoadRdbFromFile(
 	config *config.Config,
-	storage storage.Storage,
+	dbs *storage.Databases,
 ) error {
 	file, err := openRdbFile(config)
 	defer file.Close()
@@ -109,43 +115,52 @@ func LoadRdbFromFile(
 		return err
 	}
 	rd := bufio.NewReader(file)
-	return LoadRdbFromReader(rd, storage)
+	return LoadRdbFromReader(rd, dbs)
 }
 
 func LoadRdbFromReader(
 	rd *bufio.Reader,
-	s storage.Storage,
+	dbs *storage.Databases,
 ) error {
 	p := NewParser(rd)
-	values, err := p.ParseRdb()
+	parsedDbs, err := p.ParseRdb()
 
-	for _, v := range values {
-		for _, kv := range v {
+	for _, db := range parsedDbs {
+		if dbs.CheckIndex(db.selector.index) != nil {
+			return fmt.Errorf("Error loading rdb: db index %v is out of range", db.selector.index)
+		}
+		s := dbs.Get(db.selector.index)
+		for _, kv := range db.values {
 			if kv.expType == NotExpire {
-				s.Set(kv.key, storage.NewStringValue(kv.value))
+				s.Set(kv.key, kv.value)
 				continue
 			}
 			switch kv.expType {
 			case NotExpire:
-				s.Set(kv.key, storage.NewStringValue(kv.value))
+				s.Set(kv.key, kv.value)
 			case Seconds:
 				expTime := time.Unix(kv.expValue, 0)
 				currentTime := time.Now()
 				diff := expTime.Sub(currentTime).Milliseconds()
-				s.SetExp(kv.key, storage.NewStringValue(kv.value), int(diff))
+				s.SetExp(kv.key, kv.value, int(diff))
 			case MilliSeconds:
 				expTime := time.UnixMilli(kv.expValue)
 				currentTime := time.Now()
 
 				diff := expTime.Sub(currentTime).Milliseconds()
-				s.SetExp(kv.key, storage.NewStringValue(kv.value), int(diff))
+				s.SetExp(kv.key, kv.value, int(diff))
 			}
 		}
 	}
 	return err
 }
 
-func (p *Parser) ParseRdb() ([][]ParsedKeyValue, error) {
+type ParsedDb struct {
+	selector *DbSelector
+	values   []ParsedKeyValue
+}
+
+func (p *Parser) ParseRdb() ([]ParsedDb, error) {
 	err := p.readMagicString()
 	if err != nil {
 		return nil, err
@@ -154,7 +169,7 @@ func (p *Parser) ParseRdb() ([][]ParsedKeyValue, error) {
 	if err != nil {
 		return nil, err
 	}
-	out := make([][]ParsedKeyValue, 1)
+	out := make([]ParsedDb, 0, 1)
 	for {
 		isEnd, err := p.isEnd()
 		if err != nil {
@@ -164,10 +179,10 @@ func (p *Parser) ParseRdb() ([][]ParsedKeyValue, error) {
 			break
 		}
 		selector, err := p.readDbSelector()
-		logger.Logger.Debug("Parse rdb selector", logger.String("selector", selector.String()))
 		if err != nil {
 			return nil, err
 		}
+		logger.Logger.Debug("Parse rdb selector", logger.String("selector", selector.String()))
 
 		kv, err := p.readDbKeyValues()
 		if err != nil {
@@ -175,7 +190,7 @@ func (p *Parser) ParseRdb() ([][]ParsedKeyValue, error) {
 		}
 		fmt.Println("kv", kv)
 
-		out = append(out, kv)
+		out = append(out, ParsedDb{selector: selector, values: kv})
 	}
 	return out, nil
 }
@@ -258,12 +273,13 @@ func (p *Parser) readHeader() error {
 }
 
 type DbSelector struct {
+	index            int
 	hashTableSize    int
 	hashTableExpSize int
 }
 
 func (d DbSelector) String() string {
-	return fmt.Sprintf("hashTableSize: %v, hashTableExpSize: %v", d.hashTableSize, d.hashTableExpSize)
+	return fmt.Sprintf("index: %v, hashTableSize: %v, hashTableExpSize: %v", d.index, d.hashTableSize, d.hashTableExpSize)
 }
 
 func (p *Parser) readDbSelector() (*DbSelector, error) {
@@ -276,10 +292,12 @@ func (p *Parser) readDbSelector() (*DbSelector, error) {
 		p.reader.UnreadByte()
 		return nil, errors.New("Wrong opcode for selectdb section")
 	}
-	// db idx
-	_, err = p.reader.ReadByte()
+	index, t, err := p.readLengthEncoded()
 	if err != nil {
-		return nil, fmt.Errorf("Error reading header op code: %w", err)
+		return nil, fmt.Errorf("Error reading db index: %w", err)
+	}
+	if t != Int {
+		return nil, errors.New("Wrong encoded db index")
 	}
 	resizeDbOpCode, err := p.reader.ReadByte()
 	if err != nil {
@@ -304,6 +322,7 @@ func (p *Parser) readDbSelector() (*DbSelector, error) {
 	}
 
 	return &DbSelector{
+		index:            index,
 		hashTableSize:    hashTableSize,
 		hashTableExpSize: hashTableExpSize,
 	}, nil
@@ -311,7 +330,7 @@ func (p *Parser) readDbSelector() (*DbSelector, error) {
 
 type ParsedKeyValue struct {
 	key      string
-	value    string
+	value    storage.StorageValue
 	expType  ExpType
 	expValue int64
 	dataType RDBValueTypes
@@ -373,8 +392,8 @@ func (p *Parser) readKeyValuePair() (*ParsedKeyValue, error) {
 		return nil, fmt.Errorf("Error reading kv datatype: %w", err)
 	}
 	switch dataType {
-	case byte(StringEncoding):
-		out.dataType = StringEncoding
+	case byte(StringEncoding), byte(SortedSet2Encoding), byte(StreamListpacks3Encoding):
+		out.dataType = RDBValueTypes(dataType)
 	default:
 		return nil, fmt.Errorf("Format is unsuported: %v", dataType)
 	}
@@ -385,14 +404,23 @@ func (p *Parser) readKeyValuePair() (*ParsedKeyValue, error) {
 		return nil, fmt.Errorf("Error reading key of key value pair: %w", err)
 	}
 
-	value, err := p.readString()
-
+	switch out.dataType {
+	case SortedSet2Encoding:
+		out.value, err = p.readZSet()
+	case StreamListpacks3Encoding:
+		out.value, err = p.readStream()
+	default:
+		var value *ParseStringData
+		value, err = p.readString()
+		if value != nil {
+			out.value = storage.NewStringValue(value.str)
+		}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("Error reading value of key value pair: %w", err)
 	}
 
 	out.key = key.str
-	out.value = value.str
 
 	return out, nil
 }
@@ -404,7 +432,7 @@ func (p *Parser) readLengthEncoded() (int, LengthType, error) {
 		return 0, Int, fmt.Errorf("Error reading length enoded: %w", err)
 	}
 
-	switch int(lenByte >> 4) {
+	switch int(lenByte >> 6) {
 	// header byte: 00 000000 header bits: 00 int bits: 000000
 	case Int4bit:
 		return int(lenByte), Int, nil
@@ -415,14 +443,24 @@ func (p *Parser) readLengthEncoded() (int, LengthType, error) {
 			return 0, Int, fmt.Errorf("Error reading length enoded: %w", err)
 		}
 		return int(int((lenByte<<2)>>2)<<8 | int(additionalByte)), Int, nil
-	// header byte: 10 000000 read additional 4 bytes and form an int
+	// header byte: 10 000000 read additional 4 bytes and form an int, 10 000001 read additional 8 bytes
 	case Int32Bit:
-		var num int32
-		err = binary.Read(p.reader, binary.LittleEndian, &num)
-		if err != nil {
-			return 0, Int, fmt.Errorf("Error reading length enoded: %w", err)
+		switch lenByte {
+		case 0x80:
+			var num uint32
+			err = binary.Read(p.reader, binary.BigEndian, &num)
+			if err != nil {
+				return 0, Int, fmt.Errorf("Error reading length enoded: %w", err)
+			}
+			return int(num), Int, nil
+		case 0x81:
+			var num uint64
+			err = binary.Read(p.reader, binary.BigEndian, &num)
+			if err != nil {
+				return 0, Int, fmt.Errorf("Error reading length enoded: %w", err)
+			}
+			return int(num), Int, nil
 		}
-		return int(num), Int, nil
 	// header byte: 11 000000 information about how we need to interpret next bytes is stored in 4 LSB of header byte
 	case IntSpecialEncoded:
 		return int((lenByte << 2) >> 2), SpecialEncoded, nil
@@ -444,7 +482,7 @@ func (p *Parser) readString() (*ParseStringData, error) {
 	switch lengthType {
 	case Int:
 		strBuf := make([]byte, stringL)
-		_, err := p.reader.Read(strBuf)
+		_, err := io.ReadFull(p.reader, strBuf)
 		if err != nil {
 			return nil, fmt.Errorf("Error reading str to buf: %w", err)
 		}
@@ -461,6 +499,7 @@ func (p *Parser) readString() (*ParseStringData, error) {
 				return nil, fmt.Errorf("Error reading 8bit int str to buf: %w", err)
 			}
 			return &ParseStringData{
+				str: strconv.Itoa(int(num)),
 				num: int(num),
 			}, nil
 		case Bit16Integer:
@@ -470,6 +509,7 @@ func (p *Parser) readString() (*ParseStringData, error) {
 				return nil, fmt.Errorf("Error reading 16bit int str to buf: %w", err)
 			}
 			return &ParseStringData{
+				str: strconv.Itoa(int(num)),
 				num: int(num),
 			}, nil
 		case Bit32Integer:
@@ -479,10 +519,31 @@ func (p *Parser) readString() (*ParseStringData, error) {
 				return nil, fmt.Errorf("Error reading 32bit int str to buf: %w", err)
 			}
 			return &ParseStringData{
+				str: strconv.Itoa(int(num)),
 				num: int(num),
 			}, nil
 		case CompressedString:
-			return nil, errors.New("Compressed string is unsuported")
+			compressedL, _, err := p.readLengthEncoded()
+			if err != nil {
+				return nil, fmt.Errorf("Error reading compressed str length: %w", err)
+			}
+			uncompressedL, _, err := p.readLengthEncoded()
+			if err != nil {
+				return nil, fmt.Errorf("Error reading uncompressed str length: %w", err)
+			}
+			compressed := make([]byte, compressedL)
+			_, err = io.ReadFull(p.reader, compressed)
+			if err != nil {
+				return nil, fmt.Errorf("Error reading compressed str to buf: %w", err)
+			}
+			str, err := lzfDecompress(compressed, uncompressedL)
+			if err != nil {
+				return nil, err
+			}
+			return &ParseStringData{
+				str: string(str),
+				num: -1,
+			}, nil
 		}
 	}
 
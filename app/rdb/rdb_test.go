@@ -0,0 +1,148 @@
+package rdb
+
+import (
+	"bufio"
+	"bytes"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/app/sortedset"
+	"github.com/codecrafters-io/redis-starter-go/app/storage"
+	"github.com/codecrafters-io/redis-starter-go/app/stream"
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+func TestCrc64_RedisTestVector(t *testing.T) {
+	crc := crc64Update(0, []byte("123456789"))
+	if crc != 0xe9c6d914c4b8d9ca {
+		t.Fatalf("expected 0xe9c6d914c4b8d9ca, got %#x", crc)
+	}
+}
+
+func TestWriteRdb_LoadsBack(t *testing.T) {
+	dbs := storage.NewDatabases(16)
+	s := dbs.Get(0)
+	long := strings.Repeat("v", 20000)
+	s.Set("short", storage.NewStringValue("value"))
+	s.Set("long", storage.NewStringValue(long))
+	s.Set("num", storage.NewIntValue(42))
+	s.SetExp("exp", storage.NewStringValue("soon"), 60000)
+	s.SetExp("expired", storage.NewStringValue("gone"), -1)
+	dbs.Get(5).Set("other", storage.NewStringValue("db"))
+
+	var buf bytes.Buffer
+	err := WriteRdb(&buf, dbs)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	loadedDbs := storage.NewDatabases(16)
+	err = LoadRdbFromReader(bufio.NewReader(&buf), loadedDbs)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	loaded := loadedDbs.Get(0)
+	expected := map[string]string{"short": "value", "long": long, "num": "42", "exp": "soon"}
+	for key, value := range expected {
+		got, _ := loaded.Get(key)
+		if got != value {
+			t.Fatalf("expected %v to be loaded as %.20v, got %.20v", key, value, got)
+		}
+	}
+	if loaded.KeysLen() != len(expected) {
+		t.Fatalf("expected %v keys, got %v", len(expected), loaded.KeysLen())
+	}
+	if got, _ := loadedDbs.Get(5).Get("other"); got != "db" {
+		t.Fatalf("expected key of db 5 to be loaded into db 5, got %v", got)
+	}
+}
+
+func TestReadString_DecompressesLzf(t *testing.T) {
+	// literal "a" followed by back reference of 9 bytes at distance 1
+	payload := []byte{0xc3, 5, 10, 0x00, 'a', 0xe0, 0x00, 0x00}
+	p := &Parser{reader: bufio.NewReader(bytes.NewReader(payload))}
+	str, err := p.readString()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if str.str != "aaaaaaaaaa" {
+		t.Fatalf("expected aaaaaaaaaa, got %v", str.str)
+	}
+	if _, err := lzfDecompress([]byte{0xe0, 0x00, 0x00}, 9); err != CorruptedLzfError {
+		t.Fatalf("expected reference before start of output to fail, got %v", err)
+	}
+}
+
+func TestWriteRdb_LoadsBackZSetAndStream(t *testing.T) {
+	dbs := storage.NewDatabases(16)
+	zset := sortedset.NewSortedSet()
+	zset.Add("a", 1.5)
+	zset.Add("b", math.Inf(-1))
+	dbs.Get(0).Set("zset", storage.NewZSetValue(zset))
+
+	s := stream.NewStream()
+	for i := 1; i <= 5; i++ {
+		// ms part does not fit 32 bit length
+		id := stream.StreamEntrieId{Id: 1 << 40, SequenceNumber: i}
+		s.Add(stream.NewStreamEntrieFromKv(id, []types.Kv{{"f", strconv.Itoa(i)}}), stream.NodeLimits{MaxEntries: 2})
+	}
+	s.CreateGroup("g", &stream.StreamEntrieId{}, stream.InvalidEntriesRead)
+	s.ReadGroup("g", "alice", nil, 2, false, 100)
+	s.ReadGroup("g", "bob", nil, 1, false, 200)
+	s.CreateGroup("empty", nil, stream.InvalidEntriesRead)
+	s.Delete([]stream.StreamEntrieId{{Id: 1 << 40, SequenceNumber: 4}})
+	dbs.Get(0).Set("stream", storage.NewStreamValue(s))
+
+	var buf bytes.Buffer
+	if err := WriteRdb(&buf, dbs); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	loadedDbs := storage.NewDatabases(16)
+	if err := LoadRdbFromReader(bufio.NewReader(&buf), loadedDbs); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	entrie, _ := loadedDbs.Get(0).GetEntrie("zset")
+	loadedZSet, err := entrie.ToZSet()
+	if err != nil {
+		t.Fatalf("expected zset, got %v", err)
+	}
+	score, _ := loadedZSet.Score("b")
+	if loadedZSet.Len() != 2 || !math.IsInf(score, -1) {
+		t.Fatalf("unexpected zset %v members, b scored %v", loadedZSet.Len(), score)
+	}
+
+	entrie, _ = loadedDbs.Get(0).GetEntrie("stream")
+	loaded, err := entrie.ToStream()
+	if err != nil {
+		t.Fatalf("expected stream, got %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Dump(), s.Dump()) {
+		t.Fatalf("expected stream to be loaded as\n%+v\ngot\n%+v", s.Dump(), loaded.Dump())
+	}
+	if !reflect.DeepEqual(loaded.Info(true, 0), s.Info(true, 0)) {
+		t.Fatalf("expected stream info to be loaded as\n%+v\ngot\n%+v", s.Info(true, 0), loaded.Info(true, 0))
+	}
+}
+
+func TestReadStream_RejectsCorruptedNodes(t *testing.T) {
+	dbs := storage.NewDatabases(16)
+	s := stream.NewStream()
+	s.Add(stream.NewStreamEntrieFromKv(stream.StreamEntrieId{Id: 1}, []types.Kv{{"f", "v"}}), stream.DefaultNodeLimits)
+	dbs.Get(0).Set("stream", storage.NewStreamValue(s))
+	var buf bytes.Buffer
+	WriteRdb(&buf, dbs)
+
+	// length of stream follows the only node
+	data := buf.Bytes()
+	lp := s.Dump().Nodes[0].Listpack
+	at := bytes.Index(data, lp) + len(lp)
+	data[at] = 2
+	err := LoadRdbFromReader(bufio.NewReader(bytes.NewReader(data)), storage.NewDatabases(16))
+	if err == nil {
+		t.Fatalf("expected stream with wrong length to be rejected")
+	}
+}
@@ -0,0 +1,214 @@
+package rdb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/config"
+	"github.com/codecrafters-io/redis-starter-go/app/storage"
+)
+
+var NoDbFileNameError = errors.New("Error saving rdb: dbfilename is not configured")
+
+// rdb version written to header
+const rdbVersion = "0011"
+
+// redis checksums rdb with crc64 jones, hash/crc64 can not be used as it inverts crc before and after update
+var crcTable = makeCrcTable(0x95ac9329ac4bc9b5)
+
+// builds table of reflected crc64 for polynomial given in reversed form
+func makeCrcTable(poly uint64) *[256]uint64 {
+	table := &[256]uint64{}
+	for i := range table {
+		crc := uint64(i)
+		for j := 0; j < 8; j++ {
+			if crc&1 == 1 {
+				crc = crc>>1 ^ poly
+			} else {
+				crc >>= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}
+
+func crc64Update(crc uint64, p []byte) uint64 {
+	for _, b := range p {
+		crc = crcTable[byte(crc)^b] ^ crc>>8
+	}
+	return crc
+}
+
+// writes storage snapshot to dir/dbfilename, snapshot is written to temp file first and renamed,
+// so failed save does not corrupt previous rdb
+func SaveRdbToFile(config *config.Config, dbs *storage.Databases) error {
+	name := config.GetServerDbFileName()
+	if name == "" {
+		return NoDbFileNameError
+	}
+	target := path.Join(config.GetServerDbDir(), name)
+	tmp := path.Join(config.GetServerDbDir(), fmt.Sprintf("temp-%v.rdb", os.Getpid()))
+	file, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("Error creating temp rdb file: %w", err)
+	}
+	w := bufio.NewWriter(file)
+	err = WriteRdb(w, dbs)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("Error writing rdb: %w", err)
+	}
+	err = os.Rename(tmp, target)
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("Error renaming temp rdb file: %w", err)
+	}
+	return nil
+}
+
+// writes snapshot of databases in rdb format, empty databases are skipped except of first one
+func WriteRdb(w io.Writer, dbs *storage.Databases) error {
+	e := &rdbWriter{w: w}
+	e.write([]byte("REDIS" + rdbVersion))
+	e.writeAux("redis-ver", "7.2.0")
+	e.writeAux("redis-bits", "64")
+	e.writeAux("ctime", strconv.FormatInt(time.Now().Unix(), 10))
+	for i := 0; i < dbs.Len(); i++ {
+		e.writeDb(i, dbs.Get(i))
+	}
+	e.write([]byte{EOF})
+	checksum := make([]byte, 8)
+	binary.LittleEndian.PutUint64(checksum, e.crc)
+	e.write(checksum)
+	return e.err
+}
+
+func (this *rdbWriter) writeDb(idx int, s storage.Storage) {
+	type entry struct {
+		key        string
+		value      storage.StorageValue
+		validUntil time.Time
+	}
+	entries := []entry{}
+	expires := 0
+	s.ForEach(func(key string, val storage.StorageValue, validUntil time.Time) {
+		if !validUntil.IsZero() {
+			expires++
+		}
+		entries = append(entries, entry{key: key, value: val, validUntil: validUntil})
+	})
+	if len(entries) == 0 && idx != 0 {
+		return
+	}
+
+	this.write([]byte{SELECTDB})
+	this.writeLength(idx)
+	this.write([]byte{RESIZEDB})
+	this.writeLength(len(entries))
+	this.writeLength(expires)
+	for _, entry := range entries {
+		if !entry.validUntil.IsZero() {
+			this.write([]byte{EXPIRETIMEMS})
+			ms := make([]byte, 8)
+			binary.LittleEndian.PutUint64(ms, uint64(entry.validUntil.UnixMilli()))
+			this.write(ms)
+		}
+		this.writeObject(entry.key, entry.value)
+	}
+}
+
+// writes value type, key and value, unknown type fails the save so keys are never dropped silently
+func (this *rdbWriter) writeObject(key string, val storage.StorageValue) {
+	switch val.GetType() {
+	case storage.String:
+		str, _ := val.ToString()
+		this.write([]byte{byte(StringEncoding)})
+		this.writeString(key)
+		this.writeString(str)
+	case storage.Int:
+		num, _ := val.ToInt()
+		this.write([]byte{byte(StringEncoding)})
+		this.writeString(key)
+		this.writeString(strconv.Itoa(num))
+	case storage.ZSet:
+		zset, _ := val.ToZSet()
+		this.write([]byte{byte(SortedSet2Encoding)})
+		this.writeString(key)
+		this.writeZSet(zset)
+	case storage.Stream:
+		s, _ := val.ToStream()
+		this.write([]byte{byte(StreamListpacks3Encoding)})
+		this.writeString(key)
+		this.writeStream(s)
+	default:
+		if this.err == nil {
+			this.err = fmt.Errorf("Error writing key %v: type %v is not supported by rdb", key, val.GetType())
+		}
+	}
+}
+
+// accumulates checksum of written bytes, first error stops further writes
+type rdbWriter struct {
+	w   io.Writer
+	crc uint64
+	err error
+}
+
+func (this *rdbWriter) write(p []byte) {
+	if this.err != nil {
+		return
+	}
+	this.crc = crc64Update(this.crc, p)
+	_, this.err = this.w.Write(p)
+}
+
+// length encoding: 00 prefix for 6 bit, 01 for 14 bit, 0x80 followed by 32 bit big endian length,
+// 0x81 followed by 64 bit one, negative n is written as 64 bit two's complement
+func (this *rdbWriter) writeLength(n int) {
+	u := uint64(n)
+	switch {
+	case u < 1<<6:
+		this.write([]byte{byte(u)})
+	case u < 1<<14:
+		this.write([]byte{byte(u>>8) | 0x40, byte(u)})
+	case u < 1<<32:
+		buf := make([]byte, 5)
+		buf[0] = 0x80
+		binary.BigEndian.PutUint32(buf[1:], uint32(u))
+		this.write(buf)
+	default:
+		buf := make([]byte, 9)
+		buf[0] = 0x81
+		binary.BigEndian.PutUint64(buf[1:], u)
+		this.write(buf)
+	}
+}
+
+func (this *rdbWriter) writeString(str string) {
+	this.writeLength(len(str))
+	this.write([]byte(str))
+}
+
+func (this *rdbWriter) writeAux(key string, value string) {
+	this.write([]byte{AUX})
+	this.writeString(key)
+	this.writeString(value)
+}
@@ -0,0 +1,39 @@
+package reader
+
+import "errors"
+
+var InvalidMultibulkLengthError = errors.New("ERR Protocol error: invalid multibulk length")
+
+var InvalidBulkLengthError = errors.New("ERR Protocol error: invalid bulk length")
+
+var TooBigMultibulkCountError = errors.New("ERR Protocol error: too big mbulk count string")
+
+var TooBigBulkCountError = errors.New("ERR Protocol error: too big bulk count string")
+
+var TooBigInlineRequestError = errors.New("ERR Protocol error: too big inline request")
+
+var ExpectedCRLFError = errors.New("ERR Protocol error: expected CRLF after bulk string")
+
+var UnexpectedDataTypeError = errors.New("ERR Protocol error: unexpected data type")
+
+var QueryBufferLimitError = errors.New("ERR Protocol error: client reached max query buffer length")
+
+var protocolErrors = []error{
+	InvalidMultibulkLengthError,
+	InvalidBulkLengthError,
+	TooBigMultibulkCountError,
+	TooBigBulkCountError,
+	TooBigInlineRequestError,
+	ExpectedCRLFError,
+	UnexpectedDataTypeError,
+}
+
+// reports is error caused by malformed client input, in this case client should recieve an error reply and connection has to be closed
+func IsProtocolError(err error) bool {
+	for _, e := range protocolErrors {
+		if errors.Is(err, e) {
+			return true
+		}
+	}
+	return false
+}
@@ -3,32 +3,80 @@ package reader
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"strconv"
+
 	"github.com/codecrafters-io/redis-starter-go/app/data_types"
 	"github.com/codecrafters-io/redis-starter-go/app/logger"
-	"strconv"
 )
 
+// max length of line that holds type prefix with length or simple string/error value
+const MaxInlineSize = 64 * 1024
+
+// max length of length header, enough to hold any int64 with sign
+const maxLenHeaderSize = 32
+
+// protocol safety limits, zero value of any limit means that limit is disabled
+type Limits struct {
+	// max length of single bulk string
+	MaxBulkLen int64
+	// max amount of elements in single array
+	MaxMultibulkLen int64
+	// max amount of bytes that single top level command can occupy
+	QueryBufferLimit int64
+}
+
 type Reader struct {
-	rd *bufio.Reader
+	rd     *bufio.Reader
+	limits Limits
+	// bytes consumed by currently parsed top level data
+	consumed int64
 }
 
 // creates new reader
 func New(rd *bufio.Reader) Reader {
-	return Reader{rd}
+	return Reader{rd: rd}
+}
+
+// creates new reader that enforces protocol limits, used for client connections
+func NewWithLimits(rd *bufio.Reader, limits Limits) Reader {
+	return Reader{rd: rd, limits: limits}
 }
 
 // reads and parse commands from data stream
+// amount of bytes readed from source but not parsed yet
+func (this *Reader) Buffered() int {
+	return this.rd.Buffered()
+}
+
+// copy of bytes readed from source but not parsed yet
+func (this *Reader) BufferedBytes() []byte {
+	buffered, _ := this.rd.Peek(this.rd.Buffered())
+	return append([]byte{}, buffered...)
+}
+
 func (this *Reader) ParseDataType() (data *datatypes.Data, err error) {
+	this.consumed = 0
+	return this.parseDataType()
+}
+
+func (this *Reader) parseDataType() (data *datatypes.Data, err error) {
 	initType, err := this.rd.ReadByte()
 	if err != nil {
 		return nil, err
 	}
+	err = this.consume(1)
+	if err != nil {
+		return nil, err
+	}
 
 	dataType, ok := datatypes.GetDataTypeAcordType(initType)
 
 	if !ok {
-		return nil, fmt.Errorf("Unexpected commands dataType given: %v", string(initType))
+		return nil, fmt.Errorf("%w: %v", UnexpectedDataTypeError, strconv.Quote(string(initType)))
 	}
 	data, raw, err := this.ReadStreamAcordDataType(dataType)
 	if err != nil {
@@ -58,8 +106,48 @@ func (this *Reader) ReadStreamAcordDataType(dataType datatypes.DataTypeEnum) (da
 	return
 }
 
+// accounts n readed bytes against query buffer limit
+func (this *Reader) consume(n int64) error {
+	this.consumed += n
+	if this.limits.QueryBufferLimit > 0 && this.consumed > this.limits.QueryBufferLimit {
+		return QueryBufferLimitError
+	}
+	return nil
+}
+
+// reads line terminated by CRLF, without terminator, line longer than maxLen produce tooBig error
+func (this *Reader) readLine(maxLen int, tooBig error) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := this.rd.ReadSlice('\n')
+		if len(line)+len(chunk) > maxLen+2 {
+			return nil, tooBig
+		}
+		line = append(line, chunk...)
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			if err == io.EOF && len(line) > 0 {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		break
+	}
+	err := this.consume(int64(len(line)))
+	if err != nil {
+		return nil, err
+	}
+	n := len(line)
+	if n < 2 || line[n-2] != '\r' {
+		return line[:n-1], nil
+	}
+	return line[:n-2], nil
+}
+
 func (this *Reader) ReadSimpleString() (data *datatypes.Data, raw []byte, err error) {
-	commandRaw, _, err := this.rd.ReadLine()
+	commandRaw, err := this.readLine(MaxInlineSize, TooBigInlineRequestError)
 	if err != nil {
 
 		return nil, nil, err
@@ -73,6 +161,9 @@ func (this *Reader) ReadSimpleString() (data *datatypes.Data, raw []byte, err er
 
 func (this *Reader) ReadRdb() error {
 	initType, err := this.rd.ReadByte()
+	if err != nil {
+		return err
+	}
 	dataType, _ := datatypes.GetDataTypeAcordType(initType)
 	if dataType != datatypes.BULK_STRING {
 		return fmt.Errorf("Wrong datatype: %v", string(initType))
@@ -81,19 +172,21 @@ func (this *Reader) ReadRdb() error {
 	if err != nil {
 		return err
 	}
-	readed := make([]byte, stringLen)
-	n, err := this.rd.Read(readed)
+	if stringLen < 0 {
+		return InvalidBulkLengthError
+	}
+	n, err := io.CopyN(io.Discard, this.rd, int64(stringLen))
 	if err != nil {
 		return err
 	}
-	if n != stringLen {
+	if n != int64(stringLen) {
 		return fmt.Errorf("Read less amount of bytes from rdb transfer")
 	}
 	return nil
 }
 
 func (this *Reader) ReadSimpleError() (data *datatypes.Data, raw []byte, err error) {
-	commandRaw, _, err := this.rd.ReadLine()
+	commandRaw, err := this.readLine(MaxInlineSize, TooBigInlineRequestError)
 
 	if err != nil {
 		return nil, nil, err
@@ -106,47 +199,73 @@ func (this *Reader) ReadSimpleError() (data *datatypes.Data, raw []byte, err err
 }
 
 func (this *Reader) ReadLen() (len int, raw []byte, err error) {
-	lenRaw, _, err := this.rd.ReadLine()
+	lenRaw, err := this.readLine(maxLenHeaderSize, TooBigBulkCountError)
 
 	if err != nil {
-		logger.Logger.Error("Error reading line:", logger.String("error", err.Error()))
+		// partial data is expected while request is still arriving
+		if err != io.EOF && err != io.ErrUnexpectedEOF {
+			logger.Logger.Error("Error reading line:", logger.String("error", err.Error()))
+		}
 		return 0, nil, err
 	}
 
-	readedLen, err := strconv.Atoi(string(lenRaw))
+	readedLen, err := strconv.ParseInt(string(lenRaw), 10, 64)
 
 	if err != nil {
 		return 0, nil, err
 	}
 
-	return readedLen, lenRaw, nil
+	return int(readedLen), lenRaw, nil
 }
 
 func (this *Reader) ReadString() (data *datatypes.Data, raw []byte, err error) {
 	stringLen, rawLen, err := this.ReadLen()
 
 	if err != nil {
+		if errors.Is(err, strconv.ErrSyntax) || errors.Is(err, strconv.ErrRange) {
+			return nil, nil, InvalidBulkLengthError
+		}
 		return nil, nil, err
 	}
+	rawRet := []byte{}
+	rawRet = append(rawRet, rawLen...)
+	rawRet = append(rawRet, datatypes.CLFR...)
 
-	stringRaw, _, err := this.rd.ReadLine()
+	if stringLen == -1 {
+		return &datatypes.Data{
+			Type: datatypes.NULL,
+		}, rawRet, nil
+	}
+	if stringLen < 0 || (this.limits.MaxBulkLen > 0 && int64(stringLen) > this.limits.MaxBulkLen) {
+		return nil, nil, InvalidBulkLengthError
+	}
+	err = this.consume(int64(stringLen) + 2)
+	if err != nil {
+		return nil, nil, err
+	}
 
+	// buffer grows while bytes are actually arriving, so lying length header does not allocate memory upfront
+	var stringRaw bytes.Buffer
+	_, err = io.CopyN(&stringRaw, this.rd, int64(stringLen)+2)
 	if err != nil {
+		if err == io.EOF {
+			return nil, nil, io.ErrUnexpectedEOF
+		}
 		return nil, nil, err
 	}
-	rawRet := []byte{}
-	rawRet = append(rawRet, rawLen...)
-	rawRet = append(rawRet, datatypes.CLFR...)
-	rawRet = append(rawRet, stringRaw...)
-	rawRet = append(rawRet, datatypes.CLFR...)
+	readed := stringRaw.Bytes()
+	if !bytes.HasSuffix(readed, []byte(datatypes.CLFR)) {
+		return nil, nil, ExpectedCRLFError
+	}
+	rawRet = append(rawRet, readed...)
 	return &datatypes.Data{
 		Type:  datatypes.BULK_STRING,
-		Value: string(stringRaw[:stringLen]),
+		Value: string(readed[:stringLen]),
 	}, rawRet, nil
 }
 
 func (this *Reader) ReadInt() (data *datatypes.Data, raw []byte, err error) {
-	intRaw, _, err := this.rd.ReadLine()
+	intRaw, err := this.readLine(maxLenHeaderSize, TooBigInlineRequestError)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -163,18 +282,33 @@ func (this *Reader) ReadArray() (data *datatypes.Data, raw []byte, err error) {
 	arrayLen, rawLen, err := this.ReadLen()
 
 	if err != nil {
+		if errors.Is(err, strconv.ErrSyntax) || errors.Is(err, strconv.ErrRange) {
+			return nil, nil, InvalidMultibulkLengthError
+		}
+		if err == TooBigBulkCountError {
+			return nil, nil, TooBigMultibulkCountError
+		}
 		return nil, nil, err
 	}
+	rawRet := []byte{}
+	rawRet = append(rawRet, rawLen...)
+	rawRet = append(rawRet, datatypes.CLFR...)
+
+	if arrayLen == -1 {
+		return &datatypes.Data{
+			Type: datatypes.NULL,
+		}, rawRet, nil
+	}
+	if arrayLen < 0 || (this.limits.MaxMultibulkLen > 0 && int64(arrayLen) > this.limits.MaxMultibulkLen) {
+		return nil, nil, InvalidMultibulkLengthError
+	}
 
 	data = &datatypes.Data{
 		Type:   datatypes.ARRAY,
 		Values: []*datatypes.Data{},
 	}
-	rawRet := []byte{}
-	rawRet = append(rawRet, rawLen...)
-	rawRet = append(rawRet, datatypes.CLFR...)
 	for i := 0; i < arrayLen; i++ {
-		nxt, err := this.ParseDataType()
+		nxt, err := this.parseDataType()
 		if err != nil {
 			return nil, nil, err
 		}
@@ -0,0 +1,95 @@
+package reader
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
+)
+
+func newTestReader(input string, limits Limits) Reader {
+	return NewWithLimits(bufio.NewReader(strings.NewReader(input)), limits)
+}
+
+func TestReader_ParseCommand(t *testing.T) {
+	rd := newTestReader("*2\r\n$3\r\nGET\r\n$5\r\nk\r\ney\r\n", Limits{})
+
+	data, err := rd.ParseDataType()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(data.Values) != 2 || data.Values[1].Value != "k\r\ney" {
+		t.Fatalf("expected binary safe bulk string, got %v", data)
+	}
+}
+
+func TestReader_NullValues(t *testing.T) {
+	rd := newTestReader("*-1\r\n$-1\r\n", Limits{})
+
+	for _, raw := range []string{"*-1\r\n", "$-1\r\n"} {
+		data, err := rd.ParseDataType()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if data.Type != datatypes.NULL {
+			t.Fatalf("expected null, got %v", string(data.Type))
+		}
+		if string(data.Marshall()) != raw {
+			t.Fatalf("expected %q, got %q", raw, data.Marshall())
+		}
+	}
+}
+
+func TestReader_InvalidLengths(t *testing.T) {
+	cases := map[string]error{
+		"*-2\r\n":                   InvalidMultibulkLengthError,
+		"*abc\r\n":                  InvalidMultibulkLengthError,
+		"*3\r\n":                    nil,
+		"$-5\r\n":                   InvalidBulkLengthError,
+		"$3\r\nabcd\r\n":            ExpectedCRLFError,
+		"*1\r\n$99999999999999\r\n": InvalidBulkLengthError,
+	}
+	limits := Limits{MaxBulkLen: 512 * 1024 * 1024, MaxMultibulkLen: 1024}
+
+	for input, expected := range cases {
+		rd := newTestReader(input, limits)
+		_, err := rd.ParseDataType()
+		if expected == nil {
+			if err == nil || IsProtocolError(err) {
+				t.Fatalf("%q: expected io error, got %v", input, err)
+			}
+			continue
+		}
+		if err != expected {
+			t.Fatalf("%q: expected %v, got %v", input, expected, err)
+		}
+	}
+}
+
+func TestReader_Limits(t *testing.T) {
+	rd := newTestReader("*2147483647\r\n", Limits{MaxMultibulkLen: 1024})
+	_, err := rd.ParseDataType()
+	if err != InvalidMultibulkLengthError {
+		t.Fatalf("expected %v, got %v", InvalidMultibulkLengthError, err)
+	}
+
+	rd = newTestReader("$2048\r\n", Limits{MaxBulkLen: 1024})
+	_, err = rd.ParseDataType()
+	if err != InvalidBulkLengthError {
+		t.Fatalf("expected %v, got %v", InvalidBulkLengthError, err)
+	}
+
+	rd = newTestReader("*2\r\n$10\r\n0123456789\r\n$10\r\n0123456789\r\n", Limits{QueryBufferLimit: 32})
+	_, err = rd.ParseDataType()
+	if err != QueryBufferLimitError {
+		t.Fatalf("expected %v, got %v", QueryBufferLimitError, err)
+	}
+
+	rd = newTestReader("*1\r\n$"+strings.Repeat("1", 100)+"\r\n", Limits{})
+	_, err = rd.ParseDataType()
+	if err != TooBigBulkCountError {
+		t.Fatalf("expected %v, got %v", TooBigBulkCountError, err)
+	}
+}
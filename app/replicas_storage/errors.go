@@ -0,0 +1,5 @@
+package replicas_storage
+
+import "errors"
+
+var ReplicationRequiresTlsError = errors.New("ERR replication link requires TLS, tls-replication is enabled")
@@ -2,9 +2,12 @@ package replicas_storage
 
 import (
 	"bufio"
+	"bytes"
+	"crypto/tls"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"sync"
 	"time"
@@ -33,13 +36,22 @@ func New(config *config.Config) *ReplStorage {
 	}
 }
 
-func (this *ReplStorage) ProcessReplicaSync(con net.Conn, replConf *command.Command) {
-	tmpReplica := newRepl(con)
+// performs replica handshake and starts reading acks, onClose is called once replica connection is closed
+func (this *ReplStorage) ProcessReplicaSync(con net.Conn, buffered []byte, replConf *command.Command, onClose func()) {
+	tmpReplica := newRepl(con, buffered)
 
-	err := this.ProcessReplConfPort(tmpReplica, replConf)
+	err := this.checkLinkSecurity(con)
 	if err != nil {
 		con.Write(encoder.EncodeSimpleError(err.Error()))
 		con.Close()
+		onClose()
+		return
+	}
+	err = this.ProcessReplConfPort(tmpReplica, replConf)
+	if err != nil {
+		con.Write(encoder.EncodeSimpleError(err.Error()))
+		con.Close()
+		onClose()
 		return
 	}
 	ok := command.ConstructSimpleOk()
@@ -48,6 +60,7 @@ func (this *ReplStorage) ProcessReplicaSync(con net.Conn, replConf *command.Comm
 	if err != nil {
 		con.Write(encoder.EncodeSimpleError(err.Error()))
 		con.Close()
+		onClose()
 		return
 	}
 
@@ -56,19 +69,37 @@ func (this *ReplStorage) ProcessReplicaSync(con net.Conn, replConf *command.Comm
 	if err != nil {
 		con.Write(encoder.EncodeSimpleError(err.Error()))
 		con.Close()
+		onClose()
 		return
 	}
 	masterReplId, err := this.config.GetReplId()
 	if err != nil {
 		con.Write(encoder.EncodeSimpleError(err.Error()))
 		con.Close()
+		onClose()
 		return
 	}
 	con.Write(command.ConstructFullResync(masterReplId, 0).Marshall())
 	con.Write(encoder.EncodeRDB())
 
 	this.AddReplica(tmpReplica)
-	go tmpReplica.StartReadingRoutine()
+	go func() {
+		tmpReplica.StartReadingRoutine()
+		con.Close()
+		onClose()
+	}()
+}
+
+// with tls-replication enabled replication stream is not sent over plain tcp
+func (this *ReplStorage) checkLinkSecurity(con net.Conn) error {
+	if !this.config.GetTlsReplication() {
+		return nil
+	}
+	switch con.(type) {
+	case *tls.Conn, *net.UnixConn:
+		return nil
+	}
+	return ReplicationRequiresTlsError
 }
 
 func (this *ReplStorage) ProcessReplConfPort(repl *Repl, replConf *command.Command) error {
@@ -95,21 +126,26 @@ func (this *ReplStorage) ProcessPsync(repl *Repl) error {
 	return err
 }
 
-func (this *ReplStorage) PropagateCmd(cmd *command.Command) {
+// propagates write command executed in database db, replica is switched to db by SELECT if needed
+func (this *ReplStorage) PropagateCmd(db int, cmd *command.Command) {
 	if this.config.GetRole() == config.SLAVE {
 		return
 	}
-	if !cmd.IsWriteCommand() {
+	cmds := cmd.Propagated()
+	if len(cmds) == 0 {
 		return
 	}
 
 	for i, repl := range this.repls {
 		logger.Logger.Debug("Propagate command to nth's replice", logger.Int("replica number", i), logger.String("command", string(cmd.Type)))
-		repl.PropagateCmd(cmd)
+		for _, propagated := range cmds {
+			repl.PropagateCmd(db, propagated)
+		}
 	}
 }
 
-func (this *ReplStorage) ProcessCmd(cmd *command.Command) (*datatypes.Data, error) {
+// executes WAIT, it returns early with replicas acknowledged so far when interrupt is closed
+func (this *ReplStorage) ProcessBlockingCmd(cmd *command.Command, interrupt <-chan struct{}) (*datatypes.Data, error) {
 	if cmd.Type != command.WAIT {
 		return nil, errors.New("unknown command to process by replstorage")
 	}
@@ -145,6 +181,10 @@ outer:
 			{
 				break outer
 			}
+		case <-interrupt:
+			{
+				break outer
+			}
 		}
 	}
 	cancel()
@@ -152,6 +192,34 @@ outer:
 	return datatypes.ConstructInt(processedAmount), nil
 }
 
+// sends GETACK to every replica and waits until they acknowledge whole propagated stream, returns early
+// when timeout expires or interrupt is closed, returns amount of replicas in sync and total amount of replicas
+func (this *ReplStorage) AwaitReplicasInSync(timeout time.Duration, interrupt <-chan struct{}) (int, int) {
+	repls := this.repls
+	if len(repls) == 0 {
+		return 0, 0
+	}
+	counter := make(chan bool, len(repls))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for i, repl := range repls {
+		go repl.LockReplicaProcessGetAck(i, counter, ctx)
+	}
+	deadline := time.After(timeout)
+	synced := 0
+	for synced < len(repls) {
+		select {
+		case <-counter:
+			synced++
+		case <-deadline:
+			return synced, len(repls)
+		case <-interrupt:
+			return synced, len(repls)
+		}
+	}
+	return synced, len(repls)
+}
+
 func (this *ReplStorage) AddReplica(repl *Repl) error {
 	this.repls = append(this.repls, repl)
 	return nil
@@ -166,20 +234,34 @@ type Repl struct {
 	shouldProcessBytes int
 	procesedBytes      int
 	firstReplConf      bool
+	// database selected in replication stream, -1 until first SELECT is sent
+	db int
 }
 
-func newRepl(con net.Conn) *Repl {
+// buffered holds bytes readed from con by client connection after handshake command, they are parsed first
+func newRepl(con net.Conn, buffered []byte) *Repl {
 	return &Repl{
 		con:           con,
-		reader:        reader.New(bufio.NewReader(con)),
+		reader:        reader.New(bufio.NewReader(io.MultiReader(bytes.NewReader(buffered), con))),
 		readChan:      make(chan int),
 		firstReplConf: true,
+		db:            -1,
 	}
 }
 
-func (this *Repl) PropagateCmd(cmd *command.Command) error {
+func (this *Repl) PropagateCmd(db int, cmd *command.Command) error {
 	this.mu.Lock()
 	defer this.mu.Unlock()
+	if this.db != db {
+		selectCmd := command.ConstructSelect(db)
+		_, err := this.con.Write(selectCmd.Marshall())
+		if err != nil {
+			logger.Logger.Error("error propagate to replica", logger.String("error", err.Error()), logger.String("replica", this.String()))
+			return err
+		}
+		this.shouldProcessBytes += selectCmd.Raw.Len()
+		this.db = db
+	}
 	_, err := this.con.Write(cmd.Marshall())
 	if err != nil {
 		logger.Logger.Error("error propagate to replica", logger.String("error", err.Error()), logger.String("replica", this.String()))
@@ -1,31 +1,45 @@
 package main
 
 import (
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
 	"runtime"
+	"time"
 
 	"net"
 
+	"github.com/codecrafters-io/redis-starter-go/app/acl"
+	"github.com/codecrafters-io/redis-starter-go/app/client"
 	"github.com/codecrafters-io/redis-starter-go/app/config"
 	"github.com/codecrafters-io/redis-starter-go/app/conn_processor"
+	eventloop "github.com/codecrafters-io/redis-starter-go/app/event_loop"
 	"github.com/codecrafters-io/redis-starter-go/app/executor"
 	"github.com/codecrafters-io/redis-starter-go/app/handshake"
 	"github.com/codecrafters-io/redis-starter-go/app/logger"
+	"github.com/codecrafters-io/redis-starter-go/app/notify"
 	"github.com/codecrafters-io/redis-starter-go/app/offset_counter"
+	"github.com/codecrafters-io/redis-starter-go/app/pubsub"
 	"github.com/codecrafters-io/redis-starter-go/app/rdb"
 	"github.com/codecrafters-io/redis-starter-go/app/replicas_storage"
+	"github.com/codecrafters-io/redis-starter-go/app/shutdown"
+	"github.com/codecrafters-io/redis-starter-go/app/slots"
 	"github.com/codecrafters-io/redis-starter-go/app/storage"
+	tlscontext "github.com/codecrafters-io/redis-starter-go/app/tls_context"
 )
 
 type Server struct {
-	storage          storage.Storage
+	dbs              *storage.Databases
 	executor         executor.CommandExecutor
 	config           *config.Config
 	replicas_storage *replicas_storage.ReplStorage
-	processor        conn_processor.ConnProcessor
+	processor        *conn_processor.MasterConnProcessor
+	tlsContext       *tlscontext.TlsContext
+	clients          *client.Table
+	shutdown         *shutdown.Shutdown
 }
 
 func main() {
@@ -34,6 +48,7 @@ func main() {
 		fmt.Println(http.ListenAndServe("localhost:8080", nil))
 	}()
 	server := NewServer()
+	server.shutdown.HandleSignals()
 	err := server.SendHandShake()
 	if err != nil {
 		logger.Logger.Fatal("server handshake error:", logger.String("error", err.Error()))
@@ -47,7 +62,6 @@ func main() {
 
 func NewServer() *Server {
 
-	storage := storage.New()
 	config, err := config.New()
 	counter := offset_counter.New()
 	repl_storage := replicas_storage.New(config)
@@ -55,20 +69,48 @@ func NewServer() *Server {
 		logger.Logger.Fatal("server configure error:", logger.String("error", err.Error()))
 		os.Exit(1)
 	}
-	executor := executor.New(counter, repl_storage, storage, config)
-	processor := conn_processor.NewMasterProcessor(repl_storage, executor)
+	dbs := storage.NewDatabases(config.GetDatabases())
+	tlsContext, err := tlscontext.New(config)
+	if err != nil {
+		logger.Logger.Fatal("server tls configure error:", logger.String("error", err.Error()))
+		os.Exit(1)
+	}
+	config.OnApply(tlscontext.ReloadParams, tlsContext.Reload)
+	accessList, err := acl.New(config)
+	if err != nil {
+		logger.Logger.Fatal("server acl configure error:", logger.String("error", err.Error()))
+		os.Exit(1)
+	}
+	config.OnApply(acl.RequirePassParams, accessList.ApplyRequirePass)
+	clients := client.NewTable()
+	shutdown := shutdown.New(config, dbs, repl_storage, clients)
+	err = shutdown.WritePidFile()
+	if err != nil {
+		logger.Logger.Warn("failed to write pidfile", logger.String("error", err.Error()))
+	}
+	pubsub := pubsub.New()
+	slots := slots.NewTable()
+	slots.OnRelease(pubsub.UnsubscribeSlots)
+	notifier := notify.New(pubsub, config.GetNotifyKeyspaceEvents)
+	executor := executor.New(counter, repl_storage, dbs, config, accessList, clients, shutdown, pubsub, slots, notifier)
+	processor := conn_processor.NewMasterProcessor(repl_storage, executor, accessList, clients, config, pubsub)
 
-	err = rdb.LoadRdbFromFile(config, storage)
+	err = rdb.LoadRdbFromFile(config, dbs)
 	if err != nil {
 		logger.Logger.Error("load rdb error", logger.String("error", err.Error()))
 	}
+	// keys loaded from rdb file are not reported as new
+	dbs.SetNotifier(notifier)
 
 	server := Server{
-		storage:          storage,
+		dbs:              dbs,
 		executor:         executor,
 		config:           config,
 		replicas_storage: repl_storage,
 		processor:        processor,
+		tlsContext:       tlsContext,
+		clients:          clients,
+		shutdown:         shutdown,
 	}
 	return &server
 }
@@ -77,8 +119,8 @@ func (this *Server) SendHandShake() error {
 	if this.GetConfig().GetRole() != config.SLAVE {
 		return nil
 	}
-	conn, reader, err := handshake.SendHandshake(this.config)
-	replicaProcessor := conn_processor.NewReplicaProcessor(this.executor, reader)
+	conn, reader, err := handshake.SendHandshake(this.config, this.tlsContext)
+	replicaProcessor := conn_processor.NewReplicaProcessor(this.executor, reader, this.clients)
 	if err != nil {
 		return err
 	}
@@ -87,20 +129,176 @@ func (this *Server) SendHandShake() error {
 }
 
 func (this *Server) Listen() error {
-	port := this.GetConfig().GetServerPort()
-	l, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%v", port))
+	listeners, err := this.openListeners()
 	if err != nil {
-		logger.Logger.Error("Failed to bind to port", logger.Int("port", int(port)), logger.String("Error", err.Error()))
 		return err
 	}
+	defer func() {
+		for _, l := range listeners {
+			l.Close()
+		}
+	}()
+	this.shutdown.AddListeners(listeners)
 
-	logger.Logger.Info("server listen on port", logger.Int("port", int(port)))
+	go this.closeIdleClients()
+	go this.activeExpire()
 
-	defer l.Close()
+	serve := this.serveGoroutinePerConn
+	if this.GetConfig().GetIoModel() == config.EpollIoModel {
+		loop, err := eventloop.New(this.processor, this.GetConfig().GetIoThreads())
+		if err != nil {
+			logger.Logger.Error("Failed to start event loop", logger.String("error", err.Error()))
+			return err
+		}
+		defer loop.Close()
+		logger.Logger.Info("serving connections with event loop", logger.Int("io threads", this.GetConfig().GetIoThreads()))
+		serve = loop.Serve
+	}
 
+	errs := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func(l net.Listener) {
+			// event loop works with raw sockets, so tls connections are always served by goroutines
+			if _, isTls := l.(*tlsListener); isTls {
+				errs <- this.serveGoroutinePerConn(l)
+				return
+			}
+			errs <- serve(l)
+		}(l)
+	}
+	err = <-errs
+	if this.shutdown.IsExiting() {
+		// listeners are closed by shutdown, it exits process once it's done
+		select {}
+	}
+	return err
+}
+
+type tlsListener struct {
+	net.Listener
+}
+
+// applies tcp-keepalive to accepted connections, value is read on every accept so CONFIG SET applies to new clients
+type keepAliveListener struct {
+	net.Listener
+	config *config.Config
+}
+
+func (this *keepAliveListener) Accept() (net.Conn, error) {
+	conn, err := this.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	tcpConn, ok := conn.(*net.TCPConn)
+	if !ok {
+		return conn, nil
+	}
+	keepAlive := this.config.GetTcpKeepAlive()
+	if keepAlive == 0 {
+		tcpConn.SetKeepAlive(false)
+		return conn, nil
+	}
+	tcpConn.SetKeepAlive(true)
+	tcpConn.SetKeepAlivePeriod(time.Duration(keepAlive) * time.Second)
+	return conn, nil
+}
+
+// binds tcp port, tls port and unix socket, tcp listeners are skipped if their port is 0
+func (this *Server) openListeners() ([]net.Listener, error) {
+	listeners := []net.Listener{}
+	port := this.GetConfig().GetServerPort()
+	if port != 0 {
+		l, err := this.listenTcp(port)
+		if err != nil {
+			logger.Logger.Error("Failed to bind to port", logger.Int("port", int(port)), logger.String("Error", err.Error()))
+			return nil, err
+		}
+		logger.Logger.Info("server listen on port", logger.Int("port", int(port)))
+		listeners = append(listeners, l)
+	}
+	tlsPort := this.GetConfig().GetTlsPort()
+	if tlsPort != 0 {
+		l, err := this.listenTcp(tlsPort)
+		if err != nil {
+			logger.Logger.Error("Failed to bind to tls port", logger.Int("port", int(tlsPort)), logger.String("Error", err.Error()))
+			for _, opened := range listeners {
+				opened.Close()
+			}
+			return nil, err
+		}
+		logger.Logger.Info("server listen on tls port", logger.Int("port", int(tlsPort)))
+		listeners = append(listeners, &tlsListener{tls.NewListener(l, this.tlsContext.ServerConfig())})
+	}
+	socketPath := this.GetConfig().GetUnixSocket()
+	if socketPath != "" {
+		l, err := listenUnix(socketPath, this.GetConfig().GetUnixSocketPerm(), this.GetConfig().GetTcpBacklog())
+		if err != nil {
+			logger.Logger.Error("Failed to bind unix socket", logger.String("path", socketPath), logger.String("Error", err.Error()))
+			for _, opened := range listeners {
+				opened.Close()
+			}
+			return nil, err
+		}
+		logger.Logger.Info("server listen on unix socket", logger.String("path", socketPath))
+		listeners = append(listeners, l)
+	}
+	if len(listeners) == 0 {
+		return nil, errors.New("Error: neither tcp port, tls port nor unix socket is configured")
+	}
+	return listeners, nil
+}
+
+func (this *Server) listenTcp(port uint16) (net.Listener, error) {
+	l, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%v", port))
+	if err != nil {
+		return nil, err
+	}
+	err = setBacklog(l, this.GetConfig().GetTcpBacklog())
+	if err != nil {
+		l.Close()
+		return nil, fmt.Errorf("Error applying tcp-backlog: %w", err)
+	}
+	return &keepAliveListener{Listener: l, config: this.GetConfig()}, nil
+}
+
+// binds unix socket, socket file left by previous run is removed, file is removed again when listener is closed
+func listenUnix(socketPath string, perm os.FileMode, backlog int) (net.Listener, error) {
+	info, err := os.Lstat(socketPath)
+	if err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("Error: %v exists and is not a socket", socketPath)
+		}
+		err = os.Remove(socketPath)
+		if err != nil {
+			return nil, fmt.Errorf("Error removing stale unix socket: %w", err)
+		}
+	}
+	l, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, err
+	}
+	if perm != 0 {
+		err = os.Chmod(socketPath, perm)
+		if err != nil {
+			l.Close()
+			return nil, fmt.Errorf("Error applying unixsocketperm: %w", err)
+		}
+	}
+	err = setBacklog(l, backlog)
+	if err != nil {
+		l.Close()
+		return nil, fmt.Errorf("Error applying tcp-backlog: %w", err)
+	}
+	return l, nil
+}
+
+func (this *Server) serveGoroutinePerConn(l net.Listener) error {
 	for {
 		conn, err := l.Accept()
 		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return err
+			}
 			logger.Logger.Error("Failed to accept connection", logger.String("error", err.Error()))
 			continue
 		}
@@ -108,6 +306,34 @@ func (this *Server) Listen() error {
 	}
 }
 
+// closes clients idle longer than timeout, timeout is read on every tick so CONFIG SET applies at runtime
+func (this *Server) closeIdleClients() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		timeout := this.GetConfig().GetTimeout()
+		if timeout == 0 {
+			continue
+		}
+		closed := this.clients.CloseIdle(time.Duration(timeout) * time.Second)
+		if closed > 0 {
+			logger.Logger.Info("closed idle clients", logger.Int("amount", closed))
+		}
+	}
+}
+
+// deletes expired keys nobody reads 10 times per second, every cycle takes at most quarter of period
+func (this *Server) activeExpire() {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for range ticker.C {
+		expired := this.dbs.ActiveExpireCycle(25 * time.Millisecond)
+		if expired > 0 {
+			logger.Logger.Debug("expired keys", logger.Int("amount", expired))
+		}
+	}
+}
+
 func (this *Server) GetConfig() *config.Config {
 	return this.config
 }
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/acl"
+	"github.com/codecrafters-io/redis-starter-go/app/client"
+	"github.com/codecrafters-io/redis-starter-go/app/config"
+	"github.com/codecrafters-io/redis-starter-go/app/conn_processor"
+	"github.com/codecrafters-io/redis-starter-go/app/executor"
+	"github.com/codecrafters-io/redis-starter-go/app/logger"
+	"github.com/codecrafters-io/redis-starter-go/app/offset_counter"
+	"github.com/codecrafters-io/redis-starter-go/app/pubsub"
+	"github.com/codecrafters-io/redis-starter-go/app/replicas_storage"
+	"github.com/codecrafters-io/redis-starter-go/app/slots"
+	"github.com/codecrafters-io/redis-starter-go/app/storage"
+	"go.uber.org/zap"
+)
+
+var (
+	testConfig     *config.Config
+	testConfigErr  error
+	testConfigOnce sync.Once
+)
+
+// config registers command line flags, so it is created once, the rest of server state is fresh for every server
+func newTestServer(t *testing.T) *Server {
+	testConfigOnce.Do(func() {
+		logger.Logger = zap.NewNop()
+		testConfig, testConfigErr = config.New()
+	})
+	if testConfigErr != nil {
+		t.Fatalf("expected no error, got %v", testConfigErr)
+	}
+	cfg := testConfig
+	accessList, err := acl.New(cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	replStorage := replicas_storage.New(cfg)
+	clients := client.NewTable()
+	ps := pubsub.New()
+	dbs := storage.NewDatabases(cfg.GetDatabases())
+	exec := executor.New(offset_counter.New(), replStorage, dbs, cfg, accessList, clients, nil, ps, slots.NewTable(), nil)
+	return &Server{
+		dbs:              dbs,
+		executor:         exec,
+		config:           cfg,
+		replicas_storage: replStorage,
+		processor:        conn_processor.NewMasterProcessor(replStorage, exec, accessList, clients, cfg, ps),
+		clients:          clients,
+	}
+}
+
+// leaves socket file behind like crashed server does
+func createStaleSocket(t *testing.T, socketPath string) {
+	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: socketPath, Net: "unix"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	l.SetUnlinkOnClose(false)
+	l.Close()
+}
+
+func TestListenUnix(t *testing.T) {
+	server := newTestServer(t)
+	socketPath := filepath.Join(t.TempDir(), "redis.sock")
+	createStaleSocket(t, socketPath)
+
+	l, err := listenUnix(socketPath, 0700, server.GetConfig().GetTcpBacklog())
+	if err != nil {
+		t.Fatalf("expected stale socket to be replaced, got %v", err)
+	}
+	go server.serveGoroutinePerConn(l)
+	info, err := os.Stat(socketPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if info.Mode()&os.ModeSocket == 0 || info.Mode().Perm() != 0700 {
+		t.Errorf("expected socket with mode 0700, got %v", info.Mode())
+	}
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	_, err = conn.Write([]byte("*2\r\n$6\r\nCLIENT\r\n$4\r\nLIST\r\n"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	rd := bufio.NewReader(conn)
+	header, err := rd.ReadString('\n')
+	if err != nil || !strings.HasPrefix(header, "$") {
+		t.Fatalf("expected bulk string reply, got %q with error %v", header, err)
+	}
+	size, err := strconv.Atoi(strings.TrimSpace(header[1:]))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	body := make([]byte, size)
+	_, err = io.ReadFull(rd, body)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	list := string(body)
+	for _, want := range []string{fmt.Sprintf(" addr=%v:0 ", socketPath), fmt.Sprintf(" laddr=%v:0 ", socketPath), " flags=U "} {
+		if !strings.Contains(list, want) {
+			t.Errorf("expected CLIENT LIST to contain %q, got %q", want, list)
+		}
+	}
+
+	l.Close()
+	if _, err := os.Stat(socketPath); !os.IsNotExist(err) {
+		t.Errorf("expected socket file to be removed on close, got %v", err)
+	}
+}
+
+func TestListenUnix_KeepsFileWhichIsNotSocket(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "redis.sock")
+	err := os.WriteFile(socketPath, []byte("data"), 0600)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := listenUnix(socketPath, 0, 511); err == nil {
+		t.Fatalf("expected error binding over regular file")
+	}
+	if data, err := os.ReadFile(socketPath); err != nil || string(data) != "data" {
+		t.Errorf("expected regular file to stay, got %q with error %v", data, err)
+	}
+}
@@ -0,0 +1,208 @@
+package shutdown
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/client"
+	"github.com/codecrafters-io/redis-starter-go/app/config"
+	"github.com/codecrafters-io/redis-starter-go/app/logger"
+	"github.com/codecrafters-io/redis-starter-go/app/rdb"
+	"github.com/codecrafters-io/redis-starter-go/app/replicas_storage"
+	"github.com/codecrafters-io/redis-starter-go/app/storage"
+)
+
+var ShutdownError = errors.New("ERR Errors trying to SHUTDOWN. Check logs.")
+var NoShutdownInProgressError = errors.New("ERR No shutdown in progress.")
+var ShutdownInProgressError = errors.New("ERR Shutdown is already in progress.")
+
+// writes are paused while shutdown waits for replicas and saves rdb, pause is lifted if shutdown fails,
+// duration only bounds pause if shutdown hangs
+const writesPause = time.Hour
+
+type Options struct {
+	// SAVE saves rdb even if dbfilename is not configured, NOSAVE skips saving
+	Save   bool
+	NoSave bool
+	// NOW skips waiting for replicas
+	Now bool
+	// FORCE ignores errors of saving rdb
+	Force bool
+}
+
+type Shutdown struct {
+	config   *config.Config
+	dbs      *storage.Databases
+	replicas *replicas_storage.ReplStorage
+	clients  *client.Table
+
+	mu         sync.Mutex
+	listeners  []net.Listener
+	inProgress bool
+	// closed by SHUTDOWN ABORT, nil when shutdown can not be aborted
+	abort chan struct{}
+	// set once listeners are closed and process is about to exit
+	exiting bool
+	exit    func(code int)
+}
+
+func New(config *config.Config, dbs *storage.Databases, replicas *replicas_storage.ReplStorage, clients *client.Table) *Shutdown {
+	return &Shutdown{
+		config:   config,
+		dbs:      dbs,
+		replicas: replicas,
+		clients:  clients,
+		exit:     os.Exit,
+	}
+}
+
+// registers listeners closed on shutdown, closing unix socket listener removes socket file
+func (this *Shutdown) AddListeners(listeners []net.Listener) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.listeners = append(this.listeners, listeners...)
+}
+
+// reports are listeners closed by shutdown, so accept errors are expected
+func (this *Shutdown) IsExiting() bool {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.exiting
+}
+
+// waits for replicas, saves rdb, closes listeners and exits process, returns only if shutdown failed or was aborted
+func (this *Shutdown) Run(options Options) error {
+	this.mu.Lock()
+	if this.inProgress {
+		this.mu.Unlock()
+		return ShutdownInProgressError
+	}
+	this.inProgress = true
+	abort := make(chan struct{})
+	this.abort = abort
+	this.mu.Unlock()
+
+	logger.Logger.Info("User requested shutdown...")
+	this.clients.Pause(writesPause, false)
+	err := this.run(options, abort)
+	this.clients.Unpause()
+	this.mu.Lock()
+	this.inProgress = false
+	this.abort = nil
+	this.mu.Unlock()
+	return err
+}
+
+func (this *Shutdown) run(options Options, abort chan struct{}) error {
+	if !options.Now {
+		timeout := time.Duration(this.config.GetShutdownTimeout()) * time.Second
+		synced, total := this.replicas.AwaitReplicasInSync(timeout, abort)
+		if synced < total {
+			logger.Logger.Warn("Not all replicas acknowledged replication offset before shutdown", logger.Int("in sync", synced), logger.Int("replicas", total))
+		}
+	}
+
+	// abort is not possible after this point
+	this.mu.Lock()
+	select {
+	case <-abort:
+		this.mu.Unlock()
+		logger.Logger.Info("Shutdown was aborted")
+		return ShutdownError
+	default:
+	}
+	this.abort = nil
+	this.mu.Unlock()
+
+	shouldSave := !options.NoSave && (options.Save || this.config.GetServerDbFileName() != "")
+	if shouldSave {
+		logger.Logger.Info("Saving the final RDB snapshot before exiting.")
+		err := rdb.SaveRdbToFile(this.config, this.dbs)
+		if err != nil {
+			logger.Logger.Error("Error trying to save the DB, can't exit.", logger.String("error", err.Error()))
+			if !options.Force {
+				return ShutdownError
+			}
+		}
+	}
+
+	this.removePidFile()
+	this.mu.Lock()
+	this.exiting = true
+	for _, l := range this.listeners {
+		l.Close()
+	}
+	this.mu.Unlock()
+	logger.Logger.Info("Redis is now ready to exit, bye bye...")
+	this.exit(0)
+	return nil
+}
+
+// aborts shutdown waiting for replicas
+func (this *Shutdown) Abort() error {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if this.abort == nil {
+		return NoShutdownInProgressError
+	}
+	close(this.abort)
+	this.abort = nil
+	return nil
+}
+
+// writes pid of server to pidfile if it is configured
+func (this *Shutdown) WritePidFile() error {
+	pidFile := this.config.GetPidFile()
+	if pidFile == "" {
+		return nil
+	}
+	err := os.WriteFile(pidFile, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644)
+	if err != nil {
+		return fmt.Errorf("Error writing pidfile: %w", err)
+	}
+	return nil
+}
+
+func (this *Shutdown) removePidFile() {
+	pidFile := this.config.GetPidFile()
+	if pidFile == "" {
+		return
+	}
+	err := os.Remove(pidFile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		logger.Logger.Warn("Error removing pidfile", logger.String("error", err.Error()))
+	}
+}
+
+// runs shutdown with default options on SIGTERM and SIGINT, SIGINT received during shutdown exits immediately
+func (this *Shutdown) HandleSignals() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		for sig := range signals {
+			this.mu.Lock()
+			inProgress := this.inProgress
+			this.mu.Unlock()
+			if inProgress && sig == syscall.SIGINT {
+				logger.Logger.Warn("You insist... exiting now.")
+				this.removePidFile()
+				this.exit(1)
+				return
+			}
+			logger.Logger.Info("Received signal, scheduling shutdown...", logger.String("signal", sig.String()))
+			go func() {
+				err := this.Run(Options{})
+				if err != nil {
+					logger.Logger.Error("Errors trying to shut down the server", logger.String("error", err.Error()))
+				}
+			}()
+		}
+	}()
+}
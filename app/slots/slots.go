@@ -0,0 +1,110 @@
+// hash slots of keys and shard channels like in redis cluster, and slots served by this server
+package slots
+
+import "sync"
+
+// amount of hash slots
+const Count = 16384
+
+// crc16 xmodem table used by redis cluster
+var crcTable = func() [256]uint16 {
+	var table [256]uint16
+	for i := range table {
+		crc := uint16(i) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}()
+
+func crc16(s string) uint16 {
+	crc := uint16(0)
+	for i := 0; i < len(s); i++ {
+		crc = crc<<8 ^ crcTable[byte(crc>>8)^s[i]]
+	}
+	return crc
+}
+
+// returns slot of key or shard channel, when key has non empty hash tag in braces only the tag is hashed,
+// so related keys can be placed in the same slot
+func KeySlot(key string) int {
+	for i := 0; i < len(key); i++ {
+		if key[i] != '{' {
+			continue
+		}
+		for j := i + 1; j < len(key); j++ {
+			if key[j] == '}' {
+				if j > i+1 {
+					key = key[i+1 : j]
+				}
+				break
+			}
+		}
+		break
+	}
+	return int(crc16(key) & (Count - 1))
+}
+
+// slots served by this server, standalone server serves every slot
+type Table struct {
+	mu     sync.RWMutex
+	served [Count]bool
+	// called with slots that stopped being served
+	onRelease []func(slots []int)
+}
+
+func NewTable() *Table {
+	table := &Table{}
+	for i := range table.served {
+		table.served[i] = true
+	}
+	return table
+}
+
+func (this *Table) Serves(slot int) bool {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return this.served[slot]
+}
+
+func (this *Table) Assign(slots ...int) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	for _, slot := range slots {
+		this.served[slot] = true
+	}
+}
+
+// stops serving slots, listeners are called with slots that were served before.
+// nothing calls it yet, it is a hook for cluster slot migration that is not implemented
+func (this *Table) Release(slots ...int) {
+	this.mu.Lock()
+	released := []int{}
+	for _, slot := range slots {
+		if this.served[slot] {
+			this.served[slot] = false
+			released = append(released, slot)
+		}
+	}
+	listeners := this.onRelease
+	this.mu.Unlock()
+	if len(released) == 0 {
+		return
+	}
+	for _, f := range listeners {
+		f(released)
+	}
+}
+
+// registers listener of released slots, e.g. shard channel subscriptions are dropped when slot is released
+func (this *Table) OnRelease(f func(slots []int)) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.onRelease = append(this.onRelease, f)
+}
@@ -0,0 +1,45 @@
+package slots
+
+import "testing"
+
+func TestKeySlot(t *testing.T) {
+	if crc := crc16("123456789"); crc != 0x31C3 {
+		t.Fatalf("expected crc 0x31C3, got %#x", crc)
+	}
+	cases := []struct {
+		key  string
+		slot int
+	}{
+		{"123456789", 0x31C3},
+		{"{123456789}.a", 0x31C3},
+		{"a{123456789}b{c}", 0x31C3},
+		// empty hash tag is not used
+		{"{}123456789", int(crc16("{}123456789") & (Count - 1))},
+		{"{123456789", int(crc16("{123456789") & (Count - 1))},
+	}
+	for _, c := range cases {
+		if slot := KeySlot(c.key); slot != c.slot {
+			t.Fatalf("expected slot %v of %q, got %v", c.slot, c.key, slot)
+		}
+	}
+}
+
+func TestReleaseNotifiesServedSlots(t *testing.T) {
+	table := NewTable()
+	released := [][]int{}
+	table.OnRelease(func(slots []int) {
+		released = append(released, slots)
+	})
+	table.Release(1, 2)
+	table.Release(2)
+	if len(released) != 1 || len(released[0]) != 2 {
+		t.Fatalf("expected single release of 2 slots, got %v", released)
+	}
+	if table.Serves(1) || !table.Serves(3) {
+		t.Fatalf("expected slot 1 released and slot 3 served")
+	}
+	table.Assign(1)
+	if !table.Serves(1) {
+		t.Fatalf("expected slot 1 served")
+	}
+}
@@ -0,0 +1,51 @@
+package sortedset
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// formats score the same way as redis: shortest representation which is written as plain number
+// unless it is too big or too small, e.g. 3479099956230698, 0.25 and 1.5e+300
+func FormatScore(score float64) string {
+	switch {
+	case math.IsInf(score, 1):
+		return "inf"
+	case math.IsInf(score, -1):
+		return "-inf"
+	case score == 0:
+		return "0"
+	}
+	sign := ""
+	if score < 0 {
+		sign = "-"
+		score = -score
+	}
+	// shortest digits d.ddd and exponent of first digit
+	mantissa, expStr, _ := strings.Cut(strconv.FormatFloat(score, 'e', -1, 64), "e")
+	digits := strings.Replace(mantissa, ".", "", 1)
+	exp, _ := strconv.Atoi(expStr)
+	// exponent of last digit
+	k := exp - len(digits) + 1
+	absExp := max(exp, -exp)
+
+	if k >= 0 && absExp < len(digits)+7 {
+		return sign + digits + strings.Repeat("0", k)
+	}
+	if k < 0 && (k > -7 || absExp < 4) {
+		offset := len(digits) + k
+		if offset <= 0 {
+			return sign + "0." + strings.Repeat("0", -offset) + digits
+		}
+		return sign + digits[:offset] + "." + digits[offset:]
+	}
+	out := sign + digits[:1]
+	if len(digits) > 1 {
+		out += "." + digits[1:]
+	}
+	if exp < 0 {
+		return out + "e-" + strconv.Itoa(-exp)
+	}
+	return out + "e+" + strconv.Itoa(exp)
+}
@@ -0,0 +1,100 @@
+package sortedset
+
+import "math/rand"
+
+const (
+	maxLevel = 32
+	// probability of node to be promoted to the next level
+	levelP = 0.25
+)
+
+type node struct {
+	member string
+	score  float64
+	next   []*node
+}
+
+// skiplist ordered by score, members with equal score are ordered lexicographically
+type skiplist struct {
+	head   *node
+	level  int
+	length int
+}
+
+func newSkiplist() *skiplist {
+	return &skiplist{
+		head:  &node{next: make([]*node, maxLevel)},
+		level: 1,
+	}
+}
+
+// reports is node ordered before member with given score
+func (this *node) before(score float64, member string) bool {
+	return this.score < score || this.score == score && this.member < member
+}
+
+func randomLevel() int {
+	level := 1
+	for level < maxLevel && rand.Float64() < levelP {
+		level++
+	}
+	return level
+}
+
+// inserts member, caller guarantees member is not in list yet
+func (this *skiplist) insert(member string, score float64) {
+	var update [maxLevel]*node
+	x := this.head
+	for i := this.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && x.next[i].before(score, member) {
+			x = x.next[i]
+		}
+		update[i] = x
+	}
+	level := randomLevel()
+	for i := this.level; i < level; i++ {
+		update[i] = this.head
+	}
+	this.level = max(this.level, level)
+	n := &node{member: member, score: score, next: make([]*node, level)}
+	for i := 0; i < level; i++ {
+		n.next[i] = update[i].next[i]
+		update[i].next[i] = n
+	}
+	this.length++
+}
+
+// removes member with given score, reports was it found
+func (this *skiplist) delete(member string, score float64) bool {
+	var update [maxLevel]*node
+	x := this.head
+	for i := this.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && x.next[i].before(score, member) {
+			x = x.next[i]
+		}
+		update[i] = x
+	}
+	x = x.next[0]
+	if x == nil || x.score != score || x.member != member {
+		return false
+	}
+	for i := 0; i < this.level && update[i].next[i] == x; i++ {
+		update[i].next[i] = x.next[i]
+	}
+	for this.level > 1 && this.head.next[this.level-1] == nil {
+		this.level--
+	}
+	this.length--
+	return true
+}
+
+// returns first node with score not less than min
+func (this *skiplist) firstFrom(min float64) *node {
+	x := this.head
+	for i := this.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && x.next[i].score < min {
+			x = x.next[i]
+		}
+	}
+	return x.next[0]
+}
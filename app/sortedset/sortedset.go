@@ -0,0 +1,99 @@
+package sortedset
+
+import (
+	"sync"
+
+	"github.com/codecrafters-io/redis-starter-go/app/dict"
+)
+
+type SortedSet interface {
+	Len() int
+	Score(member string) (float64, bool)
+	Add(member string, score float64) (added bool, changed bool)
+	RangeByScore(min float64, max float64, fn func(member string, score float64) bool)
+	ForEach(fn func(member string, score float64))
+	Scan(cursor uint64, count int, fn func(member string, score float64)) uint64
+}
+
+// members are held in skiplist for ordered access and in map for lookup of score
+type SortedSetImpl struct {
+	dict *dict.Dict[float64]
+	zsl  *skiplist
+	mut  sync.RWMutex
+}
+
+func NewSortedSet() SortedSet {
+	return &SortedSetImpl{
+		dict: dict.New[float64](),
+		zsl:  newSkiplist(),
+	}
+}
+
+func (this *SortedSetImpl) Len() int {
+	this.mut.RLock()
+	defer this.mut.RUnlock()
+	return this.dict.Len()
+}
+
+// lookup makes rehashing step, so it takes write lock
+func (this *SortedSetImpl) Score(member string) (float64, bool) {
+	this.mut.Lock()
+	defer this.mut.Unlock()
+	return this.dict.Get(member)
+}
+
+// adds member or updates its score, reports was member added and was its score changed
+func (this *SortedSetImpl) Add(member string, score float64) (bool, bool) {
+	this.mut.Lock()
+	defer this.mut.Unlock()
+	current, ok := this.dict.Get(member)
+	if ok && current == score {
+		return false, false
+	}
+	if ok {
+		this.zsl.delete(member, current)
+	}
+	this.zsl.insert(member, score)
+	this.dict.Set(member, score)
+	return !ok, ok
+}
+
+// calls fn for members with min <= score < max in ascending order until fn returns false
+func (this *SortedSetImpl) RangeByScore(min float64, max float64, fn func(member string, score float64) bool) {
+	this.mut.RLock()
+	defer this.mut.RUnlock()
+	for x := this.zsl.firstFrom(min); x != nil && x.score < max; x = x.next[0] {
+		if !fn(x.member, x.score) {
+			return
+		}
+	}
+}
+
+// calls fn for every member in ascending order
+func (this *SortedSetImpl) ForEach(fn func(member string, score float64)) {
+	this.mut.RLock()
+	defer this.mut.RUnlock()
+	for x := this.zsl.head.next[0]; x != nil; x = x.next[0] {
+		fn(x.member, x.score)
+	}
+}
+
+// visits members from cursor until about count members are visited and returns next cursor, 0 when scan is complete,
+// every member present during whole scan is visited at least once in any order
+func (this *SortedSetImpl) Scan(cursor uint64, count int, fn func(member string, score float64)) uint64 {
+	this.mut.RLock()
+	defer this.mut.RUnlock()
+	visited := 0
+	// bounds work done for sparse tables where most of buckets are empty
+	maxIterations := count * 10
+	for {
+		cursor = this.dict.Scan(cursor, func(member string, score float64) {
+			visited++
+			fn(member, score)
+		})
+		maxIterations--
+		if cursor == 0 || visited >= count || maxIterations <= 0 {
+			return cursor
+		}
+	}
+}
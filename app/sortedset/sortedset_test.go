@@ -0,0 +1,115 @@
+package sortedset
+
+import (
+	"math/rand"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func TestSortedSet_OrderedByScoreAndMember(t *testing.T) {
+	s := NewSortedSet()
+	expected := map[string]float64{}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		member := strconv.Itoa(r.Intn(500))
+		score := float64(r.Intn(50))
+		_, exists := expected[member]
+		added, changed := s.Add(member, score)
+		if added == exists || changed != (exists && expected[member] != score) {
+			t.Fatalf("unexpected result of adding %v with score %v: added %v changed %v", member, score, added, changed)
+		}
+		expected[member] = score
+	}
+	members := make([]string, 0, len(expected))
+	for member := range expected {
+		members = append(members, member)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		a, b := members[i], members[j]
+		return expected[a] < expected[b] || expected[a] == expected[b] && a < b
+	})
+	if s.Len() != len(members) {
+		t.Fatalf("expected %v members, got %v", len(members), s.Len())
+	}
+	i := 0
+	s.ForEach(func(member string, score float64) {
+		if member != members[i] || score != expected[member] {
+			t.Fatalf("expected %v with score %v at %v, got %v with score %v", members[i], expected[members[i]], i, member, score)
+		}
+		i++
+	})
+
+	got := []string{}
+	s.RangeByScore(10, 12, func(member string, score float64) bool {
+		got = append(got, member)
+		return true
+	})
+	want := []string{}
+	for _, member := range members {
+		if expected[member] >= 10 && expected[member] < 12 {
+			want = append(want, member)
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v members in range, got %v", len(want), len(got))
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v at %v of range, got %v", want[i], i, got[i])
+		}
+	}
+}
+
+func TestFormatScore(t *testing.T) {
+	cases := map[float64]string{
+		3479099956230698: "3479099956230698",
+		0.25:             "0.25",
+		-1.5:             "-1.5",
+		1e21:             "1e+21",
+		0.0000001:        "1e-7",
+		0.000123:         "0.000123",
+		1.5e300:          "1.5e+300",
+		100:              "100",
+	}
+	for score, want := range cases {
+		if got := FormatScore(score); got != want {
+			t.Fatalf("expected %v to be formatted as %v, got %v", score, want, got)
+		}
+	}
+}
+
+// members present during whole scan are returned even if scores change and set grows between calls
+func TestSortedSet_ScanReturnsStableMembers(t *testing.T) {
+	s := NewSortedSet()
+	for i := 0; i < 1000; i++ {
+		s.Add("stable"+strconv.Itoa(i), float64(i))
+	}
+	seen := map[string]bool{}
+	cursor := uint64(0)
+	calls := 0
+	for {
+		visited := 0
+		cursor = s.Scan(cursor, 10, func(member string, score float64) {
+			seen[member] = true
+			visited++
+		})
+		if cursor != 0 && (visited < 10 || visited > 100) {
+			t.Fatalf("expected about 10 members to be visited by single call, got %v", visited)
+		}
+		s.Add("stable"+strconv.Itoa(calls%1000), -float64(calls))
+		s.Add("new"+strconv.Itoa(calls), float64(calls))
+		calls++
+		if cursor == 0 {
+			break
+		}
+	}
+	if calls < 50 {
+		t.Fatalf("expected scan to take many calls, got %v", calls)
+	}
+	for i := 0; i < 1000; i++ {
+		if !seen["stable"+strconv.Itoa(i)] {
+			t.Fatalf("expected stable%v to be returned by scan", i)
+		}
+	}
+}
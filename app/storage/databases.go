@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/notify"
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+var DbIndexOutOfRangeError = errors.New("ERR DB index is out of range")
+
+// keys with expiration checked at once by active expiration
+const activeExpireSamples = 20
+
+// numbered keyspaces selected by SELECT, every database is separate storage
+type Databases struct {
+	// guards order of databases changed by SWAPDB
+	mu  sync.RWMutex
+	dbs []*StorageImpl
+}
+
+func NewDatabases(amount int) *Databases {
+	dbs := make([]*StorageImpl, amount)
+	for i := range dbs {
+		dbs[i] = New()
+	}
+	return &Databases{dbs: dbs}
+}
+
+// keyspace events of databases are published by notifier, databases report events by their current index
+func (this *Databases) SetNotifier(notifier *notify.Notifier) {
+	for _, db := range this.dbs {
+		db.notify = func(class notify.Class, event string, key string) {
+			notifier.Notify(class, event, key, this.indexOf(db))
+		}
+	}
+}
+
+func (this *Databases) indexOf(db *StorageImpl) int {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	for i, d := range this.dbs {
+		if d == db {
+			return i
+		}
+	}
+	return -1
+}
+
+func (this *Databases) Len() int {
+	return len(this.dbs)
+}
+
+func (this *Databases) CheckIndex(idx int) error {
+	if idx < 0 || idx >= len(this.dbs) {
+		return DbIndexOutOfRangeError
+	}
+	return nil
+}
+
+// returns database by index, index should be checked by CheckIndex
+func (this *Databases) Get(idx int) Storage {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return this.dbs[idx]
+}
+
+// swaps content of two databases, clients connected to one database see data of other one
+func (this *Databases) Swap(first int, second int) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.dbs[first], this.dbs[second] = this.dbs[second], this.dbs[first]
+}
+
+// moves key with its expiration to other database, key is not moved if it exists in destination
+func (this *Databases) Move(key string, from int, to int) bool {
+	events := &keyEvents{}
+	this.mu.RLock()
+	dst := this.dbs[to]
+	moved := this.move(key, from, to, events)
+	this.mu.RUnlock()
+	// databases should be unlocked, notifier looks up index of destination
+	dst.fire(events)
+	return moved
+}
+
+func (this *Databases) move(key string, from int, to int, events *keyEvents) bool {
+	src, dst := this.dbs[from], this.dbs[to]
+	// storages are locked in order of indexes, so concurrent moves in opposite directions do not deadlock
+	first, second := src, dst
+	if from > to {
+		first, second = dst, src
+	}
+	first.Lock()
+	defer first.UnLock()
+	second.Lock()
+	defer second.UnLock()
+
+	val, ok := src.values.Get(key)
+	if !ok || src.isExpired(key) {
+		return false
+	}
+	if _, exists := dst.values.Get(key); exists && !dst.isExpired(key) {
+		return false
+	}
+	dst.set(key, val, events)
+	delete(dst.exp, key)
+	if exp, ok := src.exp[key]; ok {
+		dst.exp[key] = exp
+	}
+	src.values.Delete(key)
+	delete(src.exp, key)
+	return true
+}
+
+// deletes expired keys nobody reads, every database is sampled until less than quarter of checked keys
+// is expired or budget is spent, returns amount of deleted keys
+func (this *Databases) ActiveExpireCycle(budget time.Duration) int {
+	deadline := time.Now().Add(budget)
+	this.mu.RLock()
+	dbs := append([]*StorageImpl{}, this.dbs...)
+	this.mu.RUnlock()
+	expired := 0
+	for _, db := range dbs {
+		for time.Now().Before(deadline) {
+			checked, deleted := db.expireSample(activeExpireSamples)
+			expired += deleted
+			if deleted*4 <= checked {
+				break
+			}
+		}
+	}
+	return expired
+}
+
+func (this *Databases) FlushAll() {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	for _, db := range this.dbs {
+		db.Flush()
+	}
+}
+
+// returns keyspace section of INFO, empty databases are skipped
+func (this *Databases) GetInfo() []types.Kv {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	info := []types.Kv{}
+	for i, db := range this.dbs {
+		keys, expires, avgTtl := db.stats()
+		if keys == 0 {
+			continue
+		}
+		info = append(info, types.Kv{fmt.Sprintf("db%v", i), fmt.Sprintf("keys=%v,expires=%v,avg_ttl=%v", keys, expires, avgTtl)})
+	}
+	return info
+}
+
+// returns amount of keys, amount of keys with expiration and their average ttl in milliseconds
+func (this *StorageImpl) stats() (int, int, int64) {
+	this.Lock()
+	defer this.UnLock()
+	if len(this.exp) == 0 {
+		return this.values.Len(), 0, 0
+	}
+	now := time.Now()
+	var ttlSum int64
+	for _, exp := range this.exp {
+		ttl := exp.validUntil.Sub(now).Milliseconds()
+		if ttl > 0 {
+			ttlSum += ttl
+		}
+	}
+	return this.values.Len(), len(this.exp), ttlSum / int64(len(this.exp))
+}
+
+// reports is key expired, storage should be locked
+func (this *StorageImpl) isExpired(key string) bool {
+	exp, ok := this.exp[key]
+	return ok && exp.CheckIsExp()
+}
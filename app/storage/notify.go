@@ -0,0 +1,73 @@
+package storage
+
+import "github.com/codecrafters-io/redis-starter-go/app/notify"
+
+// event about key, events are collected while storage is locked and reported after it is unlocked
+type keyEvent struct {
+	class notify.Class
+	event string
+	key   string
+}
+
+type keyEvents []keyEvent
+
+func (this *keyEvents) add(class notify.Class, event string, key string) {
+	*this = append(*this, keyEvent{class: class, event: event, key: key})
+}
+
+// reports collected events, storage should not be locked
+func (this *StorageImpl) fire(events *keyEvents) {
+	if this.notify == nil {
+		return
+	}
+	for _, e := range *events {
+		this.notify(e.class, e.event, e.key)
+	}
+}
+
+// deletes key if it is expired, storage should be locked
+func (this *StorageImpl) expireIfNeeded(key string, events *keyEvents) bool {
+	if !this.isExpired(key) {
+		return false
+	}
+	this.values.Delete(key)
+	delete(this.exp, key)
+	events.add(notify.Expired, "expired", key)
+	return true
+}
+
+// lazily deletes expired key found by reader
+func (this *StorageImpl) expire(key string) {
+	events := &keyEvents{}
+	defer this.fire(events)
+	this.Lock()
+	defer this.UnLock()
+	this.expireIfNeeded(key, events)
+}
+
+// sets value of key keeping its expiration, storage should be locked
+func (this *StorageImpl) set(key string, val StorageValue, events *keyEvents) {
+	this.expireIfNeeded(key, events)
+	if _, ok := this.values.Get(key); !ok {
+		events.add(notify.NewKey, "new", key)
+	}
+	this.values.Set(key, val)
+}
+
+// checks up to samples keys with expiration and deletes expired ones, returns amount of checked and deleted keys
+func (this *StorageImpl) expireSample(samples int) (int, int) {
+	events := &keyEvents{}
+	defer this.fire(events)
+	this.Lock()
+	defer this.UnLock()
+	checked := 0
+	// map iteration order is random, so every call samples different keys
+	for key := range this.exp {
+		if checked == samples {
+			break
+		}
+		checked++
+		this.expireIfNeeded(key, events)
+	}
+	return checked, len(*events)
+}
@@ -6,7 +6,10 @@ import (
 	"sync"
 	"time"
 
+	"github.com/codecrafters-io/redis-starter-go/app/dict"
 	"github.com/codecrafters-io/redis-starter-go/app/logger"
+	"github.com/codecrafters-io/redis-starter-go/app/notify"
+	"github.com/codecrafters-io/redis-starter-go/app/sortedset"
 	"github.com/codecrafters-io/redis-starter-go/app/stream"
 )
 
@@ -16,6 +19,7 @@ const (
 	String = "string"
 	Stream = "stream"
 	Int    = "int"
+	ZSet   = "zset"
 )
 
 type Storage interface {
@@ -26,34 +30,41 @@ type Storage interface {
 	KeysLen() int
 	Set(key string, val StorageValue) error
 	SetExp(key string, val StorageValue, px int) error
-	Delete(key string)
+	SetMany(keys []string, vals []StorageValue, nx bool) bool
+	Update(key string, fn func(entrie *StorageValue) (*StorageValue, error)) error
+	Delete(key string) bool
+	Flush()
+	ForEach(fn func(key string, val StorageValue, validUntil time.Time))
+	Scan(cursor uint64, count int, fn func(key string, val StorageValue)) uint64
 	Lock()
 	UnLock()
 }
 
 type StorageImpl struct {
-	values map[string]StorageValue
+	values *dict.Dict[StorageValue]
 	exp    map[string]StorageExpValue
 	mu     sync.Mutex
+	// reports keyspace events of storage keys, nil if events are not published
+	notify func(class notify.Class, event string, key string)
 }
 
 func New() *StorageImpl {
 	return &StorageImpl{
-		values: make(map[string]StorageValue),
+		values: dict.New[StorageValue](),
 		exp:    make(map[string]StorageExpValue),
 	}
 }
 
 func (this *StorageImpl) Get(key string) (string, error) {
 	this.Lock()
-	val, ok := this.values[key]
+	val, ok := this.values.Get(key)
 	expMark, expOk := this.exp[key]
 	this.UnLock()
 	if !ok {
 		return "", nil
 	}
 	if expOk && expMark.CheckIsExp() {
-		this.Delete(key)
+		this.expire(key)
 		return "", nil
 	}
 	t := val.GetType()
@@ -71,36 +82,98 @@ func (this *StorageImpl) Get(key string) (string, error) {
 
 func (this *StorageImpl) GetEntrie(key string) (*StorageValue, bool) {
 	this.Lock()
-	val, ok := this.values[key]
+	val, ok := this.values.Get(key)
 	expMark, expOk := this.exp[key]
 	this.UnLock()
 	if !ok {
 		return nil, false
 	}
 	if expOk && expMark.CheckIsExp() {
-		this.Delete(key)
+		this.expire(key)
 		return nil, false
 	}
 	return &val, true
 }
 
 func (this *StorageImpl) Set(key string, val StorageValue) error {
+	events := &keyEvents{}
+	defer this.fire(events)
 	this.Lock()
-	this.values[key] = val
-	this.UnLock()
+	defer this.UnLock()
+	this.set(key, val, events)
+	return nil
+}
+
+// sets keys as single operation clearing their expiration, with nx keys are set only if none of them exists,
+// reports were keys set
+func (this *StorageImpl) SetMany(keys []string, vals []StorageValue, nx bool) bool {
+	events := &keyEvents{}
+	defer this.fire(events)
+	this.Lock()
+	defer this.UnLock()
+	if nx {
+		for _, key := range keys {
+			if _, ok := this.values.Get(key); ok && !this.isExpired(key) {
+				return false
+			}
+		}
+	}
+	for i, key := range keys {
+		this.set(key, vals[i], events)
+		delete(this.exp, key)
+	}
+	return true
+}
+
+// calls fn with value of key while storage is locked, entrie is nil if key does not exist,
+// value returned by fn replaces key keeping its expiration, nil result leaves key unchanged
+func (this *StorageImpl) Update(key string, fn func(entrie *StorageValue) (*StorageValue, error)) error {
+	events := &keyEvents{}
+	defer this.fire(events)
+	this.Lock()
+	defer this.UnLock()
+	var entrie *StorageValue
+	this.expireIfNeeded(key, events)
+	if val, ok := this.values.Get(key); ok {
+		entrie = &val
+	}
+	updated, err := fn(entrie)
+	if err != nil || updated == nil {
+		return err
+	}
+	this.set(key, *updated, events)
 	return nil
 }
 
-func (this *StorageImpl) Delete(key string) {
+// deletes key with its expiration, reports did key exist
+func (this *StorageImpl) Delete(key string) bool {
+	events := &keyEvents{}
+	defer this.fire(events)
+	this.Lock()
+	defer this.UnLock()
+	if this.expireIfNeeded(key, events) {
+		return false
+	}
+	_, ok := this.values.Get(key)
+	this.values.Delete(key)
+	delete(this.exp, key)
+	return ok
+}
+
+// removes every key of storage
+func (this *StorageImpl) Flush() {
 	this.Lock()
 	defer this.UnLock()
-	delete(this.values, key)
+	this.values = dict.New[StorageValue]()
+	this.exp = make(map[string]StorageExpValue)
 }
 
 func (this *StorageImpl) SetExp(key string, val StorageValue, px int) error {
+	events := &keyEvents{}
+	defer this.fire(events)
 	this.Lock()
 	defer this.UnLock()
-	this.values[key] = val
+	this.set(key, val, events)
 	logger.Logger.Debug("set exp", logger.String("key", key), logger.Int("px", px), logger.String("time", time.Now().Add(time.Duration(px)*time.Millisecond).String()))
 	this.exp[key] = StorageExpValue{
 		validUntil: time.Now().Add(time.Duration(px) * time.Millisecond),
@@ -111,7 +184,7 @@ func (this *StorageImpl) SetExp(key string, val StorageValue, px int) error {
 func (this *StorageImpl) GetType(key string) DataTypes {
 	this.Lock()
 	defer this.UnLock()
-	v, ok := this.values[key]
+	v, ok := this.values.Get(key)
 	if ok {
 		return v.dataType
 	}
@@ -127,19 +200,69 @@ func (this *StorageImpl) UnLock() {
 }
 
 func (this *StorageImpl) KeysLen() int {
-	return len(this.values)
+	return this.values.Len()
 }
 
 func (this *StorageImpl) GetKeys() []string {
-	out := make([]string, 0, this.KeysLen())
+	this.Lock()
+	defer this.UnLock()
+	out := make([]string, 0, this.values.Len())
 
-	for k := range this.values {
+	this.values.ForEach(func(k string, _ StorageValue) {
 		out = append(out, k)
-	}
+	})
 
 	return out
 }
 
+// calls fn for every not expired key while storage is locked, validUntil is zero for keys without expiration
+func (this *StorageImpl) ForEach(fn func(key string, val StorageValue, validUntil time.Time)) {
+	this.Lock()
+	defer this.UnLock()
+	this.values.ForEach(func(k string, v StorageValue) {
+		var validUntil time.Time
+		expMark, ok := this.exp[k]
+		if ok {
+			if expMark.CheckIsExp() {
+				return
+			}
+			validUntil = expMark.validUntil
+		}
+		fn(k, v, validUntil)
+	})
+}
+
+// scans buckets starting from cursor until about count keys are visited and returns next cursor, 0 when scan is complete,
+// fn is called for every not expired key while storage is locked, expired keys are deleted
+func (this *StorageImpl) Scan(cursor uint64, count int, fn func(key string, val StorageValue)) uint64 {
+	events := &keyEvents{}
+	defer this.fire(events)
+	this.Lock()
+	defer this.UnLock()
+	expired := []string{}
+	visited := 0
+	// bounds work done for sparse tables where most of buckets are empty
+	maxIterations := count * 10
+	for {
+		cursor = this.values.Scan(cursor, func(k string, v StorageValue) {
+			visited++
+			if this.isExpired(k) {
+				expired = append(expired, k)
+				return
+			}
+			fn(k, v)
+		})
+		maxIterations--
+		if cursor == 0 || visited >= count || maxIterations <= 0 {
+			break
+		}
+	}
+	for _, k := range expired {
+		this.expireIfNeeded(k, events)
+	}
+	return cursor
+}
+
 type StorageExpValue struct {
 	validUntil time.Time
 }
@@ -149,6 +272,7 @@ type StorageValue struct {
 	intValue int
 	dataType DataTypes
 	stream   stream.Stream
+	zset     sortedset.SortedSet
 }
 
 func (this *StorageValue) GetType() DataTypes {
@@ -163,6 +287,17 @@ return "", fmt.Errorf("Wrong string data type cast: current type: %v", this.data
 	return this.value, nil
 }
 
+// returns string representation of string and int values, ok is false for other types
+func (this *StorageValue) AsString() (string, bool) {
+	switch this.dataType {
+	case String:
+		return this.value, true
+	case Int:
+		return strconv.Itoa(this.intValue), true
+	}
+	return "", false
+}
+
 func (this *StorageValue) ToStream() (stream.Stream, error) {
 	if this.dataType != Stream {
 		return nil, fmt.Errorf("Wrong stream data type cast: current type: %v", this.dataType)
@@ -170,6 +305,13 @@ func (this *StorageValue) ToStream() (stream.Stream, error) {
 	return this.stream, nil
 }
 
+func (this *StorageValue) ToZSet() (sortedset.SortedSet, error) {
+	if this.dataType != ZSet {
+		return nil, fmt.Errorf("Wrong zset data type cast: current type: %v", this.dataType)
+	}
+	return this.zset, nil
+}
+
 func (this *StorageValue) ToInt() (int, error) {
 	if this.dataType != Int {
 		return -1, fmt.Errorf("Wrong stream data type cast: current type: %v", this.dataType)
@@ -184,6 +326,15 @@ func NewStringValue(v string) StorageValue {
 	}
 }
 
+// encodes strings that are canonical representation of integer as int values, other strings are kept raw
+func NewValueFromString(v string) StorageValue {
+	n, err := strconv.Atoi(v)
+	if err == nil && strconv.Itoa(n) == v {
+		return NewIntValue(n)
+	}
+	return NewStringValue(v)
+}
+
 func NewStreamValue(s stream.Stream) StorageValue {
 	return StorageValue{
 		stream:   s,
@@ -191,6 +342,13 @@ func NewStreamValue(s stream.Stream) StorageValue {
 	}
 }
 
+func NewZSetValue(s sortedset.SortedSet) StorageValue {
+	return StorageValue{
+		zset:     s,
+		dataType: ZSet,
+	}
+}
+
 func NewIntValue(n int) StorageValue {
 	return StorageValue{
 		intValue: n,
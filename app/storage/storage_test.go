@@ -1,8 +1,11 @@
 package storage
 
 import (
+	"reflect"
 	"testing"
 	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/notify"
 )
 
 func TestStorage_SetAndGet(t *testing.T) {
@@ -86,3 +89,90 @@ func TestStorage_Delete(t *testing.T) {
 		t.Fatalf("expected empty string, got %s", val)
 	}
 }
+
+func TestDatabases_MoveAndSwap(t *testing.T) {
+	dbs := NewDatabases(2)
+	dbs.Get(0).SetExp("key", NewStringValue("value"), 60000)
+	dbs.Get(1).Set("taken", NewStringValue("other"))
+	dbs.Get(0).Set("taken", NewStringValue("value"))
+
+	if !dbs.Move("key", 0, 1) {
+		t.Fatalf("expected key to be moved")
+	}
+	if dbs.Move("taken", 0, 1) {
+		t.Fatalf("expected key existing in destination not to be moved")
+	}
+	if dbs.Move("missing", 0, 1) {
+		t.Fatalf("expected missing key not to be moved")
+	}
+	if _, ok := dbs.Get(0).GetEntrie("key"); ok {
+		t.Fatalf("expected moved key to be removed from source")
+	}
+	if _, expires, _ := dbs.Get(1).(*StorageImpl).stats(); expires != 1 {
+		t.Fatalf("expected expiration to be moved with key, got %v expires", expires)
+	}
+
+	dbs.Swap(0, 1)
+	val, _ := dbs.Get(0).Get("key")
+	if val != "value" {
+		t.Fatalf("expected swapped database to contain moved key, got %v", val)
+	}
+	if dbs.CheckIndex(2) != DbIndexOutOfRangeError {
+		t.Fatalf("expected out of range index to be rejected")
+	}
+}
+
+func TestStorage_SetManyAndUpdate(t *testing.T) {
+	storage := New()
+	storage.Set("a", NewStringValue("1"))
+
+	if storage.SetMany([]string{"a", "b"}, []StorageValue{NewStringValue("2"), NewStringValue("3")}, true) {
+		t.Fatalf("expected nx set to fail when one of keys exists")
+	}
+	if val, _ := storage.Get("b"); val != "" {
+		t.Fatalf("expected b not to be set, got %s", val)
+	}
+
+	storage.SetExp("exp", NewIntValue(7), 60000)
+	err := storage.Update("exp", func(entrie *StorageValue) (*StorageValue, error) {
+		str, _ := entrie.AsString()
+		updated := NewStringValue(str + "x")
+		return &updated, nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if val, _ := storage.Get("exp"); val != "7x" {
+		t.Fatalf("expected 7x, got %s", val)
+	}
+	if _, ok := storage.exp["exp"]; !ok {
+		t.Fatalf("expected update to keep expiration")
+	}
+}
+
+func TestStorage_KeyEvents(t *testing.T) {
+	storage := New()
+	events := []string{}
+	storage.notify = func(class notify.Class, event string, key string) {
+		events = append(events, event+" "+key)
+	}
+
+	storage.Set("a", NewStringValue("1"))
+	storage.Set("a", NewStringValue("2"))
+	storage.SetExp("lazy", NewStringValue("1"), 1)
+	storage.SetExp("active", NewStringValue("1"), 1)
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := storage.GetEntrie("lazy"); ok {
+		t.Fatalf("expected lazy key to be expired")
+	}
+	if checked, deleted := storage.expireSample(activeExpireSamples); checked != 1 || deleted != 1 {
+		t.Fatalf("expected 1 checked and 1 deleted key, got %v and %v", checked, deleted)
+	}
+	if storage.Delete("active") {
+		t.Fatalf("expected expired key not to be reported as deleted")
+	}
+	expected := []string{"new a", "new lazy", "new active", "expired lazy", "expired active"}
+	if !reflect.DeepEqual(events, expected) {
+		t.Fatalf("expected %v, got %v", expected, events)
+	}
+}
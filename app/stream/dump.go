@@ -0,0 +1,155 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/codecrafters-io/redis-starter-go/app/listpack"
+	"github.com/codecrafters-io/redis-starter-go/app/radix"
+)
+
+var CorruptedDumpError = errors.New("Error restoring stream: corrupted dump")
+
+// state of stream saved to rdb, listpack nodes are kept in redis format so they are written as is
+type Dump struct {
+	// listpack nodes ordered by key, the id of the first entrie added to node
+	Nodes        []DumpNode
+	Length       int
+	LastId       StreamEntrieId
+	FirstId      StreamEntrieId
+	MaxDeletedId StreamEntrieId
+	EntriesAdded int64
+	Groups       []GroupDump
+}
+
+type DumpNode struct {
+	Key      []byte
+	Listpack []byte
+}
+
+type GroupDump struct {
+	Name        string
+	LastId      StreamEntrieId
+	EntriesRead int64
+	// group pending entries list, consumers refer to its entries by id
+	Pending   []PendingDump
+	Consumers []ConsumerDump
+}
+
+type PendingDump struct {
+	Id            StreamEntrieId
+	DeliveryTime  int64
+	DeliveryCount int64
+}
+
+type ConsumerDump struct {
+	Name       string
+	SeenTime   int64
+	ActiveTime int64
+	Pending    []StreamEntrieId
+}
+
+// returns copy of stream state
+func (s *StreamImpl) Dump() Dump {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	out := Dump{
+		Length:       s.length,
+		LastId:       s.lastId,
+		FirstId:      s.firstId,
+		MaxDeletedId: s.maxDeletedId,
+		EntriesAdded: s.entriesAdded,
+	}
+	it := s.rax.Iterator()
+	it.Seek("^", nil)
+	for it.Next() {
+		out.Nodes = append(out.Nodes, DumpNode{
+			Key:      append([]byte{}, it.Key()...),
+			Listpack: append([]byte{}, it.Value()...),
+		})
+	}
+	for _, g := range s.sortedGroups() {
+		group := GroupDump{Name: g.Name, LastId: g.LastId, EntriesRead: g.EntriesRead}
+		for _, nack := range g.pending.entries {
+			group.Pending = append(group.Pending, PendingDump{Id: nack.Id, DeliveryTime: nack.DeliveryTime, DeliveryCount: nack.DeliveryCount})
+		}
+		for _, c := range g.sortedConsumers() {
+			consumer := ConsumerDump{Name: c.Name, SeenTime: c.SeenTime, ActiveTime: c.ActiveTime}
+			for _, nack := range c.pending.entries {
+				consumer.Pending = append(consumer.Pending, nack.Id)
+			}
+			group.Consumers = append(group.Consumers, consumer)
+		}
+		out.Groups = append(out.Groups, group)
+	}
+	return out
+}
+
+// creates stream from dump, nodes are walked to check they hold Length entries
+func Restore(d Dump) (out Stream, err error) {
+	s := &StreamImpl{
+		rax:          radix.New(),
+		length:       d.Length,
+		lastId:       d.LastId,
+		firstId:      d.FirstId,
+		maxDeletedId: d.MaxDeletedId,
+		entriesAdded: d.EntriesAdded,
+		groups:       map[string]*ConsumerGroup{},
+		waiters:      map[int]chan<- struct{}{},
+	}
+	for _, node := range d.Nodes {
+		lp := node.Listpack
+		if len(node.Key) != 16 || len(lp) < 7 || listpack.Bytes(lp) != len(lp) || lp[len(lp)-1] != 0xFF {
+			return nil, CorruptedDumpError
+		}
+		if !s.rax.Insert(node.Key, lp) {
+			return nil, CorruptedDumpError
+		}
+	}
+	// malformed listpack makes iteration go out of bounds
+	defer func() {
+		if r := recover(); r != nil {
+			out, err = nil, CorruptedDumpError
+		}
+	}()
+	length := 0
+	it := s.iterate(StreamEntrieId{}, MaxId, false)
+	for _, ok := it.next(); ok; _, ok = it.next() {
+		length++
+	}
+	if length != d.Length {
+		return nil, CorruptedDumpError
+	}
+
+	for _, group := range d.Groups {
+		if _, ok := s.groups[group.Name]; ok {
+			return nil, fmt.Errorf("Error restoring stream: duplicated group %v", group.Name)
+		}
+		g := newConsumerGroup(group.Name, group.LastId, group.EntriesRead)
+		for _, p := range group.Pending {
+			if g.pending.get(p.Id) != nil {
+				return nil, CorruptedDumpError
+			}
+			g.pending.insert(&PendingEntry{Id: p.Id, DeliveryTime: p.DeliveryTime, DeliveryCount: p.DeliveryCount})
+		}
+		for _, consumer := range group.Consumers {
+			c := g.consumer(consumer.Name, true, consumer.SeenTime)
+			c.ActiveTime = consumer.ActiveTime
+			for _, id := range consumer.Pending {
+				// every entrie of consumer must be in group list and owned by single consumer
+				nack := g.pending.get(id)
+				if nack == nil || nack.Consumer != nil {
+					return nil, CorruptedDumpError
+				}
+				g.assign(id, c)
+			}
+		}
+		for _, nack := range g.pending.entries {
+			if nack.Consumer == nil {
+				return nil, CorruptedDumpError
+			}
+		}
+		s.groups[group.Name] = g
+	}
+	return s, nil
+}
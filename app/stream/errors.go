@@ -7,3 +7,16 @@ var LessThenPreviousStreamEntryError = errors.New("ERR The ID specified in XADD
 var LessThenAcceptedStreamEntryError = errors.New("ERR The ID specified in XADD must be greater than 0-0")
 
 var WrongIdFormatError = errors.New("Error: specified stream id is in wrong format")
+
+var InvalidIdError = errors.New("ERR Invalid stream ID specified as stream command argument")
+
+var BusyGroupError = errors.New("BUSYGROUP Consumer Group name already exists")
+
+// group is missing, commands report it with their own NOGROUP message
+var GroupNotFoundError = errors.New("consumer group not found")
+
+var SetIdSmallerThanTopError = errors.New("ERR The ID specified in XSETID is smaller than the target stream top item")
+
+var SetIdEntriesAddedError = errors.New("ERR The entries_added specified in XSETID is smaller than the target stream length")
+
+var SetIdSmallerThanMaxDeletedError = errors.New("ERR The ID specified in XSETID is smaller than the provided max_deleted_entry_id")
@@ -0,0 +1,564 @@
+package stream
+
+import (
+	"sort"
+)
+
+// entries read counter of group which position in stream is unknown
+const InvalidEntriesRead = -1
+
+// entrie delivered to consumer but not acknowledged yet
+type PendingEntry struct {
+	Id       StreamEntrieId
+	Consumer *Consumer
+	// unix time in milliseconds of the last delivery
+	DeliveryTime  int64
+	DeliveryCount int64
+}
+
+// pending entries ordered by id
+type pendingList struct {
+	entries []*PendingEntry
+}
+
+// returns index of first entrie with id not less than given one
+func (this *pendingList) seek(id StreamEntrieId) int {
+	return sort.Search(len(this.entries), func(i int) bool {
+		return this.entries[i].Id.Cmp(id) >= 0
+	})
+}
+
+func (this *pendingList) get(id StreamEntrieId) *PendingEntry {
+	i := this.seek(id)
+	if i < len(this.entries) && this.entries[i].Id == id {
+		return this.entries[i]
+	}
+	return nil
+}
+
+// inserts entrie keeping order, ids are mostly increasing so it is usually appended
+func (this *pendingList) insert(e *PendingEntry) {
+	n := len(this.entries)
+	if n == 0 || this.entries[n-1].Id.Cmp(e.Id) < 0 {
+		this.entries = append(this.entries, e)
+		return
+	}
+	i := this.seek(e.Id)
+	this.entries = append(this.entries, nil)
+	copy(this.entries[i+1:], this.entries[i:])
+	this.entries[i] = e
+}
+
+func (this *pendingList) remove(id StreamEntrieId) *PendingEntry {
+	i := this.seek(id)
+	if i == len(this.entries) || this.entries[i].Id != id {
+		return nil
+	}
+	e := this.entries[i]
+	this.entries = append(this.entries[:i], this.entries[i+1:]...)
+	return e
+}
+
+func (this *pendingList) Len() int {
+	return len(this.entries)
+}
+
+type Consumer struct {
+	Name string
+	// unix time in milliseconds of the last interaction and of the last successful read or claim, -1 if consumer was never active
+	SeenTime   int64
+	ActiveTime int64
+	pending    pendingList
+}
+
+type ConsumerGroup struct {
+	Name   string
+	LastId StreamEntrieId
+	// logical position of LastId in stream, InvalidEntriesRead if it can not be computed
+	EntriesRead int64
+	pending     pendingList
+	consumers   map[string]*Consumer
+}
+
+func newConsumerGroup(name string, lastId StreamEntrieId, entriesRead int64) *ConsumerGroup {
+	return &ConsumerGroup{
+		Name:        name,
+		LastId:      lastId,
+		EntriesRead: entriesRead,
+		consumers:   map[string]*Consumer{},
+	}
+}
+
+// returns consumer, it is created when create is set
+func (this *ConsumerGroup) consumer(name string, create bool, now int64) *Consumer {
+	c, ok := this.consumers[name]
+	if !ok && create {
+		c = &Consumer{Name: name, SeenTime: now, ActiveTime: -1}
+		this.consumers[name] = c
+	}
+	return c
+}
+
+// assigns pending entrie to consumer, entrie is created if group does not track it yet
+func (this *ConsumerGroup) assign(id StreamEntrieId, c *Consumer) *PendingEntry {
+	nack := this.pending.get(id)
+	if nack == nil {
+		nack = &PendingEntry{Id: id}
+		this.pending.insert(nack)
+	}
+	if nack.Consumer != c {
+		if nack.Consumer != nil {
+			nack.Consumer.pending.remove(id)
+		}
+		nack.Consumer = c
+		c.pending.insert(nack)
+	}
+	return nack
+}
+
+func (this *ConsumerGroup) release(nack *PendingEntry) {
+	this.pending.remove(nack.Id)
+	if nack.Consumer != nil {
+		nack.Consumer.pending.remove(nack.Id)
+	}
+}
+
+// returns consumers ordered by name
+func (this *ConsumerGroup) sortedConsumers() []*Consumer {
+	out := make([]*Consumer, 0, len(this.consumers))
+	for _, c := range this.consumers {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// entrie read by consumer, Entrie is nil if it was deleted from stream after delivery
+type Delivery struct {
+	Id     StreamEntrieId
+	Entrie *StreamEntrie
+	// state of pending entrie after delivery, zero if entrie is not pending
+	DeliveryTime  int64
+	DeliveryCount int64
+}
+
+type ConsumerPending struct {
+	Name  string
+	Count int
+}
+
+// summary of group pending entries list
+type PendingSummary struct {
+	Count     int
+	First     StreamEntrieId
+	Last      StreamEntrieId
+	Consumers []ConsumerPending
+}
+
+type PendingInfo struct {
+	Id            StreamEntrieId
+	Consumer      string
+	Idle          int64
+	DeliveryCount int64
+}
+
+type ClaimOptions struct {
+	// unix time in milliseconds set as last delivery time, -1 means now
+	DeliveryTime int64
+	// delivery counter set to claimed entries, -1 means increment
+	RetryCount int64
+	// creates pending entrie for existing stream entrie which is not pending yet
+	Force bool
+	// delivery counter is not incremented
+	JustId bool
+	// group last id is moved to LastId if it is greater
+	LastId StreamEntrieId
+}
+
+// reports can deleted entries be between start and end, nil end means end of stream
+func (s *StreamImpl) rangeHasTombstones(start StreamEntrieId, end *StreamEntrieId) bool {
+	if s.length == 0 || s.maxDeletedId.IsZero() {
+		return false
+	}
+	if s.firstId.Cmp(s.maxDeletedId) > 0 {
+		return false
+	}
+	if end == nil {
+		end = &MaxId
+	}
+	return start.Cmp(s.maxDeletedId) <= 0 && s.maxDeletedId.Cmp(*end) <= 0
+}
+
+// returns logical position of id counted from first ever added entrie, InvalidEntriesRead if it is unknown
+func (s *StreamImpl) estimateDistance(id StreamEntrieId) int64 {
+	if s.entriesAdded == 0 {
+		return 0
+	}
+	if s.length == 0 && id.Cmp(s.lastId) <= 0 {
+		return s.entriesAdded
+	}
+	cmpLast := id.Cmp(s.lastId)
+	if cmpLast == 0 {
+		return s.entriesAdded
+	}
+	if cmpLast > 0 {
+		return InvalidEntriesRead
+	}
+	if s.maxDeletedId.IsZero() || s.maxDeletedId.Cmp(s.firstId) < 0 {
+		// no deleted entries after the first one
+		cmpFirst := id.Cmp(s.firstId)
+		if cmpFirst < 0 {
+			return s.entriesAdded - int64(s.length)
+		}
+		if cmpFirst == 0 {
+			return s.entriesAdded - int64(s.length) + 1
+		}
+	}
+	return InvalidEntriesRead
+}
+
+// returns number of entries which are not delivered to group yet, fails if it is unknown because of deleted entries
+func (s *StreamImpl) lag(g *ConsumerGroup) (int64, bool) {
+	if s.entriesAdded == 0 {
+		return 0, true
+	}
+	if g.EntriesRead != InvalidEntriesRead && !s.rangeHasTombstones(g.LastId, nil) {
+		return s.entriesAdded - g.EntriesRead, true
+	}
+	entriesRead := s.estimateDistance(g.LastId)
+	if entriesRead == InvalidEntriesRead {
+		return 0, false
+	}
+	return s.entriesAdded - entriesRead, true
+}
+
+// creates group which delivers entries after id, nil id means last id of stream
+func (s *StreamImpl) CreateGroup(name string, id *StreamEntrieId, entriesRead int64) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if _, ok := s.groups[name]; ok {
+		return BusyGroupError
+	}
+	if id == nil {
+		id = &s.lastId
+	}
+	s.groups[name] = newConsumerGroup(name, *id, entriesRead)
+	return nil
+}
+
+func (s *StreamImpl) HasGroup(name string) bool {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	_, ok := s.groups[name]
+	return ok
+}
+
+// moves group last delivered id, nil id means last id of stream
+func (s *StreamImpl) SetGroupId(name string, id *StreamEntrieId, entriesRead int64) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	g, ok := s.groups[name]
+	if !ok {
+		return GroupNotFoundError
+	}
+	if id == nil {
+		id = &s.lastId
+	}
+	g.LastId = *id
+	g.EntriesRead = entriesRead
+	return nil
+}
+
+// returns last delivered id and entries read counter of group
+func (s *StreamImpl) GroupPosition(name string) (StreamEntrieId, int64, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	g, ok := s.groups[name]
+	if !ok {
+		return StreamEntrieId{}, 0, GroupNotFoundError
+	}
+	return g.LastId, g.EntriesRead, nil
+}
+
+// removes group, readers blocked on stream are woken up to notice it
+func (s *StreamImpl) DestroyGroup(name string) bool {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if _, ok := s.groups[name]; !ok {
+		return false
+	}
+	delete(s.groups, name)
+	s.notify()
+	return true
+}
+
+// reports was consumer created
+func (s *StreamImpl) CreateConsumer(group string, name string, now int64) (bool, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	g, ok := s.groups[group]
+	if !ok {
+		return false, GroupNotFoundError
+	}
+	if g.consumer(name, false, now) != nil {
+		return false, nil
+	}
+	g.consumer(name, true, now)
+	return true, nil
+}
+
+// removes consumer with its pending entries, returns number of removed pending entries
+func (s *StreamImpl) DeleteConsumer(group string, name string) (int, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	g, ok := s.groups[group]
+	if !ok {
+		return 0, GroupNotFoundError
+	}
+	c := g.consumer(name, false, 0)
+	if c == nil {
+		return 0, nil
+	}
+	pending := c.pending.Len()
+	for _, nack := range c.pending.entries {
+		g.pending.remove(nack.Id)
+	}
+	delete(g.consumers, name)
+	return pending, nil
+}
+
+// reads entries for consumer of group, count 0 means unlimited.
+// When start is set, history of consumer pending entries from start is read and the result is always served,
+// otherwise entries never delivered to group are read and recorded as pending unless noack is set,
+// served is false if there are no such entries
+func (s *StreamImpl) ReadGroup(group string, consumer string, start *StreamEntrieId, count int, noack bool, now int64) (deliveries []Delivery, served bool, err error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	g, ok := s.groups[group]
+	if !ok {
+		return nil, false, GroupNotFoundError
+	}
+	c := g.consumer(consumer, true, now)
+	c.SeenTime = now
+
+	deliveries = []Delivery{}
+	if start != nil {
+		for i := c.pending.seek(*start); i < c.pending.Len() && (count == 0 || len(deliveries) < count); i++ {
+			nack := c.pending.entries[i]
+			e := s.find(nack.Id)
+			if e != nil {
+				nack.DeliveryTime = now
+				nack.DeliveryCount++
+			}
+			deliveries = append(deliveries, Delivery{Id: nack.Id, Entrie: e, DeliveryTime: nack.DeliveryTime, DeliveryCount: nack.DeliveryCount})
+		}
+		return deliveries, true, nil
+	}
+
+	from, ok := g.LastId.Incr()
+	if !ok {
+		return deliveries, false, nil
+	}
+	it := s.iterate(from, MaxId, false)
+	for count == 0 || len(deliveries) < count {
+		nodeEntrie, ok := it.next()
+		if !ok {
+			break
+		}
+		e := nodeEntrie.toEntrie()
+		// counter is valid only if no entrie was deleted since the last delivery
+		if g.EntriesRead != InvalidEntriesRead && !s.rangeHasTombstones(g.LastId, &e.Id) {
+			g.EntriesRead++
+		} else {
+			g.EntriesRead = s.estimateDistance(e.Id)
+		}
+		g.LastId = e.Id
+		if noack {
+			deliveries = append(deliveries, Delivery{Id: e.Id, Entrie: e})
+			continue
+		}
+		nack := g.assign(e.Id, c)
+		nack.DeliveryTime = now
+		nack.DeliveryCount = 1
+		c.ActiveTime = now
+		deliveries = append(deliveries, Delivery{Id: e.Id, Entrie: e, DeliveryTime: now, DeliveryCount: 1})
+	}
+	return deliveries, len(deliveries) > 0, nil
+}
+
+// removes entries from group pending entries list, returns number of acknowledged entries
+func (s *StreamImpl) Ack(group string, ids []StreamEntrieId) (int, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	g, ok := s.groups[group]
+	if !ok {
+		return 0, GroupNotFoundError
+	}
+	acked := 0
+	for _, id := range ids {
+		nack := g.pending.get(id)
+		if nack == nil {
+			continue
+		}
+		g.release(nack)
+		acked++
+	}
+	return acked, nil
+}
+
+func (s *StreamImpl) PendingSummary(group string) (PendingSummary, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	g, ok := s.groups[group]
+	if !ok {
+		return PendingSummary{}, GroupNotFoundError
+	}
+	out := PendingSummary{Count: g.pending.Len()}
+	if out.Count == 0 {
+		return out, nil
+	}
+	out.First = g.pending.entries[0].Id
+	out.Last = g.pending.entries[out.Count-1].Id
+	for _, c := range g.sortedConsumers() {
+		if c.pending.Len() == 0 {
+			continue
+		}
+		out.Consumers = append(out.Consumers, ConsumerPending{Name: c.Name, Count: c.pending.Len()})
+	}
+	return out, nil
+}
+
+// returns up to count pending entries with ids in [start, end] idle for at least minIdle milliseconds,
+// entries of single consumer are returned when consumer is set
+func (s *StreamImpl) PendingRange(group string, consumer *string, start StreamEntrieId, end StreamEntrieId, count int, minIdle int64, now int64) ([]PendingInfo, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	g, ok := s.groups[group]
+	if !ok {
+		return nil, GroupNotFoundError
+	}
+	pel := &g.pending
+	if consumer != nil {
+		c := g.consumer(*consumer, false, now)
+		if c == nil {
+			return []PendingInfo{}, nil
+		}
+		pel = &c.pending
+	}
+	out := []PendingInfo{}
+	for i := pel.seek(start); i < pel.Len() && len(out) < count; i++ {
+		nack := pel.entries[i]
+		if nack.Id.Cmp(end) > 0 {
+			break
+		}
+		idle := max(now-nack.DeliveryTime, 0)
+		if minIdle > 0 && now-nack.DeliveryTime < minIdle {
+			continue
+		}
+		out = append(out, PendingInfo{Id: nack.Id, Consumer: nack.Consumer.Name, Idle: idle, DeliveryCount: nack.DeliveryCount})
+	}
+	return out, nil
+}
+
+// transfers pending entries idle for at least minIdle milliseconds to consumer,
+// pending entries of deleted stream entries are dropped and their ids returned
+func (s *StreamImpl) Claim(group string, consumer string, ids []StreamEntrieId, minIdle int64, opts ClaimOptions, now int64) ([]Delivery, []StreamEntrieId, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	g, ok := s.groups[group]
+	if !ok {
+		return nil, nil, GroupNotFoundError
+	}
+	if opts.LastId.Cmp(g.LastId) > 0 {
+		g.LastId = opts.LastId
+	}
+	deliveryTime := opts.DeliveryTime
+	if deliveryTime < 0 || deliveryTime > now {
+		deliveryTime = now
+	}
+	c := g.consumer(consumer, true, now)
+	c.SeenTime = now
+
+	out := []Delivery{}
+	deleted := []StreamEntrieId{}
+	for _, id := range ids {
+		nack := g.pending.get(id)
+		e := s.find(id)
+		if e == nil {
+			if nack != nil {
+				g.release(nack)
+				deleted = append(deleted, id)
+			}
+			continue
+		}
+		if nack == nil {
+			if !opts.Force {
+				continue
+			}
+			nack = &PendingEntry{Id: id}
+			g.pending.insert(nack)
+		}
+		// entrie created by FORCE has no consumer and ignores idle time
+		if nack.Consumer != nil && minIdle > 0 && now-nack.DeliveryTime < minIdle {
+			continue
+		}
+		g.assign(id, c)
+		nack.DeliveryTime = deliveryTime
+		if opts.RetryCount >= 0 {
+			nack.DeliveryCount = opts.RetryCount
+		} else if !opts.JustId {
+			nack.DeliveryCount++
+		}
+		out = append(out, Delivery{Id: id, Entrie: e, DeliveryTime: nack.DeliveryTime, DeliveryCount: nack.DeliveryCount})
+		c.ActiveTime = now
+	}
+	return out, deleted, nil
+}
+
+// claims up to count pending entries starting from start, scanning at most 10 times more entries,
+// returns id to continue scan from (0-0 when scan is complete), claimed entries and ids of deleted entries removed from group
+func (s *StreamImpl) AutoClaim(group string, consumer string, minIdle int64, start StreamEntrieId, count int, justId bool, now int64) (StreamEntrieId, []Delivery, []StreamEntrieId, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	g, ok := s.groups[group]
+	if !ok {
+		return StreamEntrieId{}, nil, nil, GroupNotFoundError
+	}
+	c := g.consumer(consumer, true, now)
+	c.SeenTime = now
+
+	// pending list is changed while scanning, so the scanned part is copied
+	i := g.pending.seek(start)
+	candidates := append([]*PendingEntry{}, g.pending.entries[i:]...)
+	claimed := []Delivery{}
+	deleted := []StreamEntrieId{}
+	attempts := count * 10
+	j := 0
+	for ; j < len(candidates) && attempts > 0 && count > 0; j++ {
+		attempts--
+		nack := candidates[j]
+		e := s.find(nack.Id)
+		if e == nil {
+			g.release(nack)
+			deleted = append(deleted, nack.Id)
+			count--
+			continue
+		}
+		if minIdle > 0 && now-nack.DeliveryTime < minIdle {
+			continue
+		}
+		g.assign(nack.Id, c)
+		nack.DeliveryTime = now
+		if !justId {
+			nack.DeliveryCount++
+		}
+		claimed = append(claimed, Delivery{Id: nack.Id, Entrie: e, DeliveryTime: nack.DeliveryTime, DeliveryCount: nack.DeliveryCount})
+		count--
+		c.ActiveTime = now
+	}
+	next := StreamEntrieId{}
+	if j < len(candidates) {
+		next = candidates[j].Id
+	}
+	return next, claimed, deleted, nil
+}
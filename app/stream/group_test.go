@@ -0,0 +1,93 @@
+package stream
+
+import (
+	"testing"
+)
+
+func addEntries(s Stream, ids ...StreamEntrieId) {
+	for _, id := range ids {
+		s.Add(NewStreamEntrieFromKv(id, nil), DefaultNodeLimits)
+	}
+}
+
+func TestReadGroup_TracksPendingAndLag(t *testing.T) {
+	s := NewStream()
+	addEntries(s, StreamEntrieId{Id: 1}, StreamEntrieId{Id: 2}, StreamEntrieId{Id: 3})
+	if err := s.CreateGroup("g", &StreamEntrieId{}, InvalidEntriesRead); err != nil {
+		t.Fatalf("unexpected error creating group: %v", err)
+	}
+	if err := s.CreateGroup("g", nil, InvalidEntriesRead); err != BusyGroupError {
+		t.Fatalf("expected BusyGroupError, got %v", err)
+	}
+
+	deliveries, served, _ := s.ReadGroup("g", "alice", nil, 2, false, 100)
+	if !served || len(deliveries) != 2 || deliveries[1].Id != (StreamEntrieId{Id: 2}) {
+		t.Fatalf("unexpected deliveries %v", deliveries)
+	}
+	impl := s.(*StreamImpl)
+	if lag, ok := impl.lag(impl.groups["g"]); !ok || lag != 1 {
+		t.Fatalf("expected lag 1, got %v %v", lag, ok)
+	}
+
+	// history read increments delivery counter
+	s.ReadGroup("g", "alice", &StreamEntrieId{}, 0, false, 200)
+	pending, _ := s.PendingRange("g", nil, StreamEntrieId{}, MaxId, 10, 0, 250)
+	if len(pending) != 2 || pending[0].DeliveryCount != 2 || pending[0].Idle != 50 {
+		t.Fatalf("unexpected pending entries %v", pending)
+	}
+
+	acked, _ := s.Ack("g", []StreamEntrieId{{Id: 1}, {Id: 5}})
+	summary, _ := s.PendingSummary("g")
+	if acked != 1 || summary.Count != 1 || summary.Consumers[0] != (ConsumerPending{Name: "alice", Count: 1}) {
+		t.Fatalf("unexpected ack %v and summary %v", acked, summary)
+	}
+}
+
+func TestClaim_TransfersIdleEntries(t *testing.T) {
+	s := NewStream()
+	addEntries(s, StreamEntrieId{Id: 1}, StreamEntrieId{Id: 2}, StreamEntrieId{Id: 3})
+	s.CreateGroup("g", &StreamEntrieId{}, InvalidEntriesRead)
+	s.ReadGroup("g", "alice", nil, 0, false, 100)
+
+	opts := ClaimOptions{DeliveryTime: -1, RetryCount: -1}
+	claimed, _, _ := s.Claim("g", "bob", []StreamEntrieId{{Id: 1}, {Id: 2}}, 1000, opts, 500)
+	if len(claimed) != 0 {
+		t.Fatalf("expected entries not idle enough not to be claimed, got %v", claimed)
+	}
+	claimed, _, _ = s.Claim("g", "bob", []StreamEntrieId{{Id: 1}}, 1000, opts, 1100)
+	if len(claimed) != 1 || claimed[0].DeliveryTime != 1100 || claimed[0].DeliveryCount != 2 {
+		t.Fatalf("expected entrie to be claimed, got %v", claimed)
+	}
+
+	next, claimed, _, _ := s.AutoClaim("g", "bob", 0, StreamEntrieId{}, 1, false, 1200)
+	if next != (StreamEntrieId{Id: 2}) || len(claimed) != 1 || claimed[0].Id != (StreamEntrieId{Id: 1}) {
+		t.Fatalf("unexpected autoclaim result %v %v", next, claimed)
+	}
+	next, claimed, _, _ = s.AutoClaim("g", "bob", 0, next, 10, false, 1200)
+	if !next.IsZero() || len(claimed) != 2 {
+		t.Fatalf("unexpected autoclaim result %v %v", next, claimed)
+	}
+	deleted, _ := s.DeleteConsumer("g", "bob")
+	summary, _ := s.PendingSummary("g")
+	if deleted != 3 || summary.Count != 0 {
+		t.Fatalf("expected every pending entrie to be removed with consumer, got %v %v", deleted, summary)
+	}
+}
+
+func TestClaim_ReturnsDeletedEntries(t *testing.T) {
+	s := NewStream()
+	addEntries(s, StreamEntrieId{Id: 1}, StreamEntrieId{Id: 2})
+	s.CreateGroup("g", &StreamEntrieId{}, InvalidEntriesRead)
+	s.ReadGroup("g", "alice", nil, 0, false, 100)
+	s.Delete([]StreamEntrieId{{Id: 1}})
+
+	opts := ClaimOptions{DeliveryTime: -1, RetryCount: -1}
+	claimed, deleted, _ := s.Claim("g", "bob", []StreamEntrieId{{Id: 1}, {Id: 2}}, 0, opts, 200)
+	if len(claimed) != 1 || len(deleted) != 1 || deleted[0] != (StreamEntrieId{Id: 1}) {
+		t.Fatalf("unexpected claim result %v %v", claimed, deleted)
+	}
+	lastId, entriesRead, _ := s.GroupPosition("g")
+	if lastId != (StreamEntrieId{Id: 2}) || entriesRead != 2 {
+		t.Fatalf("unexpected group position %v %v", lastId, entriesRead)
+	}
+}
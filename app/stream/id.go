@@ -0,0 +1,78 @@
+package stream
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// the greatest possible id, used as open end of ranges
+var MaxId = StreamEntrieId{Id: math.MaxInt64, SequenceNumber: math.MaxInt}
+
+// parses id in ms-seq or ms format, missing sequence number is 0, special ids like - and + are not accepted
+func ParseStrictId(str string) (StreamEntrieId, error) {
+	msStr, seqStr, hasSeq := strings.Cut(str, "-")
+	ms, err := strconv.ParseInt(msStr, 10, 64)
+	if err != nil || ms < 0 {
+		return StreamEntrieId{}, InvalidIdError
+	}
+	id := StreamEntrieId{Id: ms}
+	if !hasSeq {
+		return id, nil
+	}
+	seq, err := strconv.ParseInt(seqStr, 10, 64)
+	if err != nil || seq < 0 {
+		return StreamEntrieId{}, InvalidIdError
+	}
+	id.SequenceNumber = int(seq)
+	return id, nil
+}
+
+// parses bound of range which may be -, + or id prefixed with ( to exclude it,
+// missing sequence number is replaced with missingSeq
+func ParseIntervalId(str string, missingSeq int) (id StreamEntrieId, exclusive bool, err error) {
+	switch str {
+	case "-":
+		return StreamEntrieId{}, false, nil
+	case "+":
+		return MaxId, false, nil
+	}
+	if strings.HasPrefix(str, "(") && len(str) > 1 {
+		str = str[1:]
+		exclusive = true
+	}
+	id, err = ParseStrictId(str)
+	if err != nil {
+		return StreamEntrieId{}, false, err
+	}
+	if !strings.Contains(str, "-") {
+		id.SequenceNumber = missingSeq
+	}
+	return id, exclusive, nil
+}
+
+// returns the next id, fails if id is the greatest one
+func (e StreamEntrieId) Incr() (StreamEntrieId, bool) {
+	if e.SequenceNumber < math.MaxInt {
+		return StreamEntrieId{Id: e.Id, SequenceNumber: e.SequenceNumber + 1}, true
+	}
+	if e.Id == math.MaxInt64 {
+		return e, false
+	}
+	return StreamEntrieId{Id: e.Id + 1}, true
+}
+
+// returns the previous id, fails if id is 0-0
+func (e StreamEntrieId) Decr() (StreamEntrieId, bool) {
+	if e.SequenceNumber > 0 {
+		return StreamEntrieId{Id: e.Id, SequenceNumber: e.SequenceNumber - 1}, true
+	}
+	if e.Id == 0 {
+		return e, false
+	}
+	return StreamEntrieId{Id: e.Id - 1, SequenceNumber: math.MaxInt}, true
+}
+
+func (e StreamEntrieId) IsZero() bool {
+	return e.Id == 0 && e.SequenceNumber == 0
+}
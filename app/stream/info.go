@@ -0,0 +1,186 @@
+package stream
+
+import "sort"
+
+// state of stream reported by XINFO STREAM
+type StreamInfo struct {
+	Length int
+	// number of listpack nodes and radix tree nodes holding them
+	RadixTreeKeys        int
+	RadixTreeNodes       int
+	LastGeneratedId      StreamEntrieId
+	MaxDeletedId         StreamEntrieId
+	EntriesAdded         int64
+	RecordedFirstEntryId StreamEntrieId
+	// first and last entries, nil if stream is empty
+	First *StreamEntrie
+	Last  *StreamEntrie
+	// number of groups, Groups is filled only for full info
+	GroupsCount int
+	Entries     []*StreamEntrie
+	Groups      []GroupInfo
+}
+
+type GroupInfo struct {
+	Name            string
+	Consumers       int
+	Pending         int
+	LastDeliveredId StreamEntrieId
+	// InvalidEntriesRead if it is unknown
+	EntriesRead int64
+	Lag         int64
+	// lag is unknown because of deleted entries
+	LagUnknown     bool
+	PendingEntries []PendingEntryInfo
+	ConsumersInfo  []ConsumerInfo
+}
+
+type ConsumerInfo struct {
+	Name    string
+	Pending int
+	// unix time in milliseconds, ActiveTime is -1 if consumer was never active
+	SeenTime       int64
+	ActiveTime     int64
+	PendingEntries []PendingEntryInfo
+}
+
+type PendingEntryInfo struct {
+	Id            StreamEntrieId
+	Consumer      string
+	DeliveryTime  int64
+	DeliveryCount int64
+}
+
+// returns up to count entries of pending list, count 0 means unlimited
+func (this *pendingList) info(count int) []PendingEntryInfo {
+	out := []PendingEntryInfo{}
+	for _, nack := range this.entries {
+		if count > 0 && len(out) == count {
+			break
+		}
+		out = append(out, PendingEntryInfo{Id: nack.Id, Consumer: nack.Consumer.Name, DeliveryTime: nack.DeliveryTime, DeliveryCount: nack.DeliveryCount})
+	}
+	return out
+}
+
+// should be called under lock
+func (s *StreamImpl) groupInfo(g *ConsumerGroup) GroupInfo {
+	info := GroupInfo{
+		Name:            g.Name,
+		Consumers:       len(g.consumers),
+		Pending:         g.pending.Len(),
+		LastDeliveredId: g.LastId,
+		EntriesRead:     g.EntriesRead,
+	}
+	lag, ok := s.lag(g)
+	info.Lag = lag
+	info.LagUnknown = !ok
+	return info
+}
+
+// returns groups ordered by name, should be called under lock
+func (s *StreamImpl) sortedGroups() []*ConsumerGroup {
+	out := make([]*ConsumerGroup, 0, len(s.groups))
+	for _, g := range s.groups {
+		out = append(out, g)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// returns state of stream, with full entries, groups with their pending entries and consumers are included,
+// count limits amount of entries and pending entries, 0 means unlimited
+func (s *StreamImpl) Info(full bool, count int) StreamInfo {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	info := StreamInfo{
+		Length:               s.length,
+		RadixTreeKeys:        s.rax.Len(),
+		RadixTreeNodes:       s.rax.Nodes(),
+		LastGeneratedId:      s.lastId,
+		MaxDeletedId:         s.maxDeletedId,
+		EntriesAdded:         s.entriesAdded,
+		GroupsCount:          len(s.groups),
+		First:                s.edge(false),
+		Last:                 s.edge(true),
+		RecordedFirstEntryId: s.firstId,
+	}
+	if !full {
+		return info
+	}
+	info.Entries = []*StreamEntrie{}
+	it := s.iterate(StreamEntrieId{}, MaxId, false)
+	for count == 0 || len(info.Entries) < count {
+		e, ok := it.next()
+		if !ok {
+			break
+		}
+		info.Entries = append(info.Entries, e.toEntrie())
+	}
+	info.Groups = []GroupInfo{}
+	for _, g := range s.sortedGroups() {
+		gInfo := s.groupInfo(g)
+		gInfo.PendingEntries = g.pending.info(count)
+		gInfo.ConsumersInfo = []ConsumerInfo{}
+		for _, c := range g.sortedConsumers() {
+			gInfo.ConsumersInfo = append(gInfo.ConsumersInfo, ConsumerInfo{
+				Name:           c.Name,
+				Pending:        c.pending.Len(),
+				SeenTime:       c.SeenTime,
+				ActiveTime:     c.ActiveTime,
+				PendingEntries: c.pending.info(count),
+			})
+		}
+		info.Groups = append(info.Groups, gInfo)
+	}
+	return info
+}
+
+// returns groups ordered by name
+func (s *StreamImpl) GroupsInfo() []GroupInfo {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	out := []GroupInfo{}
+	for _, g := range s.sortedGroups() {
+		out = append(out, s.groupInfo(g))
+	}
+	return out
+}
+
+// returns consumers of group ordered by name
+func (s *StreamImpl) ConsumersInfo(group string) ([]ConsumerInfo, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	g, ok := s.groups[group]
+	if !ok {
+		return nil, GroupNotFoundError
+	}
+	out := []ConsumerInfo{}
+	for _, c := range g.sortedConsumers() {
+		out = append(out, ConsumerInfo{Name: c.Name, Pending: c.pending.Len(), SeenTime: c.SeenTime, ActiveTime: c.ActiveTime})
+	}
+	return out, nil
+}
+
+// sets last generated id of stream, entriesAdded -1 and nil maxDeletedId keep current values
+func (s *StreamImpl) SetId(id StreamEntrieId, entriesAdded int64, maxDeletedId *StreamEntrieId) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if last := s.edge(true); last != nil && id.Cmp(last.Id) < 0 {
+		return SetIdSmallerThanTopError
+	}
+	if entriesAdded >= 0 && entriesAdded < int64(s.length) {
+		return SetIdEntriesAddedError
+	}
+	if maxDeletedId != nil && id.Cmp(*maxDeletedId) < 0 {
+		return SetIdSmallerThanMaxDeletedError
+	}
+	s.lastId = id
+	if entriesAdded >= 0 {
+		s.entriesAdded = entriesAdded
+	}
+	if maxDeletedId != nil {
+		s.maxDeletedId = *maxDeletedId
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package stream
+
+import (
+	"testing"
+)
+
+func TestSetId_ValidatesAndMovesLastId(t *testing.T) {
+	s := NewStream()
+	addEntries(s, StreamEntrieId{Id: 1}, StreamEntrieId{Id: 2})
+	if err := s.SetId(StreamEntrieId{Id: 1}, -1, nil); err != SetIdSmallerThanTopError {
+		t.Fatalf("expected error on id smaller than top item, got %v", err)
+	}
+	if err := s.SetId(StreamEntrieId{Id: 5}, 1, nil); err != SetIdEntriesAddedError {
+		t.Fatalf("expected error on entries added smaller than length, got %v", err)
+	}
+	if err := s.SetId(StreamEntrieId{Id: 5}, -1, &StreamEntrieId{Id: 6}); err != SetIdSmallerThanMaxDeletedError {
+		t.Fatalf("expected error on id smaller than max deleted id, got %v", err)
+	}
+	if err := s.SetId(StreamEntrieId{Id: 5}, 7, &StreamEntrieId{Id: 4}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	info := s.Info(false, 0)
+	if info.LastGeneratedId != (StreamEntrieId{Id: 5}) || info.EntriesAdded != 7 || info.MaxDeletedId != (StreamEntrieId{Id: 4}) {
+		t.Fatalf("unexpected info after XSETID: %+v", info)
+	}
+	if info.Length != 2 || info.First.Id != (StreamEntrieId{Id: 1}) || info.Last.Id != (StreamEntrieId{Id: 2}) {
+		t.Fatalf("unexpected entries in info: %+v", info)
+	}
+	if _, err := s.GeneratenewStreamId(StreamEntrieId{Id: 4}, Explicit); err == nil {
+		t.Fatalf("expected id smaller than id set by XSETID to be rejected")
+	}
+}
@@ -0,0 +1,324 @@
+package stream
+
+import (
+	"encoding/binary"
+
+	"github.com/codecrafters-io/redis-starter-go/app/listpack"
+	"github.com/codecrafters-io/redis-starter-go/app/radix"
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+// entries are stored in listpack nodes of radix tree keyed by id of the first entrie added to node, like in redis.
+// Node starts with master entrie: count of valid entries, count of deleted entries, number of master fields,
+// master fields and terminating 0. Every entrie is: flags, ms and seq differences with master id, then values
+// when fields are the same as master ones, or number of fields with field value pairs otherwise, and lp-count,
+// amount of elements of entrie before it, to iterate backwards
+
+const (
+	itemFlagNone       = 0
+	itemFlagDeleted    = 1
+	itemFlagSameFields = 2
+	// hard limit of node size, used when stream-node-max-bytes is 0
+	nodeMaxSize = 1 << 30
+)
+
+// limits of node size, new node is created when the last one would exceed them, 0 means unlimited
+type NodeLimits struct {
+	MaxBytes   int
+	MaxEntries int
+}
+
+var DefaultNodeLimits = NodeLimits{MaxBytes: 4096, MaxEntries: 100}
+
+func nodeKey(id StreamEntrieId) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key, uint64(id.Id))
+	binary.BigEndian.PutUint64(key[8:], uint64(id.SequenceNumber))
+	return key
+}
+
+func idFromNodeKey(key []byte) StreamEntrieId {
+	return StreamEntrieId{
+		Id:             int64(binary.BigEndian.Uint64(key)),
+		SequenceNumber: int(binary.BigEndian.Uint64(key[8:])),
+	}
+}
+
+func getInt(lp []byte, p int) int64 {
+	v, _ := listpack.GetInt(lp, p)
+	return v
+}
+
+// master entrie of node with offsets of its elements
+type nodeMaster struct {
+	id      StreamEntrieId
+	count   int64
+	deleted int64
+	fields  []string
+	// offset of terminating element of master entrie
+	end int
+}
+
+func newNode(e *StreamEntrie) []byte {
+	lp := listpack.New()
+	lp = listpack.AppendInt(lp, 0)
+	lp = listpack.AppendInt(lp, 0)
+	lp = listpack.AppendInt(lp, int64(len(e.values)))
+	for _, kv := range e.values {
+		lp = listpack.Append(lp, kv[0])
+	}
+	return listpack.AppendInt(lp, 0)
+}
+
+func readMaster(lp []byte, id StreamEntrieId) nodeMaster {
+	m := nodeMaster{id: id}
+	p := listpack.First(lp)
+	m.count = getInt(lp, p)
+	p = listpack.Next(lp, p)
+	m.deleted = getInt(lp, p)
+	p = listpack.Next(lp, p)
+	m.fields = make([]string, getInt(lp, p))
+	for i := range m.fields {
+		p = listpack.Next(lp, p)
+		m.fields[i] = listpack.Get(lp, p)
+	}
+	m.end = listpack.Next(lp, p)
+	return m
+}
+
+// returns offset of the first entrie, -1 if node has no entries
+func (this *nodeMaster) first(lp []byte) int {
+	return listpack.Next(lp, this.end)
+}
+
+// returns offset of lp-count of the last entrie, -1 if node has no entries
+func (this *nodeMaster) last(lp []byte) int {
+	p := listpack.Last(lp)
+	if p == this.end {
+		return -1
+	}
+	return p
+}
+
+// reports would node exceed limits after adding entrie
+func (this *nodeMaster) full(lp []byte, e *StreamEntrie, limits NodeLimits) bool {
+	maxBytes := limits.MaxBytes
+	if maxBytes == 0 || maxBytes > nodeMaxSize {
+		maxBytes = nodeMaxSize
+	}
+	size := listpack.Bytes(lp)
+	for _, kv := range e.values {
+		size += len(kv[0]) + len(kv[1])
+	}
+	if size >= maxBytes {
+		return true
+	}
+	return limits.MaxEntries > 0 && this.count+this.deleted >= int64(limits.MaxEntries)
+}
+
+func (this *nodeMaster) sameFields(e *StreamEntrie) bool {
+	if len(this.fields) != len(e.values) {
+		return false
+	}
+	for i, kv := range e.values {
+		if this.fields[i] != kv[0] {
+			return false
+		}
+	}
+	return true
+}
+
+func (this *nodeMaster) appendEntrie(lp []byte, e *StreamEntrie) []byte {
+	flags := int64(itemFlagNone)
+	same := this.sameFields(e)
+	if same {
+		flags |= itemFlagSameFields
+	}
+	lp = listpack.AppendInt(lp, flags)
+	lp = listpack.AppendInt(lp, e.Id.Id-this.id.Id)
+	lp = listpack.AppendInt(lp, int64(e.Id.SequenceNumber-this.id.SequenceNumber))
+	lpCount := int64(len(e.values) + 3)
+	if same {
+		for _, kv := range e.values {
+			lp = listpack.Append(lp, kv[1])
+		}
+	} else {
+		lpCount += int64(len(e.values) + 1)
+		lp = listpack.AppendInt(lp, int64(len(e.values)))
+		for _, kv := range e.values {
+			lp = listpack.Append(lp, kv[0])
+			lp = listpack.Append(lp, kv[1])
+		}
+	}
+	lp = listpack.AppendInt(lp, lpCount)
+	this.count++
+	return this.writeCounters(lp)
+}
+
+// writes counters of valid and deleted entries, offsets of master entrie are updated as counters may change size
+func (this *nodeMaster) writeCounters(lp []byte) []byte {
+	size := len(lp)
+	p := listpack.First(lp)
+	lp = listpack.ReplaceInt(lp, p, this.count)
+	lp = listpack.ReplaceInt(lp, listpack.Next(lp, p), this.deleted)
+	this.end += len(lp) - size
+	return lp
+}
+
+// entrie of node with offsets of its flags and lp-count elements
+type nodeEntrie struct {
+	id       StreamEntrieId
+	flags    int64
+	values   []types.Kv
+	flagsPos int
+	countPos int
+}
+
+func (this *nodeMaster) readEntrie(lp []byte, p int) nodeEntrie {
+	e := nodeEntrie{flagsPos: p, flags: getInt(lp, p)}
+	p = listpack.Next(lp, p)
+	e.id.Id = this.id.Id + getInt(lp, p)
+	p = listpack.Next(lp, p)
+	e.id.SequenceNumber = this.id.SequenceNumber + int(getInt(lp, p))
+	if e.flags&itemFlagSameFields != 0 {
+		e.values = make([]types.Kv, len(this.fields))
+		for i, field := range this.fields {
+			p = listpack.Next(lp, p)
+			e.values[i] = types.Kv{field, listpack.Get(lp, p)}
+		}
+	} else {
+		p = listpack.Next(lp, p)
+		e.values = make([]types.Kv, getInt(lp, p))
+		for i := range e.values {
+			p = listpack.Next(lp, p)
+			field := listpack.Get(lp, p)
+			p = listpack.Next(lp, p)
+			e.values[i] = types.Kv{field, listpack.Get(lp, p)}
+		}
+	}
+	e.countPos = listpack.Next(lp, p)
+	return e
+}
+
+// returns offset of flags of entrie which lp-count is at p
+func (this *nodeMaster) entrieStart(lp []byte, p int) int {
+	for n := getInt(lp, p); n > 0; n-- {
+		p = listpack.Prev(lp, p)
+	}
+	return p
+}
+
+// marks entrie as deleted, returns changed node and shift of offsets caused by changed counters
+func (this *nodeMaster) markDeleted(lp []byte, e *nodeEntrie) ([]byte, int) {
+	// flags keep their size as both values fit in the smallest integer encoding
+	e.flags |= itemFlagDeleted
+	lp = listpack.ReplaceInt(lp, e.flagsPos, e.flags)
+	this.count--
+	this.deleted++
+	end := this.end
+	lp = this.writeCounters(lp)
+	return lp, this.end - end
+}
+
+func (e *nodeEntrie) toEntrie() *StreamEntrie {
+	return &StreamEntrie{Id: e.id, values: e.values}
+}
+
+// iterates valid entries with ids in [start, end], tree may be changed only by removeCurrent while iterating
+type streamIterator struct {
+	s     *StreamImpl
+	start StreamEntrieId
+	end   StreamEntrieId
+	rev   bool
+	nodes *radix.Iterator
+	done  bool
+	// current node, nil when the next one should be loaded
+	lp     []byte
+	master nodeMaster
+	// offset of flags of the next entrie or lp-count of the previous one when iterating backwards, -1 if node is over
+	pos int
+	cur nodeEntrie
+}
+
+func (s *StreamImpl) iterate(start StreamEntrieId, end StreamEntrieId, rev bool) *streamIterator {
+	it := &streamIterator{s: s, start: start, end: end, rev: rev, nodes: s.rax.Iterator()}
+	if rev {
+		it.nodes.Seek("<=", nodeKey(end))
+	} else if !it.nodes.Seek("<=", nodeKey(start)) {
+		it.nodes.Seek("^", nil)
+	}
+	return it
+}
+
+func (this *streamIterator) loadNode() bool {
+	ok := false
+	if this.rev {
+		ok = this.nodes.Prev()
+	} else {
+		ok = this.nodes.Next()
+	}
+	if !ok {
+		return false
+	}
+	this.lp = this.nodes.Value()
+	this.master = readMaster(this.lp, idFromNodeKey(this.nodes.Key()))
+	if this.rev {
+		this.pos = this.master.last(this.lp)
+	} else {
+		this.pos = this.master.first(this.lp)
+	}
+	return true
+}
+
+func (this *streamIterator) next() (*nodeEntrie, bool) {
+	for !this.done {
+		if this.lp == nil && !this.loadNode() {
+			this.done = true
+			break
+		}
+		if this.pos < 0 {
+			this.lp = nil
+			continue
+		}
+		if this.rev {
+			this.cur = this.master.readEntrie(this.lp, this.master.entrieStart(this.lp, this.pos))
+			this.pos = listpack.Prev(this.lp, this.cur.flagsPos)
+			if this.pos == this.master.end {
+				this.pos = -1
+			}
+		} else {
+			this.cur = this.master.readEntrie(this.lp, this.pos)
+			this.pos = listpack.Next(this.lp, this.cur.countPos)
+		}
+		if this.cur.flags&itemFlagDeleted != 0 {
+			continue
+		}
+		if this.cur.id.Cmp(this.start) < 0 {
+			this.done = this.rev
+			continue
+		}
+		if this.cur.id.Cmp(this.end) > 0 {
+			this.done = !this.rev
+			continue
+		}
+		return &this.cur, true
+	}
+	return nil, false
+}
+
+// removes entrie returned by the last next call, node is removed from tree when it has no more entries
+func (this *streamIterator) removeCurrent() {
+	lp, shift := this.master.markDeleted(this.lp, &this.cur)
+	if this.pos > 0 {
+		this.pos += shift
+	}
+	this.s.length--
+	key := nodeKey(this.master.id)
+	if this.master.count == 0 {
+		this.s.rax.Remove(key)
+		this.lp = nil
+		return
+	}
+	this.s.rax.Insert(key, lp)
+	this.lp = lp
+}
@@ -0,0 +1,51 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+func TestNodes_PreserveFieldOrderAndSplitByLimits(t *testing.T) {
+	s := NewStream()
+	limits := NodeLimits{MaxEntries: 3}
+	for i := 1; i <= 10; i++ {
+		kv := []types.Kv{{"z", "1"}, {"a", "2"}}
+		if i%2 == 0 {
+			kv = []types.Kv{{"b", "x"}, {"a", "y"}, {"c", "12345"}}
+		}
+		s.Add(NewStreamEntrieFromKv(StreamEntrieId{Id: int64(i), SequenceNumber: i}, kv), limits)
+	}
+	info := s.Info(false, 0)
+	if info.Length != 10 || info.RadixTreeKeys != 4 {
+		t.Fatalf("expected 10 entries in 4 nodes, got %v in %v", info.Length, info.RadixTreeKeys)
+	}
+	entries := s.GetRange(StreamEntrieId{}, MaxId, 0, true)
+	for i, e := range entries {
+		id := int64(10 - i)
+		expected := []types.Kv{{"z", "1"}, {"a", "2"}}
+		if id%2 == 0 {
+			expected = []types.Kv{{"b", "x"}, {"a", "y"}, {"c", "12345"}}
+		}
+		if e.Id != (StreamEntrieId{Id: id, SequenceNumber: int(id)}) || len(e.values) != len(expected) {
+			t.Fatalf("unexpected entrie %v at %v", e, i)
+		}
+		for j := range expected {
+			if e.values[j] != expected[j] {
+				t.Fatalf("expected field order %v, got %v", expected, e.values)
+			}
+		}
+	}
+
+	// removing all entries of node removes the node
+	if n := s.Delete([]StreamEntrieId{{Id: 1, SequenceNumber: 1}, {Id: 2, SequenceNumber: 2}, {Id: 3, SequenceNumber: 3}}); n != 3 {
+		t.Fatalf("expected 3 deleted entries, got %v", n)
+	}
+	info = s.Info(false, 0)
+	if info.Length != 7 || info.RadixTreeKeys != 3 || info.RecordedFirstEntryId != (StreamEntrieId{Id: 4, SequenceNumber: 4}) {
+		t.Fatalf("unexpected info after deleting the first node: %+v", info)
+	}
+	if e := s.GetRange(StreamEntrieId{Id: 5}, MaxId, 2, false); len(e) != 2 || e[0].Id.Id != 5 || e[1].Id.Id != 6 {
+		t.Fatalf("unexpected range across nodes: %v", e)
+	}
+}
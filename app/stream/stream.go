@@ -10,6 +10,7 @@ import (
 
 	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
 	"github.com/codecrafters-io/redis-starter-go/app/logger"
+	"github.com/codecrafters-io/redis-starter-go/app/radix"
 	"github.com/codecrafters-io/redis-starter-go/app/types"
 )
 
@@ -22,40 +23,67 @@ const (
 )
 
 type Stream interface {
-	GetAll() []*StreamEntrie
-	GetInRangeIncl(start *StreamEntrieId, end *StreamEntrieId) []*StreamEntrie
-	GetInRangeExcl(start *StreamEntrieId, end *StreamEntrieId) []*StreamEntrie
-	BlockUntilNew(timeout int) *StreamEntrie
-	Add(*StreamEntrie)
-	GetLast() *StreamEntrie
+	GetRange(start StreamEntrieId, end StreamEntrieId, count int, rev bool) []*StreamEntrie
+	Add(e *StreamEntrie, limits NodeLimits)
+	LastId() StreamEntrieId
 	GeneratenewStreamId(id StreamEntrieId, mode GenerateIdMode) (*StreamEntrieId, error)
+	NotifyOnChange(c chan<- struct{}) (cancel func())
+	Len() int
+	Delete(ids []StreamEntrieId) int
+	Trim(opts TrimOptions) int
+	Info(full bool, count int) StreamInfo
+	GroupsInfo() []GroupInfo
+	ConsumersInfo(group string) ([]ConsumerInfo, error)
+	SetId(id StreamEntrieId, entriesAdded int64, maxDeletedId *StreamEntrieId) error
+
+	CreateGroup(name string, id *StreamEntrieId, entriesRead int64) error
+	HasGroup(name string) bool
+	SetGroupId(name string, id *StreamEntrieId, entriesRead int64) error
+	GroupPosition(name string) (StreamEntrieId, int64, error)
+	DestroyGroup(name string) bool
+	CreateConsumer(group string, name string, now int64) (bool, error)
+	DeleteConsumer(group string, name string) (int, error)
+	ReadGroup(group string, consumer string, start *StreamEntrieId, count int, noack bool, now int64) ([]Delivery, bool, error)
+	Ack(group string, ids []StreamEntrieId) (int, error)
+	PendingSummary(group string) (PendingSummary, error)
+	PendingRange(group string, consumer *string, start StreamEntrieId, end StreamEntrieId, count int, minIdle int64, now int64) ([]PendingInfo, error)
+	Claim(group string, consumer string, ids []StreamEntrieId, minIdle int64, opts ClaimOptions, now int64) ([]Delivery, []StreamEntrieId, error)
+	Dump() Dump
+	AutoClaim(group string, consumer string, minIdle int64, start StreamEntrieId, count int, justId bool, now int64) (StreamEntrieId, []Delivery, []StreamEntrieId, error)
 }
 
 type StreamImpl struct {
-	entries    []*StreamEntrie
-	blockChans []chan *StreamEntrie
-	mut        sync.Mutex
+	// listpack nodes keyed by id of their master entrie
+	rax    *radix.Tree
+	length int
+	// id of the first entrie, 0-0 if stream is empty
+	firstId StreamEntrieId
+	// id of the last ever added entrie and number of ever added entries
+	lastId       StreamEntrieId
+	entriesAdded int64
+	// the greatest id of deleted entrie
+	maxDeletedId StreamEntrieId
+	groups       map[string]*ConsumerGroup
+	waiters      map[int]chan<- struct{}
+	nextWaiter   int
+	mut          sync.Mutex
 }
 
 func NewStream() Stream {
 	return &StreamImpl{
-		entries:    []*StreamEntrie{},
-		blockChans: []chan *StreamEntrie{},
+		rax:     radix.New(),
+		groups:  map[string]*ConsumerGroup{},
+		waiters: map[int]chan<- struct{}{},
 	}
 }
 
-func (s *StreamImpl) GetAll() []*StreamEntrie {
-	s.mut.Lock()
-	defer s.mut.Unlock()
-	return s.entries
-}
-
 func (s *StreamImpl) GeneratenewStreamId(id StreamEntrieId, mode GenerateIdMode) (*StreamEntrieId, error) {
 	s.mut.Lock()
 	defer s.mut.Unlock()
+	// the last ever added id is used as the last entrie may be deleted, it may be also set by XSETID
 	var prevEntrieId *StreamEntrieId = nil
-	if len(s.entries) > 0 {
-		prevEntrieId = &s.entries[len(s.entries)-1].Id
+	if s.entriesAdded > 0 || !s.lastId.IsZero() {
+		prevEntrieId = &s.lastId
 	}
 	switch mode {
 	case PartitialAuotoGenerated:
@@ -80,81 +108,107 @@ func (s *StreamImpl) GeneratenewStreamId(id StreamEntrieId, mode GenerateIdMode)
 	return nil, errors.New("Error unknown mode of generating id")
 }
 
-func (s *StreamImpl) GetInRangeIncl(start *StreamEntrieId, end *StreamEntrieId) []*StreamEntrie {
+// returns up to count entries with ids in [start, end], the latest entries are returned first when rev is set,
+// count 0 means unlimited
+func (s *StreamImpl) GetRange(start StreamEntrieId, end StreamEntrieId, count int, rev bool) []*StreamEntrie {
 	s.mut.Lock()
 	defer s.mut.Unlock()
-	out := make([]*StreamEntrie, 0, len(s.entries))
-	for _, e := range s.entries {
-		if end != nil && e.Id.Cmp(*end) > 0 {
+	out := []*StreamEntrie{}
+	it := s.iterate(start, end, rev)
+	for count == 0 || len(out) < count {
+		e, ok := it.next()
+		if !ok {
 			break
 		}
-		if start != nil && e.Id.Cmp(*start) < 0 {
-			continue
-		}
-		out = append(out, e)
+		out = append(out, e.toEntrie())
 	}
 	return out
 }
 
-func (s *StreamImpl) GetInRangeExcl(start *StreamEntrieId, end *StreamEntrieId) []*StreamEntrie {
+// appends entrie to the last node, new node is created when the last one reaches limits
+func (s *StreamImpl) Add(e *StreamEntrie, limits NodeLimits) {
 	s.mut.Lock()
 	defer s.mut.Unlock()
-	out := make([]*StreamEntrie, 0, len(s.entries))
-	for _, e := range s.entries {
-		if end != nil {
-			cmp := e.Id.Cmp(*end)
-			if cmp >= 0 {
-
-				break
-			}
+	var lp []byte
+	var master nodeMaster
+	it := s.rax.Iterator()
+	if it.Seek("$", nil) {
+		lp = it.Value()
+		master = readMaster(lp, idFromNodeKey(it.Key()))
+		if master.full(lp, e, limits) {
+			lp = nil
 		}
-		if start != nil {
-			cmp := e.Id.Cmp(*start)
-			if cmp <= 0 {
-				continue
-			}
-		}
-		out = append(out, e)
 	}
-	return out
+	if lp == nil {
+		lp = newNode(e)
+		master = readMaster(lp, e.Id)
+	}
+	s.rax.Insert(nodeKey(master.id), master.appendEntrie(lp, e))
+	if s.length == 0 {
+		s.firstId = e.Id
+	}
+	s.length++
+	s.lastId = e.Id
+	s.entriesAdded++
+	s.notify()
 }
 
-func (s *StreamImpl) BlockUntilNew(timeout int) *StreamEntrie {
-	s.mut.Lock()
-	c := make(chan *StreamEntrie, 1)
-	s.blockChans = append(s.blockChans, c)
-	s.mut.Unlock()
-	if timeout == 0 {
-		return <-c
+// returns entrie with given id, nil if it does not exist, should be called under lock
+func (s *StreamImpl) find(id StreamEntrieId) *StreamEntrie {
+	e, ok := s.iterate(id, id, false).next()
+	if !ok {
+		return nil
 	}
-	timeoutC := time.After(time.Duration(timeout) * time.Millisecond)
-	select {
-	case e := <-c:
-		logger.Logger.Debug("recieve entrie in block")
-		return e
-	case <-timeoutC:
-		logger.Logger.Debug("timeout block")
+	return e.toEntrie()
+}
+
+// returns the first or the last entrie, nil if stream is empty, should be called under lock
+func (s *StreamImpl) edge(last bool) *StreamEntrie {
+	e, ok := s.iterate(StreamEntrieId{}, MaxId, last).next()
+	if !ok {
 		return nil
 	}
+	return e.toEntrie()
 }
 
-func (s *StreamImpl) Add(e *StreamEntrie) {
+// should be called under lock after entries are removed
+func (s *StreamImpl) updateFirstId() {
+	s.firstId = StreamEntrieId{}
+	if first := s.edge(false); first != nil {
+		s.firstId = first.Id
+	}
+}
+
+// registers chan which receives value on every change of stream, c should be buffered
+// as sending does not block, returned func unregisters chan
+func (s *StreamImpl) NotifyOnChange(c chan<- struct{}) (cancel func()) {
 	s.mut.Lock()
 	defer s.mut.Unlock()
-	s.entries = append(s.entries, e)
-	logger.Logger.Debug("adding new entrie to steam", logger.String("chans", fmt.Sprintf("%v", s.blockChans)))
-	for _, c := range s.blockChans {
-		c <- e
+	id := s.nextWaiter
+	s.nextWaiter++
+	s.waiters[id] = c
+	return func() {
+		s.mut.Lock()
+		defer s.mut.Unlock()
+		delete(s.waiters, id)
 	}
-	s.blockChans = []chan *StreamEntrie{}
 }
 
-func (s *StreamImpl) GetLast() *StreamEntrie {
-	n := len(s.entries)
-	if n == 0 {
-		return nil
+// should be called under lock
+func (s *StreamImpl) notify() {
+	for _, c := range s.waiters {
+		select {
+		case c <- struct{}{}:
+		default:
+		}
 	}
-	return s.entries[n-1]
+}
+
+// returns id of the last ever added entrie, it may be already deleted
+func (s *StreamImpl) LastId() StreamEntrieId {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return s.lastId
 }
 
 type StreamEntrieId struct {
@@ -251,15 +305,15 @@ func GenerateAutoId(prev *StreamEntrieId) StreamEntrieId {
 }
 
 type StreamEntrie struct {
-	Id     StreamEntrieId
-	values map[string]string
+	Id StreamEntrieId
+	// field value pairs in insertion order
+	values []types.Kv
 }
 
 func (e StreamEntrie) ToDataType() *datatypes.Data {
-	kv := make([]string, 0, len(e.values))
-	for k, v := range e.values {
-		kv = append(kv, k)
-		kv = append(kv, v)
+	kv := make([]string, 0, len(e.values)*2)
+	for _, keyValue := range e.values {
+		kv = append(kv, keyValue[0], keyValue[1])
 	}
 
 	encodedValues := datatypes.ConstructArray(kv)
@@ -272,19 +326,10 @@ func (e StreamEntrie) ToDataType() *datatypes.Data {
 }
 
 func NewStreamEntrieFromKv(id StreamEntrieId, kv []types.Kv) *StreamEntrie {
-	out := StreamEntrie{
+	return &StreamEntrie{
 		Id:     id,
-		values: make(map[string]string, len(kv)),
-	}
-	if kv == nil {
-		return &out
-	}
-	for _, keyValue := range kv {
-		key := keyValue[0]
-		value := keyValue[1]
-		out.values[key] = value
+		values: append([]types.Kv{}, kv...),
 	}
-	return &out
 }
 
 func ParseEntrieIdFromString(id string) (*StreamEntrieId, GenerateIdMode, error) {
@@ -0,0 +1,26 @@
+package stream
+
+import (
+	"testing"
+)
+
+func TestGetRange_CountAndReverse(t *testing.T) {
+	s := NewStream()
+	for i := 1; i <= 10; i++ {
+		addEntries(s, StreamEntrieId{Id: int64(i)})
+	}
+	got := s.GetRange(StreamEntrieId{Id: 3}, StreamEntrieId{Id: 8}, 2, false)
+	if len(got) != 2 || got[0].Id.Id != 3 || got[1].Id.Id != 4 {
+		t.Fatalf("unexpected range %v", got)
+	}
+	got = s.GetRange(StreamEntrieId{Id: 3}, StreamEntrieId{Id: 8}, 2, true)
+	if len(got) != 2 || got[0].Id.Id != 8 || got[1].Id.Id != 7 {
+		t.Fatalf("unexpected reversed range %v", got)
+	}
+	if got = s.GetRange(StreamEntrieId{Id: 8}, StreamEntrieId{Id: 3}, 0, false); len(got) != 0 {
+		t.Fatalf("expected empty range when start is after end, got %v", got)
+	}
+	if got = s.GetRange(StreamEntrieId{}, MaxId, 0, true); len(got) != 10 || got[9].Id.Id != 1 {
+		t.Fatalf("unexpected whole range %v", got)
+	}
+}
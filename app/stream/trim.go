@@ -0,0 +1,111 @@
+package stream
+
+import "github.com/codecrafters-io/redis-starter-go/app/listpack"
+
+type TrimStrategy int
+
+const (
+	TrimNone TrimStrategy = iota
+	TrimMaxLen
+	TrimMinId
+)
+
+type TrimOptions struct {
+	Strategy TrimStrategy
+	MaxLen   int64
+	MinId    StreamEntrieId
+	// with Approx only whole nodes are removed, so stream may keep a bit more entries
+	Approx bool
+	// maximum amount of removed entries, 0 means unlimited
+	Limit int64
+}
+
+func (s *StreamImpl) Len() int {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return s.length
+}
+
+// deletes entries by id, returns number of deleted entries
+func (s *StreamImpl) Delete(ids []StreamEntrieId) int {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	deleted := 0
+	for _, id := range ids {
+		it := s.iterate(id, id, false)
+		if _, ok := it.next(); !ok {
+			continue
+		}
+		it.removeCurrent()
+		if id.Cmp(s.maxDeletedId) > 0 {
+			s.maxDeletedId = id
+		}
+		deleted++
+	}
+	if deleted > 0 {
+		s.updateFirstId()
+	}
+	return deleted
+}
+
+// removes the oldest entries according to options, returns number of removed entries.
+// Nodes are removed as a whole while they hold only entries to trim, entries of the next node are marked as deleted
+// one by one unless trimming is approximate
+func (s *StreamImpl) Trim(opts TrimOptions) int {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	removed := int64(0)
+	it := s.rax.Iterator()
+	it.Seek("^", nil)
+	for it.Next() {
+		lp := it.Value()
+		master := readMaster(lp, idFromNodeKey(it.Key()))
+		if opts.Limit > 0 && removed+master.count > opts.Limit {
+			break
+		}
+		removeNode := false
+		switch opts.Strategy {
+		case TrimMaxLen:
+			removeNode = int64(s.length)-master.count >= opts.MaxLen
+		case TrimMinId:
+			last := master.readEntrie(lp, master.entrieStart(lp, master.last(lp)))
+			removeNode = last.id.Cmp(opts.MinId) < 0
+		}
+		if removeNode {
+			s.rax.Remove(it.Key())
+			s.length -= int(master.count)
+			removed += master.count
+			continue
+		}
+		if opts.Approx {
+			break
+		}
+		for p := master.first(lp); p >= 0; {
+			e := master.readEntrie(lp, p)
+			p = listpack.Next(lp, e.countPos)
+			if e.flags&itemFlagDeleted != 0 {
+				continue
+			}
+			if opts.Strategy == TrimMaxLen && int64(s.length) <= opts.MaxLen || opts.Strategy == TrimMinId && e.id.Cmp(opts.MinId) >= 0 {
+				break
+			}
+			var shift int
+			lp, shift = master.markDeleted(lp, &e)
+			if p >= 0 {
+				p += shift
+			}
+			s.length--
+			removed++
+		}
+		if master.count == 0 {
+			s.rax.Remove(it.Key())
+		} else {
+			s.rax.Insert(it.Key(), lp)
+		}
+		break
+	}
+	if removed > 0 {
+		s.updateFirstId()
+	}
+	return int(removed)
+}
@@ -0,0 +1,40 @@
+package stream
+
+import (
+	"testing"
+)
+
+func TestTrim_ApproxRemovesWholeChunks(t *testing.T) {
+	s := NewStream()
+	for i := 1; i <= 250; i++ {
+		addEntries(s, StreamEntrieId{Id: int64(i)})
+	}
+	if n := s.Trim(TrimOptions{Strategy: TrimMaxLen, MaxLen: 10, Approx: true}); n != 200 {
+		t.Fatalf("expected 200 trimmed entries, got %v", n)
+	}
+	if n := s.Trim(TrimOptions{Strategy: TrimMinId, MinId: StreamEntrieId{Id: 240}}); n != 39 || s.Len() != 11 {
+		t.Fatalf("expected 39 trimmed entries and 11 left, got %v and %v", n, s.Len())
+	}
+}
+
+func TestDelete_MakesLagUnknownUntilGroupPassesTombstone(t *testing.T) {
+	s := NewStream()
+	addEntries(s, StreamEntrieId{Id: 1}, StreamEntrieId{Id: 2}, StreamEntrieId{Id: 3})
+	s.CreateGroup("g", &StreamEntrieId{}, InvalidEntriesRead)
+	s.ReadGroup("g", "c", nil, 1, true, 0)
+	if n := s.Delete([]StreamEntrieId{{Id: 2}, {Id: 7}}); n != 1 {
+		t.Fatalf("expected 1 deleted entrie, got %v", n)
+	}
+	impl := s.(*StreamImpl)
+	g := impl.groups["g"]
+	if _, ok := impl.lag(g); ok {
+		t.Fatalf("expected lag to be unknown with deleted entrie after group last id")
+	}
+	s.ReadGroup("g", "c", nil, 0, true, 0)
+	if lag, ok := impl.lag(g); !ok || lag != 0 {
+		t.Fatalf("expected lag 0 after reading the whole stream, got %v %v", lag, ok)
+	}
+	if id, err := impl.GeneratenewStreamId(StreamEntrieId{Id: 3}, Explicit); err == nil {
+		t.Fatalf("expected id %v not greater than last ever added id to be rejected", id)
+	}
+}
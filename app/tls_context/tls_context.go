@@ -0,0 +1,146 @@
+// holds certificates used by tls listener and tls replication link, certificates can be reloaded at runtime
+package tlscontext
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/codecrafters-io/redis-starter-go/app/config"
+	"github.com/codecrafters-io/redis-starter-go/app/logger"
+)
+
+// parameters which change requires reloading of tls context
+var ReloadParams = []string{
+	"tls-cert-file",
+	"tls-key-file",
+	"tls-ca-cert-file",
+	"tls-auth-clients",
+	"tls-replication",
+}
+
+type TlsContext struct {
+	config *config.Config
+	mu     sync.RWMutex
+	cert   *tls.Certificate
+	caPool *x509.CertPool
+}
+
+// loads certificates from files specified in config, if tls is not enabled certificates are loaded on first reload
+func New(config *config.Config) (*TlsContext, error) {
+	ctx := &TlsContext{
+		config: config,
+	}
+	err := ctx.Reload()
+	if err != nil {
+		return nil, err
+	}
+	return ctx, nil
+}
+
+// rereads certificates, on error previously loaded certificates stay in use
+func (this *TlsContext) Reload() error {
+	if !this.config.IsTlsEnabled() {
+		return nil
+	}
+	certFile := this.config.GetTlsCertFile()
+	keyFile := this.config.GetTlsKeyFile()
+	if certFile == "" || keyFile == "" {
+		return errors.New("Error loading tls context: tls-cert-file and tls-key-file have to be specified")
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return fmt.Errorf("Error loading tls certificate: %w", err)
+	}
+	caPool, err := loadCaPool(this.config.GetTlsCaCertFile())
+	if err != nil {
+		return err
+	}
+	if caPool == nil && (this.config.GetTlsAuthClients() != config.TlsAuthClientsNo || this.config.GetTlsReplication()) {
+		return errors.New("Error loading tls context: tls-ca-cert-file has to be specified to verify peers")
+	}
+
+	this.mu.Lock()
+	this.cert = &cert
+	this.caPool = caPool
+	this.mu.Unlock()
+	logger.Logger.Info("tls context loaded", logger.String("cert", certFile))
+	return nil
+}
+
+func loadCaPool(caFile string) (*x509.CertPool, error) {
+	if caFile == "" {
+		return nil, nil
+	}
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading tls ca certificate: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("Error parsing tls ca certificate: no certificates found in %v", caFile)
+	}
+	return pool, nil
+}
+
+func (this *TlsContext) current() (*tls.Certificate, *x509.CertPool) {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+	return this.cert, this.caPool
+}
+
+// config for tls listener, every handshake uses certificates loaded at the moment of handshake
+func (this *TlsContext) ServerConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, caPool := this.current()
+			clientAuth := tls.RequireAndVerifyClientCert
+			switch this.config.GetTlsAuthClients() {
+			case config.TlsAuthClientsNo:
+				clientAuth = tls.NoClientCert
+			case config.TlsAuthClientsOptional:
+				clientAuth = tls.VerifyClientCertIfGiven
+			}
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*cert},
+				ClientCAs:    caPool,
+				ClientAuth:   clientAuth,
+			}, nil
+		},
+	}
+}
+
+// config for replication link, like redis master certificate is verified against ca without hostname check
+func (this *TlsContext) ClientConfig() *tls.Config {
+	cert, caPool := this.current()
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		Certificates:       []tls.Certificate{*cert},
+		InsecureSkipVerify: true,
+		VerifyConnection: func(state tls.ConnectionState) error {
+			if len(state.PeerCertificates) == 0 {
+				return errors.New("Error verifying master: no certificate presented")
+			}
+			intermediates := x509.NewCertPool()
+			for _, c := range state.PeerCertificates[1:] {
+				intermediates.AddCert(c)
+			}
+			_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+				Roots:         caPool,
+				Intermediates: intermediates,
+			})
+			return err
+		},
+	}
+}
+
+// opens tls connection to addr, used by replica to connect to master
+func (this *TlsContext) Dial(addr string) (net.Conn, error) {
+	return tls.Dial("tcp", addr, this.ClientConfig())
+}
@@ -0,0 +1,267 @@
+package tlscontext
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/config"
+	"github.com/codecrafters-io/redis-starter-go/app/logger"
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+	"go.uber.org/zap"
+)
+
+var (
+	testConfig     *config.Config
+	testConfigErr  error
+	testConfigOnce sync.Once
+)
+
+// certificate and its key signed by test ca
+type testCert struct {
+	cert     *x509.Certificate
+	key      *ecdsa.PrivateKey
+	certFile string
+	keyFile  string
+}
+
+func newTestCert(t *testing.T, dir string, name string, ca *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	parent, signer := template, key
+	if ca == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		parent, signer = ca.cert, ca.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	c := &testCert{cert: cert, key: key, certFile: filepath.Join(dir, name+".crt"), keyFile: filepath.Join(dir, name+".key")}
+	writePem(t, c.certFile, "CERTIFICATE", der)
+	writePem(t, c.keyFile, "EC PRIVATE KEY", keyDer)
+	return c
+}
+
+func writePem(t *testing.T, path string, typ string, der []byte) {
+	err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0600)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func (this *testCert) tlsCert() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{this.cert.Raw}, PrivateKey: this.key}
+}
+
+// config registers command line flags, so it is created once, every test sets tls params it needs.
+// tls listener port can not be set at runtime, so tls is enabled by tls-replication
+func newTestContext(t *testing.T, params ...types.Kv) *TlsContext {
+	testConfigOnce.Do(func() {
+		logger.Logger = zap.NewNop()
+		testConfig, testConfigErr = config.New()
+	})
+	if testConfigErr != nil {
+		t.Fatalf("expected no error, got %v", testConfigErr)
+	}
+	ctx, err := New(testConfig)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	testConfig.OnApply(ReloadParams, ctx.Reload)
+	// files of the test are removed after it, so tls is disabled for the next one
+	t.Cleanup(func() { testConfig.SetParams([]types.Kv{{"tls-replication", "no"}}) })
+	err = testConfig.SetParams(append(params, types.Kv{"tls-replication", "yes"}))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return ctx
+}
+
+// starts tls listener, returned channel receives result of server side handshake of every accepted conn
+func listen(t *testing.T, ctx *TlsContext) (string, <-chan error) {
+	l, err := tls.Listen("tcp", "127.0.0.1:0", ctx.ServerConfig())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	handshakes := make(chan error, 16)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.SetDeadline(time.Now().Add(2 * time.Second))
+			handshakes <- conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+	return l.Addr().String(), handshakes
+}
+
+// returns certificate served by server at addr
+func servedCert(t *testing.T, addr string, ca *testCert, client *testCert) *x509.Certificate {
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	conn, err := tls.Dial("tcp", addr, &tls.Config{RootCAs: roots, ServerName: "server", Certificates: []tls.Certificate{client.tlsCert()}})
+	if err != nil {
+		t.Fatalf("expected handshake to succeed, got %v", err)
+	}
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates[0]
+}
+
+func TestTlsContext_ConfigSetReloadsServedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, dir, "ca", nil)
+	first := newTestCert(t, dir, "server", ca)
+	client := newTestCert(t, dir, "client", ca)
+	ctx := newTestContext(t,
+		types.Kv{"tls-cert-file", first.certFile},
+		types.Kv{"tls-key-file", first.keyFile},
+		types.Kv{"tls-ca-cert-file", ca.certFile},
+		types.Kv{"tls-auth-clients", "yes"},
+	)
+	addr, handshakes := listen(t, ctx)
+	if got := servedCert(t, addr, ca, client); !got.Equal(first.cert) {
+		t.Fatalf("expected first certificate to be served, got serial %v", got.SerialNumber)
+	}
+	if err := <-handshakes; err != nil {
+		t.Fatalf("expected client with certificate to be accepted, got %v", err)
+	}
+
+	second := newTestCert(t, t.TempDir(), "server", ca)
+	err := testConfig.SetParams([]types.Kv{{"tls-cert-file", second.certFile}, {"tls-key-file", second.keyFile}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := servedCert(t, addr, ca, client); !got.Equal(second.cert) {
+		t.Fatalf("expected reloaded certificate to be served, got serial %v", got.SerialNumber)
+	}
+	<-handshakes
+
+	// broken certificate is rejected and the loaded one stays in use
+	err = testConfig.SetParams([]types.Kv{{"tls-cert-file", filepath.Join(dir, "missing.crt")}})
+	if err == nil {
+		t.Fatalf("expected error setting missing certificate")
+	}
+	if testConfig.GetTlsCertFile() != second.certFile {
+		t.Fatalf("expected tls-cert-file to be restored, got %v", testConfig.GetTlsCertFile())
+	}
+	if got := servedCert(t, addr, ca, client); !got.Equal(second.cert) {
+		t.Fatalf("expected certificate to stay after failed reload, got serial %v", got.SerialNumber)
+	}
+	<-handshakes
+}
+
+func TestTlsContext_AuthClientsRejectsClientWithoutCertificate(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, dir, "ca", nil)
+	server := newTestCert(t, dir, "server", ca)
+	ctx := newTestContext(t,
+		types.Kv{"tls-cert-file", server.certFile},
+		types.Kv{"tls-key-file", server.keyFile},
+		types.Kv{"tls-ca-cert-file", ca.certFile},
+		types.Kv{"tls-auth-clients", "yes"},
+	)
+	addr, handshakes := listen(t, ctx)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	dial := func() {
+		conn, err := tls.Dial("tcp", addr, &tls.Config{RootCAs: roots, ServerName: "server"})
+		if err == nil {
+			// with tls 1.3 client learns about rejection only on read
+			conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+			conn.Read(make([]byte, 1))
+			conn.Close()
+		}
+	}
+	dial()
+	if err := <-handshakes; err == nil {
+		t.Fatalf("expected client without certificate to be rejected")
+	}
+
+	err := testConfig.SetParams([]types.Kv{{"tls-auth-clients", "optional"}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	dial()
+	if err := <-handshakes; err != nil {
+		t.Fatalf("expected client without certificate to be accepted when auth is optional, got %v", err)
+	}
+}
+
+func TestTlsContext_ReplicationLinkVerifiesMasterByCa(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, dir, "ca", nil)
+	replica := newTestCert(t, dir, "replica", ca)
+	ctx := newTestContext(t,
+		types.Kv{"tls-cert-file", replica.certFile},
+		types.Kv{"tls-key-file", replica.keyFile},
+		types.Kv{"tls-ca-cert-file", ca.certFile},
+		types.Kv{"tls-auth-clients", "yes"},
+	)
+	master := newTestCert(t, dir, "master", ca)
+	otherCa := newTestCert(t, t.TempDir(), "ca", nil)
+	stranger := newTestCert(t, t.TempDir(), "master", otherCa)
+	for _, c := range []struct {
+		cert *testCert
+		ok   bool
+	}{{master, true}, {stranger, false}} {
+		l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{c.cert.tlsCert()}})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		go func() {
+			conn, err := l.Accept()
+			if err == nil {
+				conn.(*tls.Conn).Handshake()
+				conn.Close()
+			}
+		}()
+		conn, err := ctx.Dial(l.Addr().String())
+		if (err == nil) != c.ok {
+			t.Errorf("expected dial to master with certificate of %v to succeed %v, got %v", c.cert.cert.Issuer.CommonName, c.ok, err)
+		}
+		if err == nil {
+			conn.Close()
+		}
+		l.Close()
+	}
+}
@@ -60,7 +60,7 @@ func parseFlags() Config {
 }
 
 func runConnection(connID int, config Config) {
-	addr := fmt.Sprintf("%s:%d", config.host, config.port)
+	addr := net.JoinHostPort(config.host, strconv.Itoa(config.port))
 	conn, err := net.Dial("tcp", addr)
 	if err != nil {
 		fmt.Printf("Connection %d failed: %v\n", connID, err)
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/codecrafters-io/redis-starter-go/app/client"
 	"github.com/codecrafters-io/redis-starter-go/app/command"
 	datatypes "github.com/codecrafters-io/redis-starter-go/app/data_types"
 	"github.com/codecrafters-io/redis-starter-go/app/executor"
@@ -12,7 +13,10 @@ import (
 
 type ConnTransaction interface {
 	ShouldConsumeCommand(cmd *command.Command) bool
-	ExecuteCmd(cmd *command.Command, globalExecutor executor.CommandExecutor) *datatypes.Data
+	ExecuteCmd(caller *client.Client, cmd *command.Command, globalExecutor executor.CommandExecutor) *datatypes.Data
+	IsInTransaction() bool
+	// amount of commands queued by MULTI, -1 outside of transaction
+	GetQueuedAmount() int
 }
 
 type ConnTransactionImpl struct {
@@ -35,14 +39,14 @@ func shouldLockGlobalTransaction(cmd *command.Command) bool {
 	return cmd.Type == command.EXEC
 }
 
-func (t *GlobalTransaction) ExecuteCmd(cmd *command.Command, tr ConnTransaction) *datatypes.Data {
+func (t *GlobalTransaction) ExecuteCmd(caller *client.Client, cmd *command.Command, tr ConnTransaction) *datatypes.Data {
 	logger.Logger.Debug("process command by global transaction", logger.String("cmd", string(cmd.Type)))
 	if shouldLockGlobalTransaction(cmd) {
 		t.lock.Lock()
 		defer t.lock.Unlock()
 	}
 	logger.Logger.Debug("start processing command by conn transaction", logger.String("cmd", string(cmd.Type)))
-	res := tr.ExecuteCmd(cmd, t.executor)
+	res := tr.ExecuteCmd(caller, cmd, t.executor)
 	return res
 }
 
@@ -64,7 +68,18 @@ func (t *ConnTransactionImpl) ShouldConsumeCommand(cmd *command.Command) bool {
 	return ok
 }
 
-func (t *ConnTransactionImpl) ExecuteCmd(cmd *command.Command, globalExecutor executor.CommandExecutor) *datatypes.Data {
+func (t *ConnTransactionImpl) IsInTransaction() bool {
+	return t.isInTransaction
+}
+
+func (t *ConnTransactionImpl) GetQueuedAmount() int {
+	if !t.isInTransaction {
+		return -1
+	}
+	return len(t.queued)
+}
+
+func (t *ConnTransactionImpl) ExecuteCmd(caller *client.Client, cmd *command.Command, globalExecutor executor.CommandExecutor) *datatypes.Data {
 	logger.Logger.Debug("process command by transaction", logger.String("cmd", string(cmd.Type)))
 	switch cmd.Type {
 	case command.MULTI:
@@ -72,7 +87,7 @@ func (t *ConnTransactionImpl) ExecuteCmd(cmd *command.Command, globalExecutor ex
 	case command.DISCARD:
 		return t.ProcessDiscard(cmd)
 	case command.EXEC:
-		return t.ProcessExec(cmd, globalExecutor)
+		return t.ProcessExec(caller, cmd, globalExecutor)
 	default:
 		t.queued = append(t.queued, cmd)
 		return datatypes.ConstructSimpleString("QUEUED")
@@ -93,20 +108,22 @@ func (t *ConnTransactionImpl) ProcessDiscard(cmd *command.Command) *datatypes.Da
 	return datatypes.ConstructSimpleError(DiscardWithoutMultiError.Error())
 }
 
-func (t *ConnTransactionImpl) ProcessExec(cmd *command.Command, globalExecutor executor.CommandExecutor) *datatypes.Data {
+func (t *ConnTransactionImpl) ProcessExec(caller *client.Client, cmd *command.Command, globalExecutor executor.CommandExecutor) *datatypes.Data {
 	if !t.isInTransaction {
 		return datatypes.ConstructSimpleError(ExecWithoutMultiError.Error())
 	}
 	t.isInTransaction = false
-	logger.Logger.Debug("start executing transaction", logger.String("queued", fmt.Sprintf("%v", t.queued)))
-	n := len(t.queued)
+	queued := t.queued
+	t.queued = nil
+	logger.Logger.Debug("start executing transaction", logger.String("queued", fmt.Sprintf("%v", queued)))
+	n := len(queued)
 	if n <= 0 {
 		logger.Logger.Debug("return empty array from transaction")
 		return datatypes.ConstructArrayFromData([]*datatypes.Data{})
 	}
 	results := make([]*datatypes.Data, n)
 	for i := 0; i < n; i++ {
-		results[i] = globalExecutor.ExecuteCmd(t.queued[i], true)
+		results[i] = globalExecutor.ExecuteCmd(caller, queued[i], true)
 	}
 	return datatypes.ConstructArrayFromData(results)
 }